@@ -0,0 +1,109 @@
+//
+// Concurrency limited fan-out over accounts and regions
+//
+
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type Target struct {
+	AccountId string
+	Region    string
+}
+
+const DefaultConcurrency = 8
+
+//
+// Every combination of accounts and regions
+//
+
+func Targets(accounts []string, regions []string) []Target {
+	targets := make([]Target, 0, len(accounts)*len(regions))
+	for _, account := range accounts {
+		for _, region := range regions {
+			targets = append(targets, Target{AccountId: account, Region: region})
+		}
+	}
+
+	return targets
+}
+
+func (t Target) String() string {
+	if t.AccountId == "" {
+		return t.Region
+	}
+
+	return t.AccountId + "/" + t.Region
+}
+
+//
+// Run fn for each key with bounded concurrency, zero concurrency uses the
+// default, failed keys are left out of the result and their errors joined
+//
+
+func FanOut[K comparable, R any](ctx context.Context, keys []K, concurrency int, fn func(ctx context.Context, key K) (R, error)) (map[K]R, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	result := make(map[K]R)
+	seen := make(map[K]bool)
+	slots := make(chan bool, concurrency)
+
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		// Stop starting work once cancelled
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case slots <- true:
+				acquired = true
+			case <-ctx.Done():
+			}
+		}
+
+		if !acquired {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%v: %w", key, ctx.Err()))
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(key K) {
+			defer wg.Done()
+			defer func() {
+				<-slots
+			}()
+
+			value, err := fn(ctx, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%v: %w", key, err))
+				return
+			}
+
+			result[key] = value
+		}(key)
+	}
+
+	wg.Wait()
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargets(t *testing.T) {
+	targets := Targets([]string{"111111111111", "222222222222"}, []string{"eu-north-1", "us-east-1"})
+	assert.Len(t, targets, 4)
+	assert.Equal(t, Target{AccountId: "111111111111", Region: "us-east-1"}, targets[1])
+	assert.Equal(t, "222222222222/eu-north-1", targets[2].String())
+	assert.Equal(t, "eu-north-1", Target{Region: "eu-north-1"}.String())
+}
+
+func TestFanOut(t *testing.T) {
+	var running, peak atomic.Int32
+	targets := Targets([]string{"111111111111", "222222222222"}, []string{"eu-north-1", "eu-west-1", "us-east-1"})
+	targets = append(targets, targets[0])
+
+	result, err := FanOut(context.Background(), targets, 2, func(ctx context.Context, target Target) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		if target.Region == "us-east-1" && target.AccountId == "222222222222" {
+			return 0, errors.New("access denied")
+		}
+
+		return len(target.Region), nil
+	})
+
+	assert.EqualError(t, err, "222222222222/us-east-1: access denied")
+	assert.Len(t, result, 5)
+	assert.Equal(t, 10, result[targets[0]])
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestFanOutCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	result, err := FanOut(ctx, []string{"eu-north-1", "us-east-1"}, 0, func(ctx context.Context, region string) (string, error) {
+		calls++
+		return region, nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, calls)
+	assert.Empty(t, result)
+}
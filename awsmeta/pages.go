@@ -0,0 +1,54 @@
+//
+// Draining AWS SDK v2 style paginators
+//
+
+package awsmeta
+
+import (
+	"context"
+)
+
+// Satisfied by the SDK v2 generated paginators, O is the service options
+type Paginator[P any, O any] interface {
+	HasMorePages() bool
+	NextPage(ctx context.Context, optFns ...func(*O)) (P, error)
+}
+
+//
+// Collect items of all pages, items gathered before a failure are returned
+// alongside the error
+//
+
+func DrainPages[P any, O any, T any](ctx context.Context, paginator Paginator[P, O], items func(page P) []T, optFns ...func(*O)) ([]T, error) {
+	var result []T
+
+	err := EachPage(ctx, paginator, func(page P) error {
+		result = append(result, items(page)...)
+		return nil
+	}, optFns...)
+
+	return result, err
+}
+
+//
+// Call fn for each page until pages run out or fn fails
+//
+
+func EachPage[P any, O any](ctx context.Context, paginator Paginator[P, O], fn func(page P) error, optFns ...func(*O)) error {
+	for paginator.HasMorePages() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := paginator.NextPage(ctx, optFns...)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
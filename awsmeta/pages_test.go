@@ -0,0 +1,84 @@
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOptions struct {
+	Region string
+}
+
+type testPage struct {
+	Items []string
+}
+
+type testPaginator struct {
+	pages   [][]string
+	next    int
+	failAt  int
+	regions []string
+}
+
+func (p *testPaginator) HasMorePages() bool {
+	return p.next < len(p.pages)
+}
+
+func (p *testPaginator) NextPage(ctx context.Context, optFns ...func(*testOptions)) (*testPage, error) {
+	opts := &testOptions{}
+	for _, fn := range optFns {
+		fn(opts)
+	}
+
+	p.regions = append(p.regions, opts.Region)
+
+	if p.next == p.failAt {
+		return nil, errors.New("page failed")
+	}
+
+	page := &testPage{Items: p.pages[p.next]}
+	p.next++
+
+	return page, nil
+}
+
+func TestDrainPages(t *testing.T) {
+	paginator := &testPaginator{pages: [][]string{{"a", "b"}, {}, {"c"}}, failAt: -1}
+
+	items, err := DrainPages(context.Background(), paginator, func(page *testPage) []string {
+		return page.Items
+	}, func(o *testOptions) {
+		o.Region = "eu-north-1"
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, items)
+	assert.Equal(t, []string{"eu-north-1", "eu-north-1", "eu-north-1"}, paginator.regions)
+}
+
+func TestDrainPagesError(t *testing.T) {
+	paginator := &testPaginator{pages: [][]string{{"a"}, {"b"}}, failAt: 1}
+
+	items, err := DrainPages(context.Background(), paginator, func(page *testPage) []string {
+		return page.Items
+	})
+
+	assert.EqualError(t, err, "page failed")
+	assert.Equal(t, []string{"a"}, items)
+}
+
+func TestEachPageCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	paginator := &testPaginator{pages: [][]string{{"a"}}, failAt: -1}
+	err := EachPage(ctx, paginator, func(page *testPage) error {
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, paginator.regions)
+}
@@ -0,0 +1,235 @@
+//
+// Throttling classification and adaptive pacing of AWS API calls
+//
+
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+type Throttle struct {
+	opts  *ThrottleOpts
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+type ThrottleOpts struct {
+	// Pause after the first throttling error, grown by Multiplier on each
+	// further one and halved on each success
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+
+	// Retries of throttled or transient calls in Call
+	Retry retry.Policy
+}
+
+var DefaultThrottleOpts = &ThrottleOpts{
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     20 * time.Second,
+	Multiplier:   2,
+	Retry: retry.Policy{
+		MaxAttempts:    8,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+		MaxElapsed:     5 * time.Minute,
+	},
+}
+
+// Error codes AWS services use for throttling
+var throttlingCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"RequestThrottled":                       true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"TransactionInProgressException":         true,
+	"RequestLimitExceeded":                   true,
+	"BandwidthLimitExceeded":                 true,
+	"LimitExceededException":                 true,
+	"SlowDown":                               true,
+	"PriorRequestNotComplete":                true,
+	"EC2ThrottledException":                  true,
+}
+
+// Error codes of transient service side failures
+var transientCodes = map[string]bool{
+	"RequestTimeout":          true,
+	"RequestTimeoutException": true,
+	"InternalError":           true,
+	"InternalFailure":         true,
+	"ServiceUnavailable":      true,
+	"InternalServerError":     true,
+}
+
+// Implemented by smithy API errors
+type errorCoder interface {
+	ErrorCode() string
+}
+
+// Implemented by smithy HTTP response errors
+type httpStatusCoder interface {
+	HTTPStatusCode() int
+}
+
+//
+// Initialize new throttle instance
+//
+
+func NewThrottle() *Throttle {
+	return NewThrottleWithOpts(DefaultThrottleOpts)
+}
+
+func NewThrottleWithOpts(opts *ThrottleOpts) *Throttle {
+	if opts.InitialDelay == 0 {
+		opts.InitialDelay = DefaultThrottleOpts.InitialDelay
+	}
+
+	if opts.MaxDelay == 0 {
+		opts.MaxDelay = DefaultThrottleOpts.MaxDelay
+	}
+
+	if opts.Multiplier == 0 {
+		opts.Multiplier = DefaultThrottleOpts.Multiplier
+	}
+
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultThrottleOpts.Retry
+	}
+
+	return &Throttle{opts: opts}
+}
+
+//
+// Wait out the current pause, zero until a call has been throttled
+//
+
+func (t *Throttle) Wait(ctx context.Context) error {
+	delay := t.Delay()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//
+// Adjust pause by call outcome, errors other than throttling leave it as is
+//
+
+func (t *Throttle) Observe(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case IsThrottling(err):
+		t.delay = time.Duration(float64(t.delay) * t.opts.Multiplier)
+		t.delay = min(max(t.delay, t.opts.InitialDelay), t.opts.MaxDelay)
+
+	case err == nil:
+		t.delay /= 2
+		if t.delay < t.opts.InitialDelay/2 {
+			t.delay = 0
+		}
+	}
+}
+
+func (t *Throttle) Delay() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.delay
+}
+
+//
+// Run fn paced by throttle, retrying throttled and transient failures, a nil
+// throttle only retries
+//
+
+func Call[T any](ctx context.Context, t *Throttle, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy := DefaultThrottleOpts.Retry
+	if t != nil {
+		policy = t.opts.Retry
+	}
+
+	return retry.DoValue(ctx, func(ctx context.Context) (T, error) {
+		if t != nil {
+			if err := t.Wait(ctx); err != nil {
+				var empty T
+				return empty, err
+			}
+		}
+
+		result, err := fn(ctx)
+		if t != nil {
+			t.Observe(err)
+		}
+
+		return result, err
+	}, retry.WithPolicy(policy), retry.WithRetryIf(IsRetryable))
+}
+
+//
+// Whether err is an AWS throttling error, by error code or HTTP 429
+//
+
+func IsThrottling(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coder errorCoder
+	if errors.As(err, &coder) && throttlingCodes[coder.ErrorCode()] {
+		return true
+	}
+
+	var status httpStatusCoder
+	return errors.As(err, &status) && (status.HTTPStatusCode() == http.StatusTooManyRequests)
+}
+
+//
+// Throttling and transient service failures are worth retrying
+//
+
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if IsThrottling(err) {
+		return true
+	}
+
+	var coder errorCoder
+	if errors.As(err, &coder) && transientCodes[coder.ErrorCode()] {
+		return true
+	}
+
+	var status httpStatusCoder
+	if errors.As(err, &status) {
+		code := status.HTTPStatusCode()
+		return (code == http.StatusBadGateway) || (code == http.StatusServiceUnavailable) ||
+			(code == http.StatusGatewayTimeout) || (code == http.StatusInternalServerError)
+	}
+
+	return false
+}
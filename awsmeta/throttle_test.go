@@ -0,0 +1,120 @@
+package awsmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAPIError struct {
+	code   string
+	status int
+}
+
+func (e *testAPIError) Error() string {
+	return e.code
+}
+
+func (e *testAPIError) ErrorCode() string {
+	return e.code
+}
+
+func (e *testAPIError) HTTPStatusCode() int {
+	return e.status
+}
+
+func TestIsThrottling(t *testing.T) {
+	assert.True(t, IsThrottling(&testAPIError{code: "ThrottlingException"}))
+	assert.True(t, IsThrottling(fmt.Errorf("describe: %w", &testAPIError{code: "RequestLimitExceeded"})))
+	assert.True(t, IsThrottling(&testAPIError{code: "Unknown", status: 429}))
+	assert.False(t, IsThrottling(&testAPIError{code: "AccessDenied", status: 403}))
+	assert.False(t, IsThrottling(errors.New("Throttling")))
+	assert.False(t, IsThrottling(nil))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&testAPIError{code: "Throttling"}))
+	assert.True(t, IsRetryable(&testAPIError{code: "InternalError"}))
+	assert.True(t, IsRetryable(&testAPIError{code: "Unknown", status: 503}))
+	assert.False(t, IsRetryable(&testAPIError{code: "ValidationException", status: 400}))
+	assert.False(t, IsRetryable(context.Canceled))
+}
+
+func TestThrottleObserve(t *testing.T) {
+	throttle := NewThrottleWithOpts(&ThrottleOpts{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     30 * time.Millisecond,
+	})
+
+	throttled := &testAPIError{code: "Throttling"}
+
+	throttle.Observe(throttled)
+	assert.Equal(t, 10*time.Millisecond, throttle.Delay())
+
+	throttle.Observe(throttled)
+	assert.Equal(t, 20*time.Millisecond, throttle.Delay())
+
+	throttle.Observe(throttled)
+	assert.Equal(t, 30*time.Millisecond, throttle.Delay())
+
+	// Other errors don't change pacing, successes back off gradually
+	throttle.Observe(errors.New("denied"))
+	assert.Equal(t, 30*time.Millisecond, throttle.Delay())
+
+	throttle.Observe(nil)
+	assert.Equal(t, 15*time.Millisecond, throttle.Delay())
+
+	throttle.Observe(nil)
+	throttle.Observe(nil)
+	assert.Equal(t, time.Duration(0), throttle.Delay())
+}
+
+func TestCall(t *testing.T) {
+	throttle := NewThrottleWithOpts(&ThrottleOpts{
+		InitialDelay: time.Millisecond,
+		Retry: retry.Policy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	attempts := 0
+	result, err := Call(context.Background(), throttle, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", &testAPIError{code: "ThrottlingException"}
+		}
+
+		return "ok", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, time.Millisecond, throttle.Delay())
+
+	// Permanent failures are not retried
+	attempts = 0
+	_, err = Call(context.Background(), throttle, func(ctx context.Context) (string, error) {
+		attempts++
+		return "", &testAPIError{code: "AccessDenied", status: 403}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestThrottleWaitCancelled(t *testing.T) {
+	throttle := NewThrottleWithOpts(&ThrottleOpts{InitialDelay: time.Hour})
+	throttle.Observe(&testAPIError{code: "Throttling"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, throttle.Wait(ctx), context.DeadlineExceeded)
+}
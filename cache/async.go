@@ -0,0 +1,55 @@
+//
+// Non-blocking writes and background refreshes
+//
+
+package cache
+
+//
+// Store data without waiting for the write, reads right after may still see
+// the previous value
+//
+
+func (c *Cache[T]) SetAsync(key string, data T) {
+	c.set(&SetOpts[T]{
+		Key:   key,
+		Data:  data,
+		TTL:   c.defaultTTL,
+		Grace: c.defaultGrace,
+	})
+}
+
+//
+// Regenerate key in the background, readers keep getting the current value
+// until the new one is written, a refresh already in flight is left alone
+//
+
+func (c *Cache[T]) Refresh(key string, generator func() (T, error)) {
+	opts := &GetOpts[T]{
+		Key:       key,
+		TTL:       c.defaultTTL,
+		Grace:     c.defaultGrace,
+		Generator: generator,
+	}
+
+	c.mu.RLock()
+	item, exists := c.items[key]
+
+	var cycle uint64
+	var working bool
+	if exists {
+		cycle = item.cycle
+		working = item.working
+	}
+
+	c.mu.RUnlock()
+
+	if working {
+		return
+	}
+
+	if exists {
+		c.updateCacheItem(opts, cycle)
+	} else {
+		c.createCacheItem(opts, cycle)
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAsync(t *testing.T) {
+	c := New[string]()
+	c.SetAsync("key", "value")
+
+	assert.Eventually(t, func() bool {
+		value, err := c.Get("key", nil)
+		return err == nil && value == "value"
+	}, time.Second, time.Millisecond)
+}
+
+func TestRefresh(t *testing.T) {
+	c := New[int]()
+	c.Set("key", 1)
+
+	release := make(chan bool)
+	var calls atomic.Int32
+
+	generator := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 2, nil
+	}
+
+	c.Refresh("key", generator)
+	c.Refresh("key", generator)
+
+	// Current value is served while the refresh runs
+	value, err := c.Get("key", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		value, _ := c.Get("key", nil)
+		return value == 2
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRefreshMissing(t *testing.T) {
+	c := New[int]()
+	c.Refresh("key", func() (int, error) {
+		return 3, nil
+	})
+
+	value, err := c.Get("key", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
+
+func TestLoaderCacheRefresh(t *testing.T) {
+	var version atomic.Int32
+	c := NewLoaderCache(func(key string) (int32, error) {
+		return version.Add(1), nil
+	})
+
+	value, err := c.Load("key")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), value)
+
+	assert.NoError(t, c.Refresh("key"))
+	assert.Eventually(t, func() bool {
+		value, _ := c.Load("key")
+		return value == 2
+	}, time.Second, time.Millisecond)
+
+	prefixOnly := NewLoaderCache[int32](nil)
+	assert.ErrorIs(t, prefixOnly.Refresh("key"), ErrNoLoader)
+}
@@ -5,7 +5,9 @@
 package cache
 
 import (
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,14 +16,62 @@ type Cache[T any] struct {
 	defaultGrace int64
 	gcInterval   int64
 	lastGcTime   int64
+	cycles       uint64
+	clock        Clock
+	locker       Locker
+	lockedLookup func(key string) (T, bool)
+	lockRetry    time.Duration
+	lockWait     time.Duration
+	serveStale   bool
+	onError      func(key string, err error)
+	clone        func(T) T
+	negErrors    []error
+	negTTL       int64
+	rejectNil    bool
+	keyStats     bool
+	name         string
+	metrics      MetricsRegistry
+	counters     *counters
+	generators   chan bool
+	queueTimeout time.Duration
 	mu           sync.RWMutex
 	items        map[string]*Item[T]
+	published    sync.Map
 }
 
 type Opts struct {
-	DefaultTTL   time.Duration
-	DefaultGrace time.Duration
-	GCInterval   time.Duration
+	DefaultTTL        time.Duration
+	DefaultGrace      time.Duration
+	GCInterval        time.Duration
+	Locker            Locker
+	LockRetryInterval time.Duration
+	LockWait          time.Duration
+	ServeStaleOnError bool
+	OnError           func(key string, err error)
+	Clock             Clock
+
+	// Zero means unlimited, excess generators queue for up to the timeout
+	// and then fail with ErrOverloaded, zero timeout queues without limit
+	MaxConcurrentGenerators int
+	GeneratorQueueTimeout   time.Duration
+
+	// Generator errors matching one of these, e.g. a not found sentinel, are
+	// cached for NegativeTTL and returned without calling the generator
+	NegativeErrors []error
+	NegativeTTL    time.Duration
+
+	// Nil pointers, maps, slices and interfaces fail with ErrNilValue instead
+	// of being cached, by default they are cached like any other value
+	RejectNil bool
+
+	// Count hits and misses per key for TopKeys, off by default as every hit
+	// then writes to shared memory
+	KeyStats bool
+
+	// Export cache wide statistics under name, counting them costs a shared
+	// write per hit so it is off without a registry
+	Name    string
+	Metrics MetricsRegistry
 }
 
 type Item[T any] struct {
@@ -32,6 +82,25 @@ type Item[T any] struct {
 	created int64
 	expires int64
 	banned  int64
+	cycle   uint64
+
+	// Cached error, served like data until it expires
+	negative bool
+
+	// Last read or write, updated without locking for LRU trimming
+	accessed atomic.Int64
+
+	// Per-key statistics, only counted with KeyStats enabled
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Immutable copy of a clean item, read without locking on the hit path
+type entry[T any] struct {
+	data     T
+	expires  int64
+	accessed *atomic.Int64
+	hits     *atomic.Uint64
 }
 
 type Channel struct {
@@ -44,6 +113,12 @@ type GetOpts[T any] struct {
 	TTL       int64
 	Grace     int64
 	Generator func() (T, error)
+	OnStore   func(T)
+	TTLFunc   func(T) int64
+	noLock    bool
+
+	// Stop waiting for a generator after this long, zero waits until done
+	MaxWait time.Duration
 }
 
 type SetOpts[T any] struct {
@@ -53,10 +128,21 @@ type SetOpts[T any] struct {
 	Data  T
 }
 
+var (
+	ErrWaitTimeout = errors.New("cache: timed out waiting for generator")
+	ErrNilValue    = errors.New("cache: generator returned nil value")
+
+	// Scope waiters of a generator that panicked
+	ErrGeneratorPanic = errors.New("cache: generator panicked")
+)
+
 var DefaultOpts = &Opts{
-	DefaultTTL:   time.Minute,
-	DefaultGrace: 0,
-	GCInterval:   time.Hour,
+	DefaultTTL:        time.Minute,
+	DefaultGrace:      0,
+	NegativeTTL:       10 * time.Second,
+	GCInterval:        time.Hour,
+	LockRetryInterval: 100 * time.Millisecond,
+	LockWait:          10 * time.Second,
 }
 
 //
@@ -73,33 +159,103 @@ func NewWithOpts[T any](opts *Opts) *Cache[T] {
 		opts.GCInterval = DefaultOpts.GCInterval
 	}
 
-	return &Cache[T]{
+	if opts.LockRetryInterval == 0 {
+		opts.LockRetryInterval = DefaultOpts.LockRetryInterval
+	}
+
+	if opts.LockWait == 0 {
+		opts.LockWait = DefaultOpts.LockWait
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	if opts.NegativeTTL == 0 {
+		opts.NegativeTTL = DefaultOpts.NegativeTTL
+	}
+
+	var generators chan bool
+	if opts.MaxConcurrentGenerators > 0 {
+		generators = make(chan bool, opts.MaxConcurrentGenerators)
+	}
+
+	c := &Cache[T]{
 		defaultTTL:   opts.DefaultTTL.Nanoseconds(),
 		defaultGrace: opts.DefaultGrace.Nanoseconds(),
 		gcInterval:   opts.GCInterval.Nanoseconds(),
-		lastGcTime:   time.Now().UnixNano(),
+		lastGcTime:   opts.Clock.Now(),
+		clock:        opts.Clock,
+		locker:       opts.Locker,
+		lockRetry:    opts.LockRetryInterval,
+		lockWait:     opts.LockWait,
+		serveStale:   opts.ServeStaleOnError,
+		onError:      opts.OnError,
+		generators:   generators,
+		queueTimeout: opts.GeneratorQueueTimeout,
+		negErrors:    opts.NegativeErrors,
+		negTTL:       opts.NegativeTTL.Nanoseconds(),
+		rejectNil:    opts.RejectNil,
+		keyStats:     opts.KeyStats,
+		name:         opts.Name,
+		metrics:      opts.Metrics,
 		items:        make(map[string]*Item[T]),
 	}
+
+	if opts.Metrics != nil {
+		c.counters = &counters{}
+		opts.Metrics.RegisterCache(opts.Name, c.Stats)
+	}
+
+	return c
 }
 
 //
 // Internal cache data writer
 //
 
-func (c *Cache[T]) write(opts *GetOpts[T], data T, err error) {
+func (c *Cache[T]) write(opts *GetOpts[T], item *Item[T], data T, err error) {
+	ttl := opts.TTL
+
+	// TTL derived from the data itself, e.g. DNS records
+	if (err == nil) && (opts.TTLFunc != nil) {
+		ttl = opts.TTLFunc(data)
+	}
+
+	// Negative entries have their own TTL and no grace
+	negative := c.isNegative(err)
+	grace := opts.Grace
+
+	if negative {
+		ttl = c.negTTL
+		grace = 0
+	}
+
 	c.mu.Lock()
-	now := time.Now().UnixNano()
-	item := c.items[opts.Key]
+	now := c.clock.Now()
+
+	// Failed refresh within grace keeps the previous good value and metadata
+	keepStale := c.serveStale && (err != nil) && !negative && (item.cycle > 0) && (item.err == nil) && (now < item.banned)
 
 	// Write item
-	item.data = data
-	item.err = err
+	if !keepStale {
+		item.data = data
+		item.err = err
+		item.negative = negative
+		item.created = now
+		item.expires = (now + ttl)
+		item.banned = (now + ttl + grace)
+	}
+
 	item.working = false
-	item.created = now
-	item.expires = (now + opts.TTL)
-	item.banned = (now + opts.TTL + opts.Grace)
+	item.accessed.Store(now)
+	c.cycles++
+	item.cycle = c.cycles
 
-	c.items[opts.Key] = item
+	// Deleted while generating, waiters still get the result
+	if c.items[opts.Key] == item {
+		c.publish(opts.Key, item)
+	}
 
 	// Trigger garbage collection
 	if (c.gcInterval > 0) && (now >= (c.lastGcTime + c.gcInterval)) {
@@ -108,18 +264,127 @@ func (c *Cache[T]) write(opts *GetOpts[T], data T, err error) {
 	}
 
 	// Item is ready, release lock and broadcast to channel
+	ready := item.ready
 	c.mu.Unlock()
-	item.ready.once.Do(func() {
-		close(item.ready.signal)
+	ready.once.Do(func() {
+		close(ready.signal)
+	})
+}
+
+//
+// Run generator and store result, notify store hook for fresh data
+//
+
+func (c *Cache[T]) generate(opts *GetOpts[T], item *Item[T]) {
+	var data T
+	var err error
+
+	// Plain sets are not generators and skip both limits and locks
+	if !opts.noLock {
+		var release func()
+		release, err = c.acquireGenerator()
+		if release != nil {
+			defer release()
+		}
+	}
+
+	// Hold distributed lock until the value is stored and mirrored
+	waited := false
+	if (err == nil) && (c.locker != nil) && !opts.noLock {
+		var release func()
+		release, waited = c.acquireLock(opts.Key)
+		if release != nil {
+			defer release()
+		}
+	}
+
+	// Holder we waited for has stored the value, no need to mirror it again
+	stored := false
+	if waited && (c.lockedLookup != nil) {
+		data, stored = c.lockedLookup(opts.Key)
+		if stored {
+			data = c.copyValue(data)
+		}
+	}
+
+	if (err == nil) && !stored {
+		start := time.Now()
+		data, err = opts.Generator()
+
+		if (c.metrics != nil) && !opts.noLock {
+			c.observeGeneration(time.Since(start), err)
+		}
+
+		// Generators may hold on to what they return, sets are copied upfront
+		if (err == nil) && !opts.noLock {
+			data = c.copyValue(data)
+		}
+	}
+
+	if (err == nil) && c.rejectNil && isNil(data) {
+		err = ErrNilValue
+	}
+
+	c.write(opts, item, data, err)
+
+	if (err != nil) && (c.onError != nil) {
+		c.onError(opts.Key, err)
+	}
+
+	if (err == nil) && !stored && (opts.OnStore != nil) {
+		opts.OnStore(c.copyValue(data))
+	}
+}
+
+//
+// Publish item for lock-free reads, must be called with write lock held
+//
+
+func (c *Cache[T]) publish(key string, item *Item[T]) {
+	if item.err != nil {
+		c.published.Delete(key)
+		return
+	}
+
+	c.published.Store(key, &entry[T]{
+		data:     item.data,
+		expires:  item.expires,
+		accessed: &item.accessed,
+		hits:     &item.hits,
 	})
 }
 
+//
+// Fast path, fresh published data needs no lock
+//
+
+func (c *Cache[T]) loadPublished(key string) (T, bool) {
+	if v, ok := c.published.Load(key); ok {
+		now := c.clock.Now()
+		if e := v.(*entry[T]); now < e.expires {
+			e.accessed.Store(now)
+			if c.keyStats {
+				e.hits.Add(1)
+			}
+
+			if c.counters != nil {
+				c.counters.hits.Add(1)
+			}
+
+			return e.data, true
+		}
+	}
+
+	var empty T
+	return empty, false
+}
+
 //
 // Clean up all expired items
 //
 
 func (c *Cache[T]) purgeExpiredItems() int {
-	now := time.Now().UnixNano()
+	now := c.clock.Now()
 	var expKeys []string
 
 	// Scan for expired keys
@@ -132,72 +397,90 @@ func (c *Cache[T]) purgeExpiredItems() int {
 	// Delete items
 	for _, k := range expKeys {
 		delete(c.items, k)
+		c.published.Delete(k)
+	}
+
+	if c.counters != nil {
+		c.counters.expired.Add(uint64(len(expKeys)))
 	}
 
 	return len(expKeys)
 }
 
 //
-// Initialize fresh cache item
+// Initialize fresh cache item, unless another goroutine has started or
+// finished generating since the caller saw cycle, returns item to wait for
 //
 
-func (c *Cache[T]) createCacheItem(opts *GetOpts[T]) *Item[T] {
+func (c *Cache[T]) createCacheItem(opts *GetOpts[T], cycle uint64) (*Item[T], *Channel) {
 	c.mu.Lock()
 	item, exists := c.items[opts.Key]
 
-	// Race, already exists
-	if exists && item.working {
+	// Race, already working or regenerated
+	if exists && (item.working || (item.cycle != cycle)) {
+		ready := item.ready
 		c.mu.Unlock()
-		return item
+		return item, ready
 	}
 
-	// Create placeholder object
-	item = &Item[T]{
+	// Create placeholder object, key statistics outlive replaced items
+	fresh := &Item[T]{
 		working: true,
 		ready: &Channel{
 			signal: make(chan bool),
 		},
 	}
 
+	if exists {
+		fresh.hits.Store(item.hits.Load())
+		fresh.misses.Store(item.misses.Load())
+	}
+
+	item = fresh
 	c.items[opts.Key] = item
+	ready := item.ready
 	c.mu.Unlock()
 
 	// Data generator
-	go func() {
-		data, err := opts.Generator()
-		c.write(opts, data, err)
-	}()
+	go c.generate(opts, item)
 
-	return item
+	return item, ready
 }
 
 //
-// Refresh data for existing cache item
+// Refresh data for existing cache item, with the same race checks as create
 //
 
-func (c *Cache[T]) updateCacheItem(opts *GetOpts[T]) {
+func (c *Cache[T]) updateCacheItem(opts *GetOpts[T], cycle uint64) (*Item[T], *Channel) {
 	c.mu.Lock()
-	item := c.items[opts.Key]
+	item, exists := c.items[opts.Key]
 
-	// Race, already working
-	if item.working {
+	// Purged since the caller looked
+	if !exists {
 		c.mu.Unlock()
-		return
+		return c.createCacheItem(opts, cycle)
+	}
+
+	// Race, already working or regenerated
+	if item.working || (item.cycle != cycle) {
+		ready := item.ready
+		c.mu.Unlock()
+		return item, ready
 	}
 
 	// Update working flag, open new channel
-	c.items[opts.Key].working = true
-	c.items[opts.Key].ready = &Channel{
+	item.working = true
+	item.ready = &Channel{
 		signal: make(chan bool),
 	}
 
+	ready := item.ready
 	c.mu.Unlock()
 
 	// Data generator
-	go func() {
-		data, err := opts.Generator()
-		c.write(opts, data, err)
-	}()
+	go c.generate(opts, item)
+
+	return item, ready
 }
 
 //
@@ -205,23 +488,34 @@ func (c *Cache[T]) updateCacheItem(opts *GetOpts[T]) {
 //
 
 func (c *Cache[T]) GetWithOpts(opts *GetOpts[T]) (T, error) {
+	if data, ok := c.loadPublished(opts.Key); ok {
+		return c.copyValue(data), nil
+	}
+
 	c.mu.RLock()
 	item, exists := c.items[opts.Key]
-	now := time.Now().UnixNano()
+	now := c.clock.Now()
 
 	var data T
 	var err error
 	var working bool
+	var negative bool
 	var expires int64
 	var banned int64
+	var cycle uint64
+	var ready *Channel
 
 	// Read data inside lock to avoid race
 	if exists {
+		item.accessed.Store(now)
 		data = item.data
 		err = item.err
 		working = item.working
+		negative = item.negative
 		expires = item.expires
 		banned = item.banned
+		cycle = item.cycle
+		ready = item.ready
 	}
 
 	c.mu.RUnlock()
@@ -229,26 +523,50 @@ func (c *Cache[T]) GetWithOpts(opts *GetOpts[T]) (T, error) {
 	if exists && (err == nil) {
 		// Clean cache hit, nice
 		if now < expires {
-			return data, nil
+			c.countHit(item)
+			return c.copyValue(data), nil
 		}
 
 		// Graceful cache hit, maybe generate new data
 		if now < banned {
 			if !working {
-				c.updateCacheItem(opts)
+				c.updateCacheItem(opts, cycle)
 			}
 
-			return data, nil
+			c.countHit(item)
+			return c.copyValue(data), nil
 		}
 	}
 
+	// Cached negative result
+	if exists && negative && (now < expires) {
+		c.countHit(item)
+		return data, err
+	}
+
+	// Expired data is kept to return alongside a wait timeout
+	var stale T
+	if exists && (err == nil) {
+		stale = data
+	}
+
 	// Complete miss, new cache item
-	if !exists || !working {
-		item = c.createCacheItem(opts)
+	if !working {
+		item, ready = c.createCacheItem(opts, cycle)
 	}
 
-	// Wait for data to be generated
-	<-item.ready.signal
+	if c.keyStats {
+		item.misses.Add(1)
+	}
+
+	if c.counters != nil {
+		c.counters.misses.Add(1)
+	}
+
+	// Wait for data to be generated, generation carries on after a timeout
+	if !waitReady(ready, opts.MaxWait) {
+		return c.copyValue(stale), ErrWaitTimeout
+	}
 
 	// Read new data
 	c.mu.RLock()
@@ -257,7 +575,32 @@ func (c *Cache[T]) GetWithOpts(opts *GetOpts[T]) (T, error) {
 	c.mu.RUnlock()
 
 	// Finally done
-	return data, err
+	if err != nil {
+		return data, err
+	}
+
+	return c.copyValue(data), nil
+}
+
+//
+// Wait for channel, reports false if max wait passed first
+//
+
+func waitReady(ready *Channel, maxWait time.Duration) bool {
+	if maxWait <= 0 {
+		<-ready.signal
+		return true
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ready.signal:
+		return true
+	case <-timer.C:
+		return false
+	}
 }
 
 //
@@ -265,6 +608,11 @@ func (c *Cache[T]) GetWithOpts(opts *GetOpts[T]) (T, error) {
 //
 
 func (c *Cache[T]) Get(key string, generator func() (T, error)) (T, error) {
+	// Checked before building opts, which escape to the heap
+	if data, ok := c.loadPublished(key); ok {
+		return c.copyValue(data), nil
+	}
+
 	return c.GetWithOpts(&GetOpts[T]{
 		Key:       key,
 		TTL:       c.defaultTTL,
@@ -273,33 +621,82 @@ func (c *Cache[T]) Get(key string, generator func() (T, error)) (T, error) {
 	})
 }
 
+//
+// Cache getter invoking onStore once for every newly computed value
+//
+
+func (c *Cache[T]) GetOrSet(key string, compute func() (T, error), onStore func(T)) (T, error) {
+	// Checked before building opts, which escape to the heap
+	if data, ok := c.loadPublished(key); ok {
+		return c.copyValue(data), nil
+	}
+
+	return c.GetWithOpts(&GetOpts[T]{
+		Key:       key,
+		TTL:       c.defaultTTL,
+		Grace:     c.defaultGrace,
+		Generator: compute,
+		OnStore:   onStore,
+	})
+}
+
 //
 // Cache setter with opts
 //
 
 func (c *Cache[T]) SetWithOpts(opts *SetOpts[T]) {
+	ready := c.set(opts)
+
+	// Wait for data to be generated
+	<-ready.signal
+}
+
+func (c *Cache[T]) set(opts *SetOpts[T]) *Channel {
+	// Copy before returning, the caller may reuse data right away
+	data := c.copyValue(opts.Data)
+
 	getOpts := &GetOpts[T]{
 		Key:   opts.Key,
 		TTL:   opts.TTL,
 		Grace: opts.Grace,
 		Generator: func() (T, error) {
-			return opts.Data, nil
+			return data, nil
 		},
+		noLock: true,
 	}
 
 	c.mu.RLock()
 	item, exists := c.items[opts.Key]
+
+	var cycle uint64
+	if exists {
+		cycle = item.cycle
+	}
+
 	c.mu.RUnlock()
 
 	// Update data if container exists, otherwise create
+	var ready *Channel
 	if exists {
-		c.updateCacheItem(getOpts)
+		_, ready = c.updateCacheItem(getOpts, cycle)
 	} else {
-		item = c.createCacheItem(getOpts)
+		_, ready = c.createCacheItem(getOpts, cycle)
 	}
 
-	// Wait for data to be generated
-	<-item.ready.signal
+	return ready
+}
+
+//
+// Remove item, a generation in flight still answers its waiters but its
+// result is not stored
+//
+
+func (c *Cache[T]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+	c.published.Delete(key)
 }
 
 //
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/publishlab/infra-golang-toolkit/cache/cachetest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,6 +25,57 @@ func BenchmarkCache(b *testing.B) {
 	}
 }
 
+func benchmarkCacheParallel(b *testing.B, keys int, writeEvery int) {
+	cache := New[[]byte]()
+	generator := func() ([]byte, error) {
+		return []byte(`ok`), nil
+	}
+
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = fmt.Sprintf("key-%d", i)
+		cache.Get(names[i], generator)
+	}
+
+	var seed int64
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+
+		for i := 1; pb.Next(); i++ {
+			key := names[rnd.Intn(keys)]
+
+			if (writeEvery > 0) && (i%writeEvery == 0) {
+				cache.Set(key, []byte(`ok`))
+				continue
+			}
+
+			data, err := cache.Get(key, generator)
+			if (err != nil) || (len(data) != 2) {
+				b.Fatal("unexpected cache result")
+			}
+		}
+	})
+}
+
+func BenchmarkCacheParallelRead(b *testing.B) {
+	for _, keys := range []int{1, 100, 10000} {
+		b.Run(fmt.Sprintf("keys=%d", keys), func(b *testing.B) {
+			benchmarkCacheParallel(b, keys, 0)
+		})
+	}
+}
+
+func BenchmarkCacheParallelReadMostly(b *testing.B) {
+	for _, keys := range []int{1, 100, 10000} {
+		b.Run(fmt.Sprintf("keys=%d", keys), func(b *testing.B) {
+			benchmarkCacheParallel(b, keys, 100)
+		})
+	}
+}
+
 func TestCacheSingle(t *testing.T) {
 	cache := New[[]byte]()
 
@@ -269,3 +322,463 @@ func TestCacheRace(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestCacheGetOrSet(t *testing.T) {
+	cache := New[int]()
+	var stored atomic.Int64
+	var generated atomic.Int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			data, err := cache.GetOrSet("test", func() (int, error) {
+				generated.Add(1)
+				time.Sleep(20 * time.Millisecond)
+				return 42, nil
+			}, func(data int) {
+				assert.Equal(t, 42, data)
+				stored.Add(1)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 42, data)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		return stored.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, int64(1), generated.Load())
+}
+
+func TestCacheGetOrSetError(t *testing.T) {
+	cache := New[int]()
+	var stored atomic.Int64
+
+	_, err := cache.GetOrSet("test", func() (int, error) {
+		return 0, fmt.Errorf("failed")
+	}, func(data int) {
+		stored.Add(1)
+	})
+
+	assert.EqualError(t, err, "failed")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int64(0), stored.Load())
+}
+
+func TestCacheTTLFunc(t *testing.T) {
+	clock := cachetest.NewClock(time.Time{})
+	cache := NewWithOpts[int64](&Opts{
+		Clock: clock,
+	})
+
+	var calls atomic.Int64
+
+	get := func() int64 {
+		data, err := cache.GetWithOpts(&GetOpts[int64]{
+			Key: "test",
+			TTL: time.Minute.Nanoseconds(),
+			Generator: func() (int64, error) {
+				calls.Add(1)
+				return (50 * time.Millisecond).Nanoseconds(), nil
+			},
+			TTLFunc: func(data int64) int64 {
+				return data
+			},
+		})
+
+		assert.NoError(t, err)
+		return data
+	}
+
+	get()
+	get()
+	assert.Equal(t, int64(1), calls.Load())
+
+	clock.Advance(50 * time.Millisecond)
+	get()
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestCacheClockBoundaries(t *testing.T) {
+	clock := cachetest.NewClock(time.Time{})
+	cache := NewWithOpts[int64](&Opts{
+		DefaultTTL:   time.Second,
+		DefaultGrace: time.Second,
+		Clock:        clock,
+	})
+
+	var calls atomic.Int64
+	get := func() int64 {
+		data, err := cache.Get("test", func() (int64, error) {
+			return calls.Add(1), nil
+		})
+
+		assert.NoError(t, err)
+		return data
+	}
+
+	assert.Equal(t, int64(1), get())
+
+	// Last nanosecond of TTL is a clean hit
+	clock.Advance(time.Second - 1)
+	assert.Equal(t, int64(1), get())
+	assert.Equal(t, int64(1), calls.Load())
+
+	// Expired, stale value served while refreshing
+	clock.Advance(1)
+	assert.Equal(t, int64(1), get())
+	assert.Eventually(t, func() bool {
+		return get() == 2
+	}, time.Second, time.Millisecond)
+
+	// Past TTL and grace, regenerated synchronously
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, int64(3), get())
+
+	// Expired items are purged on the next write after GC interval
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, 1, cache.purgeExpiredItems())
+}
+
+func TestCacheConcurrentMissSingleGenerator(t *testing.T) {
+	cache := New[int]()
+
+	for key := 0; key < 50; key++ {
+		var calls atomic.Int64
+		var wg sync.WaitGroup
+		start := make(chan bool)
+
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				<-start
+
+				// Instant generator widens the window between miss and create
+				data, err := cache.Get(fmt.Sprint(key), func() (int, error) {
+					calls.Add(1)
+					return 1, nil
+				})
+
+				assert.NoError(t, err)
+				assert.Equal(t, 1, data)
+			}()
+		}
+
+		close(start)
+		wg.Wait()
+		assert.Equal(t, int64(1), calls.Load(), "key %d", key)
+	}
+}
+
+func TestCacheConcurrentExpirySingleGenerator(t *testing.T) {
+	cache := NewWithOpts[int](&Opts{
+		DefaultTTL:   20 * time.Millisecond,
+		DefaultGrace: 0,
+	})
+
+	var calls atomic.Int64
+	generator := func() (int, error) {
+		calls.Add(1)
+		return 1, nil
+	}
+
+	for cycle := 1; cycle <= 5; cycle++ {
+		var wg sync.WaitGroup
+		start := make(chan bool)
+
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				<-start
+				cache.Get("test", generator)
+			}()
+		}
+
+		close(start)
+		wg.Wait()
+		assert.Equal(t, int64(cycle), calls.Load())
+
+		time.Sleep(30 * time.Millisecond)
+	}
+}
+
+func TestCacheConcurrentGraceSingleRefresh(t *testing.T) {
+	cache := NewWithOpts[int](&Opts{
+		DefaultTTL:   20 * time.Millisecond,
+		DefaultGrace: time.Minute,
+	})
+
+	var calls atomic.Int64
+	generator := func() (int, error) {
+		calls.Add(1)
+		return 1, nil
+	}
+
+	cache.Get("test", generator)
+	time.Sleep(30 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	start := make(chan bool)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			<-start
+			cache.Get("test", generator)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestCacheCreateItemDoubleCheck(t *testing.T) {
+	cache := New[int]()
+	var calls atomic.Int64
+
+	opts := &GetOpts[int]{
+		Key: "test",
+		TTL: time.Minute.Nanoseconds(),
+		Generator: func() (int, error) {
+			calls.Add(1)
+			return 1, nil
+		},
+	}
+
+	// Miss observed before another goroutine completed generation
+	_, ready := cache.createCacheItem(opts, 0)
+	<-ready.signal
+
+	item, ready := cache.createCacheItem(opts, 0)
+	<-ready.signal
+
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, 1, item.data)
+
+	// Same for refresh during grace
+	_, ready = cache.updateCacheItem(opts, 0)
+	<-ready.signal
+	assert.Equal(t, int64(1), calls.Load())
+
+	_, ready = cache.updateCacheItem(opts, item.cycle)
+	<-ready.signal
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestCacheServeStaleOnError(t *testing.T) {
+	var errs atomic.Int64
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL:        20 * time.Millisecond,
+		DefaultGrace:      time.Minute,
+		ServeStaleOnError: true,
+		OnError: func(key string, err error) {
+			assert.Equal(t, "test", key)
+			errs.Add(1)
+		},
+	})
+
+	var fail atomic.Bool
+	generator := func() (string, error) {
+		if fail.Load() {
+			return "", fmt.Errorf("backend down")
+		}
+
+		return "ok", nil
+	}
+
+	data, err := cache.Get("test", generator)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", data)
+
+	fail.Store(true)
+	time.Sleep(30 * time.Millisecond)
+
+	// Refresh fails in the background, previous value survives
+	for i := 0; i < 3; i++ {
+		data, err = cache.Get("test", generator)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", data)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	assert.GreaterOrEqual(t, errs.Load(), int64(1))
+	assert.Equal(t, 1, cache.Len())
+
+}
+
+func TestCacheErrorWithoutStale(t *testing.T) {
+	var errs atomic.Int64
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL:   20 * time.Millisecond,
+		DefaultGrace: time.Minute,
+		OnError: func(key string, err error) {
+			errs.Add(1)
+		},
+	})
+
+	cache.Get("test", func() (string, error) {
+		return "ok", nil
+	})
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Stale served while refresh fails and overwrites the item
+	data, err := cache.Get("test", func() (string, error) {
+		return "", fmt.Errorf("backend down")
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", data)
+
+	assert.Eventually(t, func() bool {
+		return errs.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	_, err = cache.Get("test", func() (string, error) {
+		return "", fmt.Errorf("still down")
+	})
+
+	assert.EqualError(t, err, "still down")
+}
+
+func TestCachePublishedEntries(t *testing.T) {
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL: time.Minute,
+	})
+
+	cache.Set("test", "first")
+	data, err := cache.Get("test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", data)
+
+	// Overwrite is visible on the fast path
+	cache.Set("test", "second")
+	data, err = cache.Get("test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", data)
+
+	// Errors are never published
+	_, err = cache.GetWithOpts(&GetOpts[string]{
+		Key: "failed",
+		TTL: time.Minute.Nanoseconds(),
+		Generator: func() (string, error) {
+			return "", fmt.Errorf("failed")
+		},
+	})
+
+	assert.Error(t, err)
+	_, ok := cache.published.Load("failed")
+	assert.False(t, ok)
+
+	// Purged items are unpublished
+	cache.SetWithOpts(&SetOpts[string]{
+		Key:  "test",
+		Data: "expired",
+	})
+
+	cache.mu.Lock()
+	cache.purgeExpiredItems()
+	cache.mu.Unlock()
+
+	_, ok = cache.published.Load("test")
+	assert.False(t, ok)
+}
+
+func TestCacheMaxWait(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL: time.Minute,
+		Clock:      clock,
+	})
+
+	release := make(chan bool)
+	slow := func() (string, error) {
+		<-release
+		return "fresh", nil
+	}
+
+	// Cold miss gives up with no value
+	data, err := cache.GetWithOpts(&GetOpts[string]{
+		Key:       "test",
+		TTL:       time.Minute.Nanoseconds(),
+		Generator: slow,
+		MaxWait:   10 * time.Millisecond,
+	})
+
+	assert.ErrorIs(t, err, ErrWaitTimeout)
+	assert.Equal(t, "", data)
+
+	// Generation carries on and is stored for later readers
+	close(release)
+	data, err = cache.Get("test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", data)
+
+	// Expired item returns the stale value with the timeout
+	clock.Advance(2 * time.Minute)
+	block := make(chan bool)
+	defer close(block)
+
+	data, err = cache.GetWithOpts(&GetOpts[string]{
+		Key: "test",
+		TTL: time.Minute.Nanoseconds(),
+		Generator: func() (string, error) {
+			<-block
+			return "newer", nil
+		},
+		MaxWait: 10 * time.Millisecond,
+	})
+
+	assert.ErrorIs(t, err, ErrWaitTimeout)
+	assert.Equal(t, "fresh", data)
+}
+
+func TestCacheDeleteWhileGenerating(t *testing.T) {
+	cache := New[string]()
+	release := make(chan bool)
+
+	done := make(chan string)
+	go func() {
+		data, _ := cache.Get("key", func() (string, error) {
+			<-release
+			return "stale", nil
+		})
+		done <- data
+	}()
+
+	assert.Eventually(t, func() bool {
+		cache.mu.RLock()
+		defer cache.mu.RUnlock()
+		return cache.items["key"] != nil
+	}, time.Second, time.Millisecond)
+
+	cache.Delete("key")
+	close(release)
+
+	// Waiter gets its result, but it isn't stored
+	assert.Equal(t, "stale", <-done)
+	assert.Equal(t, 0, cache.Len())
+
+	data, _ := cache.Get("key", func() (string, error) {
+		return "fresh", nil
+	})
+
+	assert.Equal(t, "fresh", data)
+}
@@ -0,0 +1,223 @@
+//
+// Metrics registry for caches, serving Prometheus text format and
+// snapshots for OpenTelemetry observable callbacks
+//
+
+package cachemetrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+)
+
+type Registry struct {
+	namespace  string
+	buckets    []float64
+	mu         sync.Mutex
+	caches     map[string]func() cache.Stats
+	histograms map[string]*histogram
+}
+
+type Opts struct {
+	// Metric name prefix
+	Namespace string
+
+	// Generation duration histogram upper bounds in seconds
+	Buckets []float64
+}
+
+// Named cache statistics at one point in time
+type Snapshot struct {
+	Name       string
+	Stats      cache.Stats
+	Generation Histogram
+}
+
+// Cumulative bucket counts, as in Prometheus
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+var DefaultOpts = &Opts{
+	Namespace: "cache",
+	Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+}
+
+//
+// Initialize new registry instance
+//
+
+func New() *Registry {
+	return NewWithOpts(DefaultOpts)
+}
+
+func NewWithOpts(opts *Opts) *Registry {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultOpts.Namespace
+	}
+
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultOpts.Buckets
+	}
+
+	buckets := append([]float64(nil), opts.Buckets...)
+	sort.Float64s(buckets)
+
+	return &Registry{
+		namespace:  opts.Namespace,
+		buckets:    buckets,
+		caches:     make(map[string]func() cache.Stats),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+//
+// Register cache, a cache registered again under the same name replaces
+// the previous one
+//
+
+func (r *Registry) RegisterCache(name string, stats func() cache.Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.caches[name] = stats
+	r.histograms[name] = &histogram{counts: make([]uint64, len(r.buckets))}
+}
+
+func (r *Registry) ObserveGeneration(name string, duration time.Duration, err error) {
+	seconds := duration.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, exists := r.histograms[name]
+	if !exists {
+		return
+	}
+
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+//
+// Statistics of all caches by name
+//
+
+func (r *Registry) Snapshot() []Snapshot {
+	r.mu.Lock()
+	result := make([]Snapshot, 0, len(r.caches))
+	stats := make([]func() cache.Stats, 0, len(r.caches))
+
+	for name, fn := range r.caches {
+		h := r.histograms[name]
+		result = append(result, Snapshot{
+			Name: name,
+			Generation: Histogram{
+				Buckets: r.buckets,
+				Counts:  append([]uint64(nil), h.counts...),
+				Count:   h.count,
+				Sum:     h.sum,
+			},
+		})
+
+		stats = append(stats, fn)
+	}
+
+	r.mu.Unlock()
+
+	// Stats take cache locks, so read them outside ours
+	for i := range result {
+		result[i].Stats = stats[i]()
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+//
+// Write all metrics in Prometheus text exposition format
+//
+
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	snapshots := r.Snapshot()
+	var b strings.Builder
+
+	metric := func(name string, kind string, help string, value func(s *Snapshot) float64) {
+		fullName := r.namespace + "_" + name
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", fullName, help, fullName, kind)
+
+		for i := range snapshots {
+			fmt.Fprintf(&b, "%s{cache=\"%s\"} %s\n", fullName, escapeLabel(snapshots[i].Name), formatValue(value(&snapshots[i])))
+		}
+	}
+
+	metric("size", "gauge", "Items held.", func(s *Snapshot) float64 { return float64(s.Stats.Size) })
+	metric("hits_total", "counter", "Reads served from cache.", func(s *Snapshot) float64 { return float64(s.Stats.Hits) })
+	metric("misses_total", "counter", "Reads waiting for a generator.", func(s *Snapshot) float64 { return float64(s.Stats.Misses) })
+	metric("expired_total", "counter", "Expired items purged.", func(s *Snapshot) float64 { return float64(s.Stats.Expired) })
+	metric("evicted_total", "counter", "Items evicted to reduce size.", func(s *Snapshot) float64 { return float64(s.Stats.Evicted) })
+	metric("generations_total", "counter", "Generator runs.", func(s *Snapshot) float64 { return float64(s.Stats.Generations) })
+	metric("generation_errors_total", "counter", "Failed generator runs.", func(s *Snapshot) float64 { return float64(s.Stats.GenerationErrors) })
+
+	name := r.namespace + "_generation_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Generator run time.\n# TYPE %s histogram\n", name, name)
+
+	for _, s := range snapshots {
+		label := escapeLabel(s.Name)
+		for i, bound := range s.Generation.Buckets {
+			fmt.Fprintf(&b, "%s_bucket{cache=\"%s\",le=\"%s\"} %d\n", name, label, formatValue(bound), s.Generation.Counts[i])
+		}
+
+		fmt.Fprintf(&b, "%s_bucket{cache=\"%s\",le=\"+Inf\"} %d\n", name, label, s.Generation.Count)
+		fmt.Fprintf(&b, "%s_sum{cache=\"%s\"} %s\n", name, label, formatValue(s.Generation.Sum))
+		fmt.Fprintf(&b, "%s_count{cache=\"%s\"} %d\n", name, label, s.Generation.Count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+//
+// Serve metrics for Prometheus scrapes
+//
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WritePrometheus(w)
+	})
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
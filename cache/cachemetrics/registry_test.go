@@ -0,0 +1,72 @@
+package cachemetrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewWithOpts(&Opts{Buckets: []float64{1, 0.5}})
+
+	users := cache.NewWithOpts[string](&cache.Opts{
+		DefaultTTL: time.Minute,
+		Name:       "users",
+		Metrics:    registry,
+	})
+
+	users.Get("a", func() (string, error) {
+		return "alice", nil
+	})
+
+	users.Get("a", nil)
+	users.Get("b", func() (string, error) {
+		return "", errors.New("backend down")
+	})
+
+	users.Set("c", "carol")
+
+	registry.ObserveGeneration("unknown", time.Second, nil)
+
+	snapshots := registry.Snapshot()
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "users", snapshots[0].Name)
+	assert.Equal(t, cache.Stats{
+		Size:             3,
+		Hits:             1,
+		Misses:           2,
+		Generations:      2,
+		GenerationErrors: 1,
+	}, snapshots[0].Stats)
+
+	assert.Equal(t, []float64{0.5, 1}, snapshots[0].Generation.Buckets)
+	assert.Equal(t, []uint64{2, 2}, snapshots[0].Generation.Counts)
+	assert.Equal(t, uint64(2), snapshots[0].Generation.Count)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	registry := New()
+	registry.RegisterCache(`we"ird`, func() cache.Stats {
+		return cache.Stats{Size: 2, Hits: 5}
+	})
+
+	registry.ObserveGeneration(`we"ird`, 20*time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, body, "# TYPE cache_size gauge\ncache_size{cache=\"we\\\"ird\"} 2\n")
+	assert.Contains(t, body, "cache_hits_total{cache=\"we\\\"ird\"} 5\n")
+	assert.Contains(t, body, "cache_generation_duration_seconds_bucket{cache=\"we\\\"ird\",le=\"0.01\"} 0\n")
+	assert.Contains(t, body, "cache_generation_duration_seconds_bucket{cache=\"we\\\"ird\",le=\"0.05\"} 1\n")
+	assert.Contains(t, body, "cache_generation_duration_seconds_bucket{cache=\"we\\\"ird\",le=\"+Inf\"} 1\n")
+	assert.Contains(t, body, "cache_generation_duration_seconds_sum{cache=\"we\\\"ird\"} 0.02\n")
+	assert.Contains(t, body, "cache_generation_duration_seconds_count{cache=\"we\\\"ird\"} 1\n")
+}
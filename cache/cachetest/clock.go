@@ -0,0 +1,46 @@
+//
+// Test helpers for code using the cache package
+//
+
+package cachetest
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Manually advanced clock, satisfies cache.Clock
+type Clock struct {
+	now atomic.Int64
+}
+
+//
+// Initialize fake clock at start, zero time means the current time
+//
+
+func NewClock(start time.Time) *Clock {
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	c := &Clock{}
+	c.now.Store(start.UnixNano())
+
+	return c
+}
+
+func (c *Clock) Now() int64 {
+	return c.now.Load()
+}
+
+func (c *Clock) Time() time.Time {
+	return time.Unix(0, c.Now())
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.now.Add(d.Nanoseconds())
+}
+
+func (c *Clock) Set(t time.Time) {
+	c.now.Store(t.UnixNano())
+}
@@ -0,0 +1,27 @@
+package cachetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ cache.Clock = (*Clock)(nil)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	assert.Equal(t, start.UnixNano(), clock.Now())
+
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, start.Add(time.Millisecond).UnixNano(), clock.Now())
+	assert.True(t, clock.Time().Equal(start.Add(time.Millisecond)))
+
+	clock.Set(start)
+	assert.Equal(t, start.UnixNano(), clock.Now())
+
+	assert.WithinDuration(t, time.Now(), NewClock(time.Time{}).Time(), time.Second)
+}
@@ -0,0 +1,24 @@
+//
+// Time source, injectable so tests can move time without sleeping
+//
+
+package cache
+
+import (
+	"time"
+)
+
+// Current time in nanoseconds since the unix epoch
+type Clock interface {
+	Now() int64
+}
+
+type ClockFunc func() int64
+
+func (f ClockFunc) Now() int64 {
+	return f()
+}
+
+var SystemClock Clock = ClockFunc(func() int64 {
+	return time.Now().UnixNano()
+})
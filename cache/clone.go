@@ -0,0 +1,32 @@
+//
+// Copy-on-read and copy-on-write for values with shared backing storage,
+// like slices and maps
+//
+
+package cache
+
+//
+// Initialize cache keeping its own copy of stored values and handing every
+// reader another, so mutating a stored or returned slice or map can't
+// corrupt what other readers see, clone must handle zero values, e.g.
+// slices.Clone[[]string]
+//
+
+func NewWithClone[T any](opts *Opts, clone func(T) T) *Cache[T] {
+	c := NewWithOpts[T](opts)
+	c.clone = clone
+	return c
+}
+
+//
+// Copy of value for readers and for storing, so neither side keeps a
+// reference into the cache, zero-copy unless a clone func is set
+//
+
+func (c *Cache[T]) copyValue(data T) T {
+	if c.clone == nil {
+		return data
+	}
+
+	return c.clone(data)
+}
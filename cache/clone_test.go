@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheClone(t *testing.T) {
+	cache := NewWithClone[[]string](&Opts{
+		DefaultTTL: time.Minute,
+	}, slices.Clone[[]string])
+
+	stored := make(chan []string, 1)
+	data, err := cache.GetOrSet("test", func() ([]string, error) {
+		return []string{"a", "b"}, nil
+	}, func(data []string) {
+		data[0] = "hook"
+		stored <- data
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, data)
+
+	// Mutating a returned value doesn't leak into the cache
+	data[1] = "mutated"
+	data, err = cache.Get("test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, data)
+
+	cache.Range(func(key string, value []string, expiresAt time.Time) bool {
+		value[0] = "mutated"
+		return true
+	})
+
+	data, _ = cache.Get("test", nil)
+	assert.Equal(t, []string{"a", "b"}, data)
+	assert.Equal(t, []string{"hook", "b"}, <-stored)
+}
+
+func TestCacheCloneMap(t *testing.T) {
+	cache := NewWithClone[map[string]int](&Opts{
+		DefaultTTL: time.Minute,
+	}, maps.Clone[map[string]int])
+
+	cache.Set("test", map[string]int{"a": 1})
+	data, _ := cache.Get("test", nil)
+	data["b"] = 2
+
+	data, _ = cache.Get("test", nil)
+	assert.Equal(t, map[string]int{"a": 1}, data)
+}
+
+func TestCacheCloneOnWrite(t *testing.T) {
+	cache := NewWithClone[[]string](&Opts{
+		DefaultTTL: time.Minute,
+	}, slices.Clone[[]string])
+
+	set := []string{"a"}
+	cache.Set("set", set)
+	set[0] = "mutated"
+
+	async := []string{"a"}
+	cache.SetAsync("async", async)
+	async[0] = "mutated"
+
+	warm := []string{"a"}
+	cache.Warm(map[string][]string{"warm": warm}, 0)
+	warm[0] = "mutated"
+
+	generated := []string{"a"}
+	cache.Get("generated", func() ([]string, error) {
+		return generated, nil
+	})
+
+	generated[0] = "mutated"
+
+	for _, key := range []string{"set", "async", "warm", "generated"} {
+		data, err := cache.Get(key, nil)
+		assert.NoError(t, err, key)
+		assert.Equal(t, []string{"a"}, data, key)
+	}
+
+	scope := cache.Scope()
+	scoped := []string{"a"}
+	scope.Set("scoped", scoped)
+	scoped[0] = "mutated"
+
+	data, _ := scope.Get("scoped", nil)
+	assert.Equal(t, []string{"a"}, data)
+}
+
+func TestCacheZeroCopy(t *testing.T) {
+	cache := New[[]string]()
+	cache.Set("test", []string{"a"})
+
+	first, _ := cache.Get("test", nil)
+	second, _ := cache.Get("test", nil)
+	assert.Same(t, &first[0], &second[0])
+}
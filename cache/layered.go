@@ -0,0 +1,133 @@
+//
+// Two-level cache, process-local L1 in front of a shared L2 store
+//
+
+package cache
+
+import (
+	"time"
+)
+
+// Shared backend, e.g. Redis or memcached, ok is false on a miss
+type Store[T any] interface {
+	Get(key string) (value T, ok bool, err error)
+	Set(key string, value T, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// Optionally implemented by stores that announce updates from other
+// processes, e.g. through Redis keyspace notifications
+type Invalidator interface {
+	OnInvalidate(fn func(key string))
+}
+
+type Layered[T any] struct {
+	l1      *Cache[T]
+	l2      Store[T]
+	ttl     time.Duration
+	onError func(key string, err error)
+}
+
+type LayeredOpts struct {
+	// TTL of values written to L2, L1 uses its own default TTL
+	TTL time.Duration
+
+	// L2 failures, reads fall back to the generator and writes to L1 only
+	OnError func(key string, err error)
+}
+
+var DefaultLayeredOpts = &LayeredOpts{
+	TTL: 10 * time.Minute,
+}
+
+//
+// Initialize layered cache
+//
+
+func NewLayered[T any](l1 *Cache[T], l2 Store[T]) *Layered[T] {
+	return NewLayeredWithOpts(l1, l2, &LayeredOpts{})
+}
+
+func NewLayeredWithOpts[T any](l1 *Cache[T], l2 Store[T], opts *LayeredOpts) *Layered[T] {
+	if opts.TTL == 0 {
+		opts.TTL = DefaultLayeredOpts.TTL
+	}
+
+	l := &Layered[T]{
+		l1:      l1,
+		l2:      l2,
+		ttl:     opts.TTL,
+		onError: opts.OnError,
+	}
+
+	if invalidator, ok := l2.(Invalidator); ok {
+		invalidator.OnInvalidate(l.Invalidate)
+	}
+
+	return l
+}
+
+//
+// Read through L1 and L2, generating and writing both on a full miss, L1
+// coalesces concurrent misses so L2 and the generator see one call per key
+//
+
+func (l *Layered[T]) Get(key string, generator func() (T, error)) (T, error) {
+	return l.l1.Get(key, func() (T, error) {
+		value, ok, err := l.l2.Get(key)
+		if err != nil {
+			l.error(key, err)
+		} else if ok {
+			return value, nil
+		}
+
+		value, err = generator()
+		if err != nil {
+			return value, err
+		}
+
+		if err := l.l2.Set(key, value, l.ttl); err != nil {
+			l.error(key, err)
+		}
+
+		return value, nil
+	})
+}
+
+//
+// Write through to L2, then L1, a failed L2 write drops the L1 copy so the
+// layers don't diverge
+//
+
+func (l *Layered[T]) Set(key string, value T) error {
+	if err := l.l2.Set(key, value, l.ttl); err != nil {
+		l.l1.Delete(key)
+		return err
+	}
+
+	l.l1.Set(key, value)
+	return nil
+}
+
+//
+// Delete from both layers
+//
+
+func (l *Layered[T]) Delete(key string) error {
+	l.l1.Delete(key)
+	return l.l2.Delete(key)
+}
+
+//
+// Drop L1 copy after L2 was updated elsewhere
+//
+
+func (l *Layered[T]) Invalidate(key string) {
+	l.l1.Delete(key)
+}
+
+func (l *Layered[T]) error(key string, err error) {
+	if l.onError != nil {
+		l.onError(key, err)
+	}
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStore struct {
+	mu         sync.Mutex
+	data       map[string]string
+	gets       atomic.Int32
+	fail       atomic.Bool
+	invalidate func(key string)
+}
+
+func newTestStore() *testStore {
+	return &testStore{data: make(map[string]string)}
+}
+
+func (s *testStore) Get(key string) (string, bool, error) {
+	s.gets.Add(1)
+	if s.fail.Load() {
+		return "", false, errors.New("store down")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data[key]
+	return value, ok, nil
+}
+
+func (s *testStore) Set(key string, value string, ttl time.Duration) error {
+	if s.fail.Load() {
+		return errors.New("store down")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+	return nil
+}
+
+func (s *testStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+	return nil
+}
+
+func (s *testStore) OnInvalidate(fn func(key string)) {
+	s.invalidate = fn
+}
+
+// Simulate another process writing to the store
+func (s *testStore) update(key string, value string) {
+	s.mu.Lock()
+	s.data[key] = value
+	s.mu.Unlock()
+
+	s.invalidate(key)
+}
+
+func TestLayeredReadThrough(t *testing.T) {
+	l2 := newTestStore()
+	l2.data["shared"] = "from l2"
+
+	layered := NewLayered[string](NewWithOpts[string](&Opts{DefaultTTL: time.Minute}), l2)
+
+	var generated atomic.Int32
+	generator := func() (string, error) {
+		generated.Add(1)
+		return "generated", nil
+	}
+
+	data, err := layered.Get("shared", generator)
+	assert.NoError(t, err)
+	assert.Equal(t, "from l2", data)
+	assert.Equal(t, int32(0), generated.Load())
+
+	// Full miss writes through to L2
+	data, err = layered.Get("new", generator)
+	assert.NoError(t, err)
+	assert.Equal(t, "generated", data)
+	assert.Equal(t, "generated", l2.data["new"])
+
+	// L1 hit skips L2
+	gets := l2.gets.Load()
+	layered.Get("new", generator)
+	assert.Equal(t, gets, l2.gets.Load())
+	assert.Equal(t, int32(1), generated.Load())
+}
+
+func TestLayeredInvalidate(t *testing.T) {
+	l2 := newTestStore()
+	layered := NewLayered[string](NewWithOpts[string](&Opts{DefaultTTL: time.Minute}), l2)
+
+	assert.NoError(t, layered.Set("key", "first"))
+	data, _ := layered.Get("key", nil)
+	assert.Equal(t, "first", data)
+
+	l2.update("key", "second")
+	data, err := layered.Get("key", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", data)
+
+	assert.NoError(t, layered.Delete("key"))
+	_, ok := l2.data["key"]
+	assert.False(t, ok)
+
+	data, _ = layered.Get("key", func() (string, error) {
+		return "regenerated", nil
+	})
+
+	assert.Equal(t, "regenerated", data)
+}
+
+func TestLayeredStoreDown(t *testing.T) {
+	l2 := newTestStore()
+	var errs atomic.Int32
+
+	layered := NewLayeredWithOpts[string](NewWithOpts[string](&Opts{DefaultTTL: time.Minute}), l2, &LayeredOpts{
+		OnError: func(key string, err error) {
+			errs.Add(1)
+		},
+	})
+
+	assert.NoError(t, layered.Set("key", "cached"))
+	l2.fail.Store(true)
+
+	// Reads degrade to the generator
+	data, err := layered.Get("other", func() (string, error) {
+		return "generated", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "generated", data)
+	assert.Equal(t, int32(2), errs.Load())
+
+	// Failed write drops the L1 copy
+	assert.Error(t, layered.Set("key", "updated"))
+	data, _ = layered.Get("key", func() (string, error) {
+		return "fallback", nil
+	})
+
+	assert.Equal(t, "fallback", data)
+}
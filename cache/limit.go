@@ -0,0 +1,49 @@
+//
+// Cap on concurrently running generators, against cold-start storms
+//
+
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrOverloaded = errors.New("cache: too many concurrent generators")
+
+//
+// Wait for a generator slot, queueing without limit unless a queue timeout
+// is set, returns nil release when no cap is configured
+//
+
+func (c *Cache[T]) acquireGenerator() (func(), error) {
+	if c.generators == nil {
+		return nil, nil
+	}
+
+	release := func() {
+		<-c.generators
+	}
+
+	// Fast path, free slot
+	select {
+	case c.generators <- true:
+		return release, nil
+	default:
+	}
+
+	if c.queueTimeout <= 0 {
+		c.generators <- true
+		return release, nil
+	}
+
+	timer := time.NewTimer(c.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case c.generators <- true:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrOverloaded
+	}
+}
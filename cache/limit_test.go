@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheMaxConcurrentGenerators(t *testing.T) {
+	cache := NewWithOpts[int](&Opts{
+		DefaultTTL:              time.Minute,
+		MaxConcurrentGenerators: 3,
+	})
+
+	var running atomic.Int64
+	var peak atomic.Int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			data, err := cache.Get(fmt.Sprintf("key-%d", i), func() (int, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+
+				time.Sleep(5 * time.Millisecond)
+				return i, nil
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, i, data)
+		}(i)
+	}
+
+	wg.Wait()
+	assert.LessOrEqual(t, peak.Load(), int64(3))
+	assert.Equal(t, 20, cache.Len())
+}
+
+func TestCacheGeneratorQueueTimeout(t *testing.T) {
+	var errs atomic.Int64
+	cache := NewWithOpts[int](&Opts{
+		DefaultTTL:              time.Minute,
+		MaxConcurrentGenerators: 1,
+		GeneratorQueueTimeout:   10 * time.Millisecond,
+		OnError: func(key string, err error) {
+			errs.Add(1)
+		},
+	})
+
+	started := make(chan bool)
+	release := make(chan bool)
+
+	go cache.Get("slow", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+
+	<-started
+
+	_, err := cache.Get("other", func() (int, error) {
+		return 2, nil
+	})
+
+	assert.ErrorIs(t, err, ErrOverloaded)
+	assert.Equal(t, int64(1), errs.Load())
+
+	// Plain sets are never throttled
+	cache.Set("set", 3)
+	data, err := cache.Get("set", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, data)
+
+	// Slot frees up once the slow generator is done
+	close(release)
+	assert.Eventually(t, func() bool {
+		data, err := cache.Get("other", func() (int, error) {
+			return 2, nil
+		})
+
+		return (err == nil) && (data == 2)
+	}, time.Second, 5*time.Millisecond)
+}
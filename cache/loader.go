@@ -0,0 +1,115 @@
+//
+// Read-through cache with registered loaders
+//
+
+package cache
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Loader[T any] func(key string) (T, error)
+
+type LoaderCache[T any] struct {
+	*Cache[T]
+	loader  Loader[T]
+	mu      sync.RWMutex
+	loaders []*prefixLoader[T]
+}
+
+type prefixLoader[T any] struct {
+	prefix string
+	loader Loader[T]
+}
+
+var ErrNoLoader = errors.New("cache: no loader for key")
+
+//
+// Initialize new loader cache, loader may be nil when only prefix loaders are used
+//
+
+func NewLoaderCache[T any](loader Loader[T]) *LoaderCache[T] {
+	return NewLoaderCacheWithOpts(DefaultOpts, loader)
+}
+
+func NewLoaderCacheWithOpts[T any](opts *Opts, loader Loader[T]) *LoaderCache[T] {
+	return &LoaderCache[T]{
+		Cache:  NewWithOpts[T](opts),
+		loader: loader,
+	}
+}
+
+//
+// Register loader for keys with prefix, the longest matching prefix wins
+//
+
+func (c *LoaderCache[T]) Register(prefix string, loader Loader[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Replace existing registration
+	for _, l := range c.loaders {
+		if l.prefix == prefix {
+			l.loader = loader
+			return
+		}
+	}
+
+	c.loaders = append(c.loaders, &prefixLoader[T]{
+		prefix: prefix,
+		loader: loader,
+	})
+
+	sort.SliceStable(c.loaders, func(i, j int) bool {
+		return len(c.loaders[i].prefix) > len(c.loaders[j].prefix)
+	})
+}
+
+//
+// Get value for key, loading it on miss
+//
+
+func (c *LoaderCache[T]) Load(key string) (T, error) {
+	loader := c.loaderFor(key)
+	if loader == nil {
+		var empty T
+		return empty, ErrNoLoader
+	}
+
+	return c.Get(key, func() (T, error) {
+		return loader(key)
+	})
+}
+
+//
+// Reload key in the background, see Cache.Refresh
+//
+
+func (c *LoaderCache[T]) Refresh(key string) error {
+	loader := c.loaderFor(key)
+	if loader == nil {
+		return ErrNoLoader
+	}
+
+	c.Cache.Refresh(key, func() (T, error) {
+		return loader(key)
+	})
+
+	return nil
+}
+
+func (c *LoaderCache[T]) loaderFor(key string) Loader[T] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, l := range c.loaders {
+		if strings.HasPrefix(key, l.prefix) {
+			return l.loader
+		}
+	}
+
+	return c.loader
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderCache(t *testing.T) {
+	var calls atomic.Int64
+
+	cache := NewLoaderCache(func(key string) (string, error) {
+		calls.Add(1)
+		return "value:" + key, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		data, err := cache.Load("test")
+		assert.NoError(t, err)
+		assert.Equal(t, "value:test", data)
+	}
+
+	assert.Equal(t, int64(1), calls.Load())
+
+	// Embedded cache is still usable directly
+	cache.Set("other", "set")
+	data, err := cache.Load("other")
+	assert.NoError(t, err)
+	assert.Equal(t, "set", data)
+}
+
+func TestLoaderCachePrefix(t *testing.T) {
+	cache := NewLoaderCache[string](nil)
+
+	_, err := cache.Load("user:1")
+	assert.ErrorIs(t, err, ErrNoLoader)
+
+	cache.Register("user:", func(key string) (string, error) {
+		return "user", nil
+	})
+
+	cache.Register("user:admin:", func(key string) (string, error) {
+		return "admin", nil
+	})
+
+	data, err := cache.Load("user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", data)
+
+	data, err = cache.Load("user:admin:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", data)
+
+	_, err = cache.Load("group:1")
+	assert.ErrorIs(t, err, ErrNoLoader)
+}
@@ -0,0 +1,59 @@
+//
+// Cross-process generation locking
+//
+
+package cache
+
+import (
+	"time"
+)
+
+// Lock held while generating key, e.g. backed by Redis or DynamoDB
+type Locker interface {
+	AcquireLock(key string) (release func(), ok bool)
+}
+
+type LockerFunc func(key string) (release func(), ok bool)
+
+func (f LockerFunc) AcquireLock(key string) (func(), bool) {
+	return f(key)
+}
+
+//
+// Initialize cache reading the shared store with lookup once the lock is
+// acquired, so replicas that waited for the holder return what it stored
+// instead of generating again, lookup reports only fresh values and a miss
+// generates
+//
+
+func NewWithLockedLookup[T any](opts *Opts, lookup func(key string) (T, bool)) *Cache[T] {
+	c := NewWithOpts[T](opts)
+	c.lockedLookup = lookup
+	return c
+}
+
+//
+// Poll for lock until acquired or lock wait passes, then generate anyway
+// rather than fail, returns nil release when the lock was not acquired and
+// whether another holder was waited for
+//
+
+func (c *Cache[T]) acquireLock(key string) (func(), bool) {
+	deadline := time.Now().Add(c.lockWait)
+	waited := false
+
+	for {
+		release, ok := c.locker.AcquireLock(key)
+		if ok {
+			return release, waited
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, true
+		}
+
+		waited = true
+
+		time.Sleep(min(c.lockRetry, time.Until(deadline)))
+	}
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Locker shared between caches, standing in for replicas
+//
+
+type testLocker struct {
+	mu       sync.Mutex
+	held     map[string]bool
+	attempts atomic.Int64
+}
+
+func (l *testLocker) AcquireLock(key string) (func(), bool) {
+	l.attempts.Add(1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[key] {
+		return nil, false
+	}
+
+	l.held[key] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true
+}
+
+func TestCacheLocker(t *testing.T) {
+	locker := &testLocker{held: make(map[string]bool)}
+	opts := &Opts{
+		DefaultTTL:        time.Minute,
+		Locker:            locker,
+		LockRetryInterval: 5 * time.Millisecond,
+	}
+
+	// Second tier the lock holder fills
+	var shared atomic.Value
+	var generated atomic.Int64
+
+	generator := func() (string, error) {
+		if value := shared.Load(); value != nil {
+			return value.(string), nil
+		}
+
+		generated.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		return "ok", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		replica := NewWithOpts[string](opts)
+
+		go func() {
+			defer wg.Done()
+
+			data, err := replica.GetOrSet("test", generator, func(data string) {
+				shared.Store(data)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", data)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(1), generated.Load())
+
+	// Released once the value is mirrored
+	assert.Eventually(t, func() bool {
+		locker.mu.Lock()
+		defer locker.mu.Unlock()
+		return len(locker.held) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestCacheLockedLookup(t *testing.T) {
+	locker := &testLocker{held: make(map[string]bool)}
+	opts := &Opts{
+		DefaultTTL:        time.Minute,
+		Locker:            locker,
+		LockRetryInterval: 5 * time.Millisecond,
+	}
+
+	var shared sync.Map
+	var generated, mirrored atomic.Int64
+
+	lookup := func(key string) (string, bool) {
+		value, ok := shared.Load(key)
+		if !ok {
+			return "", false
+		}
+
+		return value.(string), true
+	}
+
+	// Generator knows nothing about the shared store
+	generator := func() (string, error) {
+		generated.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		return "ok", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		replica := NewWithLockedLookup[string](opts, lookup)
+
+		go func() {
+			defer wg.Done()
+
+			data, err := replica.GetOrSet("test", generator, func(data string) {
+				mirrored.Add(1)
+				shared.Store("test", data)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", data)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(1), generated.Load())
+	assert.Equal(t, int64(1), mirrored.Load())
+
+	// Holder that didn't wait generates without a lookup
+	shared.Store("other", "stale")
+	data, err := NewWithLockedLookup[string](opts, lookup).Get("other", generator)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", data)
+}
+
+func TestCacheLockerWait(t *testing.T) {
+	cache := NewWithOpts[string](&Opts{
+		Locker: LockerFunc(func(key string) (func(), bool) {
+			return nil, false
+		}),
+		LockRetryInterval: 5 * time.Millisecond,
+		LockWait:          20 * time.Millisecond,
+	})
+
+	// Generates anyway once lock wait passes
+	start := time.Now()
+	data, err := cache.Get("test", func() (string, error) {
+		return "ok", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", data)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Set does not lock
+	start = time.Now()
+	cache.Set("other", "ok")
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
@@ -0,0 +1,18 @@
+//
+// Memoization decorator
+//
+
+package cache
+
+//
+// Wrap function so results are cached per key with the cache default TTL and
+// grace, concurrent calls for the same key share a single invocation
+//
+
+func Memoize[K comparable, V any](c *Cache[V], keyFn func(K) string, fn func(K) (V, error)) func(K) (V, error) {
+	return func(arg K) (V, error) {
+		return c.Get(keyFn(arg), func() (V, error) {
+			return fn(arg)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoize(t *testing.T) {
+	var calls atomic.Int64
+
+	double := Memoize(New[int](), func(n int) string {
+		return fmt.Sprint(n)
+	}, func(n int) (int, error) {
+		calls.Add(1)
+		return n * 2, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		data, err := double(21)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, data)
+	}
+
+	data, err := double(5)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, data)
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestMemoizeConcurrent(t *testing.T) {
+	var calls atomic.Int64
+
+	lookup := Memoize(New[string](), func(s string) string {
+		return s
+	}, func(s string) (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return s + "!", nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			data, err := lookup("test")
+			assert.NoError(t, err)
+			assert.Equal(t, "test!", data)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestMemoizeError(t *testing.T) {
+	var calls atomic.Int64
+
+	fail := Memoize(New[int](), func(n int) string {
+		return fmt.Sprint(n)
+	}, func(n int) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("failed")
+	})
+
+	_, err := fail(1)
+	assert.EqualError(t, err, "failed")
+
+	// Errors are not cached
+	_, err = fail(1)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, int64(2), calls.Load())
+}
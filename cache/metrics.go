@@ -0,0 +1,70 @@
+//
+// Cache wide statistics for metrics exporters
+//
+
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Implemented by exporters, see cachemetrics for one serving Prometheus
+// text format and snapshots for OpenTelemetry callbacks
+type MetricsRegistry interface {
+	// Called once per cache, stats is read on every collection
+	RegisterCache(name string, stats func() Stats)
+
+	// Called after every generator run
+	ObserveGeneration(name string, duration time.Duration, err error)
+}
+
+// Cumulative since the cache was created, except Size
+type Stats struct {
+	Size             int
+	Hits             uint64
+	Misses           uint64
+	Expired          uint64
+	Evicted          uint64
+	Generations      uint64
+	GenerationErrors uint64
+}
+
+type counters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	expired     atomic.Uint64
+	evicted     atomic.Uint64
+	generations atomic.Uint64
+	genErrors   atomic.Uint64
+}
+
+//
+// Current statistics, counters stay zero without a metrics registry
+//
+
+func (c *Cache[T]) Stats() Stats {
+	c.mu.RLock()
+	stats := Stats{Size: len(c.items)}
+	c.mu.RUnlock()
+
+	if c.counters != nil {
+		stats.Hits = c.counters.hits.Load()
+		stats.Misses = c.counters.misses.Load()
+		stats.Expired = c.counters.expired.Load()
+		stats.Evicted = c.counters.evicted.Load()
+		stats.Generations = c.counters.generations.Load()
+		stats.GenerationErrors = c.counters.genErrors.Load()
+	}
+
+	return stats
+}
+
+func (c *Cache[T]) observeGeneration(duration time.Duration, err error) {
+	c.counters.generations.Add(1)
+	if err != nil {
+		c.counters.genErrors.Add(1)
+	}
+
+	c.metrics.ObserveGeneration(c.name, duration, err)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRegistry struct {
+	mu          sync.Mutex
+	name        string
+	stats       func() Stats
+	generations int
+	errors      int
+}
+
+func (r *testRegistry) RegisterCache(name string, stats func() Stats) {
+	r.name = name
+	r.stats = stats
+}
+
+func (r *testRegistry) ObserveGeneration(name string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generations++
+	if err != nil {
+		r.errors++
+	}
+}
+
+func TestStats(t *testing.T) {
+	registry := &testRegistry{}
+	c := NewWithOpts[int](&Opts{
+		DefaultTTL: time.Minute,
+		Name:       "numbers",
+		Metrics:    registry,
+	})
+
+	assert.Equal(t, "numbers", registry.name)
+
+	c.Get("a", func() (int, error) { return 1, nil })
+	c.Get("a", nil)
+	c.Get("a", nil)
+	c.Get("b", func() (int, error) { return 0, errors.New("failed") })
+	c.Set("c", 3)
+	c.Set("d", 4)
+	c.TrimToSize(2)
+
+	assert.Equal(t, Stats{
+		Size:             2,
+		Hits:             2,
+		Misses:           2,
+		Evicted:          2,
+		Generations:      2,
+		GenerationErrors: 1,
+	}, registry.stats())
+
+	assert.Equal(t, 2, registry.generations)
+	assert.Equal(t, 1, registry.errors)
+}
+
+func TestStatsWithoutRegistry(t *testing.T) {
+	c := New[int]()
+	c.Get("a", func() (int, error) { return 1, nil })
+	c.Get("a", nil)
+
+	assert.Equal(t, Stats{Size: 1}, c.Stats())
+}
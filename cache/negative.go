@@ -0,0 +1,45 @@
+//
+// Negative caching of expected errors and nil value detection
+//
+
+package cache
+
+import (
+	"errors"
+	"reflect"
+)
+
+//
+// Whether err is one of the configured cacheable errors
+//
+
+func (c *Cache[T]) isNegative(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, target := range c.negErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Nil interface, or nil value of a nilable kind
+//
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return rv.IsNil()
+	}
+
+	return false
+}
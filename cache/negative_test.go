@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestCacheNegative(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL:     time.Minute,
+		NegativeErrors: []error{errNotFound},
+		NegativeTTL:    5 * time.Second,
+		Clock:          clock,
+	})
+
+	calls := 0
+	generator := func() (string, error) {
+		calls++
+		return "", fmt.Errorf("user 42: %w", errNotFound)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Get("user:42", generator)
+		assert.ErrorIs(t, err, errNotFound)
+	}
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, cache.Len())
+
+	// Negative TTL applies, not the default TTL
+	clock.Advance(6 * time.Second)
+	data, err := cache.Get("user:42", func() (string, error) {
+		calls++
+		return "alice", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", data)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheNegativeOtherErrors(t *testing.T) {
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL:     time.Minute,
+		NegativeErrors: []error{errNotFound},
+	})
+
+	calls := 0
+	generator := func() (string, error) {
+		calls++
+		return "", errors.New("backend down")
+	}
+
+	cache.Get("key", generator)
+	cache.Get("key", generator)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheNegativeReplacesStale(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL:        time.Minute,
+		DefaultGrace:      time.Hour,
+		ServeStaleOnError: true,
+		NegativeErrors:    []error{errNotFound},
+		Clock:             clock,
+	})
+
+	cache.Set("key", "old")
+	clock.Advance(2 * time.Minute)
+
+	// Deleted upstream, stale data must not outlive it
+	ready := make(chan bool)
+	data, err := cache.Get("key", func() (string, error) {
+		defer close(ready)
+		return "", errNotFound
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "old", data)
+	<-ready
+
+	assert.Eventually(t, func() bool {
+		_, err := cache.Get("key", nil)
+		return errors.Is(err, errNotFound)
+	}, time.Second, time.Millisecond)
+}
+
+func TestCacheZeroValues(t *testing.T) {
+	cache := New[*int]()
+
+	// Nil and zero values are cached like any other value by default
+	calls := 0
+	for i := 0; i < 2; i++ {
+		data, err := cache.Get("nil", func() (*int, error) {
+			calls++
+			return nil, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Nil(t, data)
+	}
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheRejectNil(t *testing.T) {
+	cache := NewWithOpts[any](&Opts{
+		DefaultTTL: time.Minute,
+		RejectNil:  true,
+	})
+
+	var ptr *int
+	for _, value := range []any{nil, ptr, []string(nil), map[string]int(nil)} {
+		_, err := cache.Get("key", func() (any, error) {
+			return value, nil
+		})
+
+		assert.ErrorIs(t, err, ErrNilValue)
+	}
+
+	// Zero values that aren't nil are fine
+	for _, value := range []any{0, "", []string{}} {
+		cache.Delete("key")
+		data, err := cache.Get("key", func() (any, error) {
+			return value, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, value, data)
+	}
+}
@@ -0,0 +1,180 @@
+//
+// Request scoped child caches
+//
+
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Reads fresh values through to the parent, everything generated or set in
+// the scope stays local and is dropped on Release
+type Scope[T any] struct {
+	parent   *Cache[T]
+	mu       sync.Mutex
+	values   map[string]*scopeValue[T]
+	released bool
+}
+
+type scopeValue[T any] struct {
+	data  T
+	err   error
+	ready chan bool
+
+	// Deleted in scope, hides the parent value
+	deleted bool
+}
+
+//
+// Initialize child cache, for memoization of per-request variants that must
+// not end up in the shared cache
+//
+
+func (c *Cache[T]) Scope() *Scope[T] {
+	return &Scope[T]{
+		parent: c,
+		values: make(map[string]*scopeValue[T]),
+	}
+}
+
+//
+// Get value from scope or parent, generating it in the scope on a miss,
+// concurrent misses for a key share one generator call and errors are not
+// kept
+//
+
+func (s *Scope[T]) Get(key string, generator func() (T, error)) (T, error) {
+	s.mu.Lock()
+
+	if v, exists := s.values[key]; exists && !v.deleted {
+		s.mu.Unlock()
+		<-v.ready
+
+		return s.parent.copyValue(v.data), v.err
+	}
+
+	masked := s.values[key] != nil
+	if !masked {
+		if data, ok := s.parent.loadPublished(key); ok {
+			s.mu.Unlock()
+			return s.parent.copyValue(data), nil
+		}
+	}
+
+	// Released scopes generate without storing
+	if s.released {
+		s.mu.Unlock()
+		return generator()
+	}
+
+	v := &scopeValue[T]{ready: make(chan bool)}
+	s.values[key] = v
+	s.mu.Unlock()
+
+	// Waiters get an error if the generator panics, the panic itself goes
+	// on in this caller once they are released
+	var panicked any
+	v.data, v.err, panicked = callGenerator(generator)
+	if v.err == nil {
+		v.data = s.parent.copyValue(v.data)
+	}
+
+	if v.err != nil {
+		s.mu.Lock()
+		if s.values[key] == v {
+			delete(s.values, key)
+
+			// Keep the parent value hidden after a delete
+			if masked {
+				s.values[key] = &scopeValue[T]{deleted: true}
+			}
+		}
+
+		s.mu.Unlock()
+	}
+
+	close(v.ready)
+
+	if panicked != nil {
+		panic(panicked)
+	}
+
+	return s.parent.copyValue(v.data), v.err
+}
+
+func callGenerator[T any](generator func() (T, error)) (data T, err error, panicked any) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrGeneratorPanic, r)
+			panicked = r
+		}
+	}()
+
+	data, err = generator()
+	return data, err, nil
+}
+
+//
+// Set value in scope only
+//
+
+func (s *Scope[T]) Set(key string, data T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return
+	}
+
+	v := &scopeValue[T]{data: s.parent.copyValue(data), ready: make(chan bool)}
+	close(v.ready)
+	s.values[key] = v
+}
+
+//
+// Hide key from the scope, the parent keeps its value
+//
+
+func (s *Scope[T]) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return
+	}
+
+	s.values[key] = &scopeValue[T]{deleted: true}
+}
+
+//
+// Number of values held by the scope
+//
+
+func (s *Scope[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, v := range s.values {
+		if !v.deleted {
+			n++
+		}
+	}
+
+	return n
+}
+
+//
+// Drop scope values, later reads still go through to the parent but
+// nothing is kept
+//
+
+func (s *Scope[T]) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.released = true
+	s.values = make(map[string]*scopeValue[T])
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	c := New[string]()
+	c.Set("shared", "global")
+
+	scope := c.Scope()
+
+	value, err := scope.Get("shared", func() (string, error) {
+		return "", errors.New("parent value expected")
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "global", value)
+
+	value, err = scope.Get("user:1", func() (string, error) {
+		return "alice", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", value)
+
+	scope.Set("user:2", "bob")
+	assert.Equal(t, 2, scope.Len())
+
+	// Writes never reach the parent
+	assert.Equal(t, []string{"shared"}, c.Keys())
+
+	value, _ = scope.Get("user:2", nil)
+	assert.Equal(t, "bob", value)
+}
+
+func TestScopeDelete(t *testing.T) {
+	c := New[string]()
+	c.Set("shared", "global")
+
+	scope := c.Scope()
+	scope.Delete("shared")
+
+	_, err := scope.Get("shared", func() (string, error) {
+		return "", errors.New("failed")
+	})
+
+	assert.Error(t, err)
+
+	value, err := scope.Get("shared", func() (string, error) {
+		return "local", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "local", value)
+
+	parent, _ := c.Get("shared", nil)
+	assert.Equal(t, "global", parent)
+}
+
+func TestScopeCoalesces(t *testing.T) {
+	scope := New[int]().Scope()
+
+	var calls atomic.Int32
+	release := make(chan bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			value, err := scope.Get("key", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 42, value)
+		}()
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestScopePanic(t *testing.T) {
+	scope := New[string]().Scope()
+	started := make(chan bool)
+	release := make(chan bool)
+
+	go func() {
+		assert.PanicsWithValue(t, "boom", func() {
+			scope.Get("key", func() (string, error) {
+				close(started)
+				<-release
+				panic("boom")
+			})
+		})
+	}()
+
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, err := scope.Get("key", nil)
+		waiter <- err
+	}()
+
+	// Let the waiter block on the generator
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-waiter:
+		assert.ErrorIs(t, err, ErrGeneratorPanic)
+	case <-time.After(time.Second):
+		t.Fatal("waiter blocked after generator panic")
+	}
+
+	// Not kept, next call generates
+	data, err := scope.Get("key", func() (string, error) {
+		return "ok", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", data)
+}
+
+func TestScopeRelease(t *testing.T) {
+	c := New[string]()
+	c.Set("shared", "global")
+
+	scope := c.Scope()
+	scope.Set("local", "value")
+	scope.Release()
+
+	assert.Equal(t, 0, scope.Len())
+
+	calls := 0
+	generator := func() (string, error) {
+		calls++
+		return "fresh", nil
+	}
+
+	scope.Get("local", generator)
+	scope.Get("local", generator)
+	assert.Equal(t, 2, calls)
+
+	value, _ := scope.Get("shared", nil)
+	assert.Equal(t, "global", value)
+}
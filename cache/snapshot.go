@@ -0,0 +1,81 @@
+//
+// Introspection on a consistent snapshot of cache contents
+//
+
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+type snapshotItem[T any] struct {
+	key     string
+	data    T
+	expires int64
+}
+
+//
+// Copy servable items, skipping placeholders, errors and banned items
+//
+
+func (c *Cache[T]) snapshot() []snapshotItem[T] {
+	now := c.clock.Now()
+
+	c.mu.RLock()
+	items := make([]snapshotItem[T], 0, len(c.items))
+
+	for k, v := range c.items {
+		if (v.cycle > 0) && (v.err == nil) && (now < v.banned) {
+			items = append(items, snapshotItem[T]{
+				key:     k,
+				data:    v.data,
+				expires: v.expires,
+			})
+		}
+	}
+
+	c.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].key < items[j].key
+	})
+
+	return items
+}
+
+//
+// Number of items holding data
+//
+
+func (c *Cache[T]) Len() int {
+	return len(c.snapshot())
+}
+
+//
+// Sorted keys of items holding data
+//
+
+func (c *Cache[T]) Keys() []string {
+	items := c.snapshot()
+	keys := make([]string, 0, len(items))
+
+	for _, item := range items {
+		keys = append(keys, item.key)
+	}
+
+	return keys
+}
+
+//
+// Call fn for each item in key order until it returns false, the cache is
+// not locked while fn runs so it may use the cache itself
+//
+
+func (c *Cache[T]) Range(fn func(key string, value T, expiresAt time.Time) bool) {
+	for _, item := range c.snapshot() {
+		if !fn(item.key, c.copyValue(item.data), time.Unix(0, item.expires)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheSnapshot(t *testing.T) {
+	cache := New[int]()
+	assert.Equal(t, 0, cache.Len())
+	assert.Empty(t, cache.Keys())
+
+	cache.Set("b", 2)
+	cache.Set("a", 1)
+	cache.Get("error", func() (int, error) {
+		return 0, errors.New("failed")
+	})
+
+	cache.SetWithOpts(&SetOpts[int]{
+		Key:  "expired",
+		Data: 3,
+		TTL:  -1,
+	})
+
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, []string{"a", "b"}, cache.Keys())
+
+	var keys []string
+	var values []int
+
+	cache.Range(func(key string, value int, expiresAt time.Time) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+		// Cache is usable from within callback
+		cache.Set("c", 3)
+		return true
+	})
+
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, []int{1, 2}, values)
+	assert.Equal(t, 3, cache.Len())
+
+	var count int
+	cache.Range(func(key string, value int, expiresAt time.Time) bool {
+		count++
+		return false
+	})
+
+	assert.Equal(t, 1, count)
+}
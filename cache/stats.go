@@ -0,0 +1,101 @@
+//
+// Per-key statistics, to find hot keys and TTLs that are too short
+//
+
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+type KeyStat struct {
+	Key        string
+	Hits       uint64
+	Misses     uint64
+	LastAccess time.Time
+	ExpiresAt  time.Time
+}
+
+//
+// Count hit on item, when statistics are enabled
+//
+
+func (c *Cache[T]) countHit(item *Item[T]) {
+	if c.keyStats {
+		item.hits.Add(1)
+	}
+
+	if c.counters != nil {
+		c.counters.hits.Add(1)
+	}
+}
+
+//
+// Statistics of a single key, counts are zero unless KeyStats is enabled
+//
+
+func (c *Cache[T]) Stat(key string) (KeyStat, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.items[key]
+	if !exists {
+		return KeyStat{}, false
+	}
+
+	return item.stat(key), true
+}
+
+//
+// Keys with the most hits first, ties broken by misses and then key, n below
+// one returns all keys
+//
+
+func (c *Cache[T]) TopKeys(n int) []KeyStat {
+	c.mu.RLock()
+	stats := make([]KeyStat, 0, len(c.items))
+
+	for k, v := range c.items {
+		stats = append(stats, v.stat(k))
+	}
+
+	c.mu.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Hits != stats[j].Hits {
+			return stats[i].Hits > stats[j].Hits
+		}
+
+		if stats[i].Misses != stats[j].Misses {
+			return stats[i].Misses > stats[j].Misses
+		}
+
+		return stats[i].Key < stats[j].Key
+	})
+
+	if (n > 0) && (n < len(stats)) {
+		stats = stats[:n]
+	}
+
+	return stats
+}
+
+// Must be called with read lock held
+func (item *Item[T]) stat(key string) KeyStat {
+	stat := KeyStat{
+		Key:    key,
+		Hits:   item.hits.Load(),
+		Misses: item.misses.Load(),
+	}
+
+	if accessed := item.accessed.Load(); accessed > 0 {
+		stat.LastAccess = time.Unix(0, accessed)
+	}
+
+	if item.cycle > 0 {
+		stat.ExpiresAt = time.Unix(0, item.expires)
+	}
+
+	return stat
+}
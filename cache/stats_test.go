@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheTopKeys(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL: time.Minute,
+		KeyStats:   true,
+		Clock:      clock,
+	})
+
+	generator := func() (string, error) {
+		return "data", nil
+	}
+
+	for i := 0; i < 5; i++ {
+		cache.Get("hot", generator)
+	}
+
+	cache.Get("warm", generator)
+	cache.Get("warm", generator)
+	cache.Get("cold", generator)
+
+	// Hits on the locked path count too
+	cache.published.Delete("warm")
+	cache.Get("warm", generator)
+
+	// Expired keys count a miss for each regeneration
+	clock.Advance(2 * time.Minute)
+	cache.Get("cold", generator)
+
+	top := cache.TopKeys(2)
+	assert.Len(t, top, 2)
+	assert.Equal(t, KeyStat{
+		Key:        "hot",
+		Hits:       4,
+		Misses:     1,
+		LastAccess: time.Unix(1000, 0),
+		ExpiresAt:  time.Unix(1060, 0),
+	}, top[0])
+	assert.Equal(t, "warm", top[1].Key)
+	assert.Equal(t, uint64(2), top[1].Hits)
+
+	stat, ok := cache.Stat("cold")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), stat.Hits)
+	assert.Equal(t, uint64(2), stat.Misses)
+	assert.Equal(t, time.Unix(1120, 0), stat.LastAccess)
+
+	_, ok = cache.Stat("missing")
+	assert.False(t, ok)
+	assert.Len(t, cache.TopKeys(0), 3)
+}
+
+func TestCacheKeyStatsDisabled(t *testing.T) {
+	cache := New[string]()
+	cache.Set("key", "data")
+	cache.Get("key", nil)
+
+	stat, ok := cache.Stat("key")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), stat.Hits)
+	assert.Equal(t, uint64(0), stat.Misses)
+	assert.False(t, stat.LastAccess.IsZero())
+}
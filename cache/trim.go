@@ -0,0 +1,149 @@
+//
+// Shed least recently used items, on demand or under heap pressure
+//
+
+package cache
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"time"
+)
+
+type MemoryWatchOpts struct {
+	// Trim when live heap objects exceed this many bytes, zero uses 90% of
+	// the runtime memory limit (GOMEMLIMIT), without one nothing is watched
+	HeapLimit uint64
+
+	// Share of items evicted per trim, between 0 and 1
+	TrimRatio float64
+
+	Interval time.Duration
+	OnTrim   func(evicted int, heapBytes uint64)
+}
+
+var DefaultMemoryWatchOpts = &MemoryWatchOpts{
+	TrimRatio: 0.25,
+	Interval:  10 * time.Second,
+}
+
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// Share of the runtime memory limit used as default heap limit
+const memoryLimitRatio = 0.9
+
+//
+// Evict expired and then least recently used items until at most n remain,
+// items being generated are never evicted, returns number of evicted items
+//
+
+func (c *Cache[T]) TrimToSize(n int) int {
+	if n < 0 {
+		n = 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := c.purgeExpiredItems()
+	excess := len(c.items) - n
+	if excess <= 0 {
+		return evicted
+	}
+
+	type candidate struct {
+		key      string
+		accessed int64
+	}
+
+	candidates := make([]candidate, 0, len(c.items))
+	for k, v := range c.items {
+		if !v.working {
+			candidates = append(candidates, candidate{key: k, accessed: v.accessed.Load()})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].accessed < candidates[j].accessed
+	})
+
+	for _, cand := range candidates[:min(excess, len(candidates))] {
+		delete(c.items, cand.key)
+		c.published.Delete(cand.key)
+		evicted++
+	}
+
+	if c.counters != nil {
+		c.counters.evicted.Add(uint64(min(excess, len(candidates))))
+	}
+
+	return evicted
+}
+
+//
+// Poll heap usage until context is done, trimming a share of items whenever
+// it is above the limit, returns right away without a limit to watch
+//
+
+func (c *Cache[T]) WatchMemory(ctx context.Context, opts *MemoryWatchOpts) {
+	if opts.HeapLimit == 0 {
+		// Negative input only reads the limit, MaxInt64 means none is set
+		limit := debug.SetMemoryLimit(-1)
+		if limit <= 0 || limit == math.MaxInt64 {
+			return
+		}
+
+		opts.HeapLimit = uint64(float64(limit) * memoryLimitRatio)
+	}
+
+	if opts.TrimRatio <= 0 {
+		opts.TrimRatio = DefaultMemoryWatchOpts.TrimRatio
+	}
+
+	if opts.Interval == 0 {
+		opts.Interval = DefaultMemoryWatchOpts.Interval
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		heap := heapBytes()
+		if heap <= opts.HeapLimit {
+			continue
+		}
+
+		c.mu.RLock()
+		size := len(c.items)
+		c.mu.RUnlock()
+
+		evicted := c.TrimToSize(size - int(float64(size)*min(opts.TrimRatio, 1)))
+		if opts.OnTrim != nil {
+			opts.OnTrim(evicted, heap)
+		}
+	}
+}
+
+//
+// Bytes held by live and not yet swept heap objects
+//
+
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimToSize(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[int](&Opts{
+		DefaultTTL: time.Minute,
+		Clock:      clock,
+	})
+
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("key-%d", i), i)
+		clock.Advance(time.Second)
+	}
+
+	// Reads through both the published and locked paths count as use
+	cache.Get("key-0", nil)
+	clock.Advance(time.Second)
+	cache.published.Delete("key-1")
+	cache.Get("key-1", nil)
+
+	assert.Equal(t, 0, cache.TrimToSize(5))
+	assert.Equal(t, 2, cache.TrimToSize(3))
+	assert.Equal(t, []string{"key-0", "key-1", "key-4"}, cache.Keys())
+
+	_, ok := cache.published.Load("key-2")
+	assert.False(t, ok)
+
+	assert.Equal(t, 3, cache.TrimToSize(-1))
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestTrimToSizeSkipsWorking(t *testing.T) {
+	cache := New[string]()
+
+	release := make(chan bool)
+	go cache.Get("slow", func() (string, error) {
+		<-release
+		return "done", nil
+	})
+
+	assert.Eventually(t, func() bool {
+		cache.mu.RLock()
+		defer cache.mu.RUnlock()
+		return cache.items["slow"] != nil
+	}, time.Second, time.Millisecond)
+
+	cache.Set("idle", "data")
+	assert.Equal(t, 1, cache.TrimToSize(0))
+
+	close(release)
+	data, err := cache.Get("slow", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", data)
+}
+
+func TestWatchMemory(t *testing.T) {
+	cache := New[int]()
+	for i := 0; i < 8; i++ {
+		cache.Set(fmt.Sprintf("key-%d", i), i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trimmed := make(chan int, 16)
+	go cache.WatchMemory(ctx, &MemoryWatchOpts{
+		HeapLimit: 1,
+		TrimRatio: 0.5,
+		Interval:  time.Millisecond,
+		OnTrim: func(evicted int, heap uint64) {
+			assert.Greater(t, heap, uint64(1))
+			trimmed <- evicted
+		},
+	})
+
+	assert.Equal(t, 4, <-trimmed)
+	assert.Equal(t, 2, <-trimmed)
+	cancel()
+}
+
+func TestWatchMemoryNoLimit(t *testing.T) {
+	cache := New[int]()
+	cache.Set("key", 1)
+
+	// No heap limit and no runtime memory limit, nothing to watch
+	previous := debug.SetMemoryLimit(math.MaxInt64)
+	defer debug.SetMemoryLimit(previous)
+
+	done := make(chan bool)
+	go func() {
+		cache.WatchMemory(context.Background(), &MemoryWatchOpts{
+			Interval: time.Millisecond,
+			OnTrim: func(evicted int, heap uint64) {
+				t.Error("trimmed without a limit")
+			},
+		})
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not return")
+	}
+
+	assert.Equal(t, 1, cache.Len())
+
+	// Defaults from the runtime memory limit
+	limit := int64(1 << 40)
+	debug.SetMemoryLimit(limit)
+	opts := &MemoryWatchOpts{Interval: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cache.WatchMemory(ctx, opts)
+	assert.Equal(t, uint64(float64(limit)*memoryLimitRatio), opts.HeapLimit)
+}
@@ -0,0 +1,105 @@
+//
+// Bulk preload, e.g. at startup from a snapshot or database scan
+//
+
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+type WarmOpts[T any] struct {
+	Entries map[string]T
+	TTL     time.Duration
+	Grace   time.Duration
+
+	// Random extra lifetime up to this long, so preloaded keys don't all
+	// expire and regenerate at once
+	Jitter time.Duration
+}
+
+//
+// Preload entries with TTL and default grace, zero TTL uses the default
+//
+
+func (c *Cache[T]) Warm(entries map[string]T, ttl time.Duration) int {
+	return c.WarmWithOpts(&WarmOpts[T]{
+		Entries: entries,
+		TTL:     ttl,
+	})
+}
+
+//
+// Preload keys through a bulk loader with default TTL and grace, keys the
+// loader doesn't return are left for regular generation
+//
+
+func (c *Cache[T]) WarmFunc(keys []string, loader func(keys []string) (map[string]T, error)) (int, error) {
+	entries, err := loader(keys)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Warm(entries, 0), nil
+}
+
+//
+// Preload entries under a single lock acquisition, keys that already hold
+// data or are being generated are left alone, returns number of keys stored
+//
+
+func (c *Cache[T]) WarmWithOpts(opts *WarmOpts[T]) int {
+	ttl := opts.TTL.Nanoseconds()
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+
+	grace := opts.Grace.Nanoseconds()
+	if grace == 0 {
+		grace = c.defaultGrace
+	}
+
+	// Preloaded items are never waited on, share one closed channel
+	ready := &Channel{
+		signal: make(chan bool),
+	}
+
+	ready.once.Do(func() {
+		close(ready.signal)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	stored := 0
+
+	for key, data := range opts.Entries {
+		if item, exists := c.items[key]; exists && (item.working || (item.err == nil && now < item.banned)) {
+			continue
+		}
+
+		lifetime := ttl
+		if opts.Jitter > 0 {
+			lifetime += rand.Int63n(opts.Jitter.Nanoseconds())
+		}
+
+		c.cycles++
+		item := &Item[T]{
+			data:    c.copyValue(data),
+			ready:   ready,
+			created: now,
+			expires: now + lifetime,
+			banned:  now + lifetime + grace,
+			cycle:   c.cycles,
+		}
+
+		item.accessed.Store(now)
+		c.items[key] = item
+		c.publish(key, item)
+		stored++
+	}
+
+	return stored
+}
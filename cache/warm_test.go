@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWarm(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[string](&Opts{
+		DefaultTTL: time.Minute,
+		Clock:      clock,
+	})
+
+	cache.Set("existing", "kept")
+
+	stored := cache.Warm(map[string]string{
+		"a":        "1",
+		"b":        "2",
+		"existing": "replaced",
+	}, time.Hour)
+
+	assert.Equal(t, 2, stored)
+	assert.Equal(t, 3, cache.Len())
+
+	data, err := cache.Get("a", func() (string, error) {
+		return "", errors.New("should not generate")
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", data)
+
+	data, _ = cache.Get("existing", nil)
+	assert.Equal(t, "kept", data)
+
+	// Warm TTL applies, default TTL item expires first
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, []string{"a", "b"}, cache.Keys())
+
+	clock.Advance(31 * time.Minute)
+	data, err = cache.Get("a", func() (string, error) {
+		return "regenerated", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "regenerated", data)
+}
+
+func TestCacheWarmJitter(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(0, 0))
+	cache := NewWithOpts[int](&Opts{
+		Clock: clock,
+	})
+
+	entries := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		entries[fmt.Sprint(i)] = i
+	}
+
+	cache.WarmWithOpts(&WarmOpts[int]{
+		Entries: entries,
+		TTL:     time.Minute,
+		Jitter:  time.Minute,
+	})
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, 100, cache.Len())
+
+	// Expirations are spread over the jitter window
+	clock.Advance(30 * time.Second)
+	assert.Greater(t, cache.Len(), 0)
+	assert.Less(t, cache.Len(), 100)
+
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheWarmFunc(t *testing.T) {
+	cache := New[string]()
+
+	stored, err := cache.WarmFunc([]string{"a", "b", "c"}, func(keys []string) (map[string]string, error) {
+		return map[string]string{"a": "1", "c": "3"}, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stored)
+	assert.Equal(t, []string{"a", "c"}, cache.Keys())
+
+	_, err = cache.WarmFunc([]string{"d"}, func(keys []string) (map[string]string, error) {
+		return nil, errors.New("database down")
+	})
+
+	assert.EqualError(t, err, "database down")
+}
@@ -0,0 +1,409 @@
+//
+// Circuit breaker failing fast while a dependency is unhealthy
+//
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+type Breaker struct {
+	name        string
+	opts        *Opts
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openUntil   time.Time
+	probes      int
+	successes   int
+	buckets     [windowBuckets]bucket
+}
+
+type Opts struct {
+	// Consecutive failures opening the circuit, zero disables the policy
+	ConsecutiveFailures int
+
+	// Share of failed calls within Window opening the circuit, once at least
+	// MinRequests calls were made, zero disables the policy
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+
+	// Time spent open before probing
+	Cooldown time.Duration
+
+	// Concurrent probes while half-open, as many successes close the circuit
+	HalfOpenProbes int
+
+	// Errors counting as failures, others as successes, default all errors
+	IsFailure func(err error) bool
+
+	// Metrics hooks
+	OnStateChange func(name string, from State, to State)
+	OnReject      func(name string)
+	OnResult      func(name string, err error, failure bool)
+
+	Clock func() time.Time
+}
+
+type Counts struct {
+	Requests            int
+	Failures            int
+	ConsecutiveFailures int
+}
+
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+const windowBuckets = 10
+
+var ErrOpen = errors.New("circuitbreaker: circuit open")
+
+var DefaultOpts = &Opts{
+	ConsecutiveFailures: 5,
+	MinRequests:         20,
+	Window:              time.Minute,
+	Cooldown:            30 * time.Second,
+	HalfOpenProbes:      1,
+}
+
+//
+// Initialize new breaker instance
+//
+
+func New() *Breaker {
+	return NewWithOpts(DefaultOpts)
+}
+
+func NewWithOpts(opts *Opts) *Breaker {
+	setDefaults(opts)
+	return newNamed("", opts)
+}
+
+func newNamed(name string, opts *Opts) *Breaker {
+	return &Breaker{
+		name: name,
+		opts: opts,
+	}
+}
+
+func setDefaults(opts *Opts) {
+	if opts.MinRequests == 0 {
+		opts.MinRequests = DefaultOpts.MinRequests
+	}
+
+	if opts.Window == 0 {
+		opts.Window = DefaultOpts.Window
+	}
+
+	if opts.Cooldown == 0 {
+		opts.Cooldown = DefaultOpts.Cooldown
+	}
+
+	if opts.HalfOpenProbes == 0 {
+		opts.HalfOpenProbes = DefaultOpts.HalfOpenProbes
+	}
+
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(err error) bool {
+			return err != nil
+		}
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+}
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+//
+// Check whether a call may proceed, every allowed call must be followed by
+// Record with its outcome
+//
+
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	now := b.opts.Clock()
+
+	if (b.state == StateOpen) && !now.Before(b.openUntil) {
+		b.setState(StateHalfOpen, now)
+	}
+
+	allowed := (b.state == StateClosed) ||
+		((b.state == StateHalfOpen) && (b.probes < b.opts.HalfOpenProbes))
+
+	if allowed && (b.state == StateHalfOpen) {
+		b.probes++
+	}
+
+	b.mu.Unlock()
+
+	if !allowed {
+		if b.opts.OnReject != nil {
+			b.opts.OnReject(b.name)
+		}
+
+		return ErrOpen
+	}
+
+	return nil
+}
+
+//
+// Record outcome of an allowed call, cancellation by the caller counts
+// neither way
+//
+
+func (b *Breaker) Record(err error) {
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		if (b.state == StateHalfOpen) && (b.probes > 0) {
+			b.probes--
+		}
+
+		b.mu.Unlock()
+		return
+	}
+
+	failure := b.opts.IsFailure(err)
+
+	b.mu.Lock()
+	now := b.opts.Clock()
+
+	bk := b.bucket(now)
+	bk.requests++
+
+	switch {
+	case failure && (b.state == StateHalfOpen):
+		b.consecutive++
+		bk.failures++
+		b.setState(StateOpen, now)
+
+	case failure:
+		b.consecutive++
+		bk.failures++
+
+		if (b.state == StateClosed) && b.shouldTrip(now) {
+			b.setState(StateOpen, now)
+		}
+
+	case b.state == StateHalfOpen:
+		b.consecutive = 0
+		b.successes++
+
+		if b.successes >= b.opts.HalfOpenProbes {
+			b.setState(StateClosed, now)
+		}
+
+	default:
+		b.consecutive = 0
+	}
+
+	b.mu.Unlock()
+
+	if b.opts.OnResult != nil {
+		b.opts.OnResult(b.name, err, failure)
+	}
+}
+
+//
+// Run fn through the breaker
+//
+
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := fn(ctx)
+	b.Record(err)
+
+	return err
+}
+
+//
+// Run fn through the breaker, returning its value
+//
+
+func DoValue[T any](ctx context.Context, b *Breaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	if err := b.Allow(); err != nil {
+		var empty T
+		return empty, err
+	}
+
+	value, err := fn(ctx)
+	b.Record(err)
+
+	return value, err
+}
+
+//
+// Open the circuit now, e.g. on an explicit rate limit response
+//
+
+func (b *Breaker) Trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.setState(StateOpen, b.opts.Clock())
+}
+
+//
+// Close the circuit and clear counts
+//
+
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.setState(StateClosed, b.opts.Clock())
+	b.buckets = [windowBuckets]bucket{}
+}
+
+//
+// Current state, an open circuit past its cooldown reports half-open
+//
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if (b.state == StateOpen) && !b.opts.Clock().Before(b.openUntil) {
+		return StateHalfOpen
+	}
+
+	return b.state
+}
+
+//
+// End of the current cooldown, zero unless open
+//
+
+func (b *Breaker) OpenUntil() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != StateOpen {
+		return time.Time{}
+	}
+
+	return b.openUntil
+}
+
+//
+// Calls and failures within the window
+//
+
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, failures := b.windowCounts(b.opts.Clock())
+	return Counts{
+		Requests:            requests,
+		Failures:            failures,
+		ConsecutiveFailures: b.consecutive,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+//
+// Policies deciding whether to open, must be called with lock held
+//
+
+func (b *Breaker) shouldTrip(now time.Time) bool {
+	if (b.opts.ConsecutiveFailures > 0) && (b.consecutive >= b.opts.ConsecutiveFailures) {
+		return true
+	}
+
+	if b.opts.FailureRate <= 0 {
+		return false
+	}
+
+	requests, failures := b.windowCounts(now)
+	return (requests >= b.opts.MinRequests) && (float64(failures)/float64(requests) >= b.opts.FailureRate)
+}
+
+//
+// Move to state, resetting per-state counters, must be called with lock held
+//
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.probes = 0
+	b.successes = 0
+
+	switch state {
+	case StateOpen:
+		b.openUntil = now.Add(b.opts.Cooldown)
+	case StateClosed:
+		b.consecutive = 0
+		b.openUntil = time.Time{}
+	}
+
+	if (from != state) && (b.opts.OnStateChange != nil) {
+		// Hooks run under lock and must not call back into the breaker
+		b.opts.OnStateChange(b.name, from, state)
+	}
+}
+
+//
+// Rolling window of fixed buckets, must be called with lock held
+//
+
+func (b *Breaker) bucket(now time.Time) *bucket {
+	size := b.opts.Window / windowBuckets
+	start := now.Truncate(size)
+	bk := &b.buckets[(start.UnixNano()/int64(size))%windowBuckets]
+
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+
+	return bk
+}
+
+func (b *Breaker) windowCounts(now time.Time) (int, int) {
+	cutoff := now.Add(-b.opts.Window)
+	requests, failures := 0, 0
+
+	for _, bk := range b.buckets {
+		if bk.start.After(cutoff) {
+			requests += bk.requests
+			failures += bk.failures
+		}
+	}
+
+	return requests, failures
+}
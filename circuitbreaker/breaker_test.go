@@ -0,0 +1,216 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errBackend = errors.New("backend down")
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Unix(1000, 0)}
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	clock := newTestClock()
+	var changes []string
+
+	b := NewWithOpts(&Opts{
+		ConsecutiveFailures: 3,
+		Cooldown:            time.Minute,
+		Clock:               clock.Now,
+		OnStateChange: func(name string, from State, to State) {
+			changes = append(changes, from.String()+">"+to.String())
+		},
+	})
+
+	ctx := context.Background()
+	fail := func(ctx context.Context) error {
+		return errBackend
+	}
+
+	ok := func(ctx context.Context) error {
+		return nil
+	}
+
+	// Success resets the streak
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+	b.Do(ctx, ok)
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.ErrorIs(t, b.Do(ctx, fail), errBackend)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, time.Unix(1060, 0), b.OpenUntil())
+
+	calls := 0
+	err := b.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 0, calls)
+
+	// Single probe after cooldown, failure reopens
+	clock.Advance(time.Minute)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+	b.Record(errBackend)
+	assert.Equal(t, StateOpen, b.State())
+
+	// Successful probe closes
+	clock.Advance(time.Minute)
+	assert.NoError(t, b.Do(ctx, ok))
+	assert.Equal(t, StateClosed, b.State())
+	assert.True(t, b.OpenUntil().IsZero())
+
+	assert.Equal(t, []string{
+		"closed>open",
+		"open>half-open",
+		"half-open>open",
+		"open>half-open",
+		"half-open>closed",
+	}, changes)
+}
+
+func TestBreakerFailureRate(t *testing.T) {
+	clock := newTestClock()
+	b := NewWithOpts(&Opts{
+		FailureRate: 0.5,
+		MinRequests: 10,
+		Window:      10 * time.Second,
+		Clock:       clock.Now,
+	})
+
+	// Alternating results never trip the consecutive policy
+	for i := 0; i < 8; i++ {
+		assert.NoError(t, b.Allow())
+		if i%2 == 0 {
+			b.Record(errBackend)
+		} else {
+			b.Record(nil)
+		}
+	}
+
+	assert.Equal(t, Counts{Requests: 8, Failures: 4}, b.Counts())
+	assert.Equal(t, StateClosed, b.State())
+
+	// Old calls fall out of the window
+	clock.Advance(11 * time.Second)
+	assert.Equal(t, Counts{}, b.Counts())
+
+	for i := 0; i < 11; i++ {
+		b.Allow()
+		if i%2 == 0 {
+			b.Record(errBackend)
+		} else {
+			b.Record(nil)
+		}
+	}
+
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	clock := newTestClock()
+	b := NewWithOpts(&Opts{
+		ConsecutiveFailures: 1,
+		HalfOpenProbes:      2,
+		Clock:               clock.Now,
+	})
+
+	b.Allow()
+	b.Record(errBackend)
+	clock.Advance(time.Hour)
+
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	// Cancelled probes free their slot
+	b.Record(context.Canceled)
+	assert.NoError(t, b.Allow())
+
+	b.Record(nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+	b.Record(nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreakerIsFailure(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b := NewWithOpts(&Opts{
+		ConsecutiveFailures: 1,
+		IsFailure: func(err error) bool {
+			return err != nil && !errors.Is(err, errNotFound)
+		},
+	})
+
+	value, err := DoValue(context.Background(), b, func(ctx context.Context) (int, error) {
+		return 0, errNotFound
+	})
+
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, 0, value)
+	assert.Equal(t, StateClosed, b.State())
+
+	value, err = DoValue(context.Background(), b, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+}
+
+func TestBreakerTripReset(t *testing.T) {
+	var rejected, failures int
+	b := NewWithOpts(&Opts{
+		OnReject: func(name string) {
+			rejected++
+		},
+		OnResult: func(name string, err error, failure bool) {
+			if failure {
+				failures++
+			}
+		},
+	})
+
+	b.Trip()
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+	assert.Equal(t, 1, rejected)
+
+	b.Reset()
+	assert.NoError(t, b.Allow())
+	b.Record(errBackend)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 1, b.Counts().ConsecutiveFailures)
+	assert.Equal(t, "unknown", State(9).String())
+}
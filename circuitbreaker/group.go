@@ -0,0 +1,73 @@
+//
+// Breakers by name, e.g. one per upstream host
+//
+
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+type Group struct {
+	opts     *Opts
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+//
+// Initialize group, breakers are created on first use with shared opts
+//
+
+func NewGroup(opts *Opts) *Group {
+	setDefaults(opts)
+
+	return &Group{
+		opts:     opts,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+func (g *Group) Get(name string) *Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, exists := g.breakers[name]
+	if !exists {
+		b = newNamed(name, g.opts)
+		g.breakers[name] = b
+	}
+
+	return b
+}
+
+func (g *Group) Delete(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.breakers, name)
+}
+
+//
+// Sorted names of breakers not currently closed
+//
+
+func (g *Group) Unhealthy() []string {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, b := range g.breakers {
+		breakers = append(breakers, b)
+	}
+
+	g.mu.Unlock()
+
+	var names []string
+	for _, b := range breakers {
+		if b.State() != StateClosed {
+			names = append(names, b.name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
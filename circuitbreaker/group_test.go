@@ -0,0 +1,35 @@
+package circuitbreaker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var opened []string
+	group := NewGroup(&Opts{
+		ConsecutiveFailures: 1,
+		OnStateChange: func(name string, from State, to State) {
+			if to == StateOpen {
+				opened = append(opened, name)
+			}
+		},
+	})
+
+	a := group.Get("a.example")
+	assert.Same(t, a, group.Get("a.example"))
+	assert.Equal(t, "a.example", a.Name())
+
+	a.Allow()
+	a.Record(errBackend)
+	group.Get("b.example").Allow()
+
+	assert.Equal(t, []string{"a.example"}, opened)
+	assert.Equal(t, []string{"a.example"}, group.Unhealthy())
+	assert.Equal(t, StateClosed, group.Get("b.example").State())
+
+	group.Delete("a.example")
+	assert.Empty(t, group.Unhealthy())
+	assert.NotSame(t, a, group.Get("a.example"))
+}
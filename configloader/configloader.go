@@ -0,0 +1,374 @@
+//
+// Layered configuration loading into structs, from defaults, files,
+// environment variables and flags in that order
+//
+
+package configloader
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+	"gopkg.in/yaml.v3"
+)
+
+type Opts struct {
+	Files              []string
+	IgnoreMissingFiles bool
+	ExpandEnv          bool
+	EnvPrefix          string
+	Environ            []string
+	Args               []string
+}
+
+var (
+	ErrInvalidValue    = errors.New("configloader: invalid value")
+	ErrUnsupportedFile = errors.New("configloader: unsupported file type")
+)
+
+type field struct {
+	path  string
+	env   string
+	tag   reflect.StructTag
+	value reflect.Value
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//
+// Load with process environment and no files or flags
+//
+
+func Load(dst any) error {
+	return LoadWithOpts(dst, &Opts{})
+}
+
+//
+// Load into pointer to struct, all errors are collected and returned joined
+//
+
+func LoadWithOpts(dst any, opts *Opts) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("configloader: destination must be a pointer to struct")
+	}
+
+	environ := opts.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+
+	fields := collectFields(v.Elem(), "", strings.ToUpper(opts.EnvPrefix))
+	var errs []error
+
+	// Defaults
+	for _, f := range fields {
+		if value, ok := f.tag.Lookup("default"); ok {
+			errs = appendFieldErr(errs, f, "default", setValue(f.value, value))
+		}
+	}
+
+	// Files, later files override earlier ones
+	for _, path := range opts.Files {
+		err := loadFile(dst, path, opts, env)
+		if errors.Is(err, os.ErrNotExist) && opts.IgnoreMissingFiles {
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("configloader: %s: %w", path, err))
+		}
+	}
+
+	// Environment
+	for _, f := range fields {
+		if value, ok := env[f.env]; ok && f.env != "" {
+			errs = appendFieldErr(errs, f, "env "+f.env, setValue(f.value, value))
+		}
+	}
+
+	// Flags
+	if opts.Args != nil {
+		errs = append(errs, parseFlags(fields, opts.Args)...)
+	}
+
+	// Only validate values that could be loaded
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return Validate(dst)
+}
+
+func appendFieldErr(errs []error, f *field, source string, err error) []error {
+	if err == nil {
+		return errs
+	}
+
+	return append(errs, fmt.Errorf("%s (from %s): %w", f.path, source, err))
+}
+
+//
+// Decode YAML or JSON file by extension
+//
+
+func loadFile(dst any, path string, opts *Opts, env map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if opts.ExpandEnv {
+		expanded, _, err := format.ExpandEnvFunc(string(data), func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		})
+
+		if err != nil {
+			return err
+		}
+
+		data = []byte(expanded)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, dst)
+	case ".json":
+		err = json.Unmarshal(data, dst)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFile, filepath.Ext(path))
+	}
+
+	// Empty documents are fine
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+//
+// Flags are registered for fields with a flag tag, only flags present in
+// args override values
+//
+
+func parseFlags(fields []*field, args []string) []error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	byName := make(map[string]*field)
+	for _, f := range fields {
+		name := f.tag.Get("flag")
+		if name == "" {
+			continue
+		}
+
+		byName[name] = f
+		usage := f.tag.Get("usage")
+
+		if f.value.Kind() == reflect.Bool {
+			fs.Bool(name, false, usage)
+		} else {
+			fs.String(name, "", usage)
+		}
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return []error{fmt.Errorf("configloader: %w", err)}
+	}
+
+	var errs []error
+	fs.Visit(func(fl *flag.Flag) {
+		f := byName[fl.Name]
+		errs = appendFieldErr(errs, f, "flag -"+fl.Name, setValue(f.value, fl.Value.String()))
+	})
+
+	return errs
+}
+
+//
+// Walk exported fields, recursing into nested structs
+//
+
+func collectFields(v reflect.Value, prefix string, envPrefix string) []*field {
+	var fields []*field
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := fieldName(sf)
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		envName := strings.ToUpper(name)
+		if envPrefix != "" {
+			envName = envPrefix + "_" + envName
+		}
+
+		fv := v.Field(i)
+
+		// Nested sections, unless the struct parses itself
+		if sf.Type.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(textUnmarshalerType) {
+			fields = append(fields, collectFields(fv, path, envName)...)
+			continue
+		}
+
+		if tag, ok := sf.Tag.Lookup("env"); ok {
+			envName = tag
+		}
+
+		fields = append(fields, &field{
+			path:  path,
+			env:   envName,
+			tag:   sf.Tag,
+			value: fv,
+		})
+	}
+
+	return fields
+}
+
+//
+// Key name from yaml or json tag, falling back to snake cased field name
+//
+
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"yaml", "json"} {
+		if tag, ok := sf.Tag.Lookup(key); ok {
+			// Fields hidden from files can still be set from env and flags
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+
+	return toSnake(sf.Name)
+}
+
+func toSnake(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Word boundary before upper case, keeping acronyms together
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+//
+// Set value from string representation
+//
+
+func setValue(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a duration", ErrInvalidValue, s)
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a bool", ErrInvalidValue, s)
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an integer", ErrInvalidValue, s)
+		}
+
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an unsigned integer", ErrInvalidValue, s)
+		}
+
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a number", ErrInvalidValue, s)
+		}
+
+		v.SetFloat(n)
+
+	case reflect.Slice:
+		// Comma separated list
+		elems, err := format.SplitByDelimiter(s, func(part string) (reflect.Value, error) {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			return elem, setValue(elem, part)
+		}, format.WithDelimiter(","))
+
+		if err != nil {
+			return err
+		}
+
+		slice := reflect.MakeSlice(v.Type(), 0, len(elems))
+		slice = reflect.Append(slice, elems...)
+		v.Set(slice)
+
+	default:
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidValue, v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package configloader
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name     string        `yaml:"name" json:"name" default:"app" flag:"name"`
+	Port     int           `yaml:"port" json:"port" default:"8080" flag:"port" validate:"min=1,max=65535"`
+	Debug    bool          `yaml:"debug" json:"debug" flag:"debug"`
+	Timeout  time.Duration `yaml:"timeout" json:"timeout" default:"5s"`
+	Tags     []string      `yaml:"tags" json:"tags"`
+	Listen   netip.Addr    `yaml:"listen" json:"listen" default:"127.0.0.1"`
+	Secret   string        `yaml:"-" json:"-" env:"APP_SECRET_TOKEN"`
+	Database struct {
+		Host    string `yaml:"host" json:"host" validate:"required"`
+		MaxConn int    `yaml:"max_conn" json:"max_conn" default:"10"`
+	} `yaml:"database" json:"database"`
+}
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	yamlFile := writeTestFile(t, "config.yaml", "name: from-yaml\nport: 9000\ndatabase:\n  host: db.internal\n")
+	jsonFile := writeTestFile(t, "override.json", `{"port": 9100, "timeout": 2000000000}`)
+
+	var config testConfig
+	err := LoadWithOpts(&config, &Opts{
+		Files:     []string{yamlFile, jsonFile},
+		EnvPrefix: "app",
+		Environ: []string{
+			"APP_DATABASE_MAX_CONN=20",
+			"APP_TAGS=a, b,c",
+			"APP_SECRET_TOKEN=hunter2",
+			"OTHER=ignored",
+		},
+		Args: []string{"-port", "9200", "-debug"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "from-yaml", config.Name)
+	assert.Equal(t, 9200, config.Port)
+	assert.True(t, config.Debug)
+	assert.Equal(t, 2*time.Second, config.Timeout)
+	assert.Equal(t, []string{"a", "b", "c"}, config.Tags)
+	assert.Equal(t, netip.MustParseAddr("127.0.0.1"), config.Listen)
+	assert.Equal(t, "hunter2", config.Secret)
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, 20, config.Database.MaxConn)
+}
+
+func TestLoadExpandEnv(t *testing.T) {
+	path := writeTestFile(t, "config.yml", "database:\n  host: ${DB_HOST:-localhost}\nname: ${NAME}\n")
+
+	var config testConfig
+	err := LoadWithOpts(&config, &Opts{
+		Files:     []string{path},
+		ExpandEnv: true,
+		Environ:   []string{"NAME=expanded"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", config.Database.Host)
+	assert.Equal(t, "expanded", config.Name)
+}
+
+func TestLoadErrors(t *testing.T) {
+	var config testConfig
+	err := LoadWithOpts(&config, &Opts{
+		Files:     []string{filepath.Join(t.TempDir(), "missing.yaml"), writeTestFile(t, "config.toml", "")},
+		EnvPrefix: "APP",
+		Environ:   []string{"APP_PORT=http", "APP_TIMEOUT=forever"},
+	})
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorIs(t, err, ErrUnsupportedFile)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.Contains(t, err.Error(), "port (from env APP_PORT)")
+	assert.Contains(t, err.Error(), "timeout (from env APP_TIMEOUT)")
+
+	err = LoadWithOpts(&config, &Opts{
+		Files:              []string{filepath.Join(t.TempDir(), "missing.yaml")},
+		IgnoreMissingFiles: true,
+		Environ:            []string{},
+		Args:               []string{"-port", "0x10000"},
+	})
+
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.EqualError(t, err, ""+
+		"configloader: out of range: max=65535: port\n"+
+		"configloader: required: database.host")
+
+	err = LoadWithOpts(&config, &Opts{Args: []string{"-unknown"}})
+	assert.Error(t, err)
+
+	assert.Error(t, Load(config))
+}
+
+func TestToSnake(t *testing.T) {
+	tests := map[string]string{
+		"Name":       "name",
+		"MaxConn":    "max_conn",
+		"HTTPServer": "http_server",
+		"UserID":     "user_id",
+		"IPv6":       "i_pv6",
+	}
+
+	for in, out := range tests {
+		assert.Equal(t, out, toSnake(in), in)
+	}
+}
@@ -0,0 +1,130 @@
+//
+// Struct validation from validate tags, e.g. `validate:"required,min=1"`
+//
+
+package configloader
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRequired   = errors.New("configloader: required")
+	ErrOutOfRange = errors.New("configloader: out of range")
+	ErrNotAllowed = errors.New("configloader: value not allowed")
+)
+
+//
+// Validate pointer to struct, supported rules are required, min=N, max=N and
+// oneof=a b c, min and max apply to the length of strings and slices
+//
+
+func Validate(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("configloader: destination must be a pointer to struct")
+	}
+
+	var errs []error
+	for _, f := range collectFields(v.Elem(), "", "") {
+		tag := f.tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			err := validateRule(f.value, strings.TrimSpace(rule))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s", err, f.path))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateRule(v reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "":
+		return nil
+
+	case "required":
+		if v.IsZero() {
+			return ErrRequired
+		}
+
+	case "min", "max":
+		// Unset optional values are not range checked
+		if v.IsZero() {
+			return nil
+		}
+
+		value, limit, err := rangeValues(v, arg)
+		if err != nil {
+			return err
+		}
+
+		if (name == "min" && value < limit) || (name == "max" && value > limit) {
+			return fmt.Errorf("%w: %s=%s", ErrOutOfRange, name, arg)
+		}
+
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if s == allowed {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%w: %q not in [%s]", ErrNotAllowed, s, arg)
+
+	default:
+		return fmt.Errorf("configloader: unknown validation rule %q", name)
+	}
+
+	return nil
+}
+
+//
+// Comparable value and limit as floats
+//
+
+func rangeValues(v reflect.Value, arg string) (float64, float64, error) {
+	var value float64
+
+	if v.Type() == durationType {
+		limit, err := time.ParseDuration(arg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("configloader: invalid duration limit %q", arg)
+		}
+
+		return float64(v.Int()), float64(limit), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		value = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	default:
+		return 0, 0, fmt.Errorf("configloader: range check on unsupported type %s", v.Type())
+	}
+
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("configloader: invalid limit %q", arg)
+	}
+
+	return value, limit, nil
+}
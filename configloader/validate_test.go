@@ -0,0 +1,58 @@
+package configloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	type config struct {
+		Level   string        `validate:"required,oneof=debug info warn"`
+		Hosts   []string      `validate:"min=1,max=2"`
+		Ratio   float64       `validate:"max=1"`
+		Workers uint          `validate:"min=2"`
+		Timeout time.Duration `validate:"min=1s"`
+		Nested  struct {
+			Key string `validate:"min=3"`
+		}
+	}
+
+	valid := &config{
+		Level:   "info",
+		Hosts:   []string{"a"},
+		Ratio:   0.5,
+		Workers: 4,
+		Timeout: time.Second,
+	}
+
+	assert.NoError(t, Validate(valid))
+
+	invalid := &config{
+		Level:   "trace",
+		Hosts:   []string{"a", "b", "c"},
+		Ratio:   2,
+		Workers: 1,
+		Timeout: time.Millisecond,
+	}
+
+	invalid.Nested.Key = "ab"
+
+	err := Validate(invalid)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	assert.EqualError(t, err, ""+
+		"configloader: value not allowed: \"trace\" not in [debug info warn]: level\n"+
+		"configloader: out of range: max=2: hosts\n"+
+		"configloader: out of range: max=1: ratio\n"+
+		"configloader: out of range: min=2: workers\n"+
+		"configloader: out of range: min=1s: timeout\n"+
+		"configloader: out of range: min=3: nested.key")
+
+	assert.ErrorIs(t, Validate(&config{}), ErrRequired)
+
+	assert.Error(t, Validate(&struct {
+		Value string `validate:"unknown"`
+	}{}))
+}
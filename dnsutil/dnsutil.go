@@ -0,0 +1,481 @@
+//
+// DNS lookups against explicitly chosen resolvers, with TTL honoring cache
+//
+
+package dnsutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+)
+
+type Resolver struct {
+	servers []string
+	timeout time.Duration
+	minTTL  time.Duration
+	maxTTL  time.Duration
+	dialer  *net.Dialer
+	cache   *cache.Cache[[]*Record]
+}
+
+type Opts struct {
+	Servers      []string
+	Timeout      time.Duration
+	MinTTL       time.Duration
+	MaxTTL       time.Duration
+	DisableCache bool
+}
+
+type MX struct {
+	Host       string
+	Preference uint16
+}
+
+var (
+	ErrNotFound  = errors.New("dnsutil: no such host")
+	ErrNoRecords = errors.New("dnsutil: no records")
+)
+
+var DefaultOpts = &Opts{
+	Timeout: 5 * time.Second,
+	MinTTL:  5 * time.Second,
+	MaxTTL:  time.Hour,
+}
+
+const resolvConfPath = "/etc/resolv.conf"
+
+//
+// Initialize resolver using system nameservers
+//
+
+func New() *Resolver {
+	return NewWithOpts(DefaultOpts)
+}
+
+//
+// Initialize resolver with opts, servers are "host" or "host:port"
+//
+
+func NewWithOpts(opts *Opts) *Resolver {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOpts.Timeout
+	}
+
+	if opts.MaxTTL == 0 {
+		opts.MaxTTL = DefaultOpts.MaxTTL
+	}
+
+	servers := opts.Servers
+	if len(servers) == 0 {
+		servers = systemServers(resolvConfPath)
+	}
+
+	r := &Resolver{
+		timeout: opts.Timeout,
+		minTTL:  opts.MinTTL,
+		maxTTL:  opts.MaxTTL,
+		dialer:  &net.Dialer{},
+	}
+
+	for _, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+
+		r.servers = append(r.servers, server)
+	}
+
+	if !opts.DisableCache {
+		r.cache = cache.New[[]*Record]()
+	}
+
+	return r
+}
+
+//
+// Nameservers from resolv.conf, falling back to localhost
+//
+
+func systemServers(path string) []string {
+	var servers []string
+
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, fields[1])
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1"}
+	}
+
+	return servers
+}
+
+//
+// Lookup records of type for name, trying servers in order
+//
+
+func (r *Resolver) Lookup(ctx context.Context, name string, qtype Type) ([]*Record, error) {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	if r.cache == nil {
+		return r.exchangeAll(ctx, name, qtype)
+	}
+
+	type result struct {
+		records []*Record
+		err     error
+	}
+
+	done := make(chan result, 1)
+
+	// Concurrent callers share the exchange, which must not fail for all of
+	// them when the first one gives up, each exchange has its own timeout
+	go func() {
+		records, err := r.cache.GetWithOpts(&cache.GetOpts[[]*Record]{
+			Key: qtype.String() + ":" + name,
+			Generator: func() ([]*Record, error) {
+				return r.exchangeAll(context.WithoutCancel(ctx), name, qtype)
+			},
+			TTLFunc: func(records []*Record) int64 {
+				return r.recordsTTL(records).Nanoseconds()
+			},
+		})
+
+		done <- result{records: records, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.records, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//
+// Cache lifetime is the lowest record TTL, clamped to the configured range
+//
+
+func (r *Resolver) recordsTTL(records []*Record) time.Duration {
+	ttl := r.maxTTL
+	for _, record := range records {
+		ttl = min(ttl, record.TTL)
+	}
+
+	return max(ttl, r.minTTL)
+}
+
+func (r *Resolver) exchangeAll(ctx context.Context, name string, qtype Type) ([]*Record, error) {
+	if len(r.servers) == 0 {
+		return nil, errors.New("dnsutil: no servers configured")
+	}
+
+	var errs []error
+	for _, server := range r.servers {
+		records, err := r.exchange(ctx, server, name, qtype)
+
+		// Authoritative answers end the search
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return records, err
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", server, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+//
+// Single query against server, over UDP with TCP fallback for truncated responses
+//
+
+func (r *Resolver) exchange(ctx context.Context, server string, name string, qtype Type) ([]*Record, error) {
+	id := uint16(rand.Uint32())
+
+	query, err := buildQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	msg, err := r.roundTrip(ctx, "udp", server, id, query)
+	if err == nil && msg.truncated {
+		msg, err = r.roundTrip(ctx, "tcp", server, id, query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch msg.rcode {
+	case rcodeSuccess:
+	case rcodeNXDomain:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	default:
+		return nil, fmt.Errorf("dnsutil: server returned rcode %d", msg.rcode)
+	}
+
+	return msg.answers, nil
+}
+
+func (r *Resolver) roundTrip(ctx context.Context, network, server string, id uint16, query []byte) (*message, error) {
+	con, err := r.dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+
+	defer con.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		con.SetDeadline(deadline)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		con.SetDeadline(time.Now())
+	})
+
+	defer stop()
+
+	// Stream transports prefix messages with their length
+	if network == "tcp" {
+		query = append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...)
+	}
+
+	_, err = con.Write(query)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var buf []byte
+
+		if network == "tcp" {
+			var size [2]byte
+			_, err = io.ReadFull(con, size[:])
+			if err != nil {
+				return nil, err
+			}
+
+			buf = make([]byte, binary.BigEndian.Uint16(size[:]))
+			_, err = io.ReadFull(con, buf)
+		} else {
+			buf = make([]byte, 65535)
+			var n int
+			n, err = con.Read(buf)
+			buf = buf[:n]
+		}
+
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+
+			return nil, err
+		}
+
+		msg, err := parseMessage(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ignore stray responses to other queries
+		if msg.id == id {
+			return msg, nil
+		}
+	}
+}
+
+//
+// Typed lookups
+//
+
+func (r *Resolver) LookupIP(ctx context.Context, name string) ([]netip.Addr, error) {
+	v4, err4 := r.LookupA(ctx, name)
+	v6, err6 := r.LookupAAAA(ctx, name)
+
+	if len(v4)+len(v6) > 0 {
+		return append(v4, v6...), nil
+	}
+
+	if err4 != nil {
+		return nil, err4
+	}
+
+	return nil, err6
+}
+
+func (r *Resolver) LookupA(ctx context.Context, name string) ([]netip.Addr, error) {
+	return r.lookupAddrs(ctx, name, TypeA)
+}
+
+func (r *Resolver) LookupAAAA(ctx context.Context, name string) ([]netip.Addr, error) {
+	return r.lookupAddrs(ctx, name, TypeAAAA)
+}
+
+func (r *Resolver) lookupAddrs(ctx context.Context, name string, qtype Type) ([]netip.Addr, error) {
+	values, err := r.lookupValues(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]netip.Addr, 0, len(values))
+	for _, value := range values {
+		addrs = append(addrs, netip.MustParseAddr(value))
+	}
+
+	return addrs, nil
+}
+
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.lookupValues(ctx, name, TypeTXT)
+}
+
+func (r *Resolver) LookupNS(ctx context.Context, name string) ([]string, error) {
+	return r.lookupValues(ctx, name, TypeNS)
+}
+
+func (r *Resolver) LookupPTR(ctx context.Context, addr netip.Addr) ([]string, error) {
+	return r.lookupValues(ctx, reverseName(addr), TypePTR)
+}
+
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*MX, error) {
+	records, err := r.lookupRecords(ctx, name, TypeMX)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*MX, 0, len(records))
+	for _, record := range records {
+		result = append(result, &MX{Host: record.Value, Preference: record.Preference})
+	}
+
+	return result, nil
+}
+
+func (r *Resolver) lookupValues(ctx context.Context, name string, qtype Type) ([]string, error) {
+	records, err := r.lookupRecords(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(records))
+	for _, record := range records {
+		values = append(values, record.Value)
+	}
+
+	return values, nil
+}
+
+//
+// Records of requested type only, skipping CNAME chains
+//
+
+func (r *Resolver) lookupRecords(ctx context.Context, name string, qtype Type) ([]*Record, error) {
+	records, err := r.Lookup(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Record
+	for _, record := range records {
+		if record.Type == qtype {
+			result = append(result, record)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRecords, qtype, name)
+	}
+
+	return result, nil
+}
+
+//
+// Dial resolving hostname with this resolver, compatible with whois.Dialer
+//
+
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := netip.ParseAddr(host); err == nil {
+		return r.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := r.LookupIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, addr := range addrs {
+		con, err := r.dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return con, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+//
+// Resolve addresses with the first resolver that answers, later resolvers
+// are only asked when earlier ones fail
+//
+
+func ResolveAllWithFallback(ctx context.Context, name string, resolvers ...*Resolver) ([]netip.Addr, error) {
+	var errs []error
+
+	for _, resolver := range resolvers {
+		addrs, err := resolver.LookupIP(ctx, name)
+		if err == nil {
+			return addrs, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("dnsutil: no resolvers")
+	}
+
+	return nil, errors.Join(errs...)
+}
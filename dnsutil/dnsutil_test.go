@@ -0,0 +1,283 @@
+package dnsutil
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testServer struct {
+	addr    string
+	queries atomic.Int64
+}
+
+//
+// Start UDP and TCP DNS server on the same port, answering with handler
+//
+
+func newTestServer(t *testing.T, handler func(name string, qtype Type, tcp bool) (int, bool, []*Record)) *testServer {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	server := &testServer{addr: pc.LocalAddr().String()}
+
+	respond := func(query []byte, tcp bool) []byte {
+		server.queries.Add(1)
+
+		name, next, _ := readName(query, headerLen)
+		qtype := Type(binary.BigEndian.Uint16(query[next:]))
+		rcode, truncated, records := handler(name, qtype, tcp)
+
+		return buildTestResponse(query, rcode, truncated, records...)
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			pc.WriteTo(respond(buf[:n], false), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			con, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			var size [2]byte
+			io.ReadFull(con, size[:])
+			query := make([]byte, binary.BigEndian.Uint16(size[:]))
+			io.ReadFull(con, query)
+
+			resp := respond(query, true)
+			con.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+			con.Close()
+		}
+	}()
+
+	return server
+}
+
+func exampleHandler(name string, qtype Type, tcp bool) (int, bool, []*Record) {
+	if name != "example.com." {
+		return rcodeNXDomain, false, nil
+	}
+
+	switch qtype {
+	case TypeA:
+		return rcodeSuccess, false, []*Record{{Type: TypeA, TTL: time.Minute, Value: "192.0.2.1"}}
+	case TypeAAAA:
+		return rcodeSuccess, false, []*Record{{Type: TypeAAAA, TTL: time.Minute, Value: "2001:db8::1"}}
+	case TypeMX:
+		return rcodeSuccess, false, []*Record{{Type: TypeMX, TTL: time.Minute, Value: "mx.example.com.", Preference: 10}}
+	case TypeNS:
+		return rcodeSuccess, false, []*Record{{Type: TypeNS, TTL: time.Minute, Value: "ns1.example.com."}}
+	case TypeTXT:
+		// Force TCP fallback
+		if !tcp {
+			return rcodeSuccess, true, nil
+		}
+
+		return rcodeSuccess, false, []*Record{{Type: TypeTXT, TTL: time.Minute, Value: "hello"}}
+	}
+
+	return rcodeSuccess, false, nil
+}
+
+func TestResolverLookups(t *testing.T) {
+	server := newTestServer(t, exampleHandler)
+	resolver := NewWithOpts(&Opts{Servers: []string{server.addr}})
+	ctx := context.Background()
+
+	addrs, err := resolver.LookupIP(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}, addrs)
+
+	mx, err := resolver.LookupMX(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []*MX{{Host: "mx.example.com.", Preference: 10}}, mx)
+
+	ns, err := resolver.LookupNS(ctx, "EXAMPLE.com.")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ns1.example.com."}, ns)
+
+	txt, err := resolver.LookupTXT(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, txt)
+
+	_, err = resolver.LookupA(ctx, "missing.example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = resolver.LookupPTR(ctx, netip.MustParseAddr("192.0.2.1"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestResolverCache(t *testing.T) {
+	server := newTestServer(t, func(name string, qtype Type, tcp bool) (int, bool, []*Record) {
+		return rcodeSuccess, false, []*Record{{Type: TypeA, TTL: time.Second, Value: "192.0.2.1"}}
+	})
+
+	resolver := NewWithOpts(&Opts{
+		Servers: []string{server.addr},
+		MinTTL:  time.Millisecond,
+		MaxTTL:  50 * time.Millisecond,
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := resolver.LookupA(context.Background(), "example.com")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(1), server.queries.Load())
+
+	// Record TTL is capped by max TTL
+	time.Sleep(60 * time.Millisecond)
+	_, err := resolver.LookupA(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), server.queries.Load())
+
+	assert.Equal(t, 30*time.Millisecond, resolver.recordsTTL([]*Record{{TTL: 30 * time.Millisecond}}))
+	assert.Equal(t, time.Millisecond, resolver.recordsTTL([]*Record{{TTL: 0}}))
+}
+
+func TestResolverCacheCancel(t *testing.T) {
+	server := newTestServer(t, func(name string, qtype Type, tcp bool) (int, bool, []*Record) {
+		time.Sleep(100 * time.Millisecond)
+		return rcodeSuccess, false, []*Record{{Type: TypeA, TTL: time.Minute, Value: "192.0.2.1"}}
+	})
+
+	resolver := NewWithOpts(&Opts{Servers: []string{server.addr}})
+
+	// First caller gives up while the exchange is in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := resolver.LookupA(ctx, "example.com")
+		first <- err
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+
+	addrs, err := resolver.LookupA(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("192.0.2.1")}, addrs)
+	assert.ErrorIs(t, <-first, context.DeadlineExceeded)
+	assert.Equal(t, int64(1), server.queries.Load())
+}
+
+func TestResolverFailover(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Silent server never answers
+	defer pc.Close()
+
+	server := newTestServer(t, exampleHandler)
+	resolver := NewWithOpts(&Opts{
+		Servers:      []string{pc.LocalAddr().String(), server.addr},
+		Timeout:      50 * time.Millisecond,
+		DisableCache: true,
+	})
+
+	addrs, err := resolver.LookupA(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("192.0.2.1")}, addrs)
+
+	silent := NewWithOpts(&Opts{
+		Servers: []string{pc.LocalAddr().String()},
+		Timeout: 50 * time.Millisecond,
+	})
+
+	_, err = silent.LookupA(context.Background(), "example.com")
+	assert.Error(t, err)
+
+	addrs, err = ResolveAllWithFallback(context.Background(), "example.com", silent, resolver)
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 2)
+
+	_, err = ResolveAllWithFallback(context.Background(), "example.com", silent)
+	assert.Error(t, err)
+}
+
+func TestResolverDialContext(t *testing.T) {
+	server := newTestServer(t, func(name string, qtype Type, tcp bool) (int, bool, []*Record) {
+		if qtype == TypeA {
+			return rcodeSuccess, false, []*Record{{Type: TypeA, TTL: time.Minute, Value: "127.0.0.1"}}
+		}
+
+		return rcodeSuccess, false, nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	go func() {
+		con, err := ln.Accept()
+		if err == nil {
+			con.Write([]byte("ok"))
+			con.Close()
+		}
+	}()
+
+	resolver := NewWithOpts(&Opts{Servers: []string{server.addr}})
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	con, err := resolver.DialContext(context.Background(), "tcp", net.JoinHostPort("service.internal", port))
+	assert.NoError(t, err)
+
+	data, _ := io.ReadAll(con)
+	con.Close()
+	assert.Equal(t, "ok", string(data))
+}
+
+func TestSystemServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	os.WriteFile(path, []byte(strings.Join([]string{
+		"# comment",
+		"search example.com",
+		"nameserver 192.0.2.53",
+		"nameserver 2001:db8::53",
+	}, "\n")), 0o644)
+
+	assert.Equal(t, []string{"192.0.2.53", "2001:db8::53"}, systemServers(path))
+	assert.Equal(t, []string{"127.0.0.1"}, systemServers(filepath.Join(t.TempDir(), "missing")))
+
+	resolver := NewWithOpts(&Opts{Servers: []string{"192.0.2.53", "2001:db8::53", "[2001:db8::54]:5353"}})
+	assert.Equal(t, []string{"192.0.2.53:53", "[2001:db8::53]:53", "[2001:db8::54]:5353"}, resolver.servers)
+}
@@ -0,0 +1,327 @@
+//
+// Minimal DNS wire format (RFC 1035) encoding and decoding
+//
+
+package dnsutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+type Type uint16
+
+const (
+	TypeA     Type = 1
+	TypeNS    Type = 2
+	TypeCNAME Type = 5
+	TypePTR   Type = 12
+	TypeMX    Type = 15
+	TypeTXT   Type = 16
+	TypeAAAA  Type = 28
+)
+
+type Record struct {
+	Name       string
+	Type       Type
+	TTL        time.Duration
+	Value      string
+	Preference uint16
+}
+
+type message struct {
+	id        uint16
+	rcode     int
+	truncated bool
+	answers   []*Record
+}
+
+const (
+	rcodeSuccess  = 0
+	rcodeNXDomain = 3
+	classINET     = 1
+	headerLen     = 12
+)
+
+var errMalformed = errors.New("dnsutil: malformed message")
+
+var typeNames = map[Type]string{
+	TypeA:     "A",
+	TypeNS:    "NS",
+	TypeCNAME: "CNAME",
+	TypePTR:   "PTR",
+	TypeMX:    "MX",
+	TypeTXT:   "TXT",
+	TypeAAAA:  "AAAA",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("TYPE%d", uint16(t))
+}
+
+//
+// Build recursive query for name
+//
+
+func buildQuery(id uint16, name string, qtype Type) ([]byte, error) {
+	msg := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	msg, err := appendName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	msg = binary.BigEndian.AppendUint16(msg, uint16(qtype))
+	msg = binary.BigEndian.AppendUint16(msg, classINET)
+
+	return msg, nil
+}
+
+func appendName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, fmt.Errorf("dnsutil: name too long: %q", name)
+	}
+
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, fmt.Errorf("dnsutil: invalid name: %q", name)
+			}
+
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+
+	return append(msg, 0), nil
+}
+
+//
+// Parse response message, keeping answer records only
+//
+
+func parseMessage(msg []byte) (*message, error) {
+	if len(msg) < headerLen {
+		return nil, errMalformed
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return nil, errors.New("dnsutil: message is not a response")
+	}
+
+	result := &message{
+		id:        binary.BigEndian.Uint16(msg[0:]),
+		rcode:     int(flags & 0x000f),
+		truncated: flags&0x0200 != 0,
+	}
+
+	qdCount := int(binary.BigEndian.Uint16(msg[4:]))
+	anCount := int(binary.BigEndian.Uint16(msg[6:]))
+	offset := headerLen
+
+	// Skip questions
+	for i := 0; i < qdCount; i++ {
+		_, next, err := readName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		offset = next + 4
+		if offset > len(msg) {
+			return nil, errMalformed
+		}
+	}
+
+	// Truncated responses may stop anywhere
+	if result.truncated {
+		return result, nil
+	}
+
+	for i := 0; i < anCount; i++ {
+		record, next, err := readRecord(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		offset = next
+		if record != nil {
+			result.answers = append(result.answers, record)
+		}
+	}
+
+	return result, nil
+}
+
+func readRecord(msg []byte, offset int) (*Record, int, error) {
+	name, offset, err := readName(msg, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if offset+10 > len(msg) {
+		return nil, 0, errMalformed
+	}
+
+	rtype := Type(binary.BigEndian.Uint16(msg[offset:]))
+	class := binary.BigEndian.Uint16(msg[offset+2:])
+	ttl := binary.BigEndian.Uint32(msg[offset+4:])
+	rdLen := int(binary.BigEndian.Uint16(msg[offset+8:]))
+	offset += 10
+
+	end := offset + rdLen
+	if end > len(msg) {
+		return nil, 0, errMalformed
+	}
+
+	// Only internet class records are of interest
+	if class != classINET {
+		return nil, end, nil
+	}
+
+	record := &Record{
+		Name: name,
+		Type: rtype,
+		TTL:  time.Duration(ttl) * time.Second,
+	}
+
+	rdata := msg[offset:end]
+
+	switch rtype {
+	case TypeA, TypeAAAA:
+		addr, ok := netip.AddrFromSlice(rdata)
+		if !ok || (rtype == TypeA && len(rdata) != 4) || (rtype == TypeAAAA && len(rdata) != 16) {
+			return nil, 0, errMalformed
+		}
+
+		record.Value = addr.String()
+
+	case TypeNS, TypeCNAME, TypePTR:
+		record.Value, _, err = readName(msg, offset)
+
+	case TypeMX:
+		if rdLen < 3 {
+			return nil, 0, errMalformed
+		}
+
+		record.Preference = binary.BigEndian.Uint16(rdata)
+		record.Value, _, err = readName(msg, offset+2)
+
+	case TypeTXT:
+		var sb strings.Builder
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return nil, 0, errMalformed
+			}
+
+			sb.Write(rdata[i+1 : i+1+n])
+			i += 1 + n
+		}
+
+		record.Value = sb.String()
+
+	default:
+		return nil, end, nil
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return record, end, nil
+}
+
+//
+// Read possibly compressed name, returns offset after the name at its
+// original position
+//
+
+func readName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	next := -1
+
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errMalformed
+		}
+
+		n := int(msg[offset])
+
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+
+			return strings.Join(labels, ".") + ".", next, nil
+
+		case n&0xc0 == 0xc0:
+			if offset+1 >= len(msg) {
+				return "", 0, errMalformed
+			}
+
+			// Guard against pointer loops
+			jumps++
+			if jumps > 32 {
+				return "", 0, errMalformed
+			}
+
+			if next < 0 {
+				next = offset + 2
+			}
+
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+
+		case n&0xc0 != 0:
+			return "", 0, errMalformed
+
+		default:
+			if offset+1+n > len(msg) {
+				return "", 0, errMalformed
+			}
+
+			labels = append(labels, string(msg[offset+1:offset+1+n]))
+			offset += 1 + n
+		}
+	}
+}
+
+//
+// Reverse lookup name for address
+//
+
+func reverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+
+	if addr.Is4() {
+		b := addr.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", b[3], b[2], b[1], b[0])
+	}
+
+	const hex = "0123456789abcdef"
+	b := addr.As16()
+
+	var sb strings.Builder
+	for i := len(b) - 1; i >= 0; i-- {
+		sb.WriteByte(hex[b[i]&0x0f])
+		sb.WriteByte('.')
+		sb.WriteByte(hex[b[i]>>4])
+		sb.WriteByte('.')
+	}
+
+	sb.WriteString("ip6.arpa.")
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package dnsutil
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Build response to query with answer records
+//
+
+func buildTestResponse(query []byte, rcode int, truncated bool, records ...*Record) []byte {
+	msg := append([]byte{}, query...)
+
+	flags := uint16(0x8180 | rcode)
+	if truncated {
+		flags |= 0x0200
+	}
+
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(records)))
+
+	for _, record := range records {
+		// Compressed pointer to question name
+		msg = append(msg, 0xc0, headerLen)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(record.Type))
+		msg = binary.BigEndian.AppendUint16(msg, classINET)
+		msg = binary.BigEndian.AppendUint32(msg, uint32(record.TTL/time.Second))
+
+		var rdata []byte
+		switch record.Type {
+		case TypeA, TypeAAAA:
+			rdata = netip.MustParseAddr(record.Value).AsSlice()
+		case TypeMX:
+			rdata = binary.BigEndian.AppendUint16(nil, record.Preference)
+			rdata, _ = appendName(rdata, record.Value)
+		case TypeTXT:
+			rdata = append([]byte{byte(len(record.Value))}, record.Value...)
+		default:
+			rdata, _ = appendName(nil, record.Value)
+		}
+
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+		msg = append(msg, rdata...)
+	}
+
+	return msg
+}
+
+func TestParseMessage(t *testing.T) {
+	query, err := buildQuery(1234, "example.com", TypeA)
+	assert.NoError(t, err)
+
+	msg, err := parseMessage(buildTestResponse(query, rcodeSuccess, false,
+		&Record{Type: TypeCNAME, TTL: time.Minute, Value: "www.example.net."},
+		&Record{Type: TypeA, TTL: 30 * time.Second, Value: "192.0.2.1"},
+		&Record{Type: TypeMX, TTL: time.Hour, Value: "mx.example.com.", Preference: 10},
+		&Record{Type: TypeTXT, TTL: time.Hour, Value: "v=spf1 -all"},
+	))
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1234), msg.id)
+	assert.Equal(t, []*Record{
+		{Name: "example.com.", Type: TypeCNAME, TTL: time.Minute, Value: "www.example.net."},
+		{Name: "example.com.", Type: TypeA, TTL: 30 * time.Second, Value: "192.0.2.1"},
+		{Name: "example.com.", Type: TypeMX, TTL: time.Hour, Value: "mx.example.com.", Preference: 10},
+		{Name: "example.com.", Type: TypeTXT, TTL: time.Hour, Value: "v=spf1 -all"},
+	}, msg.answers)
+}
+
+func TestParseMessageMalformed(t *testing.T) {
+	_, err := parseMessage([]byte{0, 1})
+	assert.ErrorIs(t, err, errMalformed)
+
+	// Query is not a response
+	query, _ := buildQuery(1, "example.com", TypeA)
+	_, err = parseMessage(query)
+	assert.Error(t, err)
+
+	// Pointer loop
+	msg := buildTestResponse(query, rcodeSuccess, false)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	msg = append(msg[:headerLen], 0xc0, headerLen)
+	_, err = parseMessage(msg)
+	assert.ErrorIs(t, err, errMalformed)
+
+	_, err = buildQuery(1, "bad..name", TypeA)
+	assert.Error(t, err)
+}
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "1.2.0.192.in-addr.arpa.", reverseName(netip.MustParseAddr("192.0.2.1")))
+	assert.Equal(t, "1.2.0.192.in-addr.arpa.", reverseName(netip.MustParseAddr("::ffff:192.0.2.1")))
+	assert.Equal(t,
+		"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+		reverseName(netip.MustParseAddr("2001:db8::1")))
+}
+
+func TestTypeString(t *testing.T) {
+	assert.Equal(t, "AAAA", TypeAAAA.String())
+	assert.Equal(t, "TYPE99", Type(99).String())
+}
@@ -0,0 +1,269 @@
+//
+// In-process pub/sub with typed topics and buffered subscribers
+//
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// What to do when a subscriber buffer is full
+type Policy int
+
+const (
+	// Wait for the subscriber, bounded by the publish context
+	PolicyBlock Policy = iota
+	// Discard the event being published
+	PolicyDropNewest
+	// Discard the oldest buffered event to make room
+	PolicyDropOldest
+)
+
+type Topic[T any] struct {
+	name   string
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]bool
+	closed bool
+}
+
+type Subscription[T any] struct {
+	topic   *Topic[T]
+	ch      chan T
+	done    chan bool
+	policy  Policy
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+type SubscribeOpts struct {
+	Buffer int
+	Policy Policy
+}
+
+var DefaultSubscribeOpts = &SubscribeOpts{
+	Buffer: 64,
+	Policy: PolicyBlock,
+}
+
+var ErrTopicClosed = errors.New("eventbus: topic closed")
+
+//
+// Initialize new topic
+//
+
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{
+		name: name,
+		subs: make(map[*Subscription[T]]bool),
+	}
+}
+
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+func (t *Topic[T]) Subscribers() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.subs)
+}
+
+//
+// Subscribe with default opts
+//
+
+func (t *Topic[T]) Subscribe() (*Subscription[T], error) {
+	return t.SubscribeWithOpts(&SubscribeOpts{})
+}
+
+func (t *Topic[T]) SubscribeWithOpts(opts *SubscribeOpts) (*Subscription[T], error) {
+	if opts.Buffer == 0 {
+		opts.Buffer = DefaultSubscribeOpts.Buffer
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, ErrTopicClosed
+	}
+
+	sub := &Subscription[T]{
+		topic:  t,
+		ch:     make(chan T, opts.Buffer),
+		done:   make(chan bool),
+		policy: opts.Policy,
+	}
+
+	t.subs[sub] = true
+	return sub, nil
+}
+
+//
+// Subscribe with a handler run on its own goroutine until unsubscribed
+//
+
+func (t *Topic[T]) SubscribeFunc(opts *SubscribeOpts, fn func(event T)) (*Subscription[T], error) {
+	if opts == nil {
+		opts = &SubscribeOpts{}
+	}
+
+	sub, err := t.SubscribeWithOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for event := range sub.ch {
+			fn(event)
+		}
+	}()
+
+	return sub, nil
+}
+
+//
+// Publish to all subscribers, blocking subscribers may hold this up
+// indefinitely, see PublishCtx
+//
+
+func (t *Topic[T]) Publish(event T) error {
+	return t.PublishCtx(context.Background(), event)
+}
+
+//
+// Publish to all subscribers, returns context error if a blocking
+// subscriber did not make room in time, the remaining ones still get it
+//
+
+func (t *Topic[T]) PublishCtx(ctx context.Context, event T) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed {
+		return ErrTopicClosed
+	}
+
+	var err error
+	for sub := range t.subs {
+		if !sub.deliver(ctx, event) {
+			err = ctx.Err()
+		}
+	}
+
+	return err
+}
+
+//
+// Close topic and all subscriptions, subscriber channels drain and close
+//
+
+func (t *Topic[T]) Close() {
+	// Unblock publishers first, they hold the read lock while waiting
+	t.mu.RLock()
+	for sub := range t.subs {
+		sub.stop()
+	}
+
+	t.mu.RUnlock()
+
+	t.mu.Lock()
+	subs := t.subs
+	t.subs = make(map[*Subscription[T]]bool)
+	t.closed = true
+	t.mu.Unlock()
+
+	for sub := range subs {
+		sub.close()
+	}
+}
+
+//
+// Subscription
+//
+
+// Events in publish order, closed when unsubscribed or the topic closes
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Events discarded by the drop policies
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription[T]) Unsubscribe() {
+	// Unblock publishers before waiting for the topic lock they hold
+	s.stop()
+
+	t := s.topic
+	t.mu.Lock()
+	_, ok := t.subs[s]
+	delete(t.subs, s)
+	t.mu.Unlock()
+
+	if ok {
+		s.close()
+	}
+}
+
+func (s *Subscription[T]) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *Subscription[T]) close() {
+	s.stop()
+
+	// Publishers only send under the topic read lock, which is released
+	close(s.ch)
+}
+
+//
+// Deliver event according to policy, false on context expiry
+//
+
+func (s *Subscription[T]) deliver(ctx context.Context, event T) bool {
+	select {
+	case s.ch <- event:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case PolicyDropNewest:
+		s.dropped.Add(1)
+		return true
+
+	case PolicyDropOldest:
+		for {
+			select {
+			case s.ch <- event:
+				return true
+			default:
+			}
+
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	}
+
+	select {
+	case s.ch <- event:
+		return true
+	case <-s.done:
+		return true
+	case <-ctx.Done():
+		s.dropped.Add(1)
+		return false
+	}
+}
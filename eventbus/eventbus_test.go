@@ -0,0 +1,187 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type evicted struct {
+	Key string
+}
+
+func receive[T any](t *testing.T, sub *Subscription[T], n int) []T {
+	var events []T
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-sub.C():
+			events = append(events, event)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d events", i)
+		}
+	}
+
+	return events
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	topic := NewTopic[evicted]("cache.evicted")
+	assert.Equal(t, "cache.evicted", topic.Name())
+
+	a, err := topic.Subscribe()
+	assert.NoError(t, err)
+
+	b, err := topic.Subscribe()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, topic.Subscribers())
+
+	assert.NoError(t, topic.Publish(evicted{Key: "one"}))
+	assert.NoError(t, topic.Publish(evicted{Key: "two"}))
+
+	expected := []evicted{{Key: "one"}, {Key: "two"}}
+	assert.Equal(t, expected, receive(t, a, 2))
+	assert.Equal(t, expected, receive(t, b, 2))
+
+	a.Unsubscribe()
+	a.Unsubscribe()
+	assert.Equal(t, 1, topic.Subscribers())
+
+	_, ok := <-a.C()
+	assert.False(t, ok)
+}
+
+func TestPolicyDropNewest(t *testing.T) {
+	topic := NewTopic[int]("test")
+	sub, _ := topic.SubscribeWithOpts(&SubscribeOpts{
+		Buffer: 2,
+		Policy: PolicyDropNewest,
+	})
+
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, topic.Publish(i))
+	}
+
+	assert.Equal(t, []int{1, 2}, receive(t, sub, 2))
+	assert.Equal(t, uint64(3), sub.Dropped())
+}
+
+func TestPolicyDropOldest(t *testing.T) {
+	topic := NewTopic[int]("test")
+	sub, _ := topic.SubscribeWithOpts(&SubscribeOpts{
+		Buffer: 2,
+		Policy: PolicyDropOldest,
+	})
+
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, topic.Publish(i))
+	}
+
+	assert.Equal(t, []int{4, 5}, receive(t, sub, 2))
+	assert.Equal(t, uint64(3), sub.Dropped())
+}
+
+func TestPolicyBlock(t *testing.T) {
+	topic := NewTopic[int]("test")
+	sub, _ := topic.SubscribeWithOpts(&SubscribeOpts{
+		Buffer: 1,
+	})
+
+	assert.NoError(t, topic.Publish(1))
+
+	// Full buffer holds up publisher until context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, topic.PublishCtx(ctx, 2), context.DeadlineExceeded)
+	assert.Equal(t, uint64(1), sub.Dropped())
+
+	// Reader makes room
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-sub.C()
+	}()
+
+	assert.NoError(t, topic.Publish(3))
+	assert.Equal(t, []int{3}, receive(t, sub, 1))
+}
+
+func TestUnsubscribeUnblocksPublisher(t *testing.T) {
+	topic := NewTopic[int]("test")
+	sub, _ := topic.SubscribeWithOpts(&SubscribeOpts{
+		Buffer: 1,
+	})
+
+	assert.NoError(t, topic.Publish(1))
+
+	done := make(chan error)
+	go func() {
+		done <- topic.Publish(2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked")
+	}
+}
+
+func TestSubscribeFunc(t *testing.T) {
+	topic := NewTopic[string]("test")
+
+	var mu sync.Mutex
+	var events []string
+
+	_, err := topic.SubscribeFunc(nil, func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	assert.NoError(t, err)
+	topic.Publish("a")
+	topic.Publish("b")
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestTopicClose(t *testing.T) {
+	topic := NewTopic[int]("test")
+	sub, _ := topic.SubscribeWithOpts(&SubscribeOpts{
+		Buffer: 1,
+	})
+
+	topic.Publish(1)
+
+	// Blocked publisher is released by close
+	done := make(chan error)
+	go func() {
+		done <- topic.Publish(2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	topic.Close()
+	<-done
+
+	// Buffered events drain before the channel closes
+	assert.Equal(t, []int{1}, receive(t, sub, 1))
+	_, ok := <-sub.C()
+	assert.False(t, ok)
+
+	assert.ErrorIs(t, topic.Publish(3), ErrTopicClosed)
+	_, err := topic.Subscribe()
+	assert.ErrorIs(t, err, ErrTopicClosed)
+
+	sub.Unsubscribe()
+	assert.Equal(t, 0, topic.Subscribers())
+}
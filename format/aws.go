@@ -4,7 +4,13 @@
 
 package format
 
-import "regexp"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 //
 // ARN formatting
@@ -20,3 +26,599 @@ func Ec2InstanceIdFromArn(arn string) string {
 func EcsTaskArnToClusterArn(arn string) string {
 	return arnEcsTaskToClusterRe.ReplaceAllString(arn, "${1}:cluster/${2}")
 }
+
+//
+// Generic ARN parsing
+//
+
+type Arn struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountId string
+	Resource  string
+}
+
+var ErrInvalidArn = errors.New("format: invalid arn")
+
+var arnPartitionRe = regexp.MustCompile(`^aws(-[a-z]+)*$`)
+
+func ParseArn(arn string) (*Arn, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || !arnPartitionRe.MatchString(parts[1]) || parts[2] == "" || parts[5] == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidArn, arn)
+	}
+
+	return &Arn{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountId: parts[4],
+		Resource:  parts[5],
+	}, nil
+}
+
+func (a *Arn) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountId, a.Resource}, ":")
+}
+
+//
+// Parse ARN and require service
+//
+
+func parseServiceArn(arn string, service string) (*Arn, error) {
+	parsed, err := ParseArn(arn)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.Service != service {
+		return nil, fmt.Errorf("%w: expected %s arn, got %q", ErrInvalidArn, service, arn)
+	}
+
+	return parsed, nil
+}
+
+//
+// S3 bucket and key from ARN or s3:// URI
+//
+
+var s3BucketRe = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+func S3BucketKeyFromArn(input string) (string, string, error) {
+	var path string
+
+	if strings.HasPrefix(input, "s3://") {
+		path = strings.TrimPrefix(input, "s3://")
+	} else {
+		parsed, err := parseServiceArn(input, "s3")
+		if err != nil {
+			return "", "", err
+		}
+
+		if parsed.Region != "" || parsed.AccountId != "" {
+			return "", "", fmt.Errorf("%w: not an s3 bucket or object arn %q", ErrInvalidArn, input)
+		}
+
+		path = parsed.Resource
+	}
+
+	bucket, key, _ := strings.Cut(path, "/")
+	if !s3BucketRe.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return "", "", fmt.Errorf("%w: invalid s3 bucket name in %q", ErrInvalidArn, input)
+	}
+
+	return bucket, key, nil
+}
+
+//
+// Lambda function name and optional version or alias qualifier
+//
+
+var lambdaFunctionRe = regexp.MustCompile(`^function:([a-zA-Z0-9_-]{1,64})(?::(\$LATEST|[a-zA-Z0-9_-]+))?$`)
+
+func LambdaFunctionFromArn(arn string) (string, string, error) {
+	parsed, err := parseServiceArn(arn, "lambda")
+	if err != nil {
+		return "", "", err
+	}
+
+	match := lambdaFunctionRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: not a lambda function arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], match[2], nil
+}
+
+//
+// SQS queue name
+//
+
+var sqsQueueRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,80}(\.fifo)?$`)
+
+func SqsQueueNameFromArn(arn string) (string, error) {
+	parsed, err := parseServiceArn(arn, "sqs")
+	if err != nil {
+		return "", err
+	}
+
+	if !sqsQueueRe.MatchString(parsed.Resource) {
+		return "", fmt.Errorf("%w: not an sqs queue arn %q", ErrInvalidArn, arn)
+	}
+
+	return parsed.Resource, nil
+}
+
+//
+// SNS topic name, from topic or subscription ARN
+//
+
+var snsTopicRe = regexp.MustCompile(`^([a-zA-Z0-9_-]{1,256}(?:\.fifo)?)(?::[a-f0-9-]{36})?$`)
+
+func SnsTopicNameFromArn(arn string) (string, error) {
+	parsed, err := parseServiceArn(arn, "sns")
+	if err != nil {
+		return "", err
+	}
+
+	match := snsTopicRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", fmt.Errorf("%w: not an sns topic arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], nil
+}
+
+//
+// IAM role and user names, paths are stripped
+//
+
+func IamRoleNameFromArn(arn string) (string, error) {
+	return iamNameFromArn(arn, "role")
+}
+
+func IamUserNameFromArn(arn string) (string, error) {
+	return iamNameFromArn(arn, "user")
+}
+
+var iamNameRe = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+
+func iamNameFromArn(arn string, resourceType string) (string, error) {
+	parsed, err := parseServiceArn(arn, "iam")
+	if err != nil {
+		return "", err
+	}
+
+	path, found := strings.CutPrefix(parsed.Resource, resourceType+"/")
+	name := path[strings.LastIndex(path, "/")+1:]
+
+	if !found || !iamNameRe.MatchString(name) {
+		return "", fmt.Errorf("%w: not an iam %s arn %q", ErrInvalidArn, resourceType, arn)
+	}
+
+	return name, nil
+}
+
+//
+// ECS service, task definition and cluster ARNs
+//
+
+var ecsServiceRe = regexp.MustCompile(`^service/(?:([a-zA-Z0-9_-]{1,255})/)?([a-zA-Z0-9_-]{1,255})$`)
+var ecsTaskDefinitionRe = regexp.MustCompile(`^(?:task-definition/)?([a-zA-Z0-9_-]{1,255}):([1-9][0-9]*)$`)
+var awsNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,255}$`)
+var awsAccountIdRe = regexp.MustCompile(`^\d{12}$`)
+
+// Cluster is empty for old-style ARNs without the cluster segment
+func EcsServiceFromArn(arn string) (string, string, error) {
+	parsed, err := parseServiceArn(arn, "ecs")
+	if err != nil {
+		return "", "", err
+	}
+
+	match := ecsServiceRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: not an ecs service arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], match[2], nil
+}
+
+// Accepts full ARNs and "family:revision" strings
+func EcsTaskDefinitionFamilyRevision(input string) (string, int, error) {
+	resource := input
+	if strings.HasPrefix(input, "arn:") {
+		parsed, err := parseServiceArn(input, "ecs")
+		if err != nil {
+			return "", 0, err
+		}
+
+		resource = parsed.Resource
+	}
+
+	match := ecsTaskDefinitionRe.FindStringSubmatch(resource)
+	if match == nil {
+		return "", 0, fmt.Errorf("%w: not an ecs task definition %q", ErrInvalidArn, input)
+	}
+
+	revision, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid revision in %q", ErrInvalidArn, input)
+	}
+
+	return match[1], revision, nil
+}
+
+func EcsClusterArn(region string, accountId string, name string) (string, error) {
+	return buildClusterArn("ecs", region, accountId, name)
+}
+
+//
+// EKS cluster ARNs
+//
+
+var eksClusterRe = regexp.MustCompile(`^cluster/([a-zA-Z0-9][a-zA-Z0-9_-]{0,99})$`)
+
+func EksClusterNameFromArn(arn string) (string, error) {
+	parsed, err := parseServiceArn(arn, "eks")
+	if err != nil {
+		return "", err
+	}
+
+	match := eksClusterRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", fmt.Errorf("%w: not an eks cluster arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], nil
+}
+
+func EksClusterArn(region string, accountId string, name string) (string, error) {
+	return buildClusterArn("eks", region, accountId, name)
+}
+
+func buildClusterArn(service string, region string, accountId string, name string) (string, error) {
+	if !awsNameRe.MatchString(name) {
+		return "", fmt.Errorf("%w: invalid %s cluster name %q", ErrInvalidArn, service, name)
+	}
+
+	return buildArn(service, region, accountId, "cluster/"+name)
+}
+
+//
+// ELBv2 target group, listener and CloudWatch dimension labels
+//
+
+var elbLoadBalancerRe = regexp.MustCompile(`^(?:loadbalancer|listener|listener-rule)/((?:app|net|gwy)/[a-zA-Z0-9-]{1,32}/[0-9a-f]{16})(?:/|$)`)
+var elbListenerRe = regexp.MustCompile(`^listener/(?:app|net|gwy)/[a-zA-Z0-9-]{1,32}/[0-9a-f]{16}/([0-9a-f]{16})$`)
+var elbTargetGroupRe = regexp.MustCompile(`^targetgroup/([a-zA-Z0-9-]{1,32})/([0-9a-f]{16})$`)
+
+func ElbTargetGroupFromArn(arn string) (string, string, error) {
+	parsed, err := parseServiceArn(arn, "elasticloadbalancing")
+	if err != nil {
+		return "", "", err
+	}
+
+	match := elbTargetGroupRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: not a target group arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], match[2], nil
+}
+
+func ElbListenerIdFromArn(arn string) (string, error) {
+	parsed, err := parseServiceArn(arn, "elasticloadbalancing")
+	if err != nil {
+		return "", err
+	}
+
+	match := elbListenerRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", fmt.Errorf("%w: not a listener arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], nil
+}
+
+// CloudWatch "LoadBalancer" dimension, e.g. "app/my-lb/50dc6c495c0c9188",
+// accepts load balancer, listener and listener rule ARNs
+func ElbLoadBalancerLabel(arn string) (string, error) {
+	parsed, err := parseServiceArn(arn, "elasticloadbalancing")
+	if err != nil {
+		return "", err
+	}
+
+	match := elbLoadBalancerRe.FindStringSubmatch(parsed.Resource)
+	if match == nil {
+		return "", fmt.Errorf("%w: not a load balancer arn %q", ErrInvalidArn, arn)
+	}
+
+	return match[1], nil
+}
+
+// CloudWatch "TargetGroup" dimension, e.g. "targetgroup/my-tg/73e2d6bc24d8a067"
+func ElbTargetGroupLabel(arn string) (string, error) {
+	name, id, err := ElbTargetGroupFromArn(arn)
+	if err != nil {
+		return "", err
+	}
+
+	return "targetgroup/" + name + "/" + id, nil
+}
+
+//
+// Partition from region name
+//
+
+func PartitionForRegion(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+
+	return "aws"
+}
+
+//
+// ARN building from IDs, the inverse of the extractors above
+//
+
+var awsRegionRe = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+var ec2InstanceIdRe = regexp.MustCompile(`^i-[0-9a-f]{8}([0-9a-f]{9})?$`)
+var elbIdRe = regexp.MustCompile(`^[0-9a-f]{16}$`)
+var elbNameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,30}[a-zA-Z0-9])?$`)
+var ecsTaskIdRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+var cloudFrontIdRe = regexp.MustCompile(`^[A-Z0-9]{13,14}$`)
+
+func buildArn(service string, region string, accountId string, resource string) (string, error) {
+	if !awsRegionRe.MatchString(region) {
+		return "", fmt.Errorf("%w: invalid region %q", ErrInvalidArn, region)
+	}
+
+	if !awsAccountIdRe.MatchString(accountId) {
+		return "", fmt.Errorf("%w: invalid account id %q", ErrInvalidArn, accountId)
+	}
+
+	arn := &Arn{
+		Partition: PartitionForRegion(region),
+		Service:   service,
+		Region:    region,
+		AccountId: accountId,
+		Resource:  resource,
+	}
+
+	return arn.String(), nil
+}
+
+func BuildEc2InstanceArn(region string, accountId string, instanceId string) (string, error) {
+	if !ec2InstanceIdRe.MatchString(instanceId) {
+		return "", fmt.Errorf("%w: invalid instance id %q", ErrInvalidArn, instanceId)
+	}
+
+	return buildArn("ec2", region, accountId, "instance/"+instanceId)
+}
+
+// Load balancer type is "app", "net" or "gwy"
+func BuildElbLoadBalancerArn(region string, accountId string, lbType string, name string, id string) (string, error) {
+	switch lbType {
+	case "app", "net", "gwy":
+	default:
+		return "", fmt.Errorf("%w: invalid load balancer type %q", ErrInvalidArn, lbType)
+	}
+
+	if !elbNameRe.MatchString(name) || !elbIdRe.MatchString(id) {
+		return "", fmt.Errorf("%w: invalid load balancer %q/%q", ErrInvalidArn, name, id)
+	}
+
+	return buildArn("elasticloadbalancing", region, accountId, "loadbalancer/"+lbType+"/"+name+"/"+id)
+}
+
+func BuildElbTargetGroupArn(region string, accountId string, name string, id string) (string, error) {
+	if !elbNameRe.MatchString(name) || !elbIdRe.MatchString(id) {
+		return "", fmt.Errorf("%w: invalid target group %q/%q", ErrInvalidArn, name, id)
+	}
+
+	return buildArn("elasticloadbalancing", region, accountId, "targetgroup/"+name+"/"+id)
+}
+
+func BuildEcsServiceArn(region string, accountId string, cluster string, service string) (string, error) {
+	if !awsNameRe.MatchString(cluster) || !awsNameRe.MatchString(service) {
+		return "", fmt.Errorf("%w: invalid ecs service %q/%q", ErrInvalidArn, cluster, service)
+	}
+
+	return buildArn("ecs", region, accountId, "service/"+cluster+"/"+service)
+}
+
+func BuildEcsTaskArn(region string, accountId string, cluster string, taskId string) (string, error) {
+	if !awsNameRe.MatchString(cluster) || !ecsTaskIdRe.MatchString(taskId) {
+		return "", fmt.Errorf("%w: invalid ecs task %q/%q", ErrInvalidArn, cluster, taskId)
+	}
+
+	return buildArn("ecs", region, accountId, "task/"+cluster+"/"+taskId)
+}
+
+func BuildEcsTaskDefinitionArn(region string, accountId string, family string, revision int) (string, error) {
+	if !awsNameRe.MatchString(family) || revision < 1 {
+		return "", fmt.Errorf("%w: invalid ecs task definition %q:%d", ErrInvalidArn, family, revision)
+	}
+
+	return buildArn("ecs", region, accountId, fmt.Sprintf("task-definition/%s:%d", family, revision))
+}
+
+// CloudFront is global, its ARNs carry no region
+func BuildCloudFrontDistributionArn(accountId string, distributionId string) (string, error) {
+	if !awsAccountIdRe.MatchString(accountId) || !cloudFrontIdRe.MatchString(distributionId) {
+		return "", fmt.Errorf("%w: invalid cloudfront distribution %q in %s", ErrInvalidArn, distributionId, accountId)
+	}
+
+	arn := &Arn{
+		Partition: "aws",
+		Service:   "cloudfront",
+		AccountId: accountId,
+		Resource:  "distribution/" + distributionId,
+	}
+
+	return arn.String(), nil
+}
+
+//
+// Account IDs
+//
+
+var ErrInvalidAccountId = errors.New("format: invalid aws account id")
+
+func ValidateAccountId(accountId string) error {
+	if !awsAccountIdRe.MatchString(accountId) {
+		return fmt.Errorf("%w: %q", ErrInvalidAccountId, accountId)
+	}
+
+	return nil
+}
+
+// Fails for ARNs without account, like S3 buckets
+func AccountIdFromArn(arn string) (string, error) {
+	parsed, err := ParseArn(arn)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ValidateAccountId(parsed.AccountId); err != nil {
+		return "", fmt.Errorf("%w in arn %q", err, arn)
+	}
+
+	return parsed.AccountId, nil
+}
+
+//
+// IAM principals as found in policies, CloudTrail and GetCallerIdentity
+//
+
+const (
+	PrincipalAccount       = "account"
+	PrincipalRoot          = "root"
+	PrincipalUser          = "user"
+	PrincipalRole          = "role"
+	PrincipalAssumedRole   = "assumed-role"
+	PrincipalFederatedUser = "federated-user"
+	PrincipalService       = "service"
+	PrincipalAnonymous     = "anonymous"
+)
+
+type Principal struct {
+	Type      string
+	Partition string
+	AccountId string
+
+	// User or role name without path, service host for service principals
+	Name string
+	Path string
+
+	// Only set for assumed roles
+	SessionName string
+}
+
+var ErrInvalidPrincipal = errors.New("format: invalid principal")
+
+var servicePrincipalRe = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)*\.amazonaws\.com(\.cn)?$`)
+var stsSessionNameRe = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+//
+// Parse principal from ARN, bare account ID, service principal or "*"
+//
+
+func ParsePrincipal(input string) (*Principal, error) {
+	switch {
+	case input == "*":
+		return &Principal{Type: PrincipalAnonymous}, nil
+
+	case awsAccountIdRe.MatchString(input):
+		return &Principal{Type: PrincipalAccount, Partition: "aws", AccountId: input}, nil
+
+	case servicePrincipalRe.MatchString(input):
+		return &Principal{Type: PrincipalService, Name: input}, nil
+	}
+
+	parsed, err := ParseArn(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+	}
+
+	if !awsAccountIdRe.MatchString(parsed.AccountId) || parsed.Region != "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+	}
+
+	principal := &Principal{
+		Partition: parsed.Partition,
+		AccountId: parsed.AccountId,
+	}
+
+	resourceType, rest, _ := strings.Cut(parsed.Resource, "/")
+
+	switch {
+	case parsed.Service == "iam" && parsed.Resource == "root":
+		principal.Type = PrincipalRoot
+		return principal, nil
+
+	case parsed.Service == "iam" && (resourceType == "user" || resourceType == "role"):
+		slash := strings.LastIndex(rest, "/")
+		principal.Type = resourceType
+		principal.Name = rest[slash+1:]
+		principal.Path = "/" + rest[:slash+1]
+
+		if !iamNameRe.MatchString(principal.Name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+		}
+
+		return principal, nil
+
+	case parsed.Service == "sts" && resourceType == PrincipalAssumedRole:
+		role, session, ok := strings.Cut(rest, "/")
+		if !ok || !iamNameRe.MatchString(role) || !stsSessionNameRe.MatchString(session) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+		}
+
+		principal.Type = PrincipalAssumedRole
+		principal.Name = role
+		principal.SessionName = session
+		return principal, nil
+
+	case parsed.Service == "sts" && resourceType == PrincipalFederatedUser:
+		if !iamNameRe.MatchString(rest) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+		}
+
+		principal.Type = PrincipalFederatedUser
+		principal.Name = rest
+		return principal, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrInvalidPrincipal, input)
+}
+
+//
+// IAM role ARN behind an assumed role or role principal, the path is lost in
+// assumed-role ARNs so the role must not be nested for an exact match
+//
+
+func (p *Principal) RoleArn() (string, error) {
+	if p.Type != PrincipalRole && p.Type != PrincipalAssumedRole {
+		return "", fmt.Errorf("%w: %s principal has no role", ErrInvalidPrincipal, p.Type)
+	}
+
+	path := p.Path
+	if path == "" {
+		path = "/"
+	}
+
+	arn := &Arn{
+		Partition: p.Partition,
+		Service:   "iam",
+		AccountId: p.AccountId,
+		Resource:  "role" + path + p.Name,
+	}
+
+	return arn.String(), nil
+}
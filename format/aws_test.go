@@ -27,3 +27,362 @@ func TestEcsTaskArnToClusterArnErr(t *testing.T) {
 	result := EcsTaskArnToClusterArn(arn)
 	assert.Equal(t, arn, result)
 }
+
+func TestParseArn(t *testing.T) {
+	arn, err := ParseArn("arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-1234567890abcdef")
+	assert.NoError(t, err)
+	assert.Equal(t, &Arn{
+		Partition: "aws-us-gov",
+		Service:   "ec2",
+		Region:    "us-gov-west-1",
+		AccountId: "123456789012",
+		Resource:  "instance/i-1234567890abcdef",
+	}, arn)
+
+	assert.Equal(t, "arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-1234567890abcdef", arn.String())
+
+	for _, invalid := range []string{"", "invalid-arn", "arn:aws:s3:::", "arn:gcp:s3:::bucket", "foo:aws:s3:::bucket"} {
+		_, err := ParseArn(invalid)
+		assert.ErrorIs(t, err, ErrInvalidArn, invalid)
+	}
+}
+
+func TestS3BucketKeyFromArn(t *testing.T) {
+	tests := []struct {
+		in     string
+		bucket string
+		key    string
+		err    bool
+	}{
+		{in: "arn:aws:s3:::my-bucket", bucket: "my-bucket"},
+		{in: "arn:aws:s3:::my-bucket/path/to/object.json", bucket: "my-bucket", key: "path/to/object.json"},
+		{in: "s3://my.bucket/key", bucket: "my.bucket", key: "key"},
+		{in: "s3://My_Bucket/key", err: true},
+		{in: "s3://ab", err: true},
+		{in: "arn:aws:s3:us-east-1:123456789012:accesspoint/foo", err: true},
+		{in: "arn:aws:sqs:us-east-1:123456789012:queue", err: true},
+	}
+
+	for _, test := range tests {
+		bucket, key, err := S3BucketKeyFromArn(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+		assert.Equal(t, test.bucket, bucket)
+		assert.Equal(t, test.key, key)
+	}
+}
+
+func TestLambdaFunctionFromArn(t *testing.T) {
+	tests := []struct {
+		in        string
+		name      string
+		qualifier string
+		err       bool
+	}{
+		{in: "arn:aws:lambda:eu-west-1:123456789012:function:my-function", name: "my-function"},
+		{in: "arn:aws:lambda:eu-west-1:123456789012:function:my-function:prod", name: "my-function", qualifier: "prod"},
+		{in: "arn:aws:lambda:eu-west-1:123456789012:function:my-function:$LATEST", name: "my-function", qualifier: "$LATEST"},
+		{in: "arn:aws:lambda:eu-west-1:123456789012:layer:my-layer:1", err: true},
+		{in: "invalid-arn", err: true},
+	}
+
+	for _, test := range tests {
+		name, qualifier, err := LambdaFunctionFromArn(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+		assert.Equal(t, test.name, name)
+		assert.Equal(t, test.qualifier, qualifier)
+	}
+}
+
+func TestSqsQueueNameFromArn(t *testing.T) {
+	name, err := SqsQueueNameFromArn("arn:aws:sqs:eu-west-1:123456789012:my-queue.fifo")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-queue.fifo", name)
+
+	_, err = SqsQueueNameFromArn("arn:aws:sns:eu-west-1:123456789012:my-topic")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+
+	_, err = SqsQueueNameFromArn("arn:aws:sqs:eu-west-1:123456789012:bad/queue")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+}
+
+func TestSnsTopicNameFromArn(t *testing.T) {
+	name, err := SnsTopicNameFromArn("arn:aws:sns:eu-west-1:123456789012:my-topic")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-topic", name)
+
+	name, err = SnsTopicNameFromArn("arn:aws:sns:eu-west-1:123456789012:my-topic:3f8fae2a-33ce-4c19-ba06-3f3009a7c33a")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-topic", name)
+
+	_, err = SnsTopicNameFromArn("arn:aws:sqs:eu-west-1:123456789012:my-queue")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+}
+
+func TestIamNamesFromArn(t *testing.T) {
+	name, err := IamRoleNameFromArn("arn:aws:iam::123456789012:role/service-role/my-role")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-role", name)
+
+	name, err = IamUserNameFromArn("arn:aws:iam::123456789012:user/alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", name)
+
+	_, err = IamRoleNameFromArn("arn:aws:iam::123456789012:user/alice")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+
+	_, err = IamUserNameFromArn("arn:aws:iam::123456789012:user/")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+}
+
+func TestEcsServiceFromArn(t *testing.T) {
+	cluster, service, err := EcsServiceFromArn("arn:aws:ecs:us-west-1:1234567890:service/my-cluster/my-service")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-cluster", cluster)
+	assert.Equal(t, "my-service", service)
+
+	cluster, service, err = EcsServiceFromArn("arn:aws:ecs:us-west-1:1234567890:service/my-service")
+	assert.NoError(t, err)
+	assert.Equal(t, "", cluster)
+	assert.Equal(t, "my-service", service)
+
+	_, _, err = EcsServiceFromArn("arn:aws:ecs:us-west-1:1234567890:task/my-cluster/3f8fae2a")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+}
+
+func TestEcsTaskDefinitionFamilyRevision(t *testing.T) {
+	tests := []struct {
+		in       string
+		family   string
+		revision int
+		err      bool
+	}{
+		{in: "arn:aws:ecs:us-west-1:1234567890:task-definition/my-app:42", family: "my-app", revision: 42},
+		{in: "my-app:7", family: "my-app", revision: 7},
+		{in: "my-app", err: true},
+		{in: "my-app:0", err: true},
+		{in: "arn:aws:ec2:us-west-1:1234567890:task-definition/my-app:1", err: true},
+	}
+
+	for _, test := range tests {
+		family, revision, err := EcsTaskDefinitionFamilyRevision(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+		assert.Equal(t, test.family, family)
+		assert.Equal(t, test.revision, revision)
+	}
+}
+
+func TestClusterArns(t *testing.T) {
+	arn, err := EcsClusterArn("us-west-1", "123456789012", "my-cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-west-1:123456789012:cluster/my-cluster", arn)
+	assert.Equal(t, arn, EcsTaskArnToClusterArn("arn:aws:ecs:us-west-1:123456789012:task/my-cluster/3f8fae2a"))
+
+	arn, err = EksClusterArn("cn-north-1", "123456789012", "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:eks:cn-north-1:123456789012:cluster/prod", arn)
+
+	name, err := EksClusterNameFromArn(arn)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", name)
+
+	_, err = EcsClusterArn("us-west-1", "1234", "my-cluster")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+
+	_, err = EksClusterArn("", "123456789012", "bad name")
+	assert.ErrorIs(t, err, ErrInvalidArn)
+}
+
+func TestPartitionForRegion(t *testing.T) {
+	assert.Equal(t, "aws", PartitionForRegion("eu-north-1"))
+	assert.Equal(t, "aws-cn", PartitionForRegion("cn-northwest-1"))
+	assert.Equal(t, "aws-us-gov", PartitionForRegion("us-gov-east-1"))
+}
+
+func TestBuildArns(t *testing.T) {
+	arn, err := BuildEc2InstanceArn("eu-north-1", "123456789012", "i-0123456789abcdef0")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:ec2:eu-north-1:123456789012:instance/i-0123456789abcdef0", arn)
+	assert.Equal(t, "i-0123456789abcdef0", Ec2InstanceIdFromArn(arn))
+
+	arn, err = BuildElbLoadBalancerArn("us-east-1", "123456789012", "app", "my-lb", "50dc6c495c0c9188")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188", arn)
+
+	arn, err = BuildElbTargetGroupArn("us-gov-west-1", "123456789012", "my-targets", "73e2d6bc24d8a067")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws-us-gov:elasticloadbalancing:us-gov-west-1:123456789012:targetgroup/my-targets/73e2d6bc24d8a067", arn)
+
+	arn, err = BuildEcsServiceArn("us-west-1", "123456789012", "my-cluster", "web")
+	assert.NoError(t, err)
+	cluster, service, err := EcsServiceFromArn(arn)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-cluster", cluster)
+	assert.Equal(t, "web", service)
+
+	arn, err = BuildEcsTaskArn("us-west-1", "123456789012", "my-cluster", "3f8fae2a33ce4c19ba063f3009a7c33a")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-west-1:123456789012:cluster/my-cluster", EcsTaskArnToClusterArn(arn))
+
+	arn, err = BuildEcsTaskDefinitionArn("us-west-1", "123456789012", "web", 42)
+	assert.NoError(t, err)
+	family, revision, err := EcsTaskDefinitionFamilyRevision(arn)
+	assert.NoError(t, err)
+	assert.Equal(t, "web", family)
+	assert.Equal(t, 42, revision)
+
+	arn, err = BuildCloudFrontDistributionArn("123456789012", "E2QWRUHAPOMQZL")
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:cloudfront::123456789012:distribution/E2QWRUHAPOMQZL", arn)
+}
+
+func TestBuildArnsErr(t *testing.T) {
+	errs := []error{}
+
+	_, err := BuildEc2InstanceArn("eu-north-1", "123456789012", "i-xyz")
+	errs = append(errs, err)
+	_, err = BuildEc2InstanceArn("europe", "123456789012", "i-0123456789abcdef0")
+	errs = append(errs, err)
+	_, err = BuildEc2InstanceArn("eu-north-1", "12345", "i-0123456789abcdef0")
+	errs = append(errs, err)
+	_, err = BuildElbLoadBalancerArn("us-east-1", "123456789012", "classic", "my-lb", "50dc6c495c0c9188")
+	errs = append(errs, err)
+	_, err = BuildElbLoadBalancerArn("us-east-1", "123456789012", "net", "-my-lb", "50dc6c495c0c9188")
+	errs = append(errs, err)
+	_, err = BuildElbTargetGroupArn("us-east-1", "123456789012", "tg", "short")
+	errs = append(errs, err)
+	_, err = BuildEcsServiceArn("us-west-1", "123456789012", "my cluster", "web")
+	errs = append(errs, err)
+	_, err = BuildEcsTaskArn("us-west-1", "123456789012", "my-cluster", "task")
+	errs = append(errs, err)
+	_, err = BuildEcsTaskDefinitionArn("us-west-1", "123456789012", "web", 0)
+	errs = append(errs, err)
+	_, err = BuildCloudFrontDistributionArn("123456789012", "e2qwruhapomqzl")
+	errs = append(errs, err)
+
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrInvalidArn)
+	}
+}
+
+func TestElbArns(t *testing.T) {
+	lbArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188"
+	listenerArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/50dc6c495c0c9188/f2f7dc8efc522ab2"
+	ruleArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener-rule/net/my-lb/50dc6c495c0c9188/f2f7dc8efc522ab2/9683b2d02a6cabee"
+	tgArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-tg/73e2d6bc24d8a067"
+
+	name, id, err := ElbTargetGroupFromArn(tgArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "my-tg", name)
+	assert.Equal(t, "73e2d6bc24d8a067", id)
+
+	label, err := ElbTargetGroupLabel(tgArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "targetgroup/my-tg/73e2d6bc24d8a067", label)
+
+	listenerId, err := ElbListenerIdFromArn(listenerArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "f2f7dc8efc522ab2", listenerId)
+
+	label, err = ElbLoadBalancerLabel(lbArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "app/my-lb/50dc6c495c0c9188", label)
+
+	label, err = ElbLoadBalancerLabel(listenerArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "app/my-lb/50dc6c495c0c9188", label)
+
+	label, err = ElbLoadBalancerLabel(ruleArn)
+	assert.Nil(t, err)
+	assert.Equal(t, "net/my-lb/50dc6c495c0c9188", label)
+}
+
+func TestElbArnsErr(t *testing.T) {
+	errs := []error{}
+
+	_, _, err := ElbTargetGroupFromArn("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188")
+	errs = append(errs, err)
+	_, _, err = ElbTargetGroupFromArn("arn:aws:ec2:us-east-1:123456789012:targetgroup/my-tg/73e2d6bc24d8a067")
+	errs = append(errs, err)
+	_, err = ElbListenerIdFromArn("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/50dc6c495c0c9188")
+	errs = append(errs, err)
+	_, err = ElbLoadBalancerLabel("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-tg/73e2d6bc24d8a067")
+	errs = append(errs, err)
+	_, err = ElbTargetGroupLabel("not-an-arn")
+	errs = append(errs, err)
+
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrInvalidArn)
+	}
+}
+
+func TestAccountIdFromArn(t *testing.T) {
+	accountId, err := AccountIdFromArn("arn:aws:iam::123456789012:role/deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012", accountId)
+
+	for _, arn := range []string{"arn:aws:s3:::bucket", "arn:aws:iam::12345:role/deploy", "not-an-arn"} {
+		_, err := AccountIdFromArn(arn)
+		assert.Error(t, err, arn)
+	}
+
+	assert.NoError(t, ValidateAccountId("000000000000"))
+	assert.ErrorIs(t, ValidateAccountId("12345678901a"), ErrInvalidAccountId)
+	assert.ErrorIs(t, ValidateAccountId(" 123456789012"), ErrInvalidAccountId)
+}
+
+func TestParsePrincipal(t *testing.T) {
+	tests := []struct {
+		in  string
+		out *Principal
+	}{
+		{"*", &Principal{Type: PrincipalAnonymous}},
+		{"123456789012", &Principal{Type: PrincipalAccount, Partition: "aws", AccountId: "123456789012"}},
+		{"ecs-tasks.amazonaws.com", &Principal{Type: PrincipalService, Name: "ecs-tasks.amazonaws.com"}},
+		{"arn:aws:iam::123456789012:root", &Principal{Type: PrincipalRoot, Partition: "aws", AccountId: "123456789012"}},
+		{"arn:aws:iam::123456789012:user/alice", &Principal{Type: PrincipalUser, Partition: "aws", AccountId: "123456789012", Name: "alice", Path: "/"}},
+		{"arn:aws-cn:iam::123456789012:role/ops/team/deploy", &Principal{Type: PrincipalRole, Partition: "aws-cn", AccountId: "123456789012", Name: "deploy", Path: "/ops/team/"}},
+		{"arn:aws:sts::123456789012:assumed-role/deploy/i-0123456789abcdef0", &Principal{Type: PrincipalAssumedRole, Partition: "aws", AccountId: "123456789012", Name: "deploy", SessionName: "i-0123456789abcdef0"}},
+		{"arn:aws:sts::123456789012:federated-user/bob", &Principal{Type: PrincipalFederatedUser, Partition: "aws", AccountId: "123456789012", Name: "bob"}},
+	}
+
+	for _, test := range tests {
+		principal, err := ParsePrincipal(test.in)
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.out, principal, test.in)
+	}
+}
+
+func TestParsePrincipalErr(t *testing.T) {
+	tests := []string{
+		"",
+		"1234",
+		"example.com",
+		"arn:aws:iam::123456789012:group/admins",
+		"arn:aws:iam:eu-west-1:123456789012:role/deploy",
+		"arn:aws:iam::123456789012:role/",
+		"arn:aws:sts::123456789012:assumed-role/deploy",
+		"arn:aws:sts::123456789012:assumed-role/deploy/x",
+		"arn:aws:s3:::bucket",
+	}
+
+	for _, input := range tests {
+		_, err := ParsePrincipal(input)
+		assert.ErrorIs(t, err, ErrInvalidPrincipal, input)
+	}
+}
+
+func TestPrincipalRoleArn(t *testing.T) {
+	principal, _ := ParsePrincipal("arn:aws:sts::123456789012:assumed-role/deploy/session")
+	arn, err := principal.RoleArn()
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deploy", arn)
+
+	principal, _ = ParsePrincipal("arn:aws:iam::123456789012:role/ops/deploy")
+	arn, err = principal.RoleArn()
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/ops/deploy", arn)
+
+	principal, _ = ParsePrincipal("123456789012")
+	_, err = principal.RoleArn()
+	assert.ErrorIs(t, err, ErrInvalidPrincipal)
+}
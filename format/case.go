@@ -0,0 +1,129 @@
+//
+// Case conversion with acronym-aware word splitting, e.g. "HTTPServerID"
+// becomes "http_server_id" rather than "h_t_t_p_server_i_d"
+//
+
+package format
+
+import (
+	"strings"
+	"unicode"
+)
+
+//
+// Split identifier into words on separators and case changes, an upper case
+// run followed by lower case gives its last letter to the next word, except
+// for short plural or version suffixes as in "IDs" or "IPv6"
+//
+
+func SplitWords(input string) []string {
+	words := []string{}
+	runes := []rune(input)
+	start := -1
+
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, string(runes[start:end]))
+		}
+
+		start = -1
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		prev := runes[i-1]
+
+		switch {
+		// "serverID", "base64Encode"
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush(i)
+			start = i
+
+		// "HTTPServer", the "S" starts a new word
+		case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isAcronymSuffix(runes, i+1):
+			flush(i)
+			start = i
+		}
+	}
+
+	flush(len(runes))
+	return words
+}
+
+// Single lower case letter closing an acronym, followed by a digit, an
+// upper case letter, a separator or the end
+func isAcronymSuffix(runes []rune, i int) bool {
+	if i+1 >= len(runes) {
+		return true
+	}
+
+	next := runes[i+1]
+	return !unicode.IsLower(next)
+}
+
+//
+// Conversions
+//
+
+// "http_server_id"
+func ToSnake(input string) string {
+	return joinWords(input, "_", strings.ToLower)
+}
+
+// "HTTP_SERVER_ID", e.g. for environment variables
+func ToScreamingSnake(input string) string {
+	return joinWords(input, "_", strings.ToUpper)
+}
+
+// "http-server-id"
+func ToKebab(input string) string {
+	return joinWords(input, "-", strings.ToLower)
+}
+
+// "HttpServerId"
+func ToPascal(input string) string {
+	return joinWords(input, "", capitalize)
+}
+
+// "httpServerId"
+func ToCamel(input string) string {
+	words := SplitWords(input)
+
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+
+	return strings.Join(words, "")
+}
+
+func joinWords(input string, sep string, fn func(string) string) string {
+	words := SplitWords(input)
+
+	for i, word := range words {
+		words[i] = fn(word)
+	}
+
+	return strings.Join(words, sep)
+}
+
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+
+	return string(runes)
+}
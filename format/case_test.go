@@ -0,0 +1,47 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		"":                              {},
+		"name":                          {"name"},
+		"HTTPServerID":                  {"HTTP", "Server", "ID"},
+		"httpServerId":                  {"http", "Server", "Id"},
+		"http_server-id":                {"http", "server", "id"},
+		"  spaced  out  ":               {"spaced", "out"},
+		"Ec2InstanceId":                 {"Ec2", "Instance", "Id"},
+		"Base64Encode":                  {"Base64", "Encode"},
+		"S3Bucket":                      {"S3", "Bucket"},
+		"IPv6Address":                   {"IPv6", "Address"},
+		"UserIDs":                       {"User", "IDs"},
+		"ListURLsByTag":                 {"List", "URLs", "By", "Tag"},
+		"OAuthToken":                    {"O", "Auth", "Token"},
+		"aws:cloudformation:stack-name": {"aws", "cloudformation", "stack", "name"},
+		"ÄpfelÜber":                     {"Äpfel", "Über"},
+	}
+
+	for in, out := range tests {
+		assert.Equal(t, out, SplitWords(in), in)
+	}
+}
+
+func TestCaseConversion(t *testing.T) {
+	input := "HTTPServerID"
+
+	assert.Equal(t, "http_server_id", ToSnake(input))
+	assert.Equal(t, "HTTP_SERVER_ID", ToScreamingSnake(input))
+	assert.Equal(t, "http-server-id", ToKebab(input))
+	assert.Equal(t, "HttpServerId", ToPascal(input))
+	assert.Equal(t, "httpServerId", ToCamel(input))
+
+	assert.Equal(t, "ipv6_address", ToSnake("IPv6Address"))
+	assert.Equal(t, "requestCountPerTarget", ToCamel("request_count_per_target"))
+	assert.Equal(t, "RequestCountPerTarget", ToPascal("request-count-per-target"))
+	assert.Equal(t, "max-conn", ToKebab("MAX_CONN"))
+	assert.Equal(t, "", ToCamel(""))
+}
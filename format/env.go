@@ -0,0 +1,152 @@
+//
+// Shell style environment variable expansion, e.g. "${PORT:-8080}"
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrRequiredVariable = errors.New("format: required variable not set")
+
+//
+// Expand variables from the process environment
+//
+
+func ExpandEnv(input string) (string, []string, error) {
+	return ExpandEnvFunc(input, os.LookupEnv)
+}
+
+//
+// Expand $VAR, ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?message} and
+// ${VAR?message} using lookup, "$$" is a literal "$". Unset variables without
+// default expand to an empty string and are returned as unresolved, unset
+// required variables are returned as errors
+//
+
+func ExpandEnvFunc(input string, lookup func(string) (string, bool)) (string, []string, error) {
+	var sb strings.Builder
+	var unresolved []string
+	var errs []error
+
+	for i := 0; i < len(input); i++ {
+		if input[i] != '$' || i+1 >= len(input) {
+			sb.WriteByte(input[i])
+			continue
+		}
+
+		next := input[i+1]
+
+		// Escaped dollar sign
+		if next == '$' {
+			sb.WriteByte('$')
+			i++
+			continue
+		}
+
+		// Bare $VAR
+		if isEnvNameByte(next, true) {
+			end := i + 1
+			for end < len(input) && isEnvNameByte(input[end], end == i+1) {
+				end++
+			}
+
+			name := input[i+1 : end]
+			value, found := lookup(name)
+			if !found {
+				unresolved = append(unresolved, name)
+			}
+
+			sb.WriteString(value)
+			i = end - 1
+			continue
+		}
+
+		// Braced ${...}, unterminated braces are kept as is
+		closing := strings.IndexByte(input[i:], '}')
+		if next != '{' || closing < 0 {
+			sb.WriteByte('$')
+			continue
+		}
+
+		expr := input[i+2 : i+closing]
+		value, missing, err := expandEnvExpr(expr, lookup)
+
+		if err != nil {
+			errs = append(errs, err)
+		} else if missing {
+			unresolved = append(unresolved, expr)
+		}
+
+		sb.WriteString(value)
+		i += closing
+	}
+
+	return sb.String(), unresolved, errors.Join(errs...)
+}
+
+//
+// Evaluate expression inside braces
+//
+
+func expandEnvExpr(expr string, lookup func(string) (string, bool)) (string, bool, error) {
+	end := 0
+	for end < len(expr) && isEnvNameByte(expr[end], end == 0) {
+		end++
+	}
+
+	name, op := expr[:end], expr[end:]
+	if name == "" {
+		return "", false, fmt.Errorf("format: invalid variable expression ${%s}", expr)
+	}
+
+	value, found := lookup(name)
+
+	// Colon forms treat empty values like unset ones
+	set := found
+	if strings.HasPrefix(op, ":") {
+		set = found && value != ""
+		op = op[1:]
+		if op == "" {
+			return "", false, fmt.Errorf("format: invalid variable expression ${%s}", expr)
+		}
+	}
+
+	switch {
+	case op == "":
+		return value, !found, nil
+
+	case op[0] == '-':
+		if !set {
+			return op[1:], false, nil
+		}
+
+		return value, false, nil
+
+	case op[0] == '?':
+		if !set {
+			msg := op[1:]
+			if msg == "" {
+				msg = "not set"
+			}
+
+			return "", false, fmt.Errorf("%w: %s: %s", ErrRequiredVariable, name, msg)
+		}
+
+		return value, false, nil
+	}
+
+	return "", false, fmt.Errorf("format: invalid variable expression ${%s}", expr)
+}
+
+func isEnvNameByte(b byte, first bool) bool {
+	if b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') {
+		return true
+	}
+
+	return !first && b >= '0' && b <= '9'
+}
@@ -0,0 +1,75 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnvFunc(t *testing.T) {
+	env := map[string]string{
+		"HOST":  "example.com",
+		"PORT":  "8080",
+		"EMPTY": "",
+	}
+
+	lookup := func(name string) (string, bool) {
+		value, found := env[name]
+		return value, found
+	}
+
+	tests := []struct {
+		input      string
+		expected   string
+		unresolved []string
+	}{
+		{"http://$HOST:$PORT/", "http://example.com:8080/", nil},
+		{"${HOST}", "example.com", nil},
+		{"${MISSING:-default}", "default", nil},
+		{"${EMPTY:-default}", "default", nil},
+		{"${EMPTY-default}", "", nil},
+		{"${MISSING-a b}", "a b", nil},
+		{"${PORT:-1}", "8080", nil},
+		{"${HOST:?required}", "example.com", nil},
+		{"$$HOST costs $5", "$HOST costs $5", nil},
+		{"trailing $", "trailing $", nil},
+		{"${UNTERMINATED", "${UNTERMINATED", nil},
+		{"$MISSING and ${OTHER}", " and ", []string{"MISSING", "OTHER"}},
+	}
+
+	for _, test := range tests {
+		result, unresolved, err := ExpandEnvFunc(test.input, lookup)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+		assert.Equal(t, test.unresolved, unresolved, test.input)
+	}
+}
+
+func TestExpandEnvRequired(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return "", name == "EMPTY"
+	}
+
+	_, _, err := ExpandEnvFunc("${TOKEN:?token is required} ${EMPTY:?}", lookup)
+	assert.ErrorIs(t, err, ErrRequiredVariable)
+	assert.EqualError(t, err, ""+
+		"format: required variable not set: TOKEN: token is required\n"+
+		"format: required variable not set: EMPTY: not set")
+
+	// Without colon an empty value is accepted
+	_, _, err = ExpandEnvFunc("${EMPTY?}", lookup)
+	assert.NoError(t, err)
+
+	_, _, err = ExpandEnvFunc("${1abc} ${HOST:}", lookup)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRequiredVariable)
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("FORMAT_TEST_VAR", "value")
+
+	result, unresolved, err := ExpandEnv("${FORMAT_TEST_VAR}-${FORMAT_TEST_UNSET:-x}")
+	assert.NoError(t, err)
+	assert.Empty(t, unresolved)
+	assert.Equal(t, "value-x", result)
+}
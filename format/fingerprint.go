@@ -0,0 +1,133 @@
+//
+// Checksum and fingerprint rendering, so keys and certificates print the same
+// across tooling
+//
+
+package format
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+type FingerprintFormat int
+
+const (
+	// ab12cd..., as printed by sha256sum
+	FingerprintHex FingerprintFormat = iota
+
+	// AB:12:CD:..., as printed by openssl x509 -fingerprint
+	FingerprintHexColons
+
+	// Standard base64 with padding
+	FingerprintBase64
+
+	// Unpadded URL-safe base64, as used for JWK thumbprints and key IDs
+	FingerprintBase64URL
+
+	// SHA256:unpadded base64, as printed by ssh-keygen -l
+	FingerprintOpenSSH
+
+	// First 8 bytes in hex, for log lines and table columns
+	FingerprintShort
+)
+
+const shortFingerprintSize = 8
+
+var ErrInvalidFingerprint = errors.New("format: invalid fingerprint")
+
+//
+// SHA-256 fingerprint of DER encoded key or certificate
+//
+
+func FingerprintSHA256(der []byte, format FingerprintFormat) string {
+	sum := sha256.Sum256(der)
+	return FormatFingerprint(sum[:], format)
+}
+
+//
+// Render digest, unknown formats fall back to plain hex
+//
+
+func FormatFingerprint(sum []byte, format FingerprintFormat) string {
+	switch format {
+	case FingerprintHexColons:
+		encoded := strings.ToUpper(hex.EncodeToString(sum))
+		parts := make([]string, 0, len(sum))
+		for i := 0; i < len(encoded); i += 2 {
+			parts = append(parts, encoded[i:i+2])
+		}
+		return strings.Join(parts, ":")
+
+	case FingerprintBase64:
+		return base64.StdEncoding.EncodeToString(sum)
+
+	case FingerprintBase64URL:
+		return base64.RawURLEncoding.EncodeToString(sum)
+
+	case FingerprintOpenSSH:
+		return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum)
+
+	case FingerprintShort:
+		if len(sum) > shortFingerprintSize {
+			sum = sum[:shortFingerprintSize]
+		}
+	}
+
+	return hex.EncodeToString(sum)
+}
+
+//
+// Parse fingerprint in any of the rendered forms back into digest bytes,
+// hex is assumed when the input is only hex digits
+//
+
+func ParseFingerprint(input string) ([]byte, error) {
+	input = strings.TrimSpace(input)
+	input = strings.TrimPrefix(input, "SHA256:")
+
+	if input == "" {
+		return nil, ErrInvalidFingerprint
+	}
+
+	if strings.Contains(input, ":") {
+		input = strings.ReplaceAll(input, ":", "")
+		if len(input)%2 != 0 {
+			return nil, ErrInvalidFingerprint
+		}
+	}
+
+	if sum, err := hex.DecodeString(input); err == nil {
+		return sum, nil
+	}
+
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(input, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+
+	sum, err := encoding.DecodeString(strings.TrimRight(input, "="))
+	if err != nil {
+		return nil, ErrInvalidFingerprint
+	}
+
+	return sum, nil
+}
+
+//
+// Match digest against a fingerprint in any form, truncated fingerprints
+// match on their prefix
+//
+
+func MatchFingerprint(sum []byte, input string) bool {
+	parsed, err := ParseFingerprint(input)
+	if err != nil || len(parsed) < 4 || len(parsed) > len(sum) {
+		return false
+	}
+
+	return bytes.Equal(sum[:len(parsed)], parsed)
+}
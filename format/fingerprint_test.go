@@ -0,0 +1,59 @@
+package format
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintSHA256(t *testing.T) {
+	der := []byte("hello world")
+
+	tests := []struct {
+		format FingerprintFormat
+		out    string
+	}{
+		{FingerprintHex, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+		{FingerprintHexColons, "B9:4D:27:B9:93:4D:3E:08:A5:2E:52:D7:DA:7D:AB:FA:C4:84:EF:E3:7A:53:80:EE:90:88:F7:AC:E2:EF:CD:E9"},
+		{FingerprintBase64, "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="},
+		{FingerprintBase64URL, "uU0nuZNNPgilLlLX2n2r-sSE7-N6U4DukIj3rOLvzek"},
+		{FingerprintOpenSSH, "SHA256:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek"},
+		{FingerprintShort, "b94d27b9934d3e08"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, FingerprintSHA256(der, test.format))
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello world"))
+
+	for _, format := range []FingerprintFormat{FingerprintHex, FingerprintHexColons, FingerprintBase64, FingerprintBase64URL, FingerprintOpenSSH} {
+		parsed, err := ParseFingerprint(FormatFingerprint(sum[:], format))
+		assert.NoError(t, err)
+		assert.Equal(t, sum[:], parsed)
+	}
+
+	// Lowercase colons and surrounding whitespace
+	parsed, err := ParseFingerprint(" b9:4d:27:b9 ")
+	assert.NoError(t, err)
+	assert.Equal(t, sum[:4], parsed)
+
+	for _, input := range []string{"", "SHA256:", "b9:4d:2", "not a fingerprint!"} {
+		_, err := ParseFingerprint(input)
+		assert.ErrorIs(t, err, ErrInvalidFingerprint, input)
+	}
+}
+
+func TestMatchFingerprint(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello world"))
+
+	assert.True(t, MatchFingerprint(sum[:], FormatFingerprint(sum[:], FingerprintOpenSSH)))
+	assert.True(t, MatchFingerprint(sum[:], FormatFingerprint(sum[:], FingerprintShort)))
+	assert.True(t, MatchFingerprint(sum[:], "B9:4D:27:B9"))
+	assert.False(t, MatchFingerprint(sum[:], "b94d27"))
+	assert.False(t, MatchFingerprint(sum[:], "b94d27b9934d3e09"))
+	assert.False(t, MatchFingerprint(sum[:4], FormatFingerprint(sum[:], FingerprintHex)))
+}
@@ -5,45 +5,133 @@
 package format
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 //
-// Split string by delimiter, filter out empties
+// Generic delimiter splitting with options
 //
 
-var splitStringRe = regexp.MustCompile(`\s*[,\s]+\s*`)
+type SplitOpt func(opts *splitOpts)
 
-func SplitStringByDelimiter(input string) []string {
-	result := []string{}
-	split := splitStringRe.Split(input, -1)
+type splitOpts struct {
+	delimiter *regexp.Regexp
+	trim      bool
+	empty     splitEmptyMode
+}
 
-	for _, s := range split {
-		if s != "" {
-			result = append(result, s)
-		}
+type splitEmptyMode int
+
+const (
+	splitEmptySkip splitEmptyMode = iota
+	splitEmptyKeep
+	splitEmptyForbid
+)
+
+var ErrEmptyElement = errors.New("format: empty element")
+
+// One comma with surrounding whitespace or a run of whitespace, so ",," still
+// holds an empty element
+var splitStringRe = regexp.MustCompile(`\s*,\s*|\s+`)
+
+// Split on regexp instead of commas and whitespace
+func WithDelimiterRe(re *regexp.Regexp) SplitOpt {
+	return func(opts *splitOpts) {
+		opts.delimiter = re
 	}
+}
 
-	return result
+// Split on literal separator instead of commas and whitespace
+func WithDelimiter(sep string) SplitOpt {
+	return WithDelimiterRe(regexp.MustCompile(regexp.QuoteMeta(sep)))
 }
 
-//
-// Split string of numbers by delimiter, convert to int
-//
+// Keep surrounding whitespace of elements
+func WithoutTrim() SplitOpt {
+	return func(opts *splitOpts) {
+		opts.trim = false
+	}
+}
 
-func SplitIntsByDelimiter(input string) ([]int, error) {
-	result := []int{}
-	split := SplitStringByDelimiter(input)
+// Pass empty elements to the parser instead of skipping them
+func WithEmpty() SplitOpt {
+	return func(opts *splitOpts) {
+		opts.empty = splitEmptyKeep
+	}
+}
+
+// Fail with ErrEmptyElement on empty elements
+func WithoutEmpty() SplitOpt {
+	return func(opts *splitOpts) {
+		opts.empty = splitEmptyForbid
+	}
+}
+
+func SplitByDelimiter[T any](input string, parse func(string) (T, error), opts ...SplitOpt) ([]T, error) {
+	o := &splitOpts{
+		delimiter: splitStringRe,
+		trim:      true,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Leading or trailing whitespace is not an empty element
+	if o.trim {
+		input = strings.TrimSpace(input)
+	}
+
+	result := []T{}
+	if input == "" {
+		return result, nil
+	}
+
+	for i, s := range o.delimiter.Split(input, -1) {
+		if o.trim {
+			s = strings.TrimSpace(s)
+		}
 
-	for _, s := range split {
-		i, err := strconv.Atoi(s)
+		if s == "" {
+			switch o.empty {
+			case splitEmptySkip:
+				continue
+			case splitEmptyForbid:
+				return nil, fmt.Errorf("%w at position %d", ErrEmptyElement, i)
+			}
+		}
+
+		v, err := parse(s)
 		if err != nil {
 			return nil, err
 		}
 
-		result = append(result, i)
+		result = append(result, v)
 	}
 
 	return result, nil
 }
+
+//
+// Split string by delimiter, filter out empties
+//
+
+func SplitStringByDelimiter(input string) []string {
+	result, _ := SplitByDelimiter(input, func(s string) (string, error) {
+		return s, nil
+	})
+
+	return result
+}
+
+//
+// Split string of numbers by delimiter, convert to int
+//
+
+func SplitIntsByDelimiter(input string) ([]int, error) {
+	return SplitByDelimiter(input, strconv.Atoi)
+}
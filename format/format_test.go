@@ -1,8 +1,11 @@
 package format
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -46,3 +49,66 @@ func TestSplitIntsByDelimiter(t *testing.T) {
 		assert.IsType(t, test.err, err)
 	}
 }
+
+func TestSplitByDelimiter(t *testing.T) {
+	floats, err := SplitByDelimiter("1.5, 2.25 3", func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 2.25, 3}, floats)
+
+	durations, err := SplitByDelimiter("1s;2m ; 3h", time.ParseDuration, WithDelimiter(";"))
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Minute, 3 * time.Hour}, durations)
+}
+
+func TestSplitByDelimiterOpts(t *testing.T) {
+	identity := func(s string) (string, error) {
+		return s, nil
+	}
+
+	tests := []struct {
+		in   string
+		opts []SplitOpt
+		out  []string
+		err  error
+	}{
+		{in: "a| b |c", opts: []SplitOpt{WithDelimiter("|")}, out: []string{"a", "b", "c"}},
+		{in: "a| b |c", opts: []SplitOpt{WithDelimiter("|"), WithoutTrim()}, out: []string{"a", " b ", "c"}},
+		{in: "a||c", opts: []SplitOpt{WithDelimiter("|")}, out: []string{"a", "c"}},
+		{in: "a||c", opts: []SplitOpt{WithDelimiter("|"), WithEmpty()}, out: []string{"a", "", "c"}},
+		{in: "a||c", opts: []SplitOpt{WithDelimiter("|"), WithoutEmpty()}, err: ErrEmptyElement},
+		{in: "a1b22c", opts: []SplitOpt{WithDelimiterRe(regexp.MustCompile(`\d+`))}, out: []string{"a", "b", "c"}},
+		{in: "", opts: []SplitOpt{WithoutEmpty()}, out: []string{}},
+		{in: "a,,b", out: []string{"a", "b"}},
+		{in: "a,,b", opts: []SplitOpt{WithEmpty()}, out: []string{"a", "", "b"}},
+		{in: "a,,b", opts: []SplitOpt{WithoutEmpty()}, err: ErrEmptyElement},
+		{in: " a, b c ", opts: []SplitOpt{WithoutEmpty()}, out: []string{"a", "b", "c"}},
+		{in: " a,b", opts: []SplitOpt{WithEmpty()}, out: []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		result, err := SplitByDelimiter(test.in, identity, test.opts...)
+		assert.ErrorIs(t, err, test.err)
+		assert.Equal(t, test.out, result)
+	}
+}
+
+func TestSplitByDelimiterEnum(t *testing.T) {
+	allowed := map[string]bool{"red": true, "green": true}
+	parse := func(s string) (string, error) {
+		if !allowed[s] {
+			return "", fmt.Errorf("invalid color %q", s)
+		}
+
+		return s, nil
+	}
+
+	result, err := SplitByDelimiter("red,green", parse)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"red", "green"}, result)
+
+	_, err = SplitByDelimiter("red,blue", parse)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+//
+// Stable non-cryptographic hashing for cache keys, sharding and sampling,
+// identical across processes and releases
+//
+
+package format
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+)
+
+//
+// Hash parts into a 16 character hex key, parts are length prefixed so
+// ("ab", "c") and ("a", "bc") differ
+//
+
+func HashKey(parts ...string) string {
+	h := fnv.New64a()
+	var size [binary.MaxVarintLen64]byte
+
+	for _, part := range parts {
+		n := binary.PutUvarint(size[:], uint64(len(part)))
+		h.Write(size[:n])
+		h.Write([]byte(part))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//
+// Hash key to 64 bits, FNV-1a with a final mix so similar keys spread over
+// all bits
+//
+
+func Hash64(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+
+	// MurmurHash3 fmix64
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
+
+//
+// Pick a shard in [0, n) for key with jump consistent hashing, growing n
+// only moves about 1/n of keys, returns 0 when n is below 1
+//
+
+func ConsistentShard(key string, n int) int {
+	if n <= 1 {
+		return 0
+	}
+
+	// Lamping and Veach, "A Fast, Minimal Memory, Consistent Hash Algorithm"
+	state := Hash64(key)
+	var bucket, next int64
+
+	for next < int64(n) {
+		bucket = next
+		state = state*2862933555777941757 + 1
+		next = int64(float64(bucket+1) * (float64(int64(1)<<31) / float64((state>>33)+1)))
+	}
+
+	return int(bucket)
+}
+
+//
+// Deterministic sampling, true for about rate of all keys, rate 1 keeps all
+//
+
+func Sampled(key string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+
+	if rate <= 0 {
+		return false
+	}
+
+	return float64(Hash64(key)>>11)/float64(uint64(1)<<53) < rate
+}
@@ -0,0 +1,63 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashKey(t *testing.T) {
+	key := HashKey("user", "42")
+	assert.Len(t, key, 16)
+	assert.Equal(t, key, HashKey("user", "42"))
+
+	// Pinned, keys must not change between releases
+	assert.Equal(t, "cbf29ce484222325", HashKey())
+	assert.Equal(t, "82a2a958a9bece5b", fmt.Sprintf("%016x", Hash64("a")))
+
+	assert.NotEqual(t, HashKey("ab", "c"), HashKey("a", "bc"))
+	assert.NotEqual(t, HashKey("a"), HashKey("a", ""))
+}
+
+func TestConsistentShard(t *testing.T) {
+	assert.Equal(t, 0, ConsistentShard("key", 0))
+	assert.Equal(t, 0, ConsistentShard("key", 1))
+
+	counts := make([]int, 8)
+	moved := 0
+
+	for i := 0; i < 8000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		shard := ConsistentShard(key, 8)
+		assert.Equal(t, shard, ConsistentShard(key, 8))
+		counts[shard]++
+
+		// Keys only ever move to the new shard
+		if grown := ConsistentShard(key, 9); grown != shard {
+			assert.Equal(t, 8, grown)
+			moved++
+		}
+	}
+
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 150)
+	}
+
+	assert.InDelta(t, 8000/9, moved, 150)
+}
+
+func TestSampled(t *testing.T) {
+	assert.True(t, Sampled("key", 1))
+	assert.False(t, Sampled("key", 0))
+
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if Sampled(fmt.Sprintf("key-%d", i), 0.1) {
+			kept++
+		}
+	}
+
+	assert.InDelta(t, 1000, kept, 150)
+	assert.Equal(t, Sampled("stable", 0.5), Sampled("stable", 0.5))
+}
@@ -0,0 +1,120 @@
+//
+// Placeholder interpolation, e.g. "${env:HOME}" or "${file:/run/secrets/token}"
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Look up key, found is false when the value does not exist
+type Resolver func(key string) (value string, found bool, err error)
+
+type InterpolateOpts struct {
+	Resolvers map[string]Resolver
+	Strict    bool
+}
+
+var (
+	ErrUnresolvedPlaceholder = errors.New("format: unresolved placeholder")
+	ErrUnknownResolver       = errors.New("format: unknown resolver")
+)
+
+var DefaultInterpolateOpts = &InterpolateOpts{
+	Resolvers: map[string]Resolver{
+		"env":  EnvResolver,
+		"file": FileResolver,
+	},
+	Strict: true,
+}
+
+// Escaped "$${" or placeholder "${source:key}"
+var interpolateRe = regexp.MustCompile(`\$\$\{|\$\{([a-zA-Z0-9_-]+):([^}]*)\}`)
+
+//
+// Built-in resolvers
+//
+
+func EnvResolver(key string) (string, bool, error) {
+	value, found := os.LookupEnv(key)
+	return value, found, nil
+}
+
+func FileResolver(key string) (string, bool, error) {
+	data, err := os.ReadFile(key)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+//
+// Interpolate with default resolvers in strict mode
+//
+
+func Interpolate(input string) (string, error) {
+	return InterpolateWithOpts(input, DefaultInterpolateOpts)
+}
+
+//
+// Interpolate with opts, lenient mode leaves unresolved placeholders untouched
+//
+
+func InterpolateWithOpts(input string, opts *InterpolateOpts) (string, error) {
+	var unresolved []string
+	var resolveErr error
+
+	result := interpolateRe.ReplaceAllStringFunc(input, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		if resolveErr != nil {
+			return match
+		}
+
+		parts := interpolateRe.FindStringSubmatch(match)
+		resolver, ok := opts.Resolvers[parts[1]]
+		if !ok {
+			if opts.Strict {
+				resolveErr = fmt.Errorf("%w: %q", ErrUnknownResolver, parts[1])
+			}
+
+			unresolved = append(unresolved, match)
+			return match
+		}
+
+		value, found, err := resolver(parts[2])
+		if err != nil {
+			resolveErr = fmt.Errorf("format: resolving %s: %w", match, err)
+			return match
+		}
+
+		if !found {
+			unresolved = append(unresolved, match)
+			return match
+		}
+
+		return value
+	})
+
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	if opts.Strict && len(unresolved) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUnresolvedPlaceholder, strings.Join(unresolved, ", "))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package format
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("INTERPOLATE_TEST", "world")
+
+	path := filepath.Join(t.TempDir(), "secret")
+	err := os.WriteFile(path, []byte("s3cr3t\n"), 0600)
+	assert.NoError(t, err)
+
+	result, err := Interpolate("hello ${env:INTERPOLATE_TEST}, token=${file:" + path + "}")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world, token=s3cr3t", result)
+
+	// Escaped placeholders are kept literally
+	result, err = Interpolate("cost: $${env:INTERPOLATE_TEST} $5")
+	assert.NoError(t, err)
+	assert.Equal(t, "cost: ${env:INTERPOLATE_TEST} $5", result)
+}
+
+func TestInterpolateStrict(t *testing.T) {
+	_, err := Interpolate("${env:INTERPOLATE_MISSING} ${file:/does/not/exist}")
+	assert.ErrorIs(t, err, ErrUnresolvedPlaceholder)
+	assert.Contains(t, err.Error(), "${env:INTERPOLATE_MISSING}, ${file:/does/not/exist}")
+
+	_, err = Interpolate("${vault:secret/foo}")
+	assert.ErrorIs(t, err, ErrUnknownResolver)
+}
+
+func TestInterpolateLenient(t *testing.T) {
+	result, err := InterpolateWithOpts("a=${env:INTERPOLATE_MISSING} b=${vault:x}", &InterpolateOpts{
+		Resolvers: DefaultInterpolateOpts.Resolvers,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a=${env:INTERPOLATE_MISSING} b=${vault:x}", result)
+}
+
+func TestInterpolateCustomResolver(t *testing.T) {
+	meta := map[string]string{"region": "eu-north-1"}
+	opts := &InterpolateOpts{
+		Strict: true,
+		Resolvers: map[string]Resolver{
+			"meta": func(key string) (string, bool, error) {
+				value, found := meta[key]
+				return value, found, nil
+			},
+			"broken": func(key string) (string, bool, error) {
+				return "", false, errors.New("backend down")
+			},
+		},
+	}
+
+	result, err := InterpolateWithOpts("bucket-${meta:region}", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket-eu-north-1", result)
+
+	_, err = InterpolateWithOpts("${broken:x}", opts)
+	assert.ErrorContains(t, err, "backend down")
+}
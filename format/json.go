@@ -0,0 +1,291 @@
+//
+// Canonical JSON after RFC 8785, so equal documents hash and compare equal
+// regardless of key order, whitespace and number spelling
+//
+
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrInvalidJSON = errors.New("format: invalid json")
+
+//
+// Canonicalize JSON document, rejecting duplicate keys and trailing data
+//
+
+func CanonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := canonicalValue(dec, &buf); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidJSON)
+	}
+
+	return buf.Bytes(), nil
+}
+
+//
+// Marshal value to canonical JSON
+//
+
+func MarshalCanonical(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return CanonicalJSON(data)
+}
+
+//
+// Compare documents by their canonical form
+//
+
+func JSONEqual(a []byte, b []byte) (bool, error) {
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		return false, err
+	}
+
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(ca, cb), nil
+}
+
+//
+// SHA-256 of the canonical form in hex, for drift detection
+//
+
+func HashJSON(data []byte) (string, error) {
+	canonical, err := CanonicalJSON(data)
+	if err != nil {
+		return "", err
+	}
+
+	return FingerprintSHA256(canonical, FingerprintHex), nil
+}
+
+func canonicalValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidJSON, err)
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			return canonicalArray(dec, buf)
+		}
+
+		return canonicalObject(dec, buf)
+
+	case string:
+		writeCanonicalString(buf, v)
+
+	case json.Number:
+		n, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(n)
+
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+
+	case nil:
+		buf.WriteString("null")
+	}
+
+	return nil
+}
+
+func canonicalArray(dec *json.Decoder, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := canonicalValue(dec, buf); err != nil {
+			return err
+		}
+	}
+
+	dec.Token()
+	buf.WriteByte(']')
+	return nil
+}
+
+func canonicalObject(dec *json.Decoder, buf *bytes.Buffer) error {
+	members := make(map[string][]byte)
+	var keys []string
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidJSON, err)
+		}
+
+		key := tok.(string)
+		if _, exists := members[key]; exists {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidJSON, key)
+		}
+
+		var value bytes.Buffer
+		if err := canonicalValue(dec, &value); err != nil {
+			return err
+		}
+
+		members[key] = value.Bytes()
+		keys = append(keys, key)
+	}
+
+	dec.Token()
+
+	// Keys sort by UTF-16 code units, not bytes
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i], keys[j])
+	})
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeCanonicalString(buf, key)
+		buf.WriteByte(':')
+		buf.Write(members[key])
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+func lessUTF16(a string, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+
+	return len(ua) < len(ub)
+}
+
+//
+// Escape only what must be escaped, with short forms where they exist
+//
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+}
+
+//
+// Number as serialized by ECMAScript, shortest round-trip digits of the
+// double value
+//
+
+func canonicalNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%w: number %s out of range", ErrInvalidJSON, n)
+	}
+
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// d.ddddde±x into digits and decimal exponent
+	mantissa, expStr, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(expStr)
+	point := exp + 1
+
+	switch {
+	case (len(digits) <= point) && (point <= 21):
+		return sign + digits + strings.Repeat("0", point-len(digits)), nil
+
+	case (0 < point) && (point <= 21):
+		return sign + digits[:point] + "." + digits[point:], nil
+
+	case (-6 < point) && (point <= 0):
+		return sign + "0." + strings.Repeat("0", -point) + digits, nil
+	}
+
+	result := digits[:1]
+	if len(digits) > 1 {
+		result += "." + digits[1:]
+	}
+
+	expSign := "+"
+	if point-1 < 0 {
+		expSign = "-"
+	}
+
+	return sign + result + "e" + expSign + strconv.Itoa(abs(point-1)), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,83 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: `{ "b": 2, "a": [1, 2.50, true, null], "c": {"z": "x", "y": {}} }`, out: `{"a":[1,2.5,true,null],"b":2,"c":{"y":{},"z":"x"}}`},
+		{in: `"<a & b>æ "`, out: "\"<a & b>æ \""},
+		{in: `"tab\tnl\n\u0001"`, out: `"tab\tnl\n\u0001"`},
+		{in: `1E3`, out: `1000`},
+		{in: `-0.0`, out: `0`},
+		{in: `1e21`, out: `1e+21`},
+		{in: `123456789012345678901`, out: `123456789012345680000`},
+		{in: `0.000001`, out: `0.000001`},
+		{in: `0.0000001`, out: `1e-7`},
+		{in: `-1.5e-10`, out: `-1.5e-10`},
+		{in: `  [ ]  `, out: `[]`},
+	}
+
+	for _, test := range tests {
+		out, err := CanonicalJSON([]byte(test.in))
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.out, string(out), test.in)
+	}
+}
+
+func TestCanonicalJSONKeyOrder(t *testing.T) {
+	// RFC 8785 sorts by UTF-16 code units, putting U+1F600 before U+FB33
+	out, err := CanonicalJSON([]byte(`{"דּ": 1, "😀": 2, "a": 3, "": 4}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"\":4,\"a\":3,\"\U0001F600\":2,\"דּ\":1}", string(out))
+}
+
+func TestCanonicalJSONInvalid(t *testing.T) {
+	for _, in := range []string{``, `{"a": 1, "a": 2}`, `{"a": 1} {}`, `[1,]`, `1e400`, `{"a"}`} {
+		_, err := CanonicalJSON([]byte(in))
+		assert.ErrorIs(t, err, ErrInvalidJSON, in)
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	out, err := MarshalCanonical(map[string]any{
+		"name":  "web",
+		"ports": []int{443, 80},
+		"tags":  map[string]string{"team": "infra", "env": "prod"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"web","ports":[443,80],"tags":{"env":"prod","team":"infra"}}`, string(out))
+
+	_, err = MarshalCanonical(func() {})
+	assert.Error(t, err)
+}
+
+func TestJSONEqual(t *testing.T) {
+	equal, err := JSONEqual([]byte(`{"a": 1.0, "b": [1]}`), []byte("{\n  \"b\": [1],\n  \"a\": 1\n}"))
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = JSONEqual([]byte(`{"b": [1, 2]}`), []byte(`{"b": [2, 1]}`))
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	_, err = JSONEqual([]byte(`{}`), []byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalidJSON)
+}
+
+func TestHashJSON(t *testing.T) {
+	a, err := HashJSON([]byte(`{"b": 1, "a": 2}`))
+	assert.NoError(t, err)
+	assert.Len(t, a, 64)
+
+	b, err := HashJSON([]byte(`{"a":2,"b":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+}
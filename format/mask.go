@@ -0,0 +1,160 @@
+//
+// Masking of secrets and scrubbing of sensitive values from log output
+//
+
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type MaskOpts struct {
+	// Characters kept in clear at either end
+	KeepPrefix int
+	KeepSuffix int
+
+	// Shorter secrets are masked entirely
+	MinLength int
+
+	// Fixed replacement for the hidden part, so the length is not revealed
+	Mask string
+}
+
+const Redacted = "[REDACTED]"
+
+var DefaultMaskOpts = &MaskOpts{
+	KeepPrefix: 4,
+	KeepSuffix: 4,
+	MinLength:  16,
+	Mask:       "****",
+}
+
+var (
+	jwtRe        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerRe     = regexp.MustCompile(`(?i)\b(bearer|basic|token)\s+[A-Za-z0-9._~+/=-]{8,}`)
+	cardRe       = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	awsKeyRe     = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+	tokenRe      = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|glpat-[A-Za-z0-9_-]{20,})`)
+	secretPairRe = regexp.MustCompile(`(?i)\b((?:password|passwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)["']?\s*[=:]\s*["']?)([^\s"'&,;]+)`)
+)
+
+//
+// Mask secret with default opts
+//
+
+func MaskSecret(s string) string {
+	return MaskSecretWithOpts(s, DefaultMaskOpts)
+}
+
+//
+// Keep the ends of secret and replace the rest with a fixed mask
+//
+
+func MaskSecretWithOpts(s string, opts *MaskOpts) string {
+	mask := opts.Mask
+	if mask == "" {
+		mask = DefaultMaskOpts.Mask
+	}
+
+	if s == "" {
+		return ""
+	}
+
+	n := utf8.RuneCountInString(s)
+	if (n < opts.MinLength) || (n <= opts.KeepPrefix+opts.KeepSuffix) {
+		return mask
+	}
+
+	runes := []rune(s)
+	return string(runes[:opts.KeepPrefix]) + mask + string(runes[n-opts.KeepSuffix:])
+}
+
+//
+// Keep auth scheme and JWT header, redacting claims and signature, other
+// credentials are masked
+//
+
+func RedactJWT(authz string) string {
+	scheme, token, found := strings.Cut(strings.TrimSpace(authz), " ")
+	if !found {
+		scheme, token = "", scheme
+	}
+
+	token = strings.TrimSpace(token)
+	parts := strings.Split(token, ".")
+
+	redacted := MaskSecret(token)
+	if len(parts) == 3 {
+		redacted = parts[0] + "." + Redacted + "." + Redacted
+	}
+
+	if scheme == "" {
+		return redacted
+	}
+
+	return scheme + " " + redacted
+}
+
+//
+// Scrub tokens, credentials, key-value secrets and card numbers from text
+// before it is logged
+//
+
+func Scrub(s string) string {
+	s = bearerRe.ReplaceAllStringFunc(s, func(match string) string {
+		scheme, token, _ := strings.Cut(match, " ")
+		if jwtRe.MatchString(token) {
+			return RedactJWT(match)
+		}
+
+		return scheme + " " + Redacted
+	})
+
+	s = jwtRe.ReplaceAllStringFunc(s, RedactJWT)
+
+	s = awsKeyRe.ReplaceAllStringFunc(s, MaskSecret)
+	s = tokenRe.ReplaceAllStringFunc(s, MaskSecret)
+
+	s = secretPairRe.ReplaceAllString(s, "${1}"+Redacted)
+
+	s = cardRe.ReplaceAllStringFunc(s, func(match string) string {
+		if !luhnValid(match) {
+			return match
+		}
+
+		return Redacted
+	})
+
+	return s
+}
+
+//
+// Luhn checksum over the digits of s
+//
+
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,86 @@
+//
+// Network address list parsing
+//
+
+package format
+
+import (
+	"net/netip"
+	"sort"
+)
+
+//
+// Split string of CIDRs by delimiter, bare addresses become host prefixes
+//
+
+func SplitCIDRsByDelimiter(input string) ([]netip.Prefix, error) {
+	result := []netip.Prefix{}
+	seen := make(map[netip.Prefix]bool)
+
+	for _, s := range SplitStringByDelimiter(input) {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, err
+			}
+
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		prefix = prefix.Masked()
+		if !seen[prefix] {
+			seen[prefix] = true
+			result = append(result, prefix)
+		}
+	}
+
+	return result, nil
+}
+
+//
+// Split string of IP addresses by delimiter
+//
+
+func SplitIPsByDelimiter(input string) ([]netip.Addr, error) {
+	result := []netip.Addr{}
+	seen := make(map[netip.Addr]bool)
+
+	for _, s := range SplitStringByDelimiter(input) {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[addr] {
+			seen[addr] = true
+			result = append(result, addr)
+		}
+	}
+
+	return result, nil
+}
+
+//
+// Sort prefixes in place, IPv4 before IPv6, then by address and length
+//
+
+func SortPrefixes(prefixes []netip.Prefix) {
+	sort.Slice(prefixes, func(i, j int) bool {
+		if c := prefixes[i].Addr().Compare(prefixes[j].Addr()); c != 0 {
+			return c < 0
+		}
+
+		return prefixes[i].Bits() < prefixes[j].Bits()
+	})
+}
+
+//
+// Sort addresses in place, IPv4 before IPv6
+//
+
+func SortAddrs(addrs []netip.Addr) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Less(addrs[j])
+	})
+}
@@ -0,0 +1,82 @@
+package format
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCIDRsByDelimiter(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []string
+		err bool
+	}{
+		{in: "10.0.0.0/8, 192.168.0.0/16", out: []string{"10.0.0.0/8", "192.168.0.0/16"}},
+		{in: "10.1.2.3/8 10.0.0.0/8", out: []string{"10.0.0.0/8"}},
+		{in: "192.0.2.1,2001:db8::/32", out: []string{"192.0.2.1/32", "2001:db8::/32"}},
+		{in: "", out: []string{}},
+		{in: "10.0.0.0/33", err: true},
+		{in: "bogus", err: true},
+	}
+
+	for _, test := range tests {
+		result, err := SplitCIDRsByDelimiter(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+
+		if !test.err {
+			out := []string{}
+			for _, prefix := range result {
+				out = append(out, prefix.String())
+			}
+
+			assert.Equal(t, test.out, out)
+		}
+	}
+}
+
+func TestSplitIPsByDelimiter(t *testing.T) {
+	result, err := SplitIPsByDelimiter("192.0.2.2, 192.0.2.1 192.0.2.2,::1")
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.0.2.2"),
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("::1"),
+	}, result)
+
+	_, err = SplitIPsByDelimiter("192.0.2.1,10.0.0.0/8")
+	assert.Error(t, err)
+}
+
+func TestSortPrefixes(t *testing.T) {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("10.0.0.0/16"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+	}
+
+	SortPrefixes(prefixes)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("10.0.0.0/16"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}, prefixes)
+}
+
+func TestSortAddrs(t *testing.T) {
+	addrs := []netip.Addr{
+		netip.MustParseAddr("::1"),
+		netip.MustParseAddr("192.0.2.2"),
+		netip.MustParseAddr("192.0.2.1"),
+	}
+
+	SortAddrs(addrs)
+	assert.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("192.0.2.2"),
+		netip.MustParseAddr("::1"),
+	}, addrs)
+}
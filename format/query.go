@@ -0,0 +1,372 @@
+//
+// Struct tag driven query string and form encoding
+//
+
+package format
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tags, e.g. `query:"since,omitempty" layout:"2006-01-02"`
+const (
+	// Name and options, "-" skips the field, untagged fields use their name
+	queryTag = "query"
+
+	// Time layout, or "unix" and "unixmilli" for epoch values, RFC 3339 when
+	// not set
+	layoutTag = "layout"
+)
+
+// Field options after the name in the query tag
+const (
+	// Leave out zero values, nil pointers are always left out
+	queryOmitEmpty = "omitempty"
+
+	// Join slices into one comma separated value instead of repeating the key
+	queryComma = "comma"
+)
+
+var (
+	ErrInvalidQuery        = errors.New("format: invalid query value")
+	ErrUnsupportedQueryArg = errors.New("format: query needs a struct or struct pointer")
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+type queryField struct {
+	name      string
+	omitEmpty bool
+	comma     bool
+	layout    string
+	value     reflect.Value
+}
+
+//
+// Encode struct fields as query values, url.Values.Encode gives both query
+// strings and form bodies
+//
+
+func EncodeQuery(v any) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedQueryArg, v)
+	}
+
+	values := make(url.Values)
+
+	for _, field := range queryFields(rv) {
+		fv := field.value
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+
+			fv = fv.Elem()
+		}
+
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		var list []string
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < fv.Len(); i++ {
+				s, err := encodeQueryValue(fv.Index(i), field.layout)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, field.name, err)
+				}
+
+				list = append(list, s)
+			}
+
+			if len(list) == 0 {
+				continue
+			}
+
+			if field.comma {
+				list = []string{strings.Join(list, ",")}
+			}
+		} else {
+			s, err := encodeQueryValue(fv, field.layout)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, field.name, err)
+			}
+
+			list = []string{s}
+		}
+
+		values[field.name] = append(values[field.name], list...)
+	}
+
+	return values, nil
+}
+
+//
+// Decode query values into struct fields, fields without a value are left
+// as they are and pointer fields are only allocated when a value is present
+//
+
+func DecodeQuery(values url.Values, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrUnsupportedQueryArg, v)
+	}
+
+	for _, field := range queryFields(rv.Elem()) {
+		list, exists := values[field.name]
+		if !exists || len(list) == 0 {
+			continue
+		}
+
+		fv := field.value
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			fv = fv.Elem()
+		}
+
+		var err error
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			if field.comma {
+				var split []string
+				for _, s := range list {
+					split = append(split, strings.Split(s, ",")...)
+				}
+
+				list = split
+			}
+
+			slice := reflect.MakeSlice(fv.Type(), len(list), len(list))
+			for i, s := range list {
+				if err = decodeQueryValue(slice.Index(i), s, field.layout); err != nil {
+					break
+				}
+			}
+
+			if err == nil {
+				fv.Set(slice)
+			}
+		} else {
+			err = decodeQueryValue(fv, list[0], field.layout)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidQuery, field.name, err)
+		}
+	}
+
+	return nil
+}
+
+//
+// Exported fields with their tags, embedded structs are flattened
+//
+
+func queryFields(rv reflect.Value) []queryField {
+	var fields []queryField
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get(queryTag)
+
+		if tag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, queryFields(rv.Field(i))...)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := queryField{
+			name:   name,
+			layout: sf.Tag.Get(layoutTag),
+			value:  rv.Field(i),
+		}
+
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case queryOmitEmpty:
+				field.omitEmpty = true
+			case queryComma:
+				field.comma = true
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+func encodeQueryValue(v reflect.Value, layout string) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case timeType:
+		t := v.Interface().(time.Time)
+
+		switch layout {
+		case "":
+			return t.Format(time.RFC3339), nil
+		case "unix":
+			return strconv.FormatInt(t.Unix(), 10), nil
+		case "unixmilli":
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		}
+
+		return t.Format(layout), nil
+
+	case durationType:
+		return v.Interface().(time.Duration).String(), nil
+	}
+
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func decodeQueryValue(v reflect.Value, s string, layout string) error {
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case timeType:
+		t, err := parseQueryTime(s, layout)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func parseQueryTime(s string, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		return time.Parse(time.RFC3339, s)
+
+	case "unix", "unixmilli":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if layout == "unix" {
+			return time.Unix(n, 0), nil
+		}
+
+		return time.UnixMilli(n), nil
+	}
+
+	return time.Parse(layout, s)
+}
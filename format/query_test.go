@@ -0,0 +1,132 @@
+package format
+
+import (
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testQueryPage struct {
+	Limit  int    `query:"limit,omitempty"`
+	Cursor string `query:"cursor,omitempty"`
+}
+
+type testQuery struct {
+	testQueryPage
+
+	Search   string        `query:"q"`
+	Tags     []string      `query:"tag"`
+	Ids      []int         `query:"ids,comma,omitempty"`
+	Active   *bool         `query:"active"`
+	Since    time.Time     `query:"since,omitempty" layout:"2006-01-02"`
+	Until    *time.Time    `query:"until" layout:"unix"`
+	Timeout  time.Duration `query:"timeout,omitempty"`
+	Addr     netip.Addr    `query:"addr,omitempty"`
+	Ratio    float64       `query:"ratio,omitempty"`
+	Internal string        `query:"-"`
+	Region   string
+	private  string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := false
+	until := time.Unix(1760000000, 0)
+
+	values, err := EncodeQuery(&testQuery{
+		testQueryPage: testQueryPage{Limit: 50},
+		Search:        "a b&c",
+		Tags:          []string{"x", "y"},
+		Ids:           []int{1, 2, 3},
+		Active:        &active,
+		Since:         time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Until:         &until,
+		Timeout:       90 * time.Second,
+		Addr:          netip.MustParseAddr("192.0.2.1"),
+		Ratio:         0.25,
+		Internal:      "secret",
+		Region:        "eu-west-1",
+		private:       "hidden",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"limit":   {"50"},
+		"q":       {"a b&c"},
+		"tag":     {"x", "y"},
+		"ids":     {"1,2,3"},
+		"active":  {"false"},
+		"since":   {"2026-10-15"},
+		"until":   {"1760000000"},
+		"timeout": {"1m30s"},
+		"addr":    {"192.0.2.1"},
+		"ratio":   {"0.25"},
+		"Region":  {"eu-west-1"},
+	}, values)
+
+	assert.Equal(t, "Region=eu-west-1&active=false&addr=192.0.2.1&ids=1%2C2%2C3&limit=50&q=a+b%26c&ratio=0.25&since=2026-10-15&tag=x&tag=y&timeout=1m30s&until=1760000000", values.Encode())
+}
+
+func TestEncodeQueryOmits(t *testing.T) {
+	values, err := EncodeQuery(testQuery{Search: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"q": {"x"}, "Region": {""}}, values)
+
+	_, err = EncodeQuery("bogus")
+	assert.ErrorIs(t, err, ErrUnsupportedQueryArg)
+
+	_, err = EncodeQuery(struct {
+		Values map[string]string `query:"values"`
+	}{Values: map[string]string{}})
+
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func TestDecodeQuery(t *testing.T) {
+	values, err := url.ParseQuery("limit=50&q=a+b%26c&tag=x&tag=y&ids=1,2&ids=3&active=true&since=2026-10-15&until=1760000000&timeout=1m30s&addr=192.0.2.1&Region=eu-west-1&ratio=0.25")
+	assert.NoError(t, err)
+
+	var query testQuery
+	assert.NoError(t, DecodeQuery(values, &query))
+
+	assert.Equal(t, 50, query.Limit)
+	assert.Equal(t, "a b&c", query.Search)
+	assert.Equal(t, []string{"x", "y"}, query.Tags)
+	assert.Equal(t, []int{1, 2, 3}, query.Ids)
+	assert.Equal(t, true, *query.Active)
+	assert.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), query.Since)
+	assert.Equal(t, int64(1760000000), query.Until.Unix())
+	assert.Equal(t, 90*time.Second, query.Timeout)
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), query.Addr)
+	assert.Equal(t, 0.25, query.Ratio)
+	assert.Equal(t, "eu-west-1", query.Region)
+}
+
+func TestDecodeQueryKeepsMissing(t *testing.T) {
+	query := testQuery{Search: "default"}
+	assert.NoError(t, DecodeQuery(url.Values{"limit": {"10"}}, &query))
+
+	assert.Equal(t, "default", query.Search)
+	assert.Nil(t, query.Active)
+	assert.Nil(t, query.Until)
+}
+
+func TestDecodeQueryErrors(t *testing.T) {
+	var query testQuery
+
+	err := DecodeQuery(url.Values{"limit": {"many"}}, &query)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	assert.ErrorContains(t, err, "limit")
+
+	err = DecodeQuery(url.Values{"ids": {"1,x"}}, &query)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	assert.Nil(t, query.Ids)
+
+	err = DecodeQuery(url.Values{"since": {"yesterday"}}, &query)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	assert.ErrorIs(t, DecodeQuery(url.Values{}, query), ErrUnsupportedQueryArg)
+	assert.ErrorIs(t, DecodeQuery(url.Values{}, (*testQuery)(nil)), ErrUnsupportedQueryArg)
+}
@@ -0,0 +1,84 @@
+//
+// Quoting for values interpolated into generated commands and config files
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidIdentifier = errors.New("format: invalid identifier")
+
+// Characters never interpreted by POSIX shells
+var shellSafeRe = regexp.MustCompile(`^[a-zA-Z0-9_@%+=:,./-]+$`)
+
+//
+// POSIX shell quoting, values with special characters are single quoted
+// with embedded quotes written as '"'"'
+//
+
+func ShellQuote(input string) string {
+	if input == "" {
+		return "''"
+	}
+
+	if shellSafeRe.MatchString(input) {
+		return input
+	}
+
+	return "'" + strings.ReplaceAll(input, "'", `'"'"'`) + "'"
+}
+
+//
+// Quote every argument and join with spaces, safe to paste into sh -c
+//
+
+func ShellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+//
+// SQL identifiers, ANSI double quotes or MySQL backticks with the quote
+// character doubled, empty names and NUL bytes are rejected
+//
+
+func QuoteIdentifier(name string) (string, error) {
+	return quoteIdentifier(name, `"`)
+}
+
+func QuoteMySQLIdentifier(name string) (string, error) {
+	return quoteIdentifier(name, "`")
+}
+
+// Dotted names like "schema.table" quoted part by part
+func QuoteQualifiedIdentifier(name string) (string, error) {
+	parts := strings.Split(name, ".")
+
+	for i, part := range parts {
+		quoted, err := QuoteIdentifier(part)
+		if err != nil {
+			return "", err
+		}
+
+		parts[i] = quoted
+	}
+
+	return strings.Join(parts, "."), nil
+}
+
+func quoteIdentifier(name string, quote string) (string, error) {
+	if name == "" || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote, nil
+}
@@ -0,0 +1,59 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                "''",
+		"simple":          "simple",
+		"/usr/bin/env":    "/usr/bin/env",
+		"key=value,a:b@c": "key=value,a:b@c",
+		"with space":      "'with space'",
+		"it's":            `'it'"'"'s'`,
+		"$HOME":           "'$HOME'",
+		"a;rm -rf /":      "'a;rm -rf /'",
+		"`id`":            "'`id`'",
+		"line\nbreak":     "'line\nbreak'",
+		"glob*":           "'glob*'",
+		"ünïcode":         "'ünïcode'",
+	}
+
+	for in, out := range tests {
+		assert.Equal(t, out, ShellQuote(in), in)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, `aws s3 cp 'my file.txt' s3://bucket/key --metadata 'owner='"'"'ops'"'"''`,
+		ShellJoin("aws", "s3", "cp", "my file.txt", "s3://bucket/key", "--metadata", "owner='ops'"))
+
+	assert.Equal(t, "", ShellJoin())
+	assert.Equal(t, "echo ''", ShellJoin("echo", ""))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	quoted, err := QuoteIdentifier("users")
+	assert.NoError(t, err)
+	assert.Equal(t, `"users"`, quoted)
+
+	quoted, err = QuoteIdentifier(`weird"name`)
+	assert.NoError(t, err)
+	assert.Equal(t, `"weird""name"`, quoted)
+
+	quoted, err = QuoteMySQLIdentifier("order`by")
+	assert.NoError(t, err)
+	assert.Equal(t, "`order``by`", quoted)
+
+	quoted, err = QuoteQualifiedIdentifier("public.user table")
+	assert.NoError(t, err)
+	assert.Equal(t, `"public"."user table"`, quoted)
+
+	for _, name := range []string{"", "nul\x00byte", "schema."} {
+		_, err := QuoteQualifiedIdentifier(name)
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, name)
+	}
+}
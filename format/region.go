@@ -0,0 +1,213 @@
+//
+// AWS region and availability zone parsing
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type AvailabilityZone struct {
+	Region string
+	Name   string
+	Id     string
+}
+
+// Account specific availability zone name and id pairs
+type AzMapping struct {
+	nameToId map[string]string
+	idToName map[string]string
+}
+
+var ErrInvalidRegion = errors.New("format: invalid region")
+
+// Known regions and their availability zone id prefix
+var awsRegionAzPrefixes = map[string]string{
+	"af-south-1":     "afs1",
+	"ap-east-1":      "ape1",
+	"ap-northeast-1": "apne1",
+	"ap-northeast-2": "apne2",
+	"ap-northeast-3": "apne3",
+	"ap-south-1":     "aps1",
+	"ap-south-2":     "aps2",
+	"ap-southeast-1": "apse1",
+	"ap-southeast-2": "apse2",
+	"ap-southeast-3": "apse3",
+	"ap-southeast-4": "apse4",
+	"ca-central-1":   "cac1",
+	"ca-west-1":      "caw1",
+	"cn-north-1":     "cnn1",
+	"cn-northwest-1": "cnnw1",
+	"eu-central-1":   "euc1",
+	"eu-central-2":   "euc2",
+	"eu-north-1":     "eun1",
+	"eu-south-1":     "eus1",
+	"eu-south-2":     "eus2",
+	"eu-west-1":      "euw1",
+	"eu-west-2":      "euw2",
+	"eu-west-3":      "euw3",
+	"il-central-1":   "ilc1",
+	"me-central-1":   "mec1",
+	"me-south-1":     "mes1",
+	"sa-east-1":      "sae1",
+	"us-east-1":      "use1",
+	"us-east-2":      "use2",
+	"us-gov-east-1":  "usge1",
+	"us-gov-west-1":  "usgw1",
+	"us-west-1":      "usw1",
+	"us-west-2":      "usw2",
+}
+
+var awsAzPrefixRegions = func() map[string]string {
+	result := make(map[string]string, len(awsRegionAzPrefixes))
+	for region, prefix := range awsRegionAzPrefixes {
+		result[prefix] = region
+	}
+
+	return result
+}()
+
+var azIdRe = regexp.MustCompile(`^([a-z]+[0-9])-az([0-9]+)$`)
+var azNameRe = regexp.MustCompile(`^([a-z]{2}(?:-[a-z]+)+-[0-9]+)([a-z])$`)
+
+//
+// Region validation
+//
+
+func IsKnownRegion(region string) bool {
+	_, ok := awsRegionAzPrefixes[region]
+	return ok
+}
+
+func KnownRegions() []string {
+	result := make([]string, 0, len(awsRegionAzPrefixes))
+	for region := range awsRegionAzPrefixes {
+		result = append(result, region)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Normalize case and whitespace, then require a known region
+func NormalizeRegion(region string) (string, error) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if !IsKnownRegion(region) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRegion, region)
+	}
+
+	return region, nil
+}
+
+//
+// Parse availability zone name (us-east-1a) or id (use1-az1), the name for
+// an id and vice versa is account specific and left empty
+//
+
+func ParseAvailabilityZone(input string) (*AvailabilityZone, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+
+	if match := azIdRe.FindStringSubmatch(input); match != nil {
+		region, ok := awsAzPrefixRegions[match[1]]
+		if ok {
+			return &AvailabilityZone{Region: region, Id: input}, nil
+		}
+	}
+
+	if match := azNameRe.FindStringSubmatch(input); match != nil && IsKnownRegion(match[1]) {
+		return &AvailabilityZone{Region: match[1], Name: input}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown availability zone %q", ErrInvalidRegion, input)
+}
+
+//
+// Mapping from DescribeAvailabilityZones output, as name to id pairs
+//
+
+func NewAzMapping(nameToId map[string]string) *AzMapping {
+	m := &AzMapping{
+		nameToId: make(map[string]string, len(nameToId)),
+		idToName: make(map[string]string, len(nameToId)),
+	}
+
+	for name, id := range nameToId {
+		name = strings.ToLower(name)
+		id = strings.ToLower(id)
+		m.nameToId[name] = id
+		m.idToName[id] = name
+	}
+
+	return m
+}
+
+func (m *AzMapping) Name(id string) (string, bool) {
+	name, ok := m.idToName[strings.ToLower(id)]
+	return name, ok
+}
+
+func (m *AzMapping) Id(name string) (string, bool) {
+	id, ok := m.nameToId[strings.ToLower(name)]
+	return id, ok
+}
+
+// Fill in whichever of name or id is missing
+func (m *AzMapping) Resolve(az *AvailabilityZone) bool {
+	if az.Name == "" {
+		az.Name, _ = m.Name(az.Id)
+	}
+
+	if az.Id == "" {
+		az.Id, _ = m.Id(az.Name)
+	}
+
+	return az.Name != "" && az.Id != ""
+}
+
+//
+// Extract region from ARN or service endpoint hostname
+//
+
+func RegionFromArn(arn string) (string, error) {
+	parsed, err := ParseArn(arn)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Region == "" {
+		return "", fmt.Errorf("%w: arn has no region %q", ErrInvalidRegion, arn)
+	}
+
+	return parsed.Region, nil
+}
+
+// e.g. "ec2.eu-west-1.amazonaws.com" or legacy "bucket.s3-us-west-2.amazonaws.com"
+func RegionFromEndpoint(endpoint string) (string, error) {
+	host := strings.ToLower(endpoint)
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+
+	host, _, _ = strings.Cut(host, "/")
+	host, _, _ = strings.Cut(host, ":")
+
+	for _, label := range strings.Split(host, ".") {
+		if IsKnownRegion(label) {
+			return label, nil
+		}
+
+		// Dash separated legacy S3 endpoints
+		for _, prefix := range []string{"s3-website-", "s3-"} {
+			if region := strings.TrimPrefix(label, prefix); region != label && IsKnownRegion(region) {
+				return region, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%w: no region in endpoint %q", ErrInvalidRegion, endpoint)
+}
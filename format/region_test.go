@@ -0,0 +1,91 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegions(t *testing.T) {
+	assert.True(t, IsKnownRegion("eu-north-1"))
+	assert.False(t, IsKnownRegion("eu-north-9"))
+	assert.Contains(t, KnownRegions(), "us-gov-west-1")
+
+	region, err := NormalizeRegion(" EU-West-1 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	_, err = NormalizeRegion("europe")
+	assert.ErrorIs(t, err, ErrInvalidRegion)
+
+	// Every known region has a unique zone id prefix
+	assert.Len(t, awsAzPrefixRegions, len(awsRegionAzPrefixes))
+}
+
+func TestParseAvailabilityZone(t *testing.T) {
+	az, err := ParseAvailabilityZone("use1-az4")
+	assert.NoError(t, err)
+	assert.Equal(t, &AvailabilityZone{Region: "us-east-1", Id: "use1-az4"}, az)
+
+	az, err = ParseAvailabilityZone("cn-northwest-1b")
+	assert.NoError(t, err)
+	assert.Equal(t, &AvailabilityZone{Region: "cn-northwest-1", Name: "cn-northwest-1b"}, az)
+
+	az, err = ParseAvailabilityZone("USGW1-AZ2")
+	assert.NoError(t, err)
+	assert.Equal(t, "us-gov-west-1", az.Region)
+
+	for _, input := range []string{"xxx1-az1", "eu-west-9a", "eu-west-1", ""} {
+		_, err = ParseAvailabilityZone(input)
+		assert.ErrorIs(t, err, ErrInvalidRegion, input)
+	}
+}
+
+func TestAzMapping(t *testing.T) {
+	mapping := NewAzMapping(map[string]string{
+		"us-east-1a": "use1-az6",
+		"us-east-1b": "use1-az1",
+	})
+
+	name, ok := mapping.Name("use1-az1")
+	assert.True(t, ok)
+	assert.Equal(t, "us-east-1b", name)
+
+	id, ok := mapping.Id("us-east-1a")
+	assert.True(t, ok)
+	assert.Equal(t, "use1-az6", id)
+
+	az, _ := ParseAvailabilityZone("use1-az6")
+	assert.True(t, mapping.Resolve(az))
+	assert.Equal(t, "us-east-1a", az.Name)
+
+	az, _ = ParseAvailabilityZone("us-east-1f")
+	assert.False(t, mapping.Resolve(az))
+}
+
+func TestRegionFromArnAndEndpoint(t *testing.T) {
+	region, err := RegionFromArn("arn:aws:sqs:eu-central-1:123456789012:queue")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-central-1", region)
+
+	_, err = RegionFromArn("arn:aws:iam::123456789012:role/admin")
+	assert.ErrorIs(t, err, ErrInvalidRegion)
+
+	endpoints := map[string]string{
+		"ec2.eu-west-1.amazonaws.com":                        "eu-west-1",
+		"https://sqs.cn-north-1.amazonaws.com.cn/123/queue":  "cn-north-1",
+		"bucket.s3-us-west-2.amazonaws.com":                  "us-west-2",
+		"s3.dualstack.ap-southeast-2.amazonaws.com:443":      "ap-southeast-2",
+		"bucket.s3-website-sa-east-1.amazonaws.com":          "sa-east-1",
+		"vpce-1a2b3c4d.ec2.us-gov-east-1.vpce.amazonaws.com": "us-gov-east-1",
+	}
+
+	for endpoint, expected := range endpoints {
+		region, err := RegionFromEndpoint(endpoint)
+		assert.NoError(t, err, endpoint)
+		assert.Equal(t, expected, region, endpoint)
+	}
+
+	_, err = RegionFromEndpoint("s3.amazonaws.com")
+	assert.ErrorIs(t, err, ErrInvalidRegion)
+}
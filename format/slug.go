@@ -0,0 +1,118 @@
+//
+// Normalize arbitrary strings into slugs and DNS/Kubernetes safe names
+//
+
+package format
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+const (
+	DNSLabelMaxLength = 63
+	K8sNameMaxLength  = 253
+	slugHashLength    = 8
+)
+
+// Common latin letters without a plain ascii decomposition
+var slugTransliterations = map[rune]string{
+	'æ': "ae", 'ø': "o", 'å': "a", 'ß': "ss", 'œ': "oe", 'ð': "d", 'þ': "th", 'ł': "l", 'đ': "d",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y",
+}
+
+//
+// Lowercase ascii slug with runs of other characters collapsed to "-"
+//
+
+func Slugify(input string) string {
+	return slugify(input, false)
+}
+
+//
+// RFC 1035 label: starts with a letter, ends alphanumeric, at most 63 characters
+//
+
+func SanitizeDNSLabel(input string) string {
+	slug := slugify(input, false)
+	if slug == "" || !unicode.IsLetter(rune(slug[0])) {
+		slug = "x-" + slug
+	}
+
+	return truncateWithHash(strings.TrimRight(slug, "-"), input, DNSLabelMaxLength)
+}
+
+//
+// DNS-1123 subdomain: lowercase alphanumerics, "-" and ".", at most 253 characters
+//
+
+func SanitizeK8sName(input string) string {
+	slug := slugify(input, true)
+	if slug == "" {
+		slug = "x"
+	}
+
+	return truncateWithHash(slug, input, K8sNameMaxLength)
+}
+
+func slugify(input string, keepDots bool) string {
+	var sb strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(input) {
+		if t, ok := slugTransliterations[r]; ok {
+			sb.WriteString(t)
+			dash = false
+			continue
+		}
+
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || (keepDots && r == '.') {
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.Trim(sb.String(), "-.")
+	if keepDots {
+		// Labels inside a subdomain must not be empty or dash-bounded
+		labels := strings.FieldsFunc(slug, func(r rune) bool {
+			return r == '.'
+		})
+
+		for i, label := range labels {
+			labels[i] = strings.Trim(label, "-")
+		}
+
+		slug = strings.Join(labels, ".")
+	}
+
+	return slug
+}
+
+//
+// Truncate to max length, keeping distinct inputs distinct with a hash suffix
+//
+
+func truncateWithHash(slug string, original string, max int) string {
+	if len(slug) <= max {
+		return slug
+	}
+
+	sum := sha256.Sum256([]byte(original))
+	suffix := hex.EncodeToString(sum[:])[:slugHashLength]
+	head := strings.TrimRight(slug[:max-slugHashLength-1], "-.")
+
+	return head + "-" + suffix
+}
@@ -0,0 +1,73 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "Hello World", out: "hello-world"},
+		{in: "  --Foo__Bar!!  ", out: "foo-bar"},
+		{in: "Blåbærsyltetøy", out: "blabaersyltetoy"},
+		{in: "Straße 12", out: "strasse-12"},
+		{in: "my.service.v2", out: "my-service-v2"},
+		{in: "日本", out: ""},
+		{in: "", out: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, Slugify(test.in))
+	}
+}
+
+func TestSanitizeDNSLabel(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "My Service", out: "my-service"},
+		{in: "123-app", out: "x-123-app"},
+		{in: "", out: "x"},
+		{in: "_internal_", out: "internal"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, SanitizeDNSLabel(test.in))
+	}
+}
+
+func TestSanitizeDNSLabelTruncate(t *testing.T) {
+	a := SanitizeDNSLabel(strings.Repeat("a", 100) + "-one")
+	b := SanitizeDNSLabel(strings.Repeat("a", 100) + "-two")
+
+	assert.Len(t, a, 63)
+	assert.Len(t, b, 63)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, a, SanitizeDNSLabel(strings.Repeat("a", 100)+"-one"))
+}
+
+func TestSanitizeK8sName(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "My.App_Server", out: "my.app-server"},
+		{in: "..foo..bar-.", out: "foo.bar"},
+		{in: "-.-", out: "x"},
+		{in: "123", out: "123"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, SanitizeK8sName(test.in))
+	}
+
+	long := SanitizeK8sName(strings.Repeat("abc.", 100))
+	assert.LessOrEqual(t, len(long), K8sNameMaxLength)
+	assert.NotContains(t, long, "..")
+}
@@ -0,0 +1,157 @@
+//
+// AWS resource tag conversion and validation
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type AwsTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+var ErrInvalidTag = errors.New("format: invalid tag")
+
+const (
+	AwsTagMaxKeyLength   = 128
+	AwsTagMaxValueLength = 256
+	AwsTagMaxPerResource = 50
+)
+
+var awsTagCharsRe = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+//
+// Map to tag list, sorted by key for stable output
+//
+
+func TagsToList(tags map[string]string) []AwsTag {
+	return TagsToListFunc(tags, func(key, value string) AwsTag {
+		return AwsTag{Key: key, Value: value}
+	})
+}
+
+//
+// Map to SDK specific tag types, e.g.
+//
+//	TagsToListFunc(tags, func(k, v string) types.Tag {
+//		return types.Tag{Key: aws.String(k), Value: aws.String(v)}
+//	})
+//
+
+func TagsToListFunc[T any](tags map[string]string, fn func(key, value string) T) []T {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	result := make([]T, 0, len(tags))
+	for _, key := range keys {
+		result = append(result, fn(key, tags[key]))
+	}
+
+	return result
+}
+
+//
+// Tag list to map, later duplicates win
+//
+
+func TagsFromList(list []AwsTag) map[string]string {
+	return TagsFromListFunc(list, func(tag AwsTag) (string, string) {
+		return tag.Key, tag.Value
+	})
+}
+
+func TagsFromListFunc[T any](list []T, fn func(tag T) (string, string)) map[string]string {
+	result := make(map[string]string, len(list))
+	for _, tag := range list {
+		key, value := fn(tag)
+		result[key] = value
+	}
+
+	return result
+}
+
+//
+// Cost allocation style "key=value,key=value" strings
+//
+
+func FormatTagString(tags map[string]string) string {
+	pairs := TagsToListFunc(tags, func(key, value string) string {
+		return key + "=" + value
+	})
+
+	return strings.Join(pairs, ",")
+}
+
+func ParseTagString(input string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, pair := range strings.Split(input, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+
+		if key == "" {
+			return nil, fmt.Errorf("%w: missing key in %q", ErrInvalidTag, pair)
+		}
+
+		result[key] = strings.TrimSpace(value)
+	}
+
+	return result, nil
+}
+
+//
+// Validate tags against AWS key/value constraints
+//
+
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > AwsTagMaxPerResource {
+		return fmt.Errorf("%w: %d tags exceeds limit of %d", ErrInvalidTag, len(tags), AwsTagMaxPerResource)
+	}
+
+	for key, value := range tags {
+		err := ValidateTag(key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidateTag(key string, value string) error {
+	switch {
+	case key == "" || utf8.RuneCountInString(key) > AwsTagMaxKeyLength:
+		return fmt.Errorf("%w: key %q must be 1-%d characters", ErrInvalidTag, key, AwsTagMaxKeyLength)
+
+	case strings.HasPrefix(strings.ToLower(key), "aws:"):
+		return fmt.Errorf("%w: key %q uses reserved aws: prefix", ErrInvalidTag, key)
+
+	case !awsTagCharsRe.MatchString(key):
+		return fmt.Errorf("%w: key %q contains invalid characters", ErrInvalidTag, key)
+
+	case utf8.RuneCountInString(value) > AwsTagMaxValueLength:
+		return fmt.Errorf("%w: value for %q exceeds %d characters", ErrInvalidTag, key, AwsTagMaxValueLength)
+
+	case !awsTagCharsRe.MatchString(value):
+		return fmt.Errorf("%w: value for %q contains invalid characters", ErrInvalidTag, key)
+	}
+
+	return nil
+}
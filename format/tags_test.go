@@ -0,0 +1,83 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagsToList(t *testing.T) {
+	result := TagsToList(map[string]string{"Name": "web", "Env": "prod"})
+	assert.Equal(t, []AwsTag{{Key: "Env", Value: "prod"}, {Key: "Name", Value: "web"}}, result)
+	assert.Equal(t, []AwsTag{}, TagsToList(nil))
+}
+
+func TestTagsToListFunc(t *testing.T) {
+	type sdkTag struct {
+		Key   *string
+		Value *string
+	}
+
+	result := TagsToListFunc(map[string]string{"Name": "web"}, func(key, value string) sdkTag {
+		return sdkTag{Key: &key, Value: &value}
+	})
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Name", *result[0].Key)
+	assert.Equal(t, "web", *result[0].Value)
+
+	back := TagsFromListFunc(result, func(tag sdkTag) (string, string) {
+		return *tag.Key, *tag.Value
+	})
+
+	assert.Equal(t, map[string]string{"Name": "web"}, back)
+}
+
+func TestTagsFromList(t *testing.T) {
+	result := TagsFromList([]AwsTag{{Key: "Env", Value: "dev"}, {Key: "Env", Value: "prod"}})
+	assert.Equal(t, map[string]string{"Env": "prod"}, result)
+}
+
+func TestTagString(t *testing.T) {
+	tags := map[string]string{"team": "infra", "cost-center": "1234"}
+	assert.Equal(t, "cost-center=1234,team=infra", FormatTagString(tags))
+
+	result, err := ParseTagString(" team = infra, cost-center=1234,,empty=,url=a=b ")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "infra", "cost-center": "1234", "empty": "", "url": "a=b"}, result)
+
+	_, err = ParseTagString("=value")
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		err   bool
+	}{
+		{key: "Name", value: "web-01"},
+		{key: "app:component", value: "api/v1 @ prod"},
+		{key: "Ære", value: "på norsk"},
+		{key: "", value: "x", err: true},
+		{key: "aws:cloudformation", value: "x", err: true},
+		{key: "bad*key", value: "x", err: true},
+		{key: "key", value: "bad;value", err: true},
+		{key: strings.Repeat("k", 129), value: "x", err: true},
+		{key: "key", value: strings.Repeat("v", 257), err: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateTags(map[string]string{test.key: test.value})
+		assert.Equal(t, test.err, err != nil, test.key)
+	}
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= AwsTagMaxPerResource; i++ {
+		tooMany[fmt.Sprint(i)] = "x"
+	}
+
+	assert.ErrorIs(t, ValidateTags(tooMany), ErrInvalidTag)
+}
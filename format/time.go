@@ -0,0 +1,153 @@
+//
+// Human friendly relative times and compact timestamps for CLI and chat
+// output
+//
+
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type RelativeTimeOpts struct {
+	// Reference time, time.Now when zero
+	Now time.Time
+
+	// Number of units shown, 1 gives "3h ago" and 2 gives "3h12m ago"
+	Precision int
+
+	// Differences below this read "just now"
+	JustNow time.Duration
+}
+
+type TimestampPrecision int
+
+const (
+	PrecisionMinute TimestampPrecision = iota
+	PrecisionDay
+	PrecisionSecond
+	PrecisionMillisecond
+)
+
+var DefaultRelativeTimeOpts = &RelativeTimeOpts{
+	Precision: 1,
+	JustNow:   time.Second,
+}
+
+// Calendar units are approximate, good enough for "ago" output
+var relativeTimeUnits = []struct {
+	unit time.Duration
+	name string
+}{
+	{unit: 365 * 24 * time.Hour, name: "y"},
+	{unit: 30 * 24 * time.Hour, name: "mo"},
+	{unit: 7 * 24 * time.Hour, name: "w"},
+	{unit: 24 * time.Hour, name: "d"},
+	{unit: time.Hour, name: "h"},
+	{unit: time.Minute, name: "m"},
+	{unit: time.Second, name: "s"},
+}
+
+//
+// Time relative to now, e.g. "3h ago" or "in 2d"
+//
+
+func RelativeTime(t time.Time) string {
+	return RelativeTimeWithOpts(t, &RelativeTimeOpts{})
+}
+
+func RelativeTimeWithOpts(t time.Time, opts *RelativeTimeOpts) string {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	if opts.Precision == 0 {
+		opts.Precision = DefaultRelativeTimeOpts.Precision
+	}
+
+	if opts.JustNow == 0 {
+		opts.JustNow = DefaultRelativeTimeOpts.JustNow
+	}
+
+	d := opts.Now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	if d < opts.JustNow {
+		return "just now"
+	}
+
+	var sb strings.Builder
+	parts := 0
+
+	for _, u := range relativeTimeUnits {
+		if parts == opts.Precision {
+			break
+		}
+
+		if d >= u.unit {
+			fmt.Fprintf(&sb, "%d%s", d/u.unit, u.name)
+			d %= u.unit
+			parts++
+		} else if parts > 0 {
+			// Keep units adjacent, as in FormatDurationShort
+			break
+		}
+	}
+
+	// Only reachable with JustNow below a second
+	if parts == 0 {
+		sb.WriteString("0s")
+	}
+
+	if future {
+		return "in " + sb.String()
+	}
+
+	return sb.String() + " ago"
+}
+
+//
+// Timestamp in the location of t, e.g. "2026-10-15 14:03"
+//
+
+func FormatTimestamp(t time.Time, precision TimestampPrecision) string {
+	return t.Format("2006-01-02" + timeLayout(precision))
+}
+
+//
+// Timestamp relative to now, leaving out the date on the same day and the
+// year within the same year, e.g. "14:03", "Oct 15 14:03" or
+// "2025-10-15 14:03"
+//
+
+func FormatTimestampCompact(t time.Time, now time.Time, precision TimestampPrecision) string {
+	now = now.In(t.Location())
+	clock := timeLayout(precision)
+
+	switch {
+	case t.Year() != now.Year():
+		return t.Format("2006-01-02" + clock)
+	case t.YearDay() != now.YearDay() || precision == PrecisionDay:
+		return t.Format("Jan 2" + clock)
+	}
+
+	return t.Format(strings.TrimPrefix(clock, " "))
+}
+
+func timeLayout(precision TimestampPrecision) string {
+	switch precision {
+	case PrecisionDay:
+		return ""
+	case PrecisionSecond:
+		return " 15:04:05"
+	case PrecisionMillisecond:
+		return " 15:04:05.000"
+	}
+
+	return " 15:04"
+}
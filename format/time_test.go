@@ -0,0 +1,60 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in        time.Time
+		precision int
+		out       string
+	}{
+		{in: now, out: "just now"},
+		{in: now.Add(-500 * time.Millisecond), out: "just now"},
+		{in: now.Add(-45 * time.Second), out: "45s ago"},
+		{in: now.Add(-3*time.Hour - 59*time.Minute), out: "3h ago"},
+		{in: now.Add(-3*time.Hour - 12*time.Minute), precision: 2, out: "3h12m ago"},
+		{in: now.Add(-3*time.Hour - 12*time.Second), precision: 2, out: "3h ago"},
+		{in: now.Add(49 * time.Hour), out: "in 2d"},
+		{in: now.Add(-15 * 24 * time.Hour), precision: 2, out: "2w1d ago"},
+		{in: now.AddDate(0, -2, 0), out: "2mo ago"},
+		{in: now.AddDate(-3, 0, 0), out: "3y ago"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, RelativeTimeWithOpts(test.in, &RelativeTimeOpts{Now: now, Precision: test.precision}))
+	}
+
+	assert.Equal(t, "0s ago", RelativeTimeWithOpts(now.Add(-time.Millisecond), &RelativeTimeOpts{Now: now, JustNow: time.Nanosecond}))
+	assert.Equal(t, "1h ago", RelativeTime(time.Now().Add(-90*time.Minute)))
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2026, 10, 15, 14, 3, 27, 120*int(time.Millisecond), time.UTC)
+
+	assert.Equal(t, "2026-10-15 14:03", FormatTimestamp(ts, PrecisionMinute))
+	assert.Equal(t, "2026-10-15", FormatTimestamp(ts, PrecisionDay))
+	assert.Equal(t, "2026-10-15 14:03:27", FormatTimestamp(ts, PrecisionSecond))
+	assert.Equal(t, "2026-10-15 14:03:27.120", FormatTimestamp(ts, PrecisionMillisecond))
+}
+
+func TestFormatTimestampCompact(t *testing.T) {
+	ts := time.Date(2026, 10, 15, 14, 3, 27, 0, time.UTC)
+
+	assert.Equal(t, "14:03", FormatTimestampCompact(ts, ts.Add(time.Hour), PrecisionMinute))
+	assert.Equal(t, "14:03:27", FormatTimestampCompact(ts, ts.Add(time.Hour), PrecisionSecond))
+	assert.Equal(t, "Oct 15", FormatTimestampCompact(ts, ts.Add(time.Hour), PrecisionDay))
+	assert.Equal(t, "Oct 15 14:03", FormatTimestampCompact(ts, ts.AddDate(0, 0, 3), PrecisionMinute))
+	assert.Equal(t, "2026-10-15 14:03", FormatTimestampCompact(ts, ts.AddDate(1, 0, 0), PrecisionMinute))
+
+	// Same day is decided in the location of the timestamp
+	cest := time.FixedZone("CEST", 2*60*60)
+	late := time.Date(2026, 10, 15, 23, 30, 0, 0, cest)
+	assert.Equal(t, "23:30", FormatTimestampCompact(late, late.Add(10*time.Minute).UTC(), PrecisionMinute))
+}
@@ -0,0 +1,191 @@
+//
+// Byte size and duration parsing and formatting
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidUnit = errors.New("format: invalid unit")
+
+//
+// Byte sizes, decimal (KB) and binary (KiB) units
+//
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+var binaryUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+var parseBytesRe = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+
+func ParseBytes(input string) (int64, error) {
+	match := parseBytesRe.FindStringSubmatch(strings.TrimSpace(input))
+	if match == nil {
+		return 0, fmt.Errorf("%w: invalid byte size %q", ErrInvalidUnit, input)
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	multiplier, ok := byteUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown byte unit %q", ErrInvalidUnit, match[2])
+	}
+
+	// Float compare against 2^63, the first value int64 can't hold
+	result := value * multiplier
+	if result >= 1<<63 {
+		return 0, fmt.Errorf("%w: byte size %q overflows", ErrInvalidUnit, input)
+	}
+
+	return int64(result), nil
+}
+
+func FormatBytes(n int64) string {
+	sign, size := magnitude(n)
+	value := float64(size)
+	unit := 0
+
+	for value >= 1024 && unit < len(binaryUnits)-1 {
+		value /= 1024
+		unit++
+	}
+
+	return sign + strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64) + binaryUnits[unit]
+}
+
+// Sign and absolute value, as unsigned since math.MinInt64 has no positive
+// counterpart
+func magnitude(n int64) (string, uint64) {
+	if n < 0 {
+		return "-", uint64(-(n + 1)) + 1
+	}
+
+	return "", uint64(n)
+}
+
+//
+// Durations, time.ParseDuration plus days (d) and weeks (w)
+//
+
+var durationDaysRe = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+func ParseDuration(input string) (time.Duration, error) {
+	var convErr error
+
+	expanded := durationDaysRe.ReplaceAllStringFunc(strings.TrimSpace(input), func(s string) string {
+		match := durationDaysRe.FindStringSubmatch(s)
+
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			convErr = err
+			return s
+		}
+
+		hours := value * 24
+		if match[2] == "w" {
+			hours *= 7
+		}
+
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+
+	if convErr != nil {
+		return 0, convErr
+	}
+
+	result, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid duration %q", ErrInvalidUnit, input)
+	}
+
+	return result, nil
+}
+
+//
+// Compact duration, two most significant units, e.g. "2d3h" or "150ms"
+//
+
+var durationShortUnits = []struct {
+	unit time.Duration
+	name string
+}{
+	{unit: 7 * 24 * time.Hour, name: "w"},
+	{unit: 24 * time.Hour, name: "d"},
+	{unit: time.Hour, name: "h"},
+	{unit: time.Minute, name: "m"},
+	{unit: time.Second, name: "s"},
+}
+
+func FormatDurationShort(d time.Duration) string {
+	sign, n := magnitude(int64(d))
+
+	// Sub-second durations use a single unit
+	if n < uint64(time.Second) {
+		switch {
+		case n == 0:
+			return "0s"
+		case n >= uint64(time.Millisecond):
+			return fmt.Sprintf("%s%dms", sign, n/uint64(time.Millisecond))
+		case n >= uint64(time.Microsecond):
+			return fmt.Sprintf("%s%dµs", sign, n/uint64(time.Microsecond))
+		default:
+			return fmt.Sprintf("%s%dns", sign, n)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	parts := 0
+
+	for _, u := range durationShortUnits {
+		if parts == 2 {
+			break
+		}
+
+		unit := uint64(u.unit)
+		if n >= unit {
+			fmt.Fprintf(&sb, "%d%s", n/unit, u.name)
+			n %= unit
+			parts++
+		} else if parts > 0 {
+			// Keep units adjacent, "1d5s" would be misleading
+			break
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		in  string
+		out int64
+		err bool
+	}{
+		{in: "512", out: 512},
+		{in: "512B", out: 512},
+		{in: "1KB", out: 1000},
+		{in: "1KiB", out: 1024},
+		{in: "1.5GiB", out: 1610612736},
+		{in: "2 mb", out: 2000000},
+		{in: "10Gi", out: 10737418240},
+		{in: "1XB", err: true},
+		{in: "abc", err: true},
+		{in: "", err: true},
+		{in: "99999999PiB", err: true},
+		{in: "8191PiB", out: 8191 << 50},
+		{in: "8192PiB", err: true},
+	}
+
+	for _, test := range tests {
+		result, err := ParseBytes(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+		assert.Equal(t, test.out, result, test.in)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		in  int64
+		out string
+	}{
+		{in: 0, out: "0B"},
+		{in: 512, out: "512B"},
+		{in: 1024, out: "1KiB"},
+		{in: 1536, out: "1.5KiB"},
+		{in: 1610612736, out: "1.5GiB"},
+		{in: -2048, out: "-2KiB"},
+		{in: math.MaxInt64, out: "8EiB"},
+		{in: math.MinInt64, out: "-8EiB"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, FormatBytes(test.in))
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in  string
+		out time.Duration
+		err bool
+	}{
+		{in: "90s", out: 90 * time.Second},
+		{in: "1d", out: 24 * time.Hour},
+		{in: "1.5d", out: 36 * time.Hour},
+		{in: "2w", out: 14 * 24 * time.Hour},
+		{in: "1w2d3h4m", out: 9*24*time.Hour + 3*time.Hour + 4*time.Minute},
+		{in: "-1d", out: -24 * time.Hour},
+		{in: "1y", err: true},
+		{in: "", err: true},
+	}
+
+	for _, test := range tests {
+		result, err := ParseDuration(test.in)
+		assert.Equal(t, test.err, err != nil, test.in)
+		assert.Equal(t, test.out, result, test.in)
+	}
+}
+
+func TestFormatDurationShort(t *testing.T) {
+	tests := []struct {
+		in  time.Duration
+		out string
+	}{
+		{in: 0, out: "0s"},
+		{in: 150 * time.Millisecond, out: "150ms"},
+		{in: 20 * time.Microsecond, out: "20µs"},
+		{in: 5 * time.Nanosecond, out: "5ns"},
+		{in: 90 * time.Second, out: "1m30s"},
+		{in: 26*time.Hour + 30*time.Minute, out: "1d2h"},
+		{in: 24*time.Hour + 5*time.Second, out: "1d"},
+		{in: 15 * 24 * time.Hour, out: "2w1d"},
+		{in: -90 * time.Second, out: "-1m30s"},
+		{in: -150 * time.Millisecond, out: "-150ms"},
+		{in: math.MinInt64, out: "-15250w1d"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, FormatDurationShort(test.in))
+	}
+}
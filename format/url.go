@@ -0,0 +1,200 @@
+//
+// URL canonicalization for cache keys and deduplication
+//
+
+package format
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidURL = errors.New("format: invalid url")
+	ErrUnsafePath = errors.New("format: unsafe path element")
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+//
+// Normalize URL: lowercase scheme and host, strip default port and
+// fragment, resolve dot segments, sort query parameters by key
+//
+
+func CanonicalURL(input string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(input))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Opaque != "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, input)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	// Work on the escaped form, "%2F" is not the same as "/"
+	escaped := removeDotSegments(normalizeEscapes(u.EscapedPath()))
+	if escaped == "" {
+		escaped = "/"
+	}
+
+	u.Path, err = url.PathUnescape(escaped)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, input)
+	}
+
+	u.RawPath = escaped
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.RawQuery != "" {
+		query, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidURL, input)
+		}
+
+		u.RawQuery = query.Encode()
+	}
+
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+//
+// Append path elements to base URL, each element may hold several "/"
+// separated segments, dot segments are rejected so base cannot be escaped
+//
+
+func JoinURL(base string, elems ...string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, base)
+	}
+
+	var segments []string
+	for _, elem := range elems {
+		for _, segment := range strings.Split(elem, "/") {
+			if segment == "." || segment == ".." {
+				return "", fmt.Errorf("%w: %q", ErrUnsafePath, elem)
+			}
+
+			if segment != "" {
+				segments = append(segments, url.PathEscape(segment))
+			}
+		}
+	}
+
+	if len(segments) == 0 {
+		return u.String(), nil
+	}
+
+	escaped := strings.TrimRight(u.EscapedPath(), "/") + "/" + strings.Join(segments, "/")
+
+	// Keep trailing slash of last element, e.g. for directory listings
+	if last := elems[len(elems)-1]; strings.HasSuffix(last, "/") {
+		escaped += "/"
+	}
+
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, base)
+	}
+
+	u.Path = path
+	u.RawPath = escaped
+
+	return u.String(), nil
+}
+
+//
+// Upper case percent escapes, decoding those of unreserved characters
+//
+
+func normalizeEscapes(input string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(input); i++ {
+		if input[i] != '%' || i+2 >= len(input) {
+			sb.WriteByte(input[i])
+			continue
+		}
+
+		b, err := strconv.ParseUint(input[i+1:i+3], 16, 8)
+		if err != nil {
+			sb.WriteByte(input[i])
+			continue
+		}
+
+		c := byte(b)
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("-._~", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteString(strings.ToUpper(input[i : i+3]))
+		}
+
+		i += 2
+	}
+
+	return sb.String()
+}
+
+//
+// RFC 3986 section 5.2.4, unlike path.Clean keeps trailing and double slashes
+//
+
+func removeDotSegments(input string) string {
+	var output []string
+	segments := strings.Split(input, "/")
+
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+
+			if last {
+				output = append(output, "")
+			}
+
+		default:
+			output = append(output, segment)
+		}
+	}
+
+	result := strings.Join(output, "/")
+	if strings.HasPrefix(input, "/") && !strings.HasPrefix(result, "/") {
+		result = "/" + result
+	}
+
+	return result
+}
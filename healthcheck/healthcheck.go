@@ -0,0 +1,233 @@
+//
+// Liveness and readiness checks exposed over HTTP
+//
+
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Check func(ctx context.Context) error
+
+type Registry struct {
+	defaultTimeout time.Duration
+	mu             sync.RWMutex
+	checks         []*CheckOpts
+}
+
+type Opts struct {
+	DefaultTimeout time.Duration
+}
+
+type CheckOpts struct {
+	Name     string
+	Check    Check
+	Timeout  time.Duration
+	Liveness bool
+	Optional bool
+}
+
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+const (
+	StatusOk   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+var DefaultOpts = &Opts{
+	DefaultTimeout: 5 * time.Second,
+}
+
+//
+// Initialize new registry
+//
+
+func New() *Registry {
+	return NewWithOpts(DefaultOpts)
+}
+
+func NewWithOpts(opts *Opts) *Registry {
+	if opts.DefaultTimeout == 0 {
+		opts.DefaultTimeout = DefaultOpts.DefaultTimeout
+	}
+
+	return &Registry{
+		defaultTimeout: opts.DefaultTimeout,
+	}
+}
+
+//
+// Register readiness check with default opts
+//
+
+func (r *Registry) Register(name string, check Check) {
+	r.RegisterWithOpts(&CheckOpts{
+		Name:  name,
+		Check: check,
+	})
+}
+
+//
+// Register check with opts, liveness checks are also part of readiness
+//
+
+func (r *Registry) RegisterWithOpts(opts *CheckOpts) {
+	if opts.Timeout == 0 {
+		opts.Timeout = r.defaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Replace existing check with same name
+	for i, check := range r.checks {
+		if check.Name == opts.Name {
+			r.checks[i] = opts
+			return
+		}
+	}
+
+	r.checks = append(r.checks, opts)
+}
+
+//
+// Run checks in parallel, each bounded by its timeout
+//
+
+func (r *Registry) Run(ctx context.Context, livenessOnly bool) *Report {
+	r.mu.RLock()
+	var checks []*CheckOpts
+	for _, check := range r.checks {
+		if !livenessOnly || check.Liveness {
+			checks = append(checks, check)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := &Report{
+		Status: StatusOk,
+		Checks: make(map[string]*Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, check := range checks {
+		wg.Add(1)
+
+		go func(check *CheckOpts) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = result
+
+			switch {
+			case result.Status == StatusFail:
+				report.Status = StatusFail
+			case result.Status == StatusWarn && report.Status == StatusOk:
+				report.Status = StatusWarn
+			}
+		}(check)
+	}
+
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, check *CheckOpts) *Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errc <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		errc <- check.Check(ctx)
+	}()
+
+	// Stop waiting on checks ignoring their context
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &Result{
+		Status:     StatusOk,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+
+		if check.Optional {
+			result.Status = StatusWarn
+		}
+	}
+
+	return result
+}
+
+//
+// HTTP handlers, /healthz runs liveness checks and /readyz runs all checks
+//
+
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", r.LivenessHandler())
+	mux.Handle("/readyz", r.ReadinessHandler())
+
+	return mux
+}
+
+func (r *Registry) LivenessHandler() http.Handler {
+	return r.handler(true)
+}
+
+func (r *Registry) ReadinessHandler() http.Handler {
+	return r.handler(false)
+}
+
+func (r *Registry) handler(livenessOnly bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), livenessOnly)
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,132 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryRun(t *testing.T) {
+	registry := New()
+	registry.Register("db", func(ctx context.Context) error {
+		return nil
+	})
+
+	registry.RegisterWithOpts(&CheckOpts{
+		Name:     "process",
+		Liveness: true,
+		Check: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	report := registry.Run(context.Background(), false)
+	assert.Equal(t, StatusOk, report.Status)
+	assert.Len(t, report.Checks, 2)
+
+	report = registry.Run(context.Background(), true)
+	assert.Len(t, report.Checks, 1)
+	assert.Contains(t, report.Checks, "process")
+}
+
+func TestRegistryFailures(t *testing.T) {
+	registry := New()
+	registry.RegisterWithOpts(&CheckOpts{
+		Name:     "cache",
+		Optional: true,
+		Check: func(ctx context.Context) error {
+			return errors.New("cold")
+		},
+	})
+
+	report := registry.Run(context.Background(), false)
+	assert.Equal(t, StatusWarn, report.Status)
+	assert.Equal(t, "cold", report.Checks["cache"].Error)
+
+	registry.Register("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	report = registry.Run(context.Background(), false)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, StatusFail, report.Checks["db"].Status)
+}
+
+func TestRegistryTimeoutAndPanic(t *testing.T) {
+	registry := New()
+	registry.RegisterWithOpts(&CheckOpts{
+		Name:    "slow",
+		Timeout: 20 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	registry.Register("broken", func(ctx context.Context) error {
+		panic("oops")
+	})
+
+	start := time.Now()
+	report := registry.Run(context.Background(), false)
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	assert.Equal(t, "panic: oops", report.Checks["broken"].Error)
+}
+
+func TestRegistryReplace(t *testing.T) {
+	registry := New()
+	registry.Register("db", func(ctx context.Context) error {
+		return errors.New("down")
+	})
+
+	registry.Register("db", func(ctx context.Context) error {
+		return nil
+	})
+
+	report := registry.Run(context.Background(), false)
+	assert.Equal(t, StatusOk, report.Status)
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := New()
+	registry.RegisterWithOpts(&CheckOpts{
+		Name:     "process",
+		Liveness: true,
+		Check: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	registry.Register("db", func(ctx context.Context) error {
+		return errors.New("down")
+	})
+
+	server := httptest.NewServer(registry.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/readyz")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var report Report
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "down", report.Checks["db"].Error)
+}
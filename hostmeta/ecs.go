@@ -0,0 +1,72 @@
+//
+// ECS task metadata endpoint v4
+//
+
+package hostmeta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+)
+
+type ecsTask struct {
+	Cluster          string            `json:"Cluster"`
+	TaskARN          string            `json:"TaskARN"`
+	AvailabilityZone string            `json:"AvailabilityZone"`
+	TaskTags         map[string]string `json:"TaskTags"`
+}
+
+//
+// Task identity, region and account are taken from the task ARN
+//
+
+func (c *Client) ecsMetadata(ctx context.Context) (*Metadata, error) {
+	endpoint := strings.TrimRight(c.opts.EcsEndpoint, "/")
+
+	// Tags need extra task role permissions, fall back to the plain task
+	body, err := c.fetch(ctx, http.MethodGet, endpoint+"/taskWithTags", nil)
+	if err != nil {
+		body, err = c.fetch(ctx, http.MethodGet, endpoint+"/task", nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var task ecsTask
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, err
+	}
+
+	arn, err := format.ParseArn(task.TaskARN)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterArn := task.Cluster
+	if !strings.HasPrefix(clusterArn, "arn:") {
+		clusterArn, err = format.EcsClusterArn(arn.Region, arn.AccountId, task.Cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := task.TaskTags
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+
+	return &Metadata{
+		Environment:      EnvironmentECS,
+		Region:           arn.Region,
+		AvailabilityZone: task.AvailabilityZone,
+		AccountId:        arn.AccountId,
+		ClusterArn:       clusterArn,
+		TaskArn:          task.TaskARN,
+		Tags:             tags,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package hostmeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newEcsServer(t *testing.T, withTags bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v4/abc/taskWithTags" && withTags:
+			w.Write([]byte(`{"Cluster":"arn:aws:ecs:us-west-2:123456789012:cluster/web","TaskARN":"arn:aws:ecs:us-west-2:123456789012:task/web/0123456789abcdef0123456789abcdef","AvailabilityZone":"us-west-2b","TaskTags":{"team":"platform"}}`))
+
+		case r.URL.Path == "/v4/abc/task":
+			w.Write([]byte(`{"Cluster":"web","TaskARN":"arn:aws:ecs:us-west-2:123456789012:task/web/0123456789abcdef0123456789abcdef","AvailabilityZone":"us-west-2b"}`))
+
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEcsMetadata(t *testing.T) {
+	server := newEcsServer(t, true)
+
+	client := NewWithOpts(&Opts{
+		EcsEndpoint: server.URL + "/v4/abc",
+	})
+
+	meta, err := client.ecsMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		Environment:      EnvironmentECS,
+		Region:           "us-west-2",
+		AvailabilityZone: "us-west-2b",
+		AccountId:        "123456789012",
+		ClusterArn:       "arn:aws:ecs:us-west-2:123456789012:cluster/web",
+		TaskArn:          "arn:aws:ecs:us-west-2:123456789012:task/web/0123456789abcdef0123456789abcdef",
+		Tags: map[string]string{
+			"team": "platform",
+		},
+	}, meta)
+}
+
+func TestEcsMetadataWithoutTags(t *testing.T) {
+	server := newEcsServer(t, false)
+
+	client := NewWithOpts(&Opts{
+		EcsEndpoint: server.URL + "/v4/abc/",
+	})
+
+	meta, err := client.ecsMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-west-2:123456789012:cluster/web", meta.ClusterArn)
+	assert.Equal(t, map[string]string{}, meta.Tags)
+}
@@ -0,0 +1,230 @@
+//
+// Runtime environment detection and instance metadata for EC2, ECS and Kubernetes
+//
+
+package hostmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+)
+
+type Environment string
+
+const (
+	EnvironmentUnknown    Environment = ""
+	EnvironmentEC2        Environment = "ec2"
+	EnvironmentECS        Environment = "ecs"
+	EnvironmentKubernetes Environment = "kubernetes"
+)
+
+type Metadata struct {
+	Environment      Environment
+	InstanceId       string
+	Region           string
+	AvailabilityZone string
+	AccountId        string
+	ClusterArn       string
+	TaskArn          string
+	PodName          string
+	Namespace        string
+	NodeName         string
+	Tags             map[string]string
+}
+
+type Client struct {
+	opts  *Opts
+	cache *cache.Cache[*Metadata]
+}
+
+type Opts struct {
+	HTTPClient *http.Client
+
+	// Per metadata request, and for a whole detection shared by concurrent
+	// callers and detached from their contexts
+	Timeout       time.Duration
+	DetectTimeout time.Duration
+
+	TTL            time.Duration
+	ImdsEndpoint   string
+	EcsEndpoint    string
+	DownwardAPIDir string
+	DisableImds    bool
+}
+
+var DefaultOpts = &Opts{
+	Timeout:        2 * time.Second,
+	DetectTimeout:  10 * time.Second,
+	TTL:            time.Hour,
+	ImdsEndpoint:   "http://169.254.169.254",
+	DownwardAPIDir: "/etc/podinfo",
+}
+
+var (
+	ErrNotDetected = errors.New("hostmeta: no known runtime environment detected")
+	ErrStatus      = errors.New("hostmeta: unexpected status")
+)
+
+const metadataKey = "metadata"
+
+//
+// Initialize new client
+//
+
+func New() *Client {
+	return NewWithOpts(&Opts{})
+}
+
+func NewWithOpts(opts *Opts) *Client {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOpts.Timeout
+	}
+
+	if opts.DetectTimeout == 0 {
+		opts.DetectTimeout = DefaultOpts.DetectTimeout
+	}
+
+	if opts.TTL == 0 {
+		opts.TTL = DefaultOpts.TTL
+	}
+
+	if opts.ImdsEndpoint == "" {
+		opts.ImdsEndpoint = DefaultOpts.ImdsEndpoint
+	}
+
+	if opts.EcsEndpoint == "" {
+		opts.EcsEndpoint = os.Getenv("ECS_CONTAINER_METADATA_URI_V4")
+	}
+
+	if opts.DownwardAPIDir == "" {
+		opts.DownwardAPIDir = DefaultOpts.DownwardAPIDir
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
+			Timeout: opts.Timeout,
+		}
+	}
+
+	return &Client{
+		opts:  opts,
+		cache: cache.New[*Metadata](),
+	}
+}
+
+//
+// Metadata for the current environment, refreshed in the background after
+// TTL, failed detection is retried on the next call, callers stop waiting
+// when their context is done
+//
+
+func (c *Client) Get(ctx context.Context) (*Metadata, error) {
+	type result struct {
+		meta *Metadata
+		err  error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		meta, err := c.cache.GetWithOpts(&cache.GetOpts[*Metadata]{
+			Key:   metadataKey,
+			TTL:   c.opts.TTL.Nanoseconds(),
+			Grace: c.opts.TTL.Nanoseconds(),
+			Generator: func() (*Metadata, error) {
+				// Background refreshes outlive the request that triggered them
+				detectCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.DetectTimeout)
+				defer cancel()
+
+				return c.detect(detectCtx)
+			},
+		})
+
+		done <- result{meta: meta, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.meta, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//
+// Detected environment, EnvironmentUnknown when nothing was found
+//
+
+func (c *Client) Environment(ctx context.Context) Environment {
+	meta, err := c.Get(ctx)
+	if err != nil {
+		return EnvironmentUnknown
+	}
+
+	return meta.Environment
+}
+
+//
+// Most specific environment first, ECS and EKS usually run on EC2 as well
+//
+
+func (c *Client) detect(ctx context.Context) (*Metadata, error) {
+	if c.opts.EcsEndpoint != "" {
+		return c.ecsMetadata(ctx)
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return c.kubernetesMetadata(ctx)
+	}
+
+	if c.opts.DisableImds {
+		return nil, ErrNotDetected
+	}
+
+	meta, err := c.imdsMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotDetected, err)
+	}
+
+	return meta, nil
+}
+
+//
+// Shared request helper, returns body of successful responses
+//
+
+func (c *Client) fetch(ctx context.Context, method string, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w %d from %s", ErrStatus, resp.StatusCode, url)
+	}
+
+	return body, nil
+}
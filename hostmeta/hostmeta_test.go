@@ -0,0 +1,108 @@
+package hostmeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clearEnvironment(t *testing.T) {
+	t.Setenv("ECS_CONTAINER_METADATA_URI_V4", "")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+}
+
+func TestDetectEC2(t *testing.T) {
+	clearEnvironment(t)
+	server, requests := newImdsServer(t, nil)
+
+	client := NewWithOpts(&Opts{
+		ImdsEndpoint: server.URL,
+	})
+
+	meta, err := client.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, EnvironmentEC2, meta.Environment)
+	assert.Equal(t, "eu-north-1", meta.Region)
+
+	// Cached
+	count := atomic.LoadInt32(requests)
+	_, err = client.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, count, atomic.LoadInt32(requests))
+}
+
+func TestDetectECS(t *testing.T) {
+	clearEnvironment(t)
+	server := newEcsServer(t, true)
+	t.Setenv("ECS_CONTAINER_METADATA_URI_V4", server.URL+"/v4/abc")
+
+	client := New()
+	assert.Equal(t, EnvironmentECS, client.Environment(context.Background()))
+}
+
+func TestDetectKubernetes(t *testing.T) {
+	clearEnvironment(t)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+
+	client := NewWithOpts(&Opts{
+		DownwardAPIDir: t.TempDir(),
+		DisableImds:    true,
+	})
+
+	assert.Equal(t, EnvironmentKubernetes, client.Environment(context.Background()))
+}
+
+func TestDetectNothing(t *testing.T) {
+	clearEnvironment(t)
+
+	client := NewWithOpts(&Opts{
+		DisableImds: true,
+	})
+
+	_, err := client.Get(context.Background())
+	assert.ErrorIs(t, err, ErrNotDetected)
+	assert.Equal(t, EnvironmentUnknown, client.Environment(context.Background()))
+
+	// Unreachable metadata service
+	client = NewWithOpts(&Opts{
+		ImdsEndpoint: "http://127.0.0.1:1",
+	})
+
+	_, err = client.Get(context.Background())
+	assert.ErrorIs(t, err, ErrNotDetected)
+}
+
+func TestDetectCallerDeadline(t *testing.T) {
+	clearEnvironment(t)
+
+	// Metadata service that never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := NewWithOpts(&Opts{
+		ImdsEndpoint:  server.URL,
+		Timeout:       5 * time.Second,
+		DetectTimeout: 100 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// Detection itself gives up after its own timeout
+	_, err = client.Get(context.Background())
+	assert.ErrorIs(t, err, ErrNotDetected)
+	assert.Less(t, time.Since(start), time.Second)
+}
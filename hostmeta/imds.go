@@ -0,0 +1,96 @@
+//
+// EC2 instance metadata service, IMDSv2 session tokens only
+//
+
+package hostmeta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type identityDocument struct {
+	InstanceId       string `json:"instanceId"`
+	Region           string `json:"region"`
+	AvailabilityZone string `json:"availabilityZone"`
+	AccountId        string `json:"accountId"`
+}
+
+const imdsTokenTTL = "21600"
+
+//
+// Instance identity and tags, tags are empty unless exposed in metadata
+//
+
+func (c *Client) imdsMetadata(ctx context.Context) (*Metadata, error) {
+	token, err := c.fetch(ctx, http.MethodPut, c.opts.ImdsEndpoint+"/latest/api/token", http.Header{
+		"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {imdsTokenTTL},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{
+		"X-Aws-Ec2-Metadata-Token": {string(token)},
+	}
+
+	body, err := c.fetch(ctx, http.MethodGet, c.opts.ImdsEndpoint+"/latest/dynamic/instance-identity/document", header)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc identityDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	tags, err := c.imdsTags(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metadata{
+		Environment:      EnvironmentEC2,
+		InstanceId:       doc.InstanceId,
+		Region:           doc.Region,
+		AvailabilityZone: doc.AvailabilityZone,
+		AccountId:        doc.AccountId,
+		Tags:             tags,
+	}, nil
+}
+
+func (c *Client) imdsTags(ctx context.Context, header http.Header) (map[string]string, error) {
+	tags := make(map[string]string)
+	base := c.opts.ImdsEndpoint + "/latest/meta-data/tags/instance"
+
+	body, err := c.fetch(ctx, http.MethodGet, base, header)
+
+	// Tags in metadata are opt-in per instance
+	if errors.Is(err, ErrStatus) {
+		return tags, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		if key == "" {
+			continue
+		}
+
+		value, err := c.fetch(ctx, http.MethodGet, base+"/"+url.PathEscape(key), header)
+		if err != nil {
+			return nil, err
+		}
+
+		tags[key] = string(value)
+	}
+
+	return tags, nil
+}
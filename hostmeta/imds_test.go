@@ -0,0 +1,103 @@
+package hostmeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testImdsToken = "test-token"
+
+func newImdsServer(t *testing.T, tags map[string]string) (*httptest.Server, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.URL.Path == "/latest/api/token" {
+			if r.Method != http.MethodPut || r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			w.Write([]byte(testImdsToken))
+			return
+		}
+
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token") != testImdsToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/latest/dynamic/instance-identity/document":
+			w.Write([]byte(`{"instanceId":"i-0123456789abcdef0","region":"eu-north-1","availabilityZone":"eu-north-1a","accountId":"123456789012"}`))
+
+		case r.URL.Path == "/latest/meta-data/tags/instance" && tags != nil:
+			var keys []string
+			for k := range tags {
+				keys = append(keys, k)
+			}
+
+			w.Write([]byte(strings.Join(keys, "\n")))
+
+		case strings.HasPrefix(r.URL.Path, "/latest/meta-data/tags/instance/") && tags != nil:
+			value, ok := tags[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/tags/instance/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Write([]byte(value))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestImdsMetadata(t *testing.T) {
+	server, _ := newImdsServer(t, map[string]string{
+		"Name":        "web-1",
+		"Environment": "production",
+	})
+
+	client := NewWithOpts(&Opts{
+		ImdsEndpoint: server.URL,
+	})
+
+	meta, err := client.imdsMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		Environment:      EnvironmentEC2,
+		InstanceId:       "i-0123456789abcdef0",
+		Region:           "eu-north-1",
+		AvailabilityZone: "eu-north-1a",
+		AccountId:        "123456789012",
+		Tags: map[string]string{
+			"Name":        "web-1",
+			"Environment": "production",
+		},
+	}, meta)
+}
+
+func TestImdsMetadataWithoutTags(t *testing.T) {
+	server, _ := newImdsServer(t, nil)
+
+	client := NewWithOpts(&Opts{
+		ImdsEndpoint: server.URL,
+	})
+
+	meta, err := client.imdsMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "i-0123456789abcdef0", meta.InstanceId)
+	assert.Equal(t, map[string]string{}, meta.Tags)
+}
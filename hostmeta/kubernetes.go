@@ -0,0 +1,98 @@
+//
+// Kubernetes pods, identity from downward API env vars and labels file
+//
+
+package hostmeta
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//
+// Pod identity, enriched with EC2 identity when running on EKS nodes
+//
+
+func (c *Client) kubernetesMetadata(ctx context.Context) (*Metadata, error) {
+	meta := &Metadata{
+		Environment: EnvironmentKubernetes,
+		PodName:     os.Getenv("POD_NAME"),
+		Namespace:   os.Getenv("POD_NAMESPACE"),
+		NodeName:    os.Getenv("NODE_NAME"),
+		Tags:        make(map[string]string),
+	}
+
+	if meta.PodName == "" {
+		meta.PodName, _ = os.Hostname()
+	}
+
+	// Service account mount knows the namespace when env vars are not set
+	if meta.Namespace == "" {
+		data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err == nil {
+			meta.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	labels, err := readDownwardAPIMap(filepath.Join(c.opts.DownwardAPIDir, "labels"))
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Tags = labels
+
+	// Node metadata is best effort, IMDS is often blocked for pods
+	if !c.opts.DisableImds {
+		if node, err := c.imdsMetadata(ctx); err == nil {
+			meta.InstanceId = node.InstanceId
+			meta.Region = node.Region
+			meta.AvailabilityZone = node.AvailabilityZone
+			meta.AccountId = node.AccountId
+		}
+	}
+
+	if meta.Region == "" {
+		meta.Region = os.Getenv("AWS_REGION")
+	}
+
+	return meta, nil
+}
+
+//
+// Parse downward API map file of key="quoted value" lines, missing is empty
+//
+
+func readDownwardAPIMap(path string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		result[key] = value
+	}
+
+	return result, scanner.Err()
+}
@@ -0,0 +1,62 @@
+package hostmeta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubernetesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	labels := "app=\"web\"\npod-template-hash=\"5d4f8c7b9\"\nquoted=\"a \\\"b\\\"\"\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "labels"), []byte(labels), 0o644))
+
+	server, _ := newImdsServer(t, nil)
+
+	t.Setenv("POD_NAME", "web-5d4f8c7b9-x2x7k")
+	t.Setenv("POD_NAMESPACE", "default")
+	t.Setenv("NODE_NAME", "ip-10-0-0-1.eu-north-1.compute.internal")
+
+	client := NewWithOpts(&Opts{
+		ImdsEndpoint:   server.URL,
+		DownwardAPIDir: dir,
+	})
+
+	meta, err := client.kubernetesMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		Environment:      EnvironmentKubernetes,
+		InstanceId:       "i-0123456789abcdef0",
+		Region:           "eu-north-1",
+		AvailabilityZone: "eu-north-1a",
+		AccountId:        "123456789012",
+		PodName:          "web-5d4f8c7b9-x2x7k",
+		Namespace:        "default",
+		NodeName:         "ip-10-0-0-1.eu-north-1.compute.internal",
+		Tags: map[string]string{
+			"app":               "web",
+			"pod-template-hash": "5d4f8c7b9",
+			"quoted":            `a "b"`,
+		},
+	}, meta)
+}
+
+func TestKubernetesMetadataWithoutImds(t *testing.T) {
+	t.Setenv("POD_NAME", "web")
+	t.Setenv("POD_NAMESPACE", "default")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	client := NewWithOpts(&Opts{
+		DownwardAPIDir: t.TempDir(),
+		DisableImds:    true,
+	})
+
+	meta, err := client.kubernetesMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", meta.Region)
+	assert.Equal(t, "", meta.InstanceId)
+	assert.Equal(t, map[string]string{}, meta.Tags)
+}
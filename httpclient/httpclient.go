@@ -0,0 +1,308 @@
+//
+// Resilient HTTP client with retries and backoff
+//
+
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+type Opts struct {
+	MaxAttempts        int
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+	Multiplier         float64
+	Jitter             float64
+	AttemptTimeout     time.Duration
+	MaxRetryAfter      time.Duration
+	RetryStatuses      []int
+	RetryNonIdempotent bool
+	Transport          http.RoundTripper
+}
+
+type Transport struct {
+	opts          *Opts
+	retryStatuses map[int]bool
+}
+
+var DefaultOpts = &Opts{
+	MaxAttempts:    4,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	AttemptTimeout: 30 * time.Second,
+	MaxRetryAfter:  time.Minute,
+	RetryStatuses: []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+var errRetryStatus = errors.New("httpclient: retryable status")
+
+var transientErrnos = []syscall.Errno{
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.EPIPE,
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+//
+// Initialize new client instance
+//
+
+func New() *http.Client {
+	return NewWithOpts(DefaultOpts)
+}
+
+func NewWithOpts(opts *Opts) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(opts),
+	}
+}
+
+//
+// Initialize retrying round tripper
+//
+
+func NewTransport(opts *Opts) *Transport {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 1
+	}
+
+	retryStatuses := make(map[int]bool)
+	for _, status := range opts.RetryStatuses {
+		retryStatuses[status] = true
+	}
+
+	return &Transport{
+		opts:          opts,
+		retryStatuses: retryStatuses,
+	}
+}
+
+//
+// Send request, retrying transient failures
+//
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxAttempts := t.opts.MaxAttempts
+	if !t.canRetry(req) {
+		maxAttempts = 1
+	}
+
+	var resp *http.Response
+	attempt := 0
+
+	err := retry.Do(req.Context(), func(ctx context.Context) error {
+		// Release connection of the previous failed attempt
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+			resp = nil
+		}
+
+		attempt++
+
+		var err error
+		resp, err = t.roundTripOnce(req, attempt)
+		if err != nil {
+			return err
+		}
+
+		if !t.retryStatuses[resp.StatusCode] {
+			return nil
+		}
+
+		// Honor server supplied delay, unless it exceeds what we are willing to wait
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if t.opts.MaxRetryAfter > 0 && delay > t.opts.MaxRetryAfter {
+				return retry.Permanent(errRetryStatus)
+			}
+
+			return retry.After(errRetryStatus, delay)
+		}
+
+		return errRetryStatus
+	}, retry.WithPolicy(t.policy(maxAttempts)), retry.WithRetryIf(t.isRetryable))
+
+	// Final response with retryable status is handed to the caller as is
+	if errors.Is(err, errRetryStatus) {
+		return resp, nil
+	}
+
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+//
+// Single attempt with its own timeout, cancelled when the body is closed
+//
+
+func (t *Transport) roundTripOnce(req *http.Request, attempt int) (*http.Response, error) {
+	attemptReq := req
+
+	// NoBody without GetBody is retryable too, there is nothing to replay
+	if attempt > 1 && req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		attemptReq = req.Clone(req.Context())
+		attemptReq.Body = body
+	}
+
+	if t.opts.AttemptTimeout <= 0 {
+		return t.opts.Transport.RoundTrip(attemptReq)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.opts.AttemptTimeout)
+	resp, err := t.opts.Transport.RoundTrip(attemptReq.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+//
+// Only idempotent requests with replayable bodies are retried by default
+//
+
+func (t *Transport) canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if t.opts.RetryNonIdempotent || idempotentMethods[req.Method] {
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+//
+// Only transient network failures are retried, bad URLs, unsupported
+// schemes and TLS errors won't go away on the next attempt
+//
+
+func (t *Transport) isRetryable(err error) bool {
+	if errors.Is(err, errRetryStatus) {
+		return true
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+
+	// Attempt timeout, the request context is checked by retry.Do
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Connection closed by the server before or while sending the response
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Delay before the next attempt, after n failed attempts
+//
+
+func (t *Transport) Backoff(n int) time.Duration {
+	policy := t.policy(t.opts.MaxAttempts)
+	return policy.Backoff(n)
+}
+
+func (t *Transport) policy(maxAttempts int) retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: t.opts.InitialBackoff,
+		MaxBackoff:     t.opts.MaxBackoff,
+		Multiplier:     t.opts.Multiplier,
+		Jitter:         t.opts.Jitter,
+	}
+}
+
+//
+// Retry-After is either delay seconds or an HTTP date
+//
+
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,256 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOpts() *Opts {
+	return &Opts{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		RetryStatuses:  DefaultOpts.RetryStatuses,
+	}
+}
+
+func TestClientRetryStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+
+	defer server.Close()
+
+	resp, err := NewWithOpts(testOpts()).Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClientGiveUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	defer server.Close()
+
+	resp, err := NewWithOpts(testOpts()).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClientNoRetryNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	defer server.Close()
+
+	client := NewWithOpts(testOpts())
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("data"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Idempotency key opts in
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("data"))
+	req.Header.Set("Idempotency-Key", "abc")
+
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestClientReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	resp, err := NewWithOpts(testOpts()).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestClientRetryNoBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Body = http.NoBody
+	req.GetBody = nil
+
+	resp, err := NewWithOpts(testOpts()).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientRetryErrors(t *testing.T) {
+	tests := []struct {
+		err   error
+		calls int32
+	}{
+		{err: syscall.ECONNREFUSED, calls: 3},
+		{err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, calls: 3},
+		{err: io.ErrUnexpectedEOF, calls: 3},
+		{err: context.DeadlineExceeded, calls: 3},
+		{err: errors.New("unsupported protocol scheme \"ftp\""), calls: 1},
+		{err: &net.AddrError{Err: "missing port in address", Addr: "host"}, calls: 1},
+		{err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, calls: 1},
+	}
+
+	for _, test := range tests {
+		var calls atomic.Int32
+
+		opts := testOpts()
+		opts.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return nil, test.err
+		})
+
+		_, err := NewWithOpts(opts).Get("http://example.invalid/")
+		assert.Error(t, err, test.err.Error())
+		assert.Equal(t, test.calls, calls.Load(), test.err.Error())
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+	}))
+
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := NewWithOpts(testOpts()).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// Refuse to wait beyond the cap
+	calls.Store(0)
+	opts := testOpts()
+	opts.MaxRetryAfter = 100 * time.Millisecond
+
+	resp, err = NewWithOpts(opts).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+func TestClientAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		w.Write([]byte("ok"))
+	}))
+
+	defer server.Close()
+
+	opts := testOpts()
+	opts.AttemptTimeout = 50 * time.Millisecond
+
+	resp, err := NewWithOpts(opts).Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	delay, ok := parseRetryAfter("120", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	delay, ok = parseRetryAfter(now.Add(-time.Hour).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	transport := NewTransport(&Opts{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	})
+
+	assert.Equal(t, 100*time.Millisecond, transport.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, transport.Backoff(2))
+	assert.Equal(t, 300*time.Millisecond, transport.Backoff(3))
+}
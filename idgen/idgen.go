@@ -0,0 +1,154 @@
+//
+// Sortable unique IDs (ULID, KSUID) and short random slugs
+//
+
+package idgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type Generator struct {
+	entropy   io.Reader
+	clock     func() time.Time
+	monotonic bool
+	mu        sync.Mutex
+	ulid      monotonicState
+	ksuid     monotonicState
+}
+
+type Opts struct {
+	// Random source, crypto/rand when nil
+	Entropy io.Reader
+
+	// Time source, time.Now when nil
+	Clock func() time.Time
+
+	// IDs from the same millisecond (ULID) or second (KSUID) increment the
+	// previous random part instead of drawing a new one, so they sort in
+	// generation order
+	Monotonic bool
+}
+
+// Last timestamp and random part handed out
+type monotonicState struct {
+	timestamp int64
+	random    []byte
+}
+
+var DefaultOpts = &Opts{
+	Entropy: rand.Reader,
+	Clock:   time.Now,
+}
+
+var (
+	ErrInvalidID         = errors.New("idgen: invalid id")
+	ErrMonotonicOverflow = errors.New("idgen: monotonic random part exhausted")
+)
+
+// Used by the package level functions
+var defaultGenerator = NewWithOpts(&Opts{Monotonic: true})
+
+//
+// Initialize new generator
+//
+
+func New() *Generator {
+	return NewWithOpts(&Opts{})
+}
+
+func NewWithOpts(opts *Opts) *Generator {
+	if opts.Entropy == nil {
+		opts.Entropy = DefaultOpts.Entropy
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = DefaultOpts.Clock
+	}
+
+	return &Generator{
+		entropy:   opts.Entropy,
+		clock:     opts.Clock,
+		monotonic: opts.Monotonic,
+	}
+}
+
+//
+// Package level IDs from a monotonic generator reading crypto/rand, which
+// does not fail, so errors panic
+//
+
+func ULID() string {
+	return must(defaultGenerator.ULID())
+}
+
+func KSUID() string {
+	return must(defaultGenerator.KSUID())
+}
+
+//
+// Package level slug, fails with ErrInvalidID for lengths outside 1..64
+//
+
+func Slug(n int) (string, error) {
+	return defaultGenerator.Slug(n)
+}
+
+func must(id string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+//
+// Fill random part and return the timestamp to encode, continuing from the
+// previous ID in monotonic mode
+//
+
+func (g *Generator) next(state *monotonicState, now int64, buf []byte) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Clocks going backwards keep the last timestamp, so order holds
+	if g.monotonic && state.random != nil && now <= state.timestamp {
+		random := append([]byte(nil), state.random...)
+		if !increment(random) {
+			return 0, ErrMonotonicOverflow
+		}
+
+		state.random = random
+		copy(buf, random)
+
+		return state.timestamp, nil
+	}
+
+	if _, err := io.ReadFull(g.entropy, buf); err != nil {
+		return 0, fmt.Errorf("idgen: read entropy: %w", err)
+	}
+
+	if g.monotonic {
+		state.timestamp = now
+		state.random = append(state.random[:0], buf...)
+	}
+
+	return now, nil
+}
+
+// Big endian increment, false on overflow
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package idgen
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time {
+		return t
+	}
+}
+
+func TestMonotonic(t *testing.T) {
+	now := time.UnixMilli(1760000000000)
+	g := NewWithOpts(&Opts{Clock: fixedClock(now), Monotonic: true})
+
+	ids := make([]string, 1000)
+	for i := range ids {
+		id, err := g.ULID()
+		assert.NoError(t, err)
+		ids[i] = id
+	}
+
+	assert.True(t, sort.StringsAreSorted(ids))
+	assert.NotEqual(t, ids[0], ids[1])
+
+	// A clock going backwards keeps the last timestamp
+	g.clock = fixedClock(now.Add(-time.Second))
+	id, err := g.ULID()
+	assert.NoError(t, err)
+	assert.Greater(t, id, ids[len(ids)-1])
+}
+
+func TestMonotonicOverflow(t *testing.T) {
+	g := NewWithOpts(&Opts{
+		Entropy:   bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)),
+		Clock:     fixedClock(time.UnixMilli(1760000000000)),
+		Monotonic: true,
+	})
+
+	_, err := g.ULID()
+	assert.NoError(t, err)
+
+	_, err = g.ULID()
+	assert.ErrorIs(t, err, ErrMonotonicOverflow)
+}
+
+func TestEntropyError(t *testing.T) {
+	g := NewWithOpts(&Opts{Entropy: iotest.ErrReader(errors.New("drained"))})
+
+	_, err := g.ULID()
+	assert.ErrorContains(t, err, "drained")
+
+	_, err = g.KSUID()
+	assert.ErrorContains(t, err, "drained")
+
+	_, err = g.Slug(8)
+	assert.ErrorContains(t, err, "drained")
+}
+
+func TestPackageFunctions(t *testing.T) {
+	assert.Len(t, ULID(), 26)
+	assert.Len(t, KSUID(), 27)
+	assert.NotEqual(t, ULID(), ULID())
+
+	slug, err := Slug(10)
+	assert.NoError(t, err)
+	assert.Len(t, slug, 10)
+
+	// Bad lengths are errors, not panics
+	_, err = Slug(0)
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	_, err = Slug(65)
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
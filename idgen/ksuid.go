@@ -0,0 +1,83 @@
+//
+// KSUIDs, 32 bit second timestamp and 128 random bits in base62, 27
+// characters that sort by creation time
+//
+
+package idgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	ksuidLength = 27
+
+	// Timestamps count seconds from 2014-05-13, as in the reference
+	// implementation
+	ksuidEpoch = 1400000000
+
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+//
+// New KSUID
+//
+
+func (g *Generator) KSUID() (string, error) {
+	var id [20]byte
+
+	timestamp, err := g.next(&g.ksuid, g.clock().Unix()-ksuidEpoch, id[4:])
+	if err != nil {
+		return "", err
+	}
+
+	binary.BigEndian.PutUint32(id[:4], uint32(timestamp))
+
+	// Base62 digits, zero padded to a fixed length
+	n := new(big.Int).SetBytes(id[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	out := []byte(strings.Repeat("0", ksuidLength))
+
+	for pos := ksuidLength - 1; n.Sign() > 0; pos-- {
+		n.DivMod(n, base, mod)
+		out[pos] = base62Alphabet[mod.Int64()]
+	}
+
+	return string(out), nil
+}
+
+//
+// Creation time of KSUID
+//
+
+func KSUIDTime(id string) (time.Time, error) {
+	if len(id) != ksuidLength {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+
+	n := new(big.Int)
+	base := big.NewInt(62)
+
+	for _, c := range id {
+		i := strings.IndexRune(base62Alphabet, c)
+		if i < 0 {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+
+		n.Mul(n, base).Add(n, big.NewInt(int64(i)))
+	}
+
+	if n.BitLen() > 160 {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+
+	var raw [20]byte
+	n.FillBytes(raw[:])
+
+	return time.Unix(int64(binary.BigEndian.Uint32(raw[:4]))+ksuidEpoch, 0), nil
+}
@@ -0,0 +1,48 @@
+package idgen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKSUID(t *testing.T) {
+	g := NewWithOpts(&Opts{
+		Entropy: bytes.NewReader(make([]byte, 16)),
+		Clock:   fixedClock(time.Unix(ksuidEpoch, 0)),
+	})
+
+	id, err := g.KSUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "000000000000000000000000000", id)
+
+	g = NewWithOpts(&Opts{
+		Entropy: bytes.NewReader(bytes.Repeat([]byte{0xff}, 16)),
+		Clock:   fixedClock(time.Unix(ksuidEpoch+0xffffffff, 0)),
+	})
+
+	id, err = g.KSUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "aWgEPTl1tmebfsQzFP4bxwgy80V", id)
+}
+
+func TestKSUIDTime(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	id, err := NewWithOpts(&Opts{Clock: fixedClock(now)}).KSUID()
+	assert.NoError(t, err)
+
+	created, err := KSUIDTime(id)
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(created))
+
+	created, err = KSUIDTime("0ujtsYcgvSTl8PAuAdqWYSMnLOv")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1507608047), created.Unix())
+
+	for _, id := range []string{"", "0ujtsYcgvSTl8PAuAdqWYSMnLO-", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"} {
+		_, err := KSUIDTime(id)
+		assert.ErrorIs(t, err, ErrInvalidID, id)
+	}
+}
@@ -0,0 +1,62 @@
+//
+// Short random slugs for resource names
+//
+
+package idgen
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// Lowercase Crockford base32, no characters that read alike
+	slugAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+	slugLetters  = "abcdefghjkmnpqrstvwxyz"
+
+	maxSlugLength = 64
+)
+
+//
+// Random slug of n characters, 5 bits each, starting with a letter so it is
+// a valid name for resources that reject a leading digit, e.g. 10
+// characters give about 49 bits
+//
+
+func (g *Generator) Slug(n int) (string, error) {
+	if n < 1 || n > maxSlugLength {
+		return "", fmt.Errorf("%w: slug length %d", ErrInvalidID, n)
+	}
+
+	buf := make([]byte, n)
+	if err := g.read(buf); err != nil {
+		return "", err
+	}
+
+	out := make([]byte, n)
+	for i, b := range buf {
+		out[i] = slugAlphabet[b&31]
+	}
+
+	// Rejection sampling keeps the first letter uniform
+	first := buf[:1]
+	for first[0] >= byte(256/len(slugLetters)*len(slugLetters)) {
+		if err := g.read(first); err != nil {
+			return "", err
+		}
+	}
+
+	out[0] = slugLetters[int(first[0])%len(slugLetters)]
+	return string(out), nil
+}
+
+func (g *Generator) read(buf []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, err := io.ReadFull(g.entropy, buf); err != nil {
+		return fmt.Errorf("idgen: read entropy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package idgen
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlug(t *testing.T) {
+	slugRe := regexp.MustCompile(`^[a-hjkmnp-tv-z][0-9a-hjkmnp-tv-z]{9}$`)
+
+	g := New()
+	seen := make(map[string]bool)
+
+	for i := 0; i < 1000; i++ {
+		slug, err := g.Slug(10)
+		assert.NoError(t, err)
+		assert.Regexp(t, slugRe, slug)
+		assert.False(t, seen[slug])
+		seen[slug] = true
+	}
+
+	_, err := g.Slug(0)
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	_, err = g.Slug(65)
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func TestSlugRejection(t *testing.T) {
+	// 0xff is rejected for the first letter, 0x01 maps to "b"
+	g := NewWithOpts(&Opts{Entropy: bytes.NewReader([]byte{0xff, 0x00, 0x1f, 0x01})})
+
+	slug, err := g.Slug(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "b0z", slug)
+}
@@ -0,0 +1,99 @@
+//
+// ULIDs, 48 bit millisecond timestamp and 80 random bits in Crockford
+// base32, 26 characters that sort by creation time
+//
+
+package idgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ulidLength = 26
+
+	// Crockford base32, without I, L, O and U
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+//
+// New ULID
+//
+
+func (g *Generator) ULID() (string, error) {
+	var id [16]byte
+
+	timestamp, err := g.next(&g.ulid, g.clock().UnixMilli(), id[6:])
+	if err != nil {
+		return "", err
+	}
+
+	// 48 bit big endian timestamp
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(timestamp))
+	copy(id[:6], ts[2:])
+
+	return encodeBase32(id[:], ulidLength), nil
+}
+
+//
+// Creation time of ULID, lowercase input is accepted
+//
+
+func ULIDTime(id string) (time.Time, error) {
+	if len(id) != ulidLength || id[0] > '7' {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+
+	var ts uint64
+	for _, c := range strings.ToUpper(id[:10]) {
+		i := strings.IndexRune(crockfordAlphabet, c)
+		if i < 0 {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+
+		ts = ts<<5 | uint64(i)
+	}
+
+	for _, c := range strings.ToUpper(id[10:]) {
+		if !strings.ContainsRune(crockfordAlphabet, c) {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+	}
+
+	return time.UnixMilli(int64(ts)), nil
+}
+
+//
+// Encode big endian bytes as n base32 characters, zero padded on the left
+//
+
+func encodeBase32(src []byte, n int) string {
+	out := make([]byte, n)
+
+	var acc uint64
+	bits := 0
+	pos := n - 1
+
+	for i := len(src) - 1; i >= 0 && pos >= 0; i-- {
+		acc |= uint64(src[i]) << bits
+		bits += 8
+
+		for bits >= 5 && pos >= 0 {
+			out[pos] = crockfordAlphabet[acc&31]
+			acc >>= 5
+			bits -= 5
+			pos--
+		}
+	}
+
+	for ; pos >= 0; pos-- {
+		out[pos] = crockfordAlphabet[acc&31]
+		acc >>= 5
+	}
+
+	return string(out)
+}
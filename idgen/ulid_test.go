@@ -0,0 +1,42 @@
+package idgen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestULID(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	g := NewWithOpts(&Opts{
+		Entropy: bytes.NewReader(make([]byte, 20)),
+		Clock:   fixedClock(now),
+	})
+
+	id, err := g.ULID()
+	assert.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S410000000000000000", id)
+
+	// Without monotonic mode the random part is drawn again
+	id, err = g.ULID()
+	assert.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S410000000000000000", id)
+
+	created, err := ULIDTime("01aryz6s41tsv4rrffq69g5fav")
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(created))
+}
+
+func TestULIDTimeInvalid(t *testing.T) {
+	for _, id := range []string{"", "01ARYZ6S41", "81ARYZ6S410000000000000000", "01ARYZ6S41000000000000000U"} {
+		_, err := ULIDTime(id)
+		assert.ErrorIs(t, err, ErrInvalidID, id)
+	}
+}
+
+func TestEncodeBase32(t *testing.T) {
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeBase32(bytes.Repeat([]byte{0xff}, 16), 26))
+	assert.Equal(t, "0000000000", encodeBase32(nil, 10))
+}
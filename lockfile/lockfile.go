@@ -0,0 +1,272 @@
+//
+// Advisory lock files with owner metadata and stale lock recovery, to keep
+// CLIs from running concurrently against the same state directory
+//
+
+package lockfile
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+type Lock struct {
+	path string
+	info Info
+}
+
+// Owner metadata, stored as JSON in the lock file
+type Info struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Created  time.Time `json:"created"`
+	Token    string    `json:"token"`
+}
+
+type Opts struct {
+	Path string
+
+	// Locks older than this are taken over, zero only takes over locks of
+	// dead processes on this host
+	StaleAfter time.Duration
+
+	// Poll interval while waiting in Lock
+	RetryInterval time.Duration
+}
+
+var DefaultOpts = &Opts{
+	RetryInterval: 100 * time.Millisecond,
+}
+
+var (
+	ErrLocked   = errors.New("lockfile: locked by another process")
+	ErrNotOwner = errors.New("lockfile: lock no longer owned")
+	ErrCorrupt  = errors.New("lockfile: corrupt lock file")
+)
+
+//
+// Single attempt with default opts
+//
+
+func TryLock(path string) (*Lock, error) {
+	return TryLockWithOpts(&Opts{
+		Path: path,
+	})
+}
+
+//
+// Single attempt, fails with ErrLocked naming the holder when held
+//
+
+func TryLockWithOpts(opts *Opts) (*Lock, error) {
+	lock, err := create(opts.Path)
+	if !errors.Is(err, os.ErrExist) {
+		return lock, err
+	}
+
+	holder, err := ReadInfo(opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Released in the meantime
+		return create(opts.Path)
+	}
+
+	if !opts.stale(holder, err) {
+		return nil, lockedErr(holder)
+	}
+
+	// One takeover at a time, a concurrent one may have replaced the stale
+	// lock with a fresh one already, so check again
+	release, err := acquireTakeover(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	current, err := ReadInfo(opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(opts.Path)
+	}
+
+	if !opts.stale(current, err) {
+		return nil, lockedErr(current)
+	}
+
+	if err := os.Remove(opts.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	lock, err = create(opts.Path)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrLocked
+	}
+
+	return lock, err
+}
+
+//
+// Wait for lock up to timeout with default opts
+//
+
+func LockWithTimeout(path string, timeout time.Duration) (*Lock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return LockCtx(ctx, &Opts{
+		Path: path,
+	})
+}
+
+//
+// Wait for lock until context is done, returns the last ErrLocked on expiry
+//
+
+func LockCtx(ctx context.Context, opts *Opts) (*Lock, error) {
+	if opts.RetryInterval == 0 {
+		opts.RetryInterval = DefaultOpts.RetryInterval
+	}
+
+	ticker := time.NewTicker(opts.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		lock, err := TryLockWithOpts(opts)
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-ticker.C:
+		}
+	}
+}
+
+//
+// Release lock, only removing the file while it is still ours
+//
+
+func (l *Lock) Unlock() error {
+	current, err := ReadInfo(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotOwner
+		}
+
+		return err
+	}
+
+	if current.Token != l.info.Token {
+		return ErrNotOwner
+	}
+
+	return os.Remove(l.path)
+}
+
+func (l *Lock) Info() Info {
+	return l.info
+}
+
+func (l *Lock) Path() string {
+	return l.path
+}
+
+//
+// Read lock owner metadata
+//
+
+func ReadInfo(path string) (*Info, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrCorrupt, path, err)
+	}
+
+	return info, nil
+}
+
+//
+// Create lock file exclusively, written in full under a temporary name and
+// linked into place, so it never exists half written
+//
+
+func create(path string) (*Lock, error) {
+	hostname, _ := os.Hostname()
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	info := Info{
+		PID:      os.Getpid(),
+		Hostname: hostname,
+		Created:  time.Now().UTC(),
+		Token:    hex.EncodeToString(token),
+	}
+
+	data, err := json.Marshal(&info)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := fmt.Sprintf("%s.%s.tmp", path, info.Token)
+	defer os.Remove(tmp)
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return nil, err
+	}
+
+	if err := os.Link(tmp, path); err != nil {
+		return nil, err
+	}
+
+	return &Lock{path: path, info: info}, nil
+}
+
+//
+// Holder can be taken over, corrupt lock files left by older versions or
+// other tools only once their modification time is past StaleAfter
+//
+
+func (o *Opts) stale(holder *Info, err error) bool {
+	if err == nil {
+		return holder.stale(o.StaleAfter)
+	}
+
+	if !errors.Is(err, ErrCorrupt) || (o.StaleAfter <= 0) {
+		return false
+	}
+
+	stat, statErr := os.Stat(o.Path)
+	return (statErr == nil) && (time.Since(stat.ModTime()) > o.StaleAfter)
+}
+
+//
+// Stale when older than max age, or held by a dead process on this host
+//
+
+func (i *Info) stale(maxAge time.Duration) bool {
+	if (maxAge > 0) && (time.Since(i.Created) > maxAge) {
+		return true
+	}
+
+	hostname, _ := os.Hostname()
+	return (i.Hostname == hostname) && (i.PID > 0) && !processAlive(i.PID)
+}
+
+func lockedErr(holder *Info) error {
+	if holder == nil {
+		return ErrLocked
+	}
+
+	return fmt.Errorf("%w: pid %d on %s since %s", ErrLocked, holder.PID, holder.Hostname, holder.Created.Format(time.RFC3339))
+}
@@ -0,0 +1,160 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeInfo(t *testing.T, path string, info *Info) {
+	data, err := json.Marshal(info)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestTryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	lock, err := TryLock(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), lock.Info().PID)
+	assert.Equal(t, path, lock.Path())
+
+	info, err := ReadInfo(path)
+	assert.NoError(t, err)
+	assert.Equal(t, lock.Info().Token, info.Token)
+
+	_, err = TryLock(path)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.ErrorContains(t, err, "pid")
+
+	assert.NoError(t, lock.Unlock())
+	assert.NoFileExists(t, path)
+
+	lock, err = TryLock(path)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestUnlockNotOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	lock, err := TryLock(path)
+	assert.NoError(t, err)
+
+	// Taken over by someone else
+	writeInfo(t, path, &Info{PID: 1, Token: "other"})
+	assert.ErrorIs(t, lock.Unlock(), ErrNotOwner)
+	assert.FileExists(t, path)
+
+	assert.NoError(t, os.Remove(path))
+	assert.ErrorIs(t, lock.Unlock(), ErrNotOwner)
+}
+
+func TestStaleDeadProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	hostname, _ := os.Hostname()
+
+	// Pid far above any default pid_max
+	writeInfo(t, path, &Info{PID: 1 << 30, Hostname: hostname, Created: time.Now(), Token: "dead"})
+
+	lock, err := TryLock(path)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "dead", lock.Info().Token)
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestStaleOtherHost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	writeInfo(t, path, &Info{PID: 1 << 30, Hostname: "elsewhere.invalid", Created: time.Now().Add(-time.Hour), Token: "remote"})
+
+	// Liveness of remote processes is unknown
+	_, err := TryLock(path)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	lock, err := TryLockWithOpts(&Opts{Path: path, StaleAfter: time.Minute})
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestCorruptLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	assert.NoError(t, os.WriteFile(path, []byte("garbage"), 0o644))
+
+	_, err := TryLock(path)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	_, err = ReadInfo(path)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	// Empty file of a crashed writer, fresh ones are still respected
+	assert.NoError(t, os.WriteFile(path, nil, 0o644))
+	_, err = TryLockWithOpts(&Opts{Path: path, StaleAfter: time.Minute})
+	assert.ErrorIs(t, err, ErrLocked)
+
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(path, old, old))
+
+	lock, err := TryLockWithOpts(&Opts{Path: path, StaleAfter: time.Minute})
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestConcurrentTakeover(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.lock")
+	writeInfo(t, path, &Info{PID: 1 << 30, Hostname: "elsewhere.invalid", Created: time.Now().Add(-time.Hour), Token: "stale"})
+
+	var wg sync.WaitGroup
+	var acquired atomic.Int32
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := TryLockWithOpts(&Opts{Path: path, StaleAfter: time.Minute}); err == nil {
+				acquired.Add(1)
+			} else {
+				assert.ErrorIs(t, err, ErrLocked)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(1), acquired.Load())
+
+	// No temporary files left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".tmp")
+	}
+}
+
+func TestLockWithTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	lock, err := TryLock(path)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = LockWithTimeout(path, 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	go func(first *Lock) {
+		time.Sleep(50 * time.Millisecond)
+		first.Unlock()
+	}(lock)
+
+	second, err := LockWithTimeout(path, 5*time.Second)
+	assert.NoError(t, err)
+	assert.NoError(t, second.Unlock())
+}
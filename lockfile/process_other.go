@@ -0,0 +1,8 @@
+//go:build !unix
+
+package lockfile
+
+// No portable liveness check, rely on StaleAfter
+func processAlive(pid int) bool {
+	return true
+}
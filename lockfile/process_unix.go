@@ -0,0 +1,14 @@
+//go:build unix
+
+package lockfile
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Signal 0 checks for existence, EPERM means alive but not ours
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return (err == nil) || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package lockfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// Serialize takeovers with flock on a side file, which is left in place
+// and released by the kernel if the process dies mid takeover
+func acquireTakeover(path string) (func(), error) {
+	f, err := os.OpenFile(path+".takeover", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,37 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Guards left by a process dying mid takeover are removed after this
+const takeoverStaleAfter = 10 * time.Second
+
+// No flock, serialize takeovers with an exclusively created side file,
+// concurrent takeovers fail with ErrLocked and are retried by LockCtx
+func acquireTakeover(path string) (func(), error) {
+	guard := path + ".takeover"
+
+	f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		if stat, err := os.Stat(guard); err == nil && time.Since(stat.ModTime()) > takeoverStaleAfter {
+			os.Remove(guard)
+		}
+
+		return nil, ErrLocked
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.Close()
+
+	return func() {
+		os.Remove(guard)
+	}, nil
+}
@@ -0,0 +1,117 @@
+//
+// Request and trace IDs carried in context and added to every record
+//
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/publishlab/infra-golang-toolkit/idgen"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceIDKey
+	attrsKey
+	loggerKey
+)
+
+// Handler adding context IDs and attributes to records
+type ContextHandler struct {
+	handler slog.Handler
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
+}
+
+//
+// Add attributes to every record logged with ctx
+//
+
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsKey, merged)
+}
+
+//
+// Logger carried in context, slog default when there is none
+//
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+//
+// ULID request ID, so IDs in logs sort by request start
+//
+
+func NewRequestID() string {
+	return idgen.ULID()
+}
+
+//
+// Wrap handler, context values are added at the level of the last group
+//
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{handler: handler}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String(KeyRequestID, id))
+	}
+
+	if id := TraceID(ctx); id != "" {
+		record.AddAttrs(slog.String(KeyTraceID, id))
+	}
+
+	if attrs, ok := ctx.Value(attrsKey).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.handler.WithAttrs(attrs))
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.handler.WithGroup(name))
+}
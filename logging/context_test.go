@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithTraceID(ctx, "trace-1")
+	ctx = WithAttrs(ctx, slog.String("tenant", "acme"))
+	ctx = WithAttrs(ctx, slog.Int("attempt", 2))
+
+	assert.Equal(t, "req-1", RequestID(ctx))
+	assert.Equal(t, "trace-1", TraceID(ctx))
+
+	logger.With("component", "sync").InfoContext(ctx, "done")
+	logger.Info("no context")
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "req-1", records[0][KeyRequestID])
+	assert.Equal(t, "trace-1", records[0][KeyTraceID])
+	assert.Equal(t, "acme", records[0]["tenant"])
+	assert.Equal(t, 2.0, records[0]["attempt"])
+	assert.Equal(t, "sync", records[0]["component"])
+	assert.NotContains(t, records[1], KeyRequestID)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	logger := New()
+	assert.Equal(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Len(t, id, 26)
+	assert.NotEqual(t, id, NewRequestID())
+}
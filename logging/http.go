@@ -0,0 +1,140 @@
+//
+// HTTP server middleware and client transport propagating request IDs
+//
+
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderRequestID   = "X-Request-Id"
+	HeaderTraceParent = "traceparent"
+)
+
+// Round tripper forwarding the context request ID and logging requests
+type Transport struct {
+	transport http.RoundTripper
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+//
+// Wrap handler, taking request and trace IDs from headers or creating a
+// request ID, and logging each request with the standard fields
+//
+
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+
+		id := r.Header.Get(HeaderRequestID)
+		if id == "" {
+			id = NewRequestID()
+		}
+
+		ctx = WithRequestID(ctx, id)
+		if traceID := parseTraceParent(r.Header.Get(HeaderTraceParent)); traceID != "" {
+			ctx = WithTraceID(ctx, traceID)
+		}
+
+		ctx = WithLogger(ctx, logger)
+		w.Header().Set(HeaderRequestID, id)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= 500 {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(ctx, level, "http request",
+			slog.String(KeyHTTPMethod, r.Method),
+			slog.String(KeyHTTPPath, r.URL.Path),
+			slog.Int(KeyHTTPStatus, rec.status),
+			Duration(time.Since(start)),
+		)
+	})
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+//
+// Initialize transport wrapping next, nil means the default transport,
+// usable as httpclient transport
+//
+
+func NewTransport(next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{transport: next}
+}
+
+//
+// Send request with the context request ID, logged at debug level with the
+// context logger
+//
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	if id := RequestID(ctx); (id != "") && (req.Header.Get(HeaderRequestID) == "") {
+		req = req.Clone(ctx)
+		req.Header.Set(HeaderRequestID, id)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String(KeyHTTPMethod, req.Method),
+		slog.String(KeyHTTPHost, req.URL.Host),
+		slog.String(KeyHTTPPath, req.URL.Path),
+		Duration(time.Since(start)),
+	}
+
+	if err != nil {
+		FromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "http client request", append(attrs, Err(err))...)
+		return nil, err
+	}
+
+	FromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "http client request", append(attrs, slog.Int(KeyHTTPStatus, resp.StatusCode))...)
+	return resp, nil
+}
+
+//
+// Trace ID of a W3C traceparent header, version-traceid-parentid-flags
+//
+
+func parseTraceParent(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if (len(parts) < 4) || (len(parts[1]) != 32) || (parts[1] == strings.Repeat("0", 32)) {
+		return ""
+	}
+
+	for _, c := range parts[1] {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return ""
+		}
+	}
+
+	return parts[1]
+}
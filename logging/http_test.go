@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf})
+
+	var seen context.Context
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Context()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/things", nil)
+	req.Header.Set(HeaderRequestID, "req-1")
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-1", rec.Header().Get(HeaderRequestID))
+	assert.Equal(t, "req-1", RequestID(seen))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(seen))
+	assert.Equal(t, logger, FromContext(seen))
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "GET", records[0][KeyHTTPMethod])
+	assert.Equal(t, "/things", records[0][KeyHTTPPath])
+	assert.Equal(t, 418.0, records[0][KeyHTTPStatus])
+	assert.Equal(t, "req-1", records[0][KeyRequestID])
+
+	// Request ID is created when missing
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, rec.Header().Get(HeaderRequestID), 26)
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(HeaderRequestID)))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf, Level: slog.LevelDebug})
+
+	ctx := WithLogger(WithRequestID(context.Background(), "req-2"), logger)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/path", nil)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body := make([]byte, 16)
+	n, _ := resp.Body.Read(body)
+	assert.Equal(t, "req-2", string(body[:n]))
+	assert.Empty(t, req.Header.Get(HeaderRequestID))
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "/path", records[0][KeyHTTPPath])
+	assert.Equal(t, 200.0, records[0][KeyHTTPStatus])
+	assert.Equal(t, "req-2", records[0][KeyRequestID])
+}
+
+func TestParseTraceParent(t *testing.T) {
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	assert.Empty(t, parseTraceParent(""))
+	assert.Empty(t, parseTraceParent("00-00000000000000000000000000000000-00f067aa0ba902b7-01"))
+	assert.Empty(t, parseTraceParent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"))
+}
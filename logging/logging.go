@@ -0,0 +1,155 @@
+//
+// Structured logging on slog with shared field conventions across services
+//
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+)
+
+type Opts struct {
+	Output    io.Writer
+	Level     slog.Leveler
+	Format    string
+	Service   string
+	Version   string
+	AddSource bool
+
+	// Share of records below warning level kept, zero keeps all
+	SampleRate float64
+
+	// Scrub tokens, credentials and card numbers from string values
+	Scrub bool
+}
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Standard field names, use these rather than ad-hoc variants
+const (
+	KeyService    = "service"
+	KeyVersion    = "version"
+	KeyRequestID  = "request_id"
+	KeyTraceID    = "trace_id"
+	KeyError      = "error"
+	KeyDuration   = "duration_ms"
+	KeyHTTPMethod = "http.method"
+	KeyHTTPPath   = "http.path"
+	KeyHTTPStatus = "http.status"
+	KeyHTTPHost   = "http.host"
+)
+
+var DefaultOpts = &Opts{
+	Level:  slog.LevelInfo,
+	Format: FormatJSON,
+}
+
+//
+// Initialize new logger writing JSON to stderr
+//
+
+func New() *slog.Logger {
+	return NewWithOpts(DefaultOpts)
+}
+
+func NewWithOpts(opts *Opts) *slog.Logger {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+
+	if opts.Level == nil {
+		opts.Level = DefaultOpts.Level
+	}
+
+	if opts.Format == "" {
+		opts.Format = DefaultOpts.Format
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:     opts.Level,
+		AddSource: opts.AddSource,
+	}
+
+	if opts.Scrub {
+		handlerOpts.ReplaceAttr = scrubAttr
+	}
+
+	var handler slog.Handler
+	if opts.Format == FormatText {
+		handler = slog.NewTextHandler(opts.Output, handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(opts.Output, handlerOpts)
+	}
+
+	var attrs []slog.Attr
+	if opts.Service != "" {
+		attrs = append(attrs, slog.String(KeyService, opts.Service))
+	}
+
+	if opts.Version != "" {
+		attrs = append(attrs, slog.String(KeyVersion, opts.Version))
+	}
+
+	handler = NewContextHandler(handler.WithAttrs(attrs))
+
+	if (opts.SampleRate > 0) && (opts.SampleRate < 1) {
+		handler = NewSamplingHandler(handler, opts.SampleRate)
+	}
+
+	return slog.New(handler)
+}
+
+//
+// Parse level name as used in flags and env, e.g. "debug" or "warn"
+//
+
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return level, fmt.Errorf("logging: invalid level %q", name)
+	}
+
+	return level, nil
+}
+
+//
+// Standard error field, empty for nil errors so it is left out
+//
+
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+
+	return slog.String(KeyError, err.Error())
+}
+
+//
+// Standard duration field, in fractional milliseconds
+//
+
+func Duration(d time.Duration) slog.Attr {
+	return slog.Float64(KeyDuration, float64(d)/float64(time.Millisecond))
+}
+
+//
+// Scrub string values, including the message
+//
+
+func scrubAttr(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindString {
+		attr.Value = slog.StringValue(format.Scrub(attr.Value.String()))
+	}
+
+	return attr
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		record := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestNewWithOpts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{
+		Output:  &buf,
+		Service: "billing",
+		Version: "1.2.3",
+	})
+
+	logger.Debug("hidden")
+	logger.Info("started", Err(errors.New("boom")), Err(nil), Duration(1500*time.Microsecond))
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "started", records[0]["msg"])
+	assert.Equal(t, "billing", records[0][KeyService])
+	assert.Equal(t, "1.2.3", records[0][KeyVersion])
+	assert.Equal(t, "boom", records[0][KeyError])
+	assert.Equal(t, 1.5, records[0][KeyDuration])
+}
+
+func TestNewWithOptsText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{
+		Output: &buf,
+		Format: FormatText,
+		Level:  slog.LevelDebug,
+	})
+
+	logger.Debug("visible", "key", "value")
+	assert.Contains(t, buf.String(), "msg=visible key=value")
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel(" debug ")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	level, err = ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("loud")
+	assert.ErrorContains(t, err, "invalid level")
+}
+
+func TestNewWithOptsScrub(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf, Scrub: true})
+
+	logger.Info("login password=hunter2", "authorization", "Bearer abcdefghijklmnop", "count", 3)
+
+	records := decodeLines(t, &buf)
+	assert.Equal(t, "login password=[REDACTED]", records[0]["msg"])
+	assert.Equal(t, "Bearer [REDACTED]", records[0]["authorization"])
+	assert.Equal(t, 3.0, records[0]["count"])
+}
@@ -0,0 +1,56 @@
+//
+// Sampling of low level records, keeping whole requests together
+//
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+)
+
+// Handler keeping a share of records below warning level
+type SamplingHandler struct {
+	handler slog.Handler
+	rate    float64
+}
+
+//
+// Wrap handler, records with a request ID are kept or dropped per request
+// so sampled requests stay complete
+//
+
+func NewSamplingHandler(handler slog.Handler, rate float64) *SamplingHandler {
+	return &SamplingHandler{handler: handler, rate: rate}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if (record.Level < slog.LevelWarn) && !h.keep(ctx) {
+		return nil
+	}
+
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewSamplingHandler(h.handler.WithAttrs(attrs), h.rate)
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return NewSamplingHandler(h.handler.WithGroup(name), h.rate)
+}
+
+func (h *SamplingHandler) keep(ctx context.Context) bool {
+	if id := RequestID(ctx); id != "" {
+		return format.Sampled(id, h.rate)
+	}
+
+	return rand.Float64() < h.rate
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf, SampleRate: 0.1})
+
+	for i := 0; i < 1000; i++ {
+		logger.Info("sampled")
+		logger.Warn("kept")
+	}
+
+	assert.Equal(t, 1000, strings.Count(buf.String(), `"kept"`))
+	assert.InDelta(t, 100, strings.Count(buf.String(), `"sampled"`), 50)
+}
+
+func TestSamplingHandlerRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithOpts(&Opts{Output: &buf, SampleRate: 0.5})
+
+	// Requests are kept or dropped as a whole
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		ctx := WithRequestID(context.Background(), fmt.Sprintf("req-%d", i))
+
+		for j := 0; j < 5; j++ {
+			logger.InfoContext(ctx, "step")
+		}
+
+		assert.Contains(t, []int{0, 5}, strings.Count(buf.String(), `"step"`))
+	}
+}
@@ -0,0 +1,259 @@
+//
+// CIDR math over netip.Prefix sets, e.g. for firewall and route-server configs
+//
+
+package netutil
+
+import (
+	"net/netip"
+	"sort"
+)
+
+//
+// Aggregate prefixes into the smallest equivalent set, masking host bits,
+// dropping duplicates and covered prefixes, and merging adjacent siblings
+//
+
+func Aggregate(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix.IsValid() {
+			sorted = append(sorted, prefix.Masked())
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return ComparePrefixes(sorted[i], sorted[j]) < 0
+	})
+
+	result := []netip.Prefix{}
+	for _, prefix := range sorted {
+		// Sorted order puts covering prefixes first
+		if len(result) > 0 && covers(result[len(result)-1], prefix) {
+			continue
+		}
+
+		result = append(result, prefix)
+
+		// Collapse sibling pairs into their parent, which may cascade
+		for len(result) >= 2 {
+			a := result[len(result)-2]
+			b := result[len(result)-1]
+
+			if a.Bits() != b.Bits() || a.Bits() == 0 || a.Addr().BitLen() != b.Addr().BitLen() {
+				break
+			}
+
+			parent := netip.PrefixFrom(a.Addr(), a.Bits()-1).Masked()
+			if parent.Addr() != a.Addr() || !parent.Contains(b.Addr()) {
+				break
+			}
+
+			result = append(result[:len(result)-2], parent)
+		}
+	}
+
+	return result
+}
+
+//
+// Remove all addresses in remove from prefixes
+//
+
+func Subtract(prefixes []netip.Prefix, remove []netip.Prefix) []netip.Prefix {
+	remove = Aggregate(remove)
+	result := []netip.Prefix{}
+
+	for _, prefix := range Aggregate(prefixes) {
+		result = append(result, subtractPrefix(prefix, remove)...)
+	}
+
+	return Aggregate(result)
+}
+
+func subtractPrefix(prefix netip.Prefix, remove []netip.Prefix) []netip.Prefix {
+	var overlapping []netip.Prefix
+
+	for _, r := range remove {
+		if covers(r, prefix) {
+			return nil
+		}
+
+		if r.Overlaps(prefix) {
+			overlapping = append(overlapping, r)
+		}
+	}
+
+	if len(overlapping) == 0 {
+		return []netip.Prefix{prefix}
+	}
+
+	// Some removal is strictly inside, split in halves and recurse
+	lower, upper := split(prefix)
+	return append(subtractPrefix(lower, overlapping), subtractPrefix(upper, overlapping)...)
+}
+
+//
+// Addresses present in both sets
+//
+
+func Intersect(a []netip.Prefix, b []netip.Prefix) []netip.Prefix {
+	b = Aggregate(b)
+	result := []netip.Prefix{}
+
+	for _, pa := range Aggregate(a) {
+		for _, pb := range b {
+			if !pa.Overlaps(pb) {
+				continue
+			}
+
+			// Overlapping prefixes are always nested, keep the inner one
+			if pa.Bits() > pb.Bits() {
+				result = append(result, pa)
+			} else {
+				result = append(result, pb)
+			}
+		}
+	}
+
+	return Aggregate(result)
+}
+
+//
+// Containment checks against a set
+//
+
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// True if every address of prefix is in the set, possibly spread over
+// several smaller prefixes
+func ContainsPrefix(prefixes []netip.Prefix, prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+
+	return len(subtractPrefix(prefix.Masked(), Aggregate(prefixes))) == 0
+}
+
+//
+// Smallest single prefix per address family covering all given prefixes,
+// a lossy summary unlike Aggregate
+//
+
+func Summarize(prefixes []netip.Prefix) []netip.Prefix {
+	var v4, v6 *netip.Prefix
+
+	for _, prefix := range Aggregate(prefixes) {
+		target := &v6
+		if prefix.Addr().Is4() {
+			target = &v4
+		}
+
+		if *target == nil {
+			p := prefix
+			*target = &p
+			continue
+		}
+
+		summary := **target
+		for !covers(summary, prefix) {
+			summary = netip.PrefixFrom(summary.Addr(), summary.Bits()-1).Masked()
+		}
+
+		*target = &summary
+	}
+
+	result := []netip.Prefix{}
+	for _, p := range []*netip.Prefix{v4, v6} {
+		if p != nil {
+			result = append(result, *p)
+		}
+	}
+
+	return result
+}
+
+//
+// Smallest set of prefixes exactly covering an inclusive address range
+//
+
+func RangeToPrefixes(start netip.Addr, end netip.Addr) []netip.Prefix {
+	var result []netip.Prefix
+
+	if !start.IsValid() || start.BitLen() != end.BitLen() || end.Less(start) {
+		return nil
+	}
+
+	for {
+		// Largest aligned prefix starting at start that does not pass end
+		bits := start.BitLen()
+		for bits > 0 {
+			candidate, _ := start.Prefix(bits - 1)
+			if candidate.Addr() != start || LastAddr(candidate).Compare(end) > 0 {
+				break
+			}
+
+			bits--
+		}
+
+		prefix := netip.PrefixFrom(start, bits)
+		result = append(result, prefix)
+
+		last := LastAddr(prefix)
+		if last.Compare(end) >= 0 || !last.Next().IsValid() {
+			return result
+		}
+
+		start = last.Next()
+	}
+}
+
+//
+// Last address within prefix, e.g. the broadcast address for IPv4
+//
+
+func LastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Masked().Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+//
+// Order by family, address and then prefix length
+//
+
+func ComparePrefixes(a netip.Prefix, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
+	}
+
+	return a.Bits() - b.Bits()
+}
+
+//
+// Internal helpers
+//
+
+func covers(outer netip.Prefix, inner netip.Prefix) bool {
+	return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+}
+
+func split(prefix netip.Prefix) (netip.Prefix, netip.Prefix) {
+	bits := prefix.Bits() + 1
+	lower := netip.PrefixFrom(prefix.Addr(), bits)
+	upper := netip.PrefixFrom(LastAddr(lower).Next(), bits)
+
+	return lower, upper
+}
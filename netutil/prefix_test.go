@@ -0,0 +1,100 @@
+package netutil
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prefixes(values ...string) []netip.Prefix {
+	result := []netip.Prefix{}
+	for _, value := range values {
+		result = append(result, netip.MustParsePrefix(value))
+	}
+
+	return result
+}
+
+func TestAggregate(t *testing.T) {
+	assert.Equal(t, prefixes(), Aggregate(nil))
+
+	assert.Equal(t, prefixes("10.0.0.0/23", "2001:db8::/32"), Aggregate(prefixes(
+		"2001:db8::/33",
+		"10.0.1.0/24",
+		"10.0.0.0/25",
+		"10.0.0.128/25",
+		"10.0.0.5/32",
+		"2001:db8:8000::/33",
+		"10.0.1.77/24",
+	)))
+
+	// Adjacent but not siblings
+	assert.Equal(t, prefixes("10.0.1.0/24", "10.0.2.0/24"), Aggregate(prefixes("10.0.2.0/24", "10.0.1.0/24")))
+
+	// Cascading merge
+	assert.Equal(t, prefixes("10.0.0.0/22"), Aggregate(prefixes("10.0.3.0/24", "10.0.2.0/24", "10.0.0.0/24", "10.0.1.0/24")))
+
+	assert.Equal(t, prefixes("0.0.0.0/0"), Aggregate(prefixes("0.0.0.0/1", "128.0.0.0/1")))
+}
+
+func TestSubtract(t *testing.T) {
+	assert.Equal(t, prefixes("10.0.0.0/25", "10.0.0.192/26"), Subtract(prefixes("10.0.0.0/24"), prefixes("10.0.0.128/26")))
+	assert.Equal(t, prefixes("10.0.0.0/24"), Subtract(prefixes("10.0.0.0/24"), prefixes("192.168.0.0/16", "2001:db8::/32")))
+	assert.Equal(t, prefixes(), Subtract(prefixes("10.0.0.0/24"), prefixes("10.0.0.0/8")))
+	assert.Equal(t, prefixes("10.0.0.0/32", "10.0.0.2/31"), Subtract(prefixes("10.0.0.0/30"), prefixes("10.0.0.1/32")))
+	assert.Equal(t, prefixes("2001:db8:8000::/33"), Subtract(prefixes("2001:db8::/32"), prefixes("2001:db8::/33")))
+}
+
+func TestIntersect(t *testing.T) {
+	assert.Equal(t, prefixes("10.0.0.128/25", "10.0.2.0/24"), Intersect(
+		prefixes("10.0.0.128/25", "10.0.2.0/23"),
+		prefixes("10.0.0.0/24", "10.0.2.0/24", "192.168.0.0/16"),
+	))
+
+	assert.Equal(t, prefixes(), Intersect(prefixes("10.0.0.0/24"), prefixes("10.0.1.0/24")))
+}
+
+func TestContains(t *testing.T) {
+	set := prefixes("10.0.0.0/25", "10.0.0.128/25", "2001:db8::/32")
+
+	assert.True(t, Contains(set, netip.MustParseAddr("10.0.0.200")))
+	assert.True(t, Contains(set, netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, Contains(set, netip.MustParseAddr("10.0.1.1")))
+
+	assert.True(t, ContainsPrefix(set, netip.MustParsePrefix("10.0.0.0/24")))
+	assert.True(t, ContainsPrefix(set, netip.MustParsePrefix("10.0.0.64/26")))
+	assert.False(t, ContainsPrefix(set, netip.MustParsePrefix("10.0.0.0/23")))
+	assert.False(t, ContainsPrefix(set, netip.Prefix{}))
+}
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, prefixes("10.0.0.0/22", "2001:db8::/31"), Summarize(prefixes(
+		"10.0.0.0/24",
+		"10.0.3.0/24",
+		"2001:db8::/32",
+		"2001:db9::/48",
+	)))
+
+	assert.Equal(t, prefixes(), Summarize(nil))
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	assert.Equal(t, prefixes("10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"),
+		RangeToPrefixes(netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.6")))
+
+	assert.Equal(t, prefixes("2001:db8::/112"),
+		RangeToPrefixes(netip.MustParseAddr("2001:db8::"), netip.MustParseAddr("2001:db8::ffff")))
+
+	assert.Equal(t, prefixes("0.0.0.0/0"),
+		RangeToPrefixes(netip.MustParseAddr("0.0.0.0"), netip.MustParseAddr("255.255.255.255")))
+
+	assert.Nil(t, RangeToPrefixes(netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("10.0.0.1")))
+	assert.Nil(t, RangeToPrefixes(netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2001:db8::")))
+}
+
+func TestLastAddr(t *testing.T) {
+	assert.Equal(t, netip.MustParseAddr("10.0.0.255"), LastAddr(netip.MustParsePrefix("10.0.0.0/24")))
+	assert.Equal(t, netip.MustParseAddr("10.0.0.255"), LastAddr(netip.MustParsePrefix("10.0.0.17/24")))
+	assert.Equal(t, netip.MustParseAddr("2001:db8::ffff:ffff"), LastAddr(netip.MustParsePrefix("2001:db8::/96")))
+}
@@ -0,0 +1,76 @@
+//
+// Bounded output capture
+//
+
+package procutil
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Keeps the first max bytes and counts the rest, safe for concurrent writers
+type cappedBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	max     int64
+	dropped int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.max - int64(b.buf.Len())
+	if room < int64(len(p)) {
+		if room < 0 {
+			room = 0
+		}
+
+		b.dropped += int64(len(p)) - room
+		b.buf.Write(p[:room])
+	} else {
+		b.buf.Write(p)
+	}
+
+	// Report everything as written, the process must not see a short write
+	return len(p), nil
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes())
+}
+
+func (b *cappedBuffer) Truncated() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dropped > 0
+}
+
+//
+// Last non-empty line, for error messages
+//
+
+func lastLine(data []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	line := bytes.TrimSpace(lines[len(lines)-1])
+
+	const maxLen = 200
+	if len(line) > maxLen {
+		line = append(line[:maxLen:maxLen], "..."...)
+	}
+
+	return string(line)
+}
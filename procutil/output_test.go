@@ -0,0 +1,32 @@
+package procutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCappedBuffer(t *testing.T) {
+	buf := &cappedBuffer{max: 5}
+
+	n, err := buf.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = buf.Write([]byte("defgh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	assert.Equal(t, "abcde", string(buf.Bytes()))
+	assert.True(t, buf.Truncated())
+
+	var empty *cappedBuffer
+	assert.Nil(t, empty.Bytes())
+	assert.False(t, empty.Truncated())
+}
+
+func TestLastLine(t *testing.T) {
+	assert.Equal(t, "last", lastLine([]byte("first\nlast\n\n")))
+	assert.Equal(t, "", lastLine(nil))
+	assert.Len(t, lastLine([]byte(string(make([]byte, 300)))), 203)
+}
@@ -0,0 +1,265 @@
+//
+// External command runner with timeouts, bounded output capture, environment
+// scrubbing and retries
+//
+
+package procutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+type Opts struct {
+	Command string
+	Args    []string
+	Dir     string
+	Stdin   []byte
+
+	// Extra KEY=VALUE entries, applied on top of the inherited environment
+	Env []string
+
+	// Start from an empty environment, keeping only the KeepEnv variables
+	ScrubEnv bool
+	KeepEnv  []string
+
+	// Per attempt, on expiry the process gets SIGTERM and is killed after
+	// the grace period
+	Timeout     time.Duration
+	GracePeriod time.Duration
+
+	// Per stream, output beyond the limit is dropped and flagged
+	MaxOutputSize int64
+
+	// Capture stdout and stderr interleaved in Result.Combined
+	Combined bool
+
+	// Nil makes a single attempt, start failures are never retried
+	Retry *retry.Policy
+}
+
+type Result struct {
+	Stdout    []byte
+	Stderr    []byte
+	Combined  []byte
+	ExitCode  int
+	Duration  time.Duration
+	Attempts  int
+	Truncated bool
+}
+
+// Command failed to run to a zero exit code
+type ExitError struct {
+	Command  string
+	Args     []string
+	ExitCode int
+	Output   []byte
+	Err      error
+}
+
+var DefaultOpts = &Opts{
+	GracePeriod:   5 * time.Second,
+	MaxOutputSize: 1 << 20,
+	KeepEnv:       []string{"PATH", "HOME", "USER", "LANG", "TZ", "TMPDIR"},
+}
+
+//
+// Error with the last line of output, which usually carries the reason
+//
+
+func (e *ExitError) Error() string {
+	reason := ""
+	if len(bytes.TrimSpace(e.Output)) > 0 {
+		reason = ": " + lastLine(e.Output)
+	}
+
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("procutil: %s timed out%s", e.Command, reason)
+	}
+
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("procutil: %s failed: %v%s", e.Command, e.Err, reason)
+	}
+
+	return fmt.Sprintf("procutil: %s exited with code %d%s", e.Command, e.ExitCode, reason)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+//
+// Run command with default opts
+//
+
+func Run(ctx context.Context, command string, args ...string) (*Result, error) {
+	return RunWithOpts(ctx, &Opts{
+		Command: command,
+		Args:    args,
+	})
+}
+
+//
+// Run command with opts, the result of the last attempt is returned along
+// with any error
+//
+
+func RunWithOpts(ctx context.Context, opts *Opts) (*Result, error) {
+	if opts.GracePeriod == 0 {
+		opts.GracePeriod = DefaultOpts.GracePeriod
+	}
+
+	if opts.MaxOutputSize == 0 {
+		opts.MaxOutputSize = DefaultOpts.MaxOutputSize
+	}
+
+	if opts.KeepEnv == nil {
+		opts.KeepEnv = DefaultOpts.KeepEnv
+	}
+
+	policy := retry.Policy{MaxAttempts: 1}
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+
+	attempts := 0
+	result, err := retry.DoValue(ctx, func(ctx context.Context) (*Result, error) {
+		attempts++
+		return runOnce(ctx, opts)
+	}, retry.WithPolicy(policy))
+
+	if result != nil {
+		result.Attempts = attempts
+	}
+
+	return result, err
+}
+
+//
+// Single attempt
+//
+
+func runOnce(ctx context.Context, opts *Opts) (*Result, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, opts.Command, opts.Args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = buildEnv(opts)
+	cmd.WaitDelay = opts.GracePeriod
+
+	// Ask nicely first, WaitDelay kills after the grace period
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+
+	if opts.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(opts.Stdin)
+	}
+
+	var stdout, stderr, combined *cappedBuffer
+	if opts.Combined {
+		combined = &cappedBuffer{max: opts.MaxOutputSize}
+		cmd.Stdout = combined
+		cmd.Stderr = combined
+	} else {
+		stdout = &cappedBuffer{max: opts.MaxOutputSize}
+		stderr = &cappedBuffer{max: opts.MaxOutputSize}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+
+	result := &Result{
+		Stdout:    stdout.Bytes(),
+		Stderr:    stderr.Bytes(),
+		Combined:  combined.Bytes(),
+		ExitCode:  -1,
+		Duration:  time.Since(start),
+		Truncated: stdout.Truncated() || stderr.Truncated() || combined.Truncated(),
+	}
+
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if err == nil {
+		return result, nil
+	}
+
+	exitErr := &ExitError{
+		Command:  opts.Command,
+		Args:     opts.Args,
+		ExitCode: result.ExitCode,
+		Output:   result.Stderr,
+		Err:      err,
+	}
+
+	if opts.Combined {
+		exitErr.Output = result.Combined
+	}
+
+	// Killed by our own deadline or cancellation
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		exitErr.Err = ctxErr
+	}
+
+	// Missing binary or bad working directory won't fix itself
+	if cmd.ProcessState == nil && ctx.Err() == nil {
+		return result, retry.Permanent(exitErr)
+	}
+
+	return result, exitErr
+}
+
+//
+// Inherited or scrubbed environment with extra entries, later entries win
+//
+
+func buildEnv(opts *Opts) []string {
+	var env []string
+
+	if opts.ScrubEnv {
+		for _, name := range opts.KeepEnv {
+			if value, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+value)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+
+	env = append(env, opts.Env...)
+
+	// Drop duplicates so the override is unambiguous for every libc
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(env))
+
+	for i := len(env) - 1; i >= 0; i-- {
+		name, _, _ := strings.Cut(env[i], "=")
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, env[i])
+		}
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
@@ -0,0 +1,153 @@
+package procutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	result, err := Run(context.Background(), "sh", "-c", "echo out; echo err >&2")
+	assert.NoError(t, err)
+	assert.Equal(t, "out\n", string(result.Stdout))
+	assert.Equal(t, "err\n", string(result.Stderr))
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, 1, result.Attempts)
+	assert.False(t, result.Truncated)
+}
+
+func TestRunCombinedStdin(t *testing.T) {
+	result, err := RunWithOpts(context.Background(), &Opts{
+		Command:  "sh",
+		Args:     []string{"-c", "cat; echo err >&2"},
+		Stdin:    []byte("in\n"),
+		Combined: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "in\nerr\n", string(result.Combined))
+	assert.Nil(t, result.Stdout)
+}
+
+func TestRunExitError(t *testing.T) {
+	result, err := Run(context.Background(), "sh", "-c", "echo progress; echo 'Error: state locked' >&2; exit 3")
+
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "procutil: sh exited with code 3: Error: state locked", err.Error())
+
+	var execErr *exec.ExitError
+	assert.ErrorAs(t, err, &execErr)
+}
+
+func TestRunNotFound(t *testing.T) {
+	result, err := RunWithOpts(context.Background(), &Opts{
+		Command: "procutil-does-not-exist",
+		Retry:   &retry.Policy{MaxAttempts: 3},
+	})
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, -1, result.ExitCode)
+}
+
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := RunWithOpts(context.Background(), &Opts{
+		Command:     "sh",
+		Args:        []string{"-c", "trap '' TERM; sleep 5"},
+		Timeout:     50 * time.Millisecond,
+		GracePeriod: 50 * time.Millisecond,
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRunRetry(t *testing.T) {
+	marker := t.TempDir() + "/ran"
+
+	// Fails until the marker exists
+	result, err := RunWithOpts(context.Background(), &Opts{
+		Command: "sh",
+		Args:    []string{"-c", `[ -e "$MARKER" ] || { touch "$MARKER"; exit 1; }; echo ok`},
+		Env:     []string{"MARKER=" + marker},
+		Retry: &retry.Policy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", string(result.Stdout))
+	assert.Equal(t, 2, result.Attempts)
+}
+
+func TestRunMaxOutputSize(t *testing.T) {
+	result, err := RunWithOpts(context.Background(), &Opts{
+		Command:       "sh",
+		Args:          []string{"-c", "yes | head -c 10000"},
+		MaxOutputSize: 100,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Stdout, 100)
+	assert.True(t, result.Truncated)
+}
+
+func TestRunEnv(t *testing.T) {
+	t.Setenv("PROCUTIL_SECRET", "hunter2")
+	t.Setenv("PROCUTIL_KEEP", "kept")
+
+	result, err := RunWithOpts(context.Background(), &Opts{
+		Command: "sh",
+		Args:    []string{"-c", "env"},
+		Env:     []string{"PROCUTIL_EXTRA=1", "PROCUTIL_KEEP=overridden"},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(result.Stdout), "PROCUTIL_SECRET=hunter2")
+	assert.Contains(t, string(result.Stdout), "PROCUTIL_KEEP=overridden")
+	assert.NotContains(t, string(result.Stdout), "PROCUTIL_KEEP=kept")
+
+	result, err = RunWithOpts(context.Background(), &Opts{
+		Command:  "/bin/sh",
+		Args:     []string{"-c", "env"},
+		Env:      []string{"PROCUTIL_EXTRA=1"},
+		ScrubEnv: true,
+		KeepEnv:  []string{"PROCUTIL_KEEP"},
+	})
+
+	assert.NoError(t, err)
+	env := strings.Fields(string(result.Stdout))
+	assert.Contains(t, env, "PROCUTIL_KEEP=kept")
+	assert.Contains(t, env, "PROCUTIL_EXTRA=1")
+	assert.NotContains(t, string(result.Stdout), "hunter2")
+	assert.NotContains(t, env, "HOME="+os.Getenv("HOME"))
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := RunWithOpts(ctx, &Opts{
+		Command: "sleep",
+		Args:    []string{"5"},
+		Retry:   &retry.Policy{MaxAttempts: 3},
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,229 @@
+//
+// Generic retry with exponential backoff and jitter
+//
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type Policy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	MaxElapsed     time.Duration
+	RetryIf        func(err error) bool
+	OnRetry        func(attempt int, err error, delay time.Duration)
+}
+
+type Option func(p *Policy)
+
+var DefaultPolicy = Policy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+//
+// Options
+//
+
+// Total attempts including the first, zero or less retries forever
+func WithMaxAttempts(n int) Option {
+	return func(p *Policy) {
+		p.MaxAttempts = n
+	}
+}
+
+func WithExpBackoff(initial time.Duration, max time.Duration, multiplier float64) Option {
+	return func(p *Policy) {
+		p.InitialBackoff = initial
+		p.MaxBackoff = max
+		p.Multiplier = multiplier
+	}
+}
+
+func WithConstantBackoff(d time.Duration) Option {
+	return WithExpBackoff(d, d, 1)
+}
+
+// Randomize each delay by up to +/- fraction
+func WithJitter(fraction float64) Option {
+	return func(p *Policy) {
+		p.Jitter = fraction
+	}
+}
+
+// Stop when the next delay would exceed the elapsed time budget
+func WithMaxElapsed(d time.Duration) Option {
+	return func(p *Policy) {
+		p.MaxElapsed = d
+	}
+}
+
+// Only retry errors matching predicate
+func WithRetryIf(pred func(err error) bool) Option {
+	return func(p *Policy) {
+		p.RetryIf = pred
+	}
+}
+
+// Observe failed attempts before waiting
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) Option {
+	return func(p *Policy) {
+		p.OnRetry = fn
+	}
+}
+
+// Use all settings of policy
+func WithPolicy(policy Policy) Option {
+	return func(p *Policy) {
+		*p = policy
+	}
+}
+
+//
+// Run fn until it succeeds, fails permanently or the policy gives up
+//
+
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+
+	return err
+}
+
+func DoValue[T any](ctx context.Context, fn func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	p := DefaultPolicy
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+
+		// Permanent failures and cancellation end immediately
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return value, permanent.err
+		}
+
+		if ctx.Err() != nil || (p.RetryIf != nil && !p.RetryIf(err)) {
+			return value, err
+		}
+
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return value, err
+		}
+
+		delay := p.Backoff(attempt)
+
+		var after *afterError
+		if errors.As(err, &after) {
+			delay = after.delay
+		}
+
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return value, err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return value, ctx.Err()
+		}
+	}
+}
+
+//
+// Delay before the next attempt, after n failed attempts
+//
+
+func (p *Policy) Backoff(n int) time.Duration {
+	multiplier := math.Max(p.Multiplier, 1)
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(n-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	// Spread retries from concurrent callers
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(backoff)
+}
+
+//
+// Error wrappers controlling retries
+//
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Stop retrying, Do returns the wrapped error
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type afterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *afterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *afterError) Unwrap() error {
+	return e.err
+}
+
+// Retry after a fixed delay instead of the backoff, e.g. from Retry-After headers
+func After(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return &afterError{err: err, delay: delay}
+}
@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDo(t *testing.T) {
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+
+		return nil
+	}, WithMaxAttempts(5), WithConstantBackoff(time.Millisecond))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoMaxAttempts(t *testing.T) {
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTransient
+	}, WithMaxAttempts(4), WithConstantBackoff(time.Millisecond))
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 4, calls)
+}
+
+func TestDoValue(t *testing.T) {
+	var calls int
+	value, err := DoValue(context.Background(), func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errTransient
+		}
+
+		return "ok", nil
+	}, WithConstantBackoff(time.Millisecond))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", value)
+}
+
+func TestDoRetryIf(t *testing.T) {
+	permanent := errors.New("permanent")
+
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return permanent
+	}, WithRetryIf(func(err error) bool {
+		return errors.Is(err, errTransient)
+	}))
+
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoPermanent(t *testing.T) {
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return Permanent(errTransient)
+	}, WithMaxAttempts(5))
+
+	assert.Equal(t, errTransient, err)
+	assert.Equal(t, 1, calls)
+	assert.True(t, IsPermanent(Permanent(errTransient)))
+	assert.False(t, IsPermanent(errTransient))
+	assert.Nil(t, Permanent(nil))
+}
+
+func TestDoAfter(t *testing.T) {
+	var delays []time.Duration
+	var calls int
+
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return After(errTransient, 5*time.Millisecond)
+		}
+
+		return nil
+	}, WithExpBackoff(time.Hour, time.Hour, 2), WithOnRetry(func(attempt int, err error, delay time.Duration) {
+		delays = append(delays, delay)
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{5 * time.Millisecond}, delays)
+}
+
+func TestDoMaxElapsed(t *testing.T) {
+	var calls int
+	err := Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTransient
+	}, WithConstantBackoff(time.Hour), WithMaxElapsed(time.Minute), WithMaxAttempts(0))
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoContextCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := Do(ctx, func(ctx context.Context) error {
+		return errTransient
+	}, WithConstantBackoff(time.Hour))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	p := &Policy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(8))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := p.Backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
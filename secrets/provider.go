@@ -0,0 +1,173 @@
+//
+// Secret providers
+//
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Minimal client, e.g. a wrapper around the SDK GetSecretValue call
+type SecretsManagerClient interface {
+	GetSecretString(ctx context.Context, secretId string) (string, error)
+}
+
+// Minimal client, e.g. a wrapper around the SDK GetParameter call
+type ParameterStoreClient interface {
+	GetParameter(ctx context.Context, name string, withDecryption bool) (string, error)
+}
+
+type envProvider struct {
+	prefix string
+}
+
+type fileProvider struct {
+	dir string
+}
+
+type secretsManagerProvider struct {
+	client SecretsManagerClient
+}
+
+type parameterStoreProvider struct {
+	client ParameterStoreClient
+	prefix string
+}
+
+type chainProvider struct {
+	providers []Provider
+}
+
+var ErrNotFound = errors.New("secrets: secret not found")
+
+func (f ProviderFunc) Get(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+//
+// Environment variables, name "db-password" with prefix "APP_" reads APP_DB_PASSWORD
+//
+
+func NewEnvProvider(prefix string) Provider {
+	return &envProvider{prefix: prefix}
+}
+
+func (p *envProvider) Get(ctx context.Context, name string) (string, error) {
+	key := p.prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	return value, nil
+}
+
+//
+// Files in directory, e.g. mounted Kubernetes or Docker secrets
+//
+
+func NewFileProvider(dir string) Provider {
+	return &fileProvider{dir: dir}
+}
+
+func (p *fileProvider) Get(ctx context.Context, name string) (string, error) {
+	// Names must stay inside the directory
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("secrets: invalid secret name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+//
+// AWS Secrets Manager, "name#key" selects a key from a JSON secret
+//
+
+func NewSecretsManagerProvider(client SecretsManagerClient) Provider {
+	return &secretsManagerProvider{client: client}
+}
+
+func (p *secretsManagerProvider) Get(ctx context.Context, name string) (string, error) {
+	secretId, key, hasKey := strings.Cut(name, "#")
+
+	value, err := p.client.GetSecretString(ctx, secretId)
+	if err != nil || !hasKey {
+		return value, err
+	}
+
+	var fields map[string]any
+	err = json.Unmarshal([]byte(value), &fields)
+	if err != nil {
+		return "", fmt.Errorf("secrets: %s is not a json secret: %w", secretId, err)
+	}
+
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+
+	data, err := json.Marshal(field)
+	return string(data), err
+}
+
+//
+// AWS SSM Parameter Store, names are joined to prefix and decrypted
+//
+
+func NewParameterStoreProvider(client ParameterStoreClient, prefix string) Provider {
+	return &parameterStoreProvider{client: client, prefix: prefix}
+}
+
+func (p *parameterStoreProvider) Get(ctx context.Context, name string) (string, error) {
+	if p.prefix != "" {
+		name = strings.TrimRight(p.prefix, "/") + "/" + strings.TrimLeft(name, "/")
+	}
+
+	return p.client.GetParameter(ctx, name, true)
+}
+
+//
+// Try providers in order, moving on only when a secret is not found
+//
+
+func NewChainProvider(providers ...Provider) Provider {
+	return &chainProvider{providers: providers}
+}
+
+func (p *chainProvider) Get(ctx context.Context, name string) (string, error) {
+	for _, provider := range p.providers {
+		value, err := provider.Get(ctx, name)
+		if !errors.Is(err, ErrNotFound) {
+			return value, err
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
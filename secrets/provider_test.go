@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSecretsManager map[string]string
+
+func (c testSecretsManager) GetSecretString(ctx context.Context, secretId string) (string, error) {
+	value, ok := c[secretId]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+type testParameterStore struct {
+	names []string
+}
+
+func (c *testParameterStore) GetParameter(ctx context.Context, name string, withDecryption bool) (string, error) {
+	c.names = append(c.names, name)
+	return "value", nil
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("APP_DB_PASSWORD", "hunter2")
+	provider := NewEnvProvider("APP_")
+
+	value, err := provider.Get(context.Background(), "db-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = provider.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0o600)
+	provider := NewFileProvider(dir)
+
+	value, err := provider.Get(context.Background(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = provider.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = provider.Get(context.Background(), "../etc/passwd")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	provider := NewSecretsManagerProvider(testSecretsManager{
+		"plain": "value",
+		"db":    `{"username": "app", "port": 5432}`,
+	})
+
+	value, err := provider.Get(context.Background(), "plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	value, err = provider.Get(context.Background(), "db#username")
+	assert.NoError(t, err)
+	assert.Equal(t, "app", value)
+
+	value, err = provider.Get(context.Background(), "db#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value)
+
+	_, err = provider.Get(context.Background(), "db#password")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = provider.Get(context.Background(), "plain#key")
+	assert.Error(t, err)
+}
+
+func TestParameterStoreProvider(t *testing.T) {
+	client := &testParameterStore{}
+	provider := NewParameterStoreProvider(client, "/prod/app/")
+
+	_, err := provider.Get(context.Background(), "db/password")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/prod/app/db/password"}, client.names)
+}
+
+func TestChainProvider(t *testing.T) {
+	failing := ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		return "", errors.New("access denied")
+	})
+
+	provider := NewChainProvider(NewSecretsManagerProvider(testSecretsManager{}), NewSecretsManagerProvider(testSecretsManager{
+		"token": "value",
+	}))
+
+	value, err := provider.Get(context.Background(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = provider.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Other errors stop the chain
+	_, err = NewChainProvider(failing, provider).Get(context.Background(), "token")
+	assert.EqualError(t, err, "access denied")
+}
@@ -0,0 +1,144 @@
+//
+// Cached secret store with rotation callbacks
+//
+
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/cache"
+)
+
+type Store struct {
+	provider Provider
+	ttl      time.Duration
+	grace    time.Duration
+	timeout  time.Duration
+	cache    *cache.Cache[string]
+	mu       sync.Mutex
+	values   map[string]string
+	rotate   map[string][]func(value string)
+}
+
+type Opts struct {
+	Provider Provider
+	TTL      time.Duration
+	Grace    time.Duration
+
+	// Limit for a provider call, which is shared by concurrent callers and
+	// runs detached from their contexts
+	Timeout time.Duration
+}
+
+var DefaultOpts = &Opts{
+	TTL:     5 * time.Minute,
+	Grace:   time.Hour,
+	Timeout: 30 * time.Second,
+}
+
+//
+// Initialize new store
+//
+
+func New(provider Provider) *Store {
+	return NewWithOpts(&Opts{
+		Provider: provider,
+	})
+}
+
+func NewWithOpts(opts *Opts) *Store {
+	if opts.TTL == 0 {
+		opts.TTL = DefaultOpts.TTL
+	}
+
+	if opts.Grace == 0 {
+		opts.Grace = DefaultOpts.Grace
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOpts.Timeout
+	}
+
+	return &Store{
+		provider: opts.Provider,
+		ttl:      opts.TTL,
+		grace:    opts.Grace,
+		timeout:  opts.Timeout,
+		cache:    cache.New[string](),
+		values:   make(map[string]string),
+		rotate:   make(map[string][]func(string)),
+	}
+}
+
+//
+// Get secret, refreshed in the background once TTL passes while the
+// previous value is served during grace, callers stop waiting when their
+// context is done
+//
+
+func (s *Store) Get(ctx context.Context, name string) (string, error) {
+	type result struct {
+		value string
+		err   error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		value, err := s.cache.GetWithOpts(&cache.GetOpts[string]{
+			Key:   name,
+			TTL:   s.ttl.Nanoseconds(),
+			Grace: s.grace.Nanoseconds(),
+			Generator: func() (string, error) {
+				// Background refreshes outlive the request that triggered them
+				providerCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+				defer cancel()
+
+				return s.provider.Get(providerCtx, name)
+			},
+			OnStore: func(value string) {
+				s.stored(name, value)
+			},
+		})
+
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+//
+// Register callback for when a secret changes after it was first fetched
+//
+
+func (s *Store) OnRotate(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate[name] = append(s.rotate[name], fn)
+}
+
+func (s *Store) stored(name string, value string) {
+	s.mu.Lock()
+	previous, seen := s.values[name]
+	s.values[name] = value
+
+	var callbacks []func(string)
+	if seen && previous != value {
+		callbacks = append(callbacks, s.rotate[name]...)
+	}
+
+	s.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(value)
+	}
+}
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreCache(t *testing.T) {
+	var calls atomic.Int64
+
+	store := New(ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		calls.Add(1)
+		return "value:" + name, nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		value, err := store.Get(context.Background(), "token")
+		assert.NoError(t, err)
+		assert.Equal(t, "value:token", value)
+	}
+
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestStoreRotation(t *testing.T) {
+	var version atomic.Int64
+	version.Store(1)
+
+	store := NewWithOpts(&Opts{
+		TTL: 10 * time.Millisecond,
+		Provider: ProviderFunc(func(ctx context.Context, name string) (string, error) {
+			if version.Load() == 1 {
+				return "v1", nil
+			}
+
+			return "v2", nil
+		}),
+	})
+
+	rotated := make(chan string, 1)
+	store.OnRotate("token", func(value string) {
+		rotated <- value
+	})
+
+	value, err := store.Get(context.Background(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", value)
+
+	// Unchanged value does not trigger rotation
+	time.Sleep(20 * time.Millisecond)
+	store.Get(context.Background(), "token")
+	time.Sleep(5 * time.Millisecond)
+
+	version.Store(2)
+	time.Sleep(20 * time.Millisecond)
+
+	// Stale value served during grace while refreshing
+	ctx, cancel := context.WithCancel(context.Background())
+	value, err = store.Get(ctx, "token")
+	cancel()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", value)
+
+	select {
+	case value = <-rotated:
+		assert.Equal(t, "v2", value)
+	case <-time.After(time.Second):
+		t.Fatal("rotation callback not called")
+	}
+
+	assert.Empty(t, rotated)
+}
+
+func TestStoreCallerDeadline(t *testing.T) {
+	providerErr := make(chan error, 1)
+
+	// Hangs until its own timeout
+	store := NewWithOpts(&Opts{
+		Timeout: 100 * time.Millisecond,
+		Provider: ProviderFunc(func(ctx context.Context, name string) (string, error) {
+			<-ctx.Done()
+			providerErr <- ctx.Err()
+			return "", ctx.Err()
+		}),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := store.Get(ctx, "token")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	select {
+	case err := <-providerErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("provider call not bounded by timeout")
+	}
+}
@@ -0,0 +1,389 @@
+//
+// Outbound notifications to Slack and Teams compatible incoming webhooks,
+// with batching and rate limit aware retries
+//
+
+package slackhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+// Text only messages are accepted by both Slack and Teams webhooks, blocks
+// are Slack Block Kit objects
+type Message struct {
+	Text   string           `json:"text,omitempty"`
+	Blocks []map[string]any `json:"blocks,omitempty"`
+}
+
+type Notifier struct {
+	opts    *Opts
+	mu      sync.Mutex
+	pending []*Message
+	timer   *time.Timer
+	closed  bool
+	sent    []*Message
+
+	// Background flushes under way, idle is signalled on mu when none are
+	flushing int
+	idle     *sync.Cond
+}
+
+type Opts struct {
+	URL        string
+	HTTPClient *http.Client
+
+	// Per request, retries get their own
+	Timeout time.Duration
+
+	// Queued messages are merged into one post when BatchSize is reached or
+	// BatchInterval after the first one was queued
+	BatchSize     int
+	BatchInterval time.Duration
+
+	Retry retry.Policy
+
+	// Record messages in Sent instead of posting them
+	Noop bool
+
+	// Failures of batches flushed in the background
+	OnError func(err error)
+}
+
+var DefaultOpts = &Opts{
+	Timeout:       10 * time.Second,
+	BatchSize:     10,
+	BatchInterval: 2 * time.Second,
+	Retry: retry.Policy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxElapsed:     2 * time.Minute,
+	},
+}
+
+var (
+	ErrClosed        = errors.New("slackhook: notifier closed")
+	ErrRateLimited   = errors.New("slackhook: rate limited")
+	ErrRequestFailed = errors.New("slackhook: request failed")
+)
+
+// Slack rejects messages with more blocks
+const maxBlocks = 50
+
+// Longest Retry-After honoured, longer waits fail the send
+const maxRetryAfter = 5 * time.Minute
+
+//
+// Initialize new notifier posting to webhook URL
+//
+
+func New(url string) *Notifier {
+	return NewWithOpts(&Opts{URL: url})
+}
+
+//
+// Initialize notifier that only records messages, for tests and dry runs
+//
+
+func NewNoop() *Notifier {
+	return NewWithOpts(&Opts{Noop: true})
+}
+
+func NewWithOpts(opts *Opts) *Notifier {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOpts.Timeout
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultOpts.BatchSize
+	}
+
+	if opts.BatchInterval == 0 {
+		opts.BatchInterval = DefaultOpts.BatchInterval
+	}
+
+	if opts.Retry.MaxAttempts == 0 {
+		opts.Retry = DefaultOpts.Retry
+	}
+
+	n := &Notifier{opts: opts}
+	n.idle = sync.NewCond(&n.mu)
+
+	return n
+}
+
+//
+// Post text message right away
+//
+
+func (n *Notifier) SendText(ctx context.Context, text string) error {
+	return n.Send(ctx, &Message{Text: text})
+}
+
+//
+// Post message right away, retrying rate limits and server errors
+//
+
+func (n *Notifier) Send(ctx context.Context, msg *Message) error {
+	n.mu.Lock()
+	closed := n.closed
+	n.mu.Unlock()
+
+	if closed {
+		return ErrClosed
+	}
+
+	return n.post(ctx, msg)
+}
+
+//
+// Queue message for the next batch
+//
+
+func (n *Notifier) Notify(msg *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return ErrClosed
+	}
+
+	n.pending = append(n.pending, msg)
+
+	if len(n.pending) >= n.opts.BatchSize {
+		n.flushAsync()
+	} else if n.timer == nil {
+		n.timer = time.AfterFunc(n.opts.BatchInterval, func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			n.flushAsync()
+		})
+	}
+
+	return nil
+}
+
+//
+// Post queued messages now, after background flushes already under way
+//
+
+func (n *Notifier) Flush(ctx context.Context) error {
+	n.mu.Lock()
+	batch := n.takePending()
+	n.waitFlushes()
+	n.mu.Unlock()
+
+	return n.postBatch(ctx, batch)
+}
+
+//
+// Flush queued messages and wait for background flushes, later sends fail
+// with ErrClosed
+//
+
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	n.closed = true
+	batch := n.takePending()
+	n.waitFlushes()
+	n.mu.Unlock()
+
+	return n.postBatch(ctx, batch)
+}
+
+//
+// Messages recorded in noop mode
+//
+
+func (n *Notifier) Sent() []*Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]*Message(nil), n.sent...)
+}
+
+// Caller holds lock
+func (n *Notifier) takePending() []*Message {
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+
+	batch := n.pending
+	n.pending = nil
+
+	return batch
+}
+
+// Caller holds lock
+func (n *Notifier) flushAsync() {
+	batch := n.takePending()
+	if len(batch) == 0 {
+		return
+	}
+
+	n.flushing++
+	go func() {
+		defer func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			n.flushing--
+			if n.flushing == 0 {
+				n.idle.Broadcast()
+			}
+		}()
+
+		err := n.postBatch(context.Background(), batch)
+		if err != nil && n.opts.OnError != nil {
+			n.opts.OnError(err)
+		}
+	}()
+}
+
+// Caller holds lock, which is released while waiting
+func (n *Notifier) waitFlushes() {
+	for n.flushing > 0 {
+		n.idle.Wait()
+	}
+}
+
+func (n *Notifier) postBatch(ctx context.Context, batch []*Message) error {
+	var errs []error
+
+	for _, msg := range mergeMessages(batch) {
+		if err := n.post(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, msg *Message) error {
+	if n.opts.Noop {
+		n.mu.Lock()
+		n.sent = append(n.sent, msg)
+		n.mu.Unlock()
+
+		return nil
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(ctx, func(ctx context.Context) error {
+		return n.postOnce(ctx, body)
+	}, retry.WithPolicy(n.opts.Retry))
+}
+
+func (n *Notifier) postOnce(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+
+	case resp.StatusCode == http.StatusTooManyRequests:
+		delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			return ErrRateLimited
+		}
+
+		if delay > maxRetryAfter {
+			return retry.Permanent(fmt.Errorf("%w: retry after %s", ErrRateLimited, delay))
+		}
+
+		return retry.After(ErrRateLimited, delay)
+	}
+
+	err = fmt.Errorf("%w: %s: %s", ErrRequestFailed, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode < 500 {
+		return retry.Permanent(err)
+	}
+
+	return err
+}
+
+//
+// Merge queued messages into as few posts as Slack accepts
+//
+
+func mergeMessages(batch []*Message) []*Message {
+	var result []*Message
+	var current *Message
+
+	for _, msg := range batch {
+		if current != nil && len(current.Blocks)+len(msg.Blocks) > maxBlocks {
+			current = nil
+		}
+
+		if current == nil {
+			current = &Message{}
+			result = append(result, current)
+		}
+
+		if msg.Text != "" {
+			if current.Text != "" {
+				current.Text += "\n"
+			}
+
+			current.Text += msg.Text
+		}
+
+		current.Blocks = append(current.Blocks, msg.Blocks...)
+	}
+
+	return result
+}
+
+//
+// Retry-After is delay seconds, Slack does not send HTTP dates
+//
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
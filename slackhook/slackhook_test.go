@@ -0,0 +1,213 @@
+package slackhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHook struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func newTestHook(t *testing.T, handler func(w http.ResponseWriter, attempt int) bool) (*testHook, string) {
+	hook := &testHook{}
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil && !handler(w, int(attempts.Add(1))) {
+			return
+		}
+
+		msg := &Message{}
+		json.NewDecoder(r.Body).Decode(msg)
+
+		hook.mu.Lock()
+		hook.messages = append(hook.messages, msg)
+		hook.mu.Unlock()
+
+		w.Write([]byte("ok"))
+	}))
+
+	t.Cleanup(server.Close)
+	return hook, server.URL
+}
+
+func (h *testHook) received() []*Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]*Message(nil), h.messages...)
+}
+
+var testRetry = retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+func TestSend(t *testing.T) {
+	hook, url := newTestHook(t, nil)
+	notifier := New(url)
+
+	assert.NoError(t, notifier.SendText(context.Background(), "deploy finished"))
+	assert.Equal(t, []*Message{{Text: "deploy finished"}}, hook.received())
+}
+
+func TestSendRetries(t *testing.T) {
+	hook, url := newTestHook(t, func(w http.ResponseWriter, attempt int) bool {
+		switch attempt {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return false
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+			return false
+		}
+
+		return true
+	})
+
+	notifier := NewWithOpts(&Opts{URL: url, Retry: testRetry})
+	assert.NoError(t, notifier.SendText(context.Background(), "hello"))
+	assert.Len(t, hook.received(), 1)
+}
+
+func TestSendErrors(t *testing.T) {
+	var attempts atomic.Int32
+	_, url := newTestHook(t, func(w http.ResponseWriter, attempt int) bool {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no_service"))
+		return false
+	})
+
+	notifier := NewWithOpts(&Opts{URL: url, Retry: testRetry})
+	err := notifier.SendText(context.Background(), "hello")
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorContains(t, err, "no_service")
+	assert.Equal(t, int32(1), attempts.Load())
+
+	_, url = newTestHook(t, func(w http.ResponseWriter, attempt int) bool {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return false
+	})
+
+	notifier = NewWithOpts(&Opts{URL: url, Retry: testRetry})
+	assert.ErrorIs(t, notifier.SendText(context.Background(), "hello"), ErrRateLimited)
+}
+
+func TestNotifyBatches(t *testing.T) {
+	hook, url := newTestHook(t, nil)
+	notifier := NewWithOpts(&Opts{URL: url, BatchSize: 3, BatchInterval: time.Hour})
+
+	for _, text := range []string{"job a done", "job b done", "job c failed"} {
+		assert.NoError(t, notifier.Notify(&Message{Text: text}))
+	}
+
+	assert.NoError(t, notifier.Notify(&Message{Text: "job d done"}))
+	assert.NoError(t, notifier.Close(context.Background()))
+
+	assert.Equal(t, []*Message{
+		{Text: "job a done\njob b done\njob c failed"},
+		{Text: "job d done"},
+	}, hook.received())
+
+	assert.ErrorIs(t, notifier.Notify(&Message{Text: "late"}), ErrClosed)
+	assert.ErrorIs(t, notifier.SendText(context.Background(), "late"), ErrClosed)
+}
+
+func TestNotifyInterval(t *testing.T) {
+	hook, url := newTestHook(t, nil)
+	notifier := NewWithOpts(&Opts{URL: url, BatchInterval: 10 * time.Millisecond})
+
+	notifier.Notify(&Message{Text: "one"})
+	notifier.Notify(&Message{Text: "two"})
+
+	assert.Eventually(t, func() bool {
+		return len(hook.received()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, "one\ntwo", hook.received()[0].Text)
+}
+
+func TestNotifyOnError(t *testing.T) {
+	_, url := newTestHook(t, func(w http.ResponseWriter, attempt int) bool {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	})
+
+	errs := make(chan error, 1)
+	notifier := NewWithOpts(&Opts{URL: url, BatchSize: 1, OnError: func(err error) {
+		errs <- err
+	}})
+
+	notifier.Notify(&Message{Text: "one"})
+	assert.ErrorIs(t, <-errs, ErrRequestFailed)
+}
+
+func TestFlushConcurrentNotify(t *testing.T) {
+	notifier := NewWithOpts(&Opts{Noop: true, BatchSize: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 200; j++ {
+				notifier.Notify(&Message{Text: "job done"})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 200; j++ {
+				assert.NoError(t, notifier.Flush(context.Background()))
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.NoError(t, notifier.Close(context.Background()))
+	assert.Len(t, notifier.Sent(), 800)
+}
+
+func TestMergeMessages(t *testing.T) {
+	block := map[string]any{"type": "divider"}
+	many := make([]map[string]any, 30)
+	for i := range many {
+		many[i] = block
+	}
+
+	merged := mergeMessages([]*Message{
+		{Text: "a", Blocks: many},
+		{Blocks: many},
+		{Text: "c", Blocks: []map[string]any{block}},
+	})
+
+	assert.Len(t, merged, 2)
+	assert.Equal(t, "a", merged[0].Text)
+	assert.Len(t, merged[0].Blocks, 30)
+	assert.Equal(t, "c", merged[1].Text)
+	assert.Len(t, merged[1].Blocks, 31)
+}
+
+func TestNoop(t *testing.T) {
+	notifier := NewNoop()
+
+	assert.NoError(t, notifier.SendText(context.Background(), "direct"))
+	assert.NoError(t, notifier.Notify(&Message{Text: "queued"}))
+	assert.NoError(t, notifier.Flush(context.Background()))
+
+	assert.Equal(t, []*Message{{Text: "direct"}, {Text: "queued"}}, notifier.Sent())
+}
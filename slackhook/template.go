@@ -0,0 +1,96 @@
+//
+// Messages from text/template, for notifications sharing a layout
+//
+
+package slackhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+)
+
+// Renders message JSON, either {"text": ..., "blocks": [...]} or a bare
+// blocks array
+type Template struct {
+	tmpl *template.Template
+}
+
+var ErrInvalidTemplate = errors.New("slackhook: invalid template")
+
+var templateFuncs = template.FuncMap{
+	// Quoted JSON string, for values placed inside block text
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+
+	"truncate": func(n int, s string) string {
+		runes := []rune(s)
+		if len(runes) <= n {
+			return s
+		}
+
+		return string(runes[:max(n-1, 0)]) + "…"
+	},
+
+	"ago":      format.RelativeTime,
+	"duration": format.FormatDurationShort,
+	"timestamp": func(t time.Time) string {
+		return format.FormatTimestamp(t.UTC(), format.PrecisionMinute) + " UTC"
+	},
+}
+
+//
+// Parse template, see templateFuncs for available functions
+//
+
+func NewTemplate(name string, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	return &Template{tmpl: tmpl}, nil
+}
+
+func MustTemplate(name string, text string) *Template {
+	t, err := NewTemplate(name, text)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+//
+// Render message for data
+//
+
+func (t *Template) Render(data any) (*Message, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	msg := &Message{}
+	out := bytes.TrimSpace(buf.Bytes())
+
+	var err error
+	if bytes.HasPrefix(out, []byte("[")) {
+		err = json.Unmarshal(out, &msg.Blocks)
+	} else {
+		err = json.Unmarshal(out, msg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, t.tmpl.Name(), err)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,52 @@
+package slackhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl := MustTemplate("job", `{
+  "text": {{ printf "%s %s" .Job .Status | json }},
+  "blocks": [
+    {"type": "section", "text": {"type": "mrkdwn", "text": {{ printf "*%s* %s in %s" .Job .Status (duration .Took) | json }}}},
+    {"type": "context", "elements": [{"type": "mrkdwn", "text": {{ truncate 8 .Detail | json }}}]}
+  ]
+}`)
+
+	msg, err := tmpl.Render(map[string]any{
+		"Job":    `backup "db"`,
+		"Status": "failed",
+		"Took":   90 * time.Second,
+		"Detail": "disk full on /var",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `backup "db" failed`, msg.Text)
+	assert.Len(t, msg.Blocks, 2)
+	assert.Equal(t, `*backup "db"* failed in 1m30s`, msg.Blocks[0]["text"].(map[string]any)["text"])
+	assert.Equal(t, "disk fu…", msg.Blocks[1]["elements"].([]any)[0].(map[string]any)["text"])
+}
+
+func TestTemplateBlocks(t *testing.T) {
+	tmpl := MustTemplate("blocks", `[{"type": "header", "text": {"type": "plain_text", "text": {{ timestamp .At | json }}}}]`)
+
+	msg, err := tmpl.Render(map[string]any{"At": time.Date(2026, 10, 15, 14, 3, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	assert.Empty(t, msg.Text)
+	assert.Equal(t, "2026-10-15 14:03 UTC", msg.Blocks[0]["text"].(map[string]any)["text"])
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := NewTemplate("broken", `{{ .Job `)
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+
+	tmpl := MustTemplate("unquoted", `{"text": {{ .Job }}}`)
+	_, err = tmpl.Render(map[string]any{"Job": "backup"})
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+
+	_, err = tmpl.Render(map[string]any{})
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+}
@@ -0,0 +1,260 @@
+//
+// Schedules, cron expressions and fixed intervals
+//
+
+package taskscheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// First activation strictly after the given time
+	Next(after time.Time) time.Time
+}
+
+var ErrInvalidSchedule = errors.New("taskscheduler: invalid schedule")
+
+//
+// Fixed interval, measured from the previous activation
+//
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &intervalSchedule{interval: interval}
+}
+
+func (s *intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+//
+// Standard five field cron expression, minute hour day-of-month month
+// day-of-week, evaluated in the location of the time passed to Next
+//
+
+type cronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// Both day fields restricted, either may match as in Vixie cron
+	dayOr bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//
+// Parse cron expression, also accepting macros like @daily and @every 5m
+//
+
+func ParseCron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, expr)
+		}
+
+		return Every(interval), nil
+	}
+
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q needs 5 fields", ErrInvalidSchedule, expr)
+	}
+
+	var s cronSchedule
+	var err error
+
+	for i, target := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dom, domField},
+		{&s.month, monthField},
+		{&s.dow, dowField},
+	} {
+		*target.bits, err = target.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, expr, err)
+		}
+	}
+
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	s.dayOr = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+//
+// Parse field into a bitset, supports *, lists, ranges, steps and names
+//
+
+func (f cronField) parse(input string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(input, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step %q", part)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*":
+			lo, hi = f.min, f.max
+
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+
+		default:
+			var err error
+			if lo, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+
+			// "5/15" runs from 5 to the end of the range
+			hi = lo
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		if lo > hi {
+			return 0, fmt.Errorf("bad range %q", part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(input string) (int, error) {
+	if v, ok := f.names[strings.ToLower(input)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(input)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", input, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+//
+// Walk forward field by field, giving up after five years without a match,
+// e.g. for February 30th
+//
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.dayOr {
+		return dom || dow
+	}
+
+	return dom && dow
+}
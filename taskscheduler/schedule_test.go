@@ -0,0 +1,88 @@
+package taskscheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next []string
+	}{
+		{"* * * * *", []string{"2024-01-31T10:18:00Z", "2024-01-31T10:19:00Z"}},
+		{"*/15 * * * *", []string{"2024-01-31T10:30:00Z", "2024-01-31T10:45:00Z", "2024-01-31T11:00:00Z"}},
+		{"5/20 9-10 * * *", []string{"2024-01-31T10:25:00Z", "2024-01-31T10:45:00Z", "2024-02-01T09:05:00Z"}},
+		{"0 0 * * *", []string{"2024-02-01T00:00:00Z", "2024-02-02T00:00:00Z"}},
+		{"@daily", []string{"2024-02-01T00:00:00Z"}},
+		{"@hourly", []string{"2024-01-31T11:00:00Z"}},
+		{"0 12 * * mon,FRI", []string{"2024-02-02T12:00:00Z", "2024-02-05T12:00:00Z"}},
+		{"0 0 * * 7", []string{"2024-02-04T00:00:00Z"}},
+		{"0 0 29 feb *", []string{"2024-02-29T00:00:00Z", "2028-02-29T00:00:00Z"}},
+		{"0 0 31 * *", []string{"2024-03-31T00:00:00Z", "2024-05-31T00:00:00Z"}},
+
+		// Restricted day of month and day of week match either
+		{"0 0 1 * mon", []string{"2024-02-01T00:00:00Z", "2024-02-05T00:00:00Z"}},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.expr)
+		assert.NoError(t, err, test.expr)
+
+		now := start
+		for _, expected := range test.next {
+			now = schedule.Next(now)
+			assert.Equal(t, expected, now.Format(time.RFC3339), test.expr)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@every",
+		"@every -1s",
+	}
+
+	for _, expr := range tests {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, expr)
+	}
+}
+
+func TestCronImpossible(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 feb *")
+	assert.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestCronLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+5:30", 5*3600+1800)
+	schedule, err := ParseCron("0 9 * * *")
+	assert.NoError(t, err)
+
+	next := schedule.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, loc))
+	assert.Equal(t, "2024-01-02T09:00:00+05:30", next.Format(time.RFC3339))
+}
+
+func TestEvery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	schedule, err := ParseCron("@every 90s")
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(90*time.Second), schedule.Next(start))
+	assert.Equal(t, start.Add(time.Minute), Every(time.Minute).Next(start))
+}
@@ -0,0 +1,338 @@
+//
+// In-process job scheduler with overlap policies and panic recovery
+//
+
+package taskscheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Job func(ctx context.Context) error
+
+// What to do when a job is due while its previous run is still going
+type OverlapPolicy int
+
+const (
+	// Skip the activation
+	OverlapSkip OverlapPolicy = iota
+	// Run once more after the current run, further activations are skipped
+	OverlapQueue
+	// Start another run alongside
+	OverlapConcurrent
+)
+
+type Scheduler struct {
+	opts    *Opts
+	mu      sync.Mutex
+	jobs    map[string]*job
+	ctx     context.Context
+	running sync.WaitGroup
+}
+
+type Opts struct {
+	Location *time.Location
+	OnStart  func(name string)
+	OnFinish func(name string, duration time.Duration, err error)
+	OnSkip   func(name string)
+}
+
+type JobOpts struct {
+	Name     string
+	Schedule Schedule
+	Job      Job
+	Overlap  OverlapPolicy
+	Timeout  time.Duration
+
+	// Random delay up to this long added to every activation
+	Jitter time.Duration
+}
+
+type job struct {
+	opts    *JobOpts
+	cancel  context.CancelFunc
+	active  atomic.Int32
+	pending chan bool
+	next    atomic.Int64
+}
+
+var DefaultOpts = &Opts{
+	Location: time.Local,
+}
+
+var (
+	ErrDuplicateJob = errors.New("taskscheduler: job already exists")
+	ErrInvalidJob   = errors.New("taskscheduler: job needs name, schedule and func")
+	ErrJobPanic     = errors.New("taskscheduler: job panicked")
+)
+
+//
+// Initialize new scheduler
+//
+
+func New() *Scheduler {
+	return NewWithOpts(&Opts{})
+}
+
+func NewWithOpts(opts *Opts) *Scheduler {
+	if opts.Location == nil {
+		opts.Location = DefaultOpts.Location
+	}
+
+	return &Scheduler{
+		opts: opts,
+		jobs: make(map[string]*job),
+	}
+}
+
+//
+// Add job with cron expression and default opts
+//
+
+func (s *Scheduler) AddFunc(name string, expr string, fn Job) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	return s.Add(&JobOpts{
+		Name:     name,
+		Schedule: schedule,
+		Job:      fn,
+	})
+}
+
+//
+// Add job, it starts right away when the scheduler is running
+//
+
+func (s *Scheduler) Add(opts *JobOpts) error {
+	if (opts.Name == "") || (opts.Schedule == nil) || (opts.Job == nil) {
+		return ErrInvalidJob
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[opts.Name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, opts.Name)
+	}
+
+	j := &job{
+		opts:    opts,
+		pending: make(chan bool, 1),
+	}
+
+	s.jobs[opts.Name] = j
+
+	if s.ctx != nil {
+		s.start(j)
+	}
+
+	return nil
+}
+
+//
+// Remove job, runs in progress are cancelled
+//
+
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, exists := s.jobs[name]
+	if !exists {
+		return false
+	}
+
+	if j.cancel != nil {
+		j.cancel()
+	}
+
+	delete(s.jobs, name)
+	return true
+}
+
+//
+// Next activation of job, zero when unknown or not running
+//
+
+func (s *Scheduler) Next(name string) time.Time {
+	s.mu.Lock()
+	j, exists := s.jobs[name]
+	s.mu.Unlock()
+
+	if !exists || (j.next.Load() == 0) {
+		return time.Time{}
+	}
+
+	return time.Unix(0, j.next.Load()).In(s.opts.Location)
+}
+
+//
+// Run all jobs until context is done, then wait for runs in progress
+//
+
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+
+	for _, j := range s.jobs {
+		s.start(j)
+	}
+
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+
+	s.running.Wait()
+}
+
+//
+// Start job loop, must be called with lock held
+//
+
+func (s *Scheduler) start(j *job) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	j.cancel = cancel
+
+	s.running.Add(1)
+	go s.loop(ctx, j)
+
+	if j.opts.Overlap == OverlapQueue {
+		s.running.Add(1)
+		go s.drain(ctx, j)
+	}
+}
+
+//
+// Sleep until each activation and dispatch it per overlap policy
+//
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.running.Done()
+
+	now := time.Now().In(s.opts.Location)
+
+	for {
+		next := j.opts.Schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+
+		if j.opts.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(j.opts.Jitter))))
+		}
+
+		j.next.Store(next.UnixNano())
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Schedule from the planned time so slow wakeups don't drift
+		now = next.In(s.opts.Location)
+		s.dispatch(ctx, j)
+	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context, j *job) {
+	switch j.opts.Overlap {
+	case OverlapConcurrent:
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.execute(ctx, j)
+		}()
+
+	case OverlapQueue:
+		select {
+		case j.pending <- true:
+		default:
+			s.skipped(j)
+		}
+
+	default:
+		if !j.active.CompareAndSwap(0, 1) {
+			s.skipped(j)
+			return
+		}
+
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			defer j.active.Store(0)
+			s.execute(ctx, j)
+		}()
+	}
+}
+
+//
+// Run queued activations one at a time
+//
+
+func (s *Scheduler) drain(ctx context.Context, j *job) {
+	defer s.running.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.pending:
+			s.execute(ctx, j)
+		}
+	}
+}
+
+func (s *Scheduler) skipped(j *job) {
+	if s.opts.OnSkip != nil {
+		s.opts.OnSkip(j.opts.Name)
+	}
+}
+
+//
+// Single run with timeout, hooks and panic recovery
+//
+
+func (s *Scheduler) execute(ctx context.Context, j *job) {
+	if j.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.Timeout)
+		defer cancel()
+	}
+
+	if s.opts.OnStart != nil {
+		s.opts.OnStart(j.opts.Name)
+	}
+
+	start := time.Now()
+	err := runJob(ctx, j.opts.Job)
+
+	if s.opts.OnFinish != nil {
+		s.opts.OnFinish(j.opts.Name, time.Since(start), err)
+	}
+}
+
+func runJob(ctx context.Context, fn Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+		}
+	}()
+
+	return fn(ctx)
+}
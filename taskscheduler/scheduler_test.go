@@ -0,0 +1,186 @@
+package taskscheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runScheduler(t *testing.T, s *Scheduler, d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	s.Run(ctx)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	var runs atomic.Int32
+	var mu sync.Mutex
+	var errs []error
+
+	s := NewWithOpts(&Opts{
+		OnFinish: func(name string, duration time.Duration, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+
+	assert.NoError(t, s.Add(&JobOpts{
+		Name:     "tick",
+		Schedule: Every(10 * time.Millisecond),
+		Job: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	}))
+
+	assert.ErrorIs(t, s.Add(&JobOpts{Name: "tick", Schedule: Every(time.Second), Job: func(ctx context.Context) error { return nil }}), ErrDuplicateJob)
+	assert.ErrorIs(t, s.Add(&JobOpts{Name: "empty"}), ErrInvalidJob)
+	assert.ErrorIs(t, s.AddFunc("bad", "* *", nil), ErrInvalidSchedule)
+
+	runScheduler(t, s, 105*time.Millisecond)
+
+	assert.GreaterOrEqual(t, runs.Load(), int32(5))
+	mu.Lock()
+	assert.Len(t, errs, int(runs.Load()))
+	mu.Unlock()
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		policy OverlapPolicy
+		check  func(t *testing.T, started int32, skipped int32, maxActive int32)
+	}{
+		{OverlapSkip, func(t *testing.T, started int32, skipped int32, maxActive int32) {
+			assert.Equal(t, int32(1), maxActive)
+			assert.Greater(t, skipped, int32(0))
+		}},
+		{OverlapQueue, func(t *testing.T, started int32, skipped int32, maxActive int32) {
+			assert.Equal(t, int32(1), maxActive)
+			assert.GreaterOrEqual(t, started, int32(2))
+		}},
+		{OverlapConcurrent, func(t *testing.T, started int32, skipped int32, maxActive int32) {
+			assert.Greater(t, maxActive, int32(1))
+			assert.Equal(t, int32(0), skipped)
+		}},
+	}
+
+	for _, test := range tests {
+		var started, skipped, active, maxActive atomic.Int32
+
+		s := NewWithOpts(&Opts{
+			OnSkip: func(name string) {
+				skipped.Add(1)
+			},
+		})
+
+		s.Add(&JobOpts{
+			Name:     "slow",
+			Schedule: Every(10 * time.Millisecond),
+			Overlap:  test.policy,
+			Job: func(ctx context.Context) error {
+				started.Add(1)
+				n := active.Add(1)
+				defer active.Add(-1)
+
+				for {
+					m := maxActive.Load()
+					if n <= m || maxActive.CompareAndSwap(m, n) {
+						break
+					}
+				}
+
+				select {
+				case <-time.After(35 * time.Millisecond):
+				case <-ctx.Done():
+				}
+
+				return nil
+			},
+		})
+
+		runScheduler(t, s, 100*time.Millisecond)
+		test.check(t, started.Load(), skipped.Load(), maxActive.Load())
+		assert.Equal(t, int32(0), active.Load())
+	}
+}
+
+func TestSchedulerPanicAndTimeout(t *testing.T) {
+	results := make(chan error, 10)
+	s := NewWithOpts(&Opts{
+		OnFinish: func(name string, duration time.Duration, err error) {
+			results <- err
+		},
+	})
+
+	s.Add(&JobOpts{
+		Name:     "panic",
+		Schedule: Every(20 * time.Millisecond),
+		Job: func(ctx context.Context) error {
+			panic("boom")
+		},
+	})
+
+	s.Add(&JobOpts{
+		Name:     "timeout",
+		Schedule: Every(20 * time.Millisecond),
+		Timeout:  5 * time.Millisecond,
+		Job: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	runScheduler(t, s, 30*time.Millisecond)
+	close(results)
+
+	var panicked, timedOut bool
+	for err := range results {
+		panicked = panicked || errors.Is(err, ErrJobPanic)
+		timedOut = timedOut || errors.Is(err, context.DeadlineExceeded)
+	}
+
+	assert.True(t, panicked)
+	assert.True(t, timedOut)
+}
+
+func TestSchedulerAddRemoveWhileRunning(t *testing.T) {
+	var runs atomic.Int32
+	s := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	s.Add(&JobOpts{
+		Name:     "late",
+		Schedule: Every(5 * time.Millisecond),
+		Jitter:   time.Millisecond,
+		Job: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	assert.Eventually(t, func() bool {
+		return runs.Load() > 0
+	}, time.Second, time.Millisecond)
+
+	assert.False(t, s.Next("late").IsZero())
+	assert.True(t, s.Remove("late"))
+	assert.False(t, s.Remove("late"))
+	assert.True(t, s.Next("late").IsZero())
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,102 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReloader(t *testing.T, name string) (*Reloader, *x509.CertPool) {
+	certPEM, keyPEM := newTestCert(t, name, time.Now().Add(24*time.Hour))
+	r, err := NewReloader(context.Background(), SourceFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		return certPEM, keyPEM, nil
+	}))
+
+	assert.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(r.Leaf())
+	return r, pool
+}
+
+// Loopback connection, socket buffers keep alerts from blocking either side
+func handshake(server *tls.Config, client *tls.Config) (*tls.ConnectionState, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	defer ln.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		defer conn.Close()
+		errs <- tls.Server(conn, server).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err != nil {
+		<-errs
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	// TLS 1.3 clients finish before the server has verified their certificate
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	state := conn.ConnectionState()
+	return &state, nil
+}
+
+func TestServerClientConfig(t *testing.T) {
+	serverCerts, serverPool := newTestReloader(t, "server")
+	clientCerts, clientPool := newTestReloader(t, "client")
+
+	server := NewServerConfig(&ServerConfigOpts{
+		Certificates:      serverCerts,
+		ClientCAs:         clientPool,
+		RequireClientCert: true,
+	})
+
+	assert.Equal(t, uint16(tls.VersionTLS12), server.MinVersion)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, server.ClientAuth)
+
+	client := NewClientConfig(&ClientConfigOpts{
+		Certificates: clientCerts,
+		RootCAs:      serverPool,
+		ServerName:   "localhost",
+	})
+
+	state, err := handshake(server, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "server", state.PeerCertificates[0].Subject.CommonName)
+
+	// Missing client certificate
+	_, err = handshake(server, NewClientConfig(&ClientConfigOpts{
+		RootCAs:    serverPool,
+		ServerName: "localhost",
+	}))
+
+	assert.Error(t, err)
+}
+
+func TestServerConfigOptionalClientCert(t *testing.T) {
+	_, pool := newTestReloader(t, "client")
+
+	assert.Equal(t, tls.VerifyClientCertIfGiven, NewServerConfig(&ServerConfigOpts{ClientCAs: pool}).ClientAuth)
+	assert.Equal(t, tls.NoClientCert, NewServerConfig(&ServerConfigOpts{}).ClientAuth)
+}
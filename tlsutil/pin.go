@@ -0,0 +1,86 @@
+//
+// SPKI pinning, pins are base64 SHA-256 digests of the subject public key info
+//
+
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	"github.com/publishlab/infra-golang-toolkit/format"
+)
+
+var ErrPinMismatch = errors.New("tlsutil: no certificate matches pinned keys")
+
+//
+// Pin of certificate public key, as used by HPKP and curl --pinnedpubkey
+//
+
+func SPKIPin(cert *x509.Certificate) string {
+	return format.FingerprintSHA256(cert.RawSubjectPublicKeyInfo, format.FingerprintBase64)
+}
+
+//
+// VerifyConnection callback accepting connections where any certificate of
+// a verified chain matches a pin, "sha256/" prefixes allowed, runs on
+// resumed sessions too, unlike VerifyPeerCertificate
+//
+
+func VerifyConnectionPins(pins []string) func(state tls.ConnectionState) error {
+	match := pinMatcher(pins)
+
+	return func(state tls.ConnectionState) error {
+		var leaf *x509.Certificate
+		if len(state.PeerCertificates) > 0 {
+			leaf = state.PeerCertificates[0]
+		}
+
+		return match(leaf, state.VerifiedChains)
+	}
+}
+
+//
+// VerifyPeerCertificate callback with the same checks, for configs that
+// can't use VerifyConnection
+//
+
+func VerifyPins(pins []string) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	match := pinMatcher(pins)
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		var leaf *x509.Certificate
+		if len(rawCerts) > 0 {
+			leaf, _ = x509.ParseCertificate(rawCerts[0])
+		}
+
+		return match(leaf, verifiedChains)
+	}
+}
+
+func pinMatcher(pins []string) func(leaf *x509.Certificate, verifiedChains [][]*x509.Certificate) error {
+	allowed := make(map[string]bool)
+	for _, pin := range pins {
+		allowed[strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")] = true
+	}
+
+	return func(leaf *x509.Certificate, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if allowed[SPKIPin(cert)] {
+					return nil
+				}
+			}
+		}
+
+		// Chain verification disabled, only the leaf key is proven by the
+		// handshake, other presented certificates could be anyone's
+		if len(verifiedChains) == 0 && leaf != nil && allowed[SPKIPin(leaf)] {
+			return nil
+		}
+
+		return ErrPinMismatch
+	}
+}
@@ -0,0 +1,86 @@
+package tlsutil
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPKIPin(t *testing.T) {
+	r, _ := newTestReloader(t, "pinned")
+	leaf := r.Leaf()
+
+	digest := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(digest[:]), SPKIPin(leaf))
+}
+
+func TestVerifyPins(t *testing.T) {
+	serverCerts, serverPool := newTestReloader(t, "server")
+	other, _ := newTestReloader(t, "other")
+	server := NewServerConfig(&ServerConfigOpts{Certificates: serverCerts})
+
+	tests := []struct {
+		pins []string
+		err  error
+	}{
+		{[]string{SPKIPin(serverCerts.Leaf())}, nil},
+		{[]string{SPKIPin(other.Leaf()), "sha256/" + SPKIPin(serverCerts.Leaf())}, nil},
+		{[]string{SPKIPin(other.Leaf())}, ErrPinMismatch},
+	}
+
+	for _, test := range tests {
+		_, err := handshake(server, NewClientConfig(&ClientConfigOpts{
+			RootCAs:    serverPool,
+			ServerName: "localhost",
+			Pins:       test.pins,
+		}))
+
+		if test.err == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, test.err)
+		}
+	}
+
+	// Pins still apply with chain verification disabled
+	verify := VerifyPins([]string{SPKIPin(serverCerts.Leaf())})
+	assert.NoError(t, verify(serverCerts.Certificate().Certificate, nil))
+	assert.ErrorIs(t, verify(other.Certificate().Certificate, nil), ErrPinMismatch)
+}
+
+func TestVerifyPinsLeafOnly(t *testing.T) {
+	serverCerts, _ := newTestReloader(t, "server")
+	pinned, _ := newTestReloader(t, "pinned")
+
+	// Server sends a pinned certificate it has no key for next to its leaf
+	cert := *serverCerts.Certificate()
+	cert.Certificate = append(append([][]byte{}, cert.Certificate...), pinned.Certificate().Certificate[0])
+
+	verify := VerifyPins([]string{SPKIPin(pinned.Leaf())})
+	assert.ErrorIs(t, verify(cert.Certificate, nil), ErrPinMismatch)
+
+	server := &tls.Config{Certificates: []tls.Certificate{cert}}
+	client := NewClientConfig(&ClientConfigOpts{
+		ServerName: "localhost",
+		Pins:       []string{SPKIPin(pinned.Leaf())},
+	})
+
+	client.InsecureSkipVerify = true
+
+	_, err := handshake(server, client)
+	assert.ErrorIs(t, err, ErrPinMismatch)
+
+	// Leaf pin passes without chain verification
+	client = NewClientConfig(&ClientConfigOpts{
+		ServerName: "localhost",
+		Pins:       []string{SPKIPin(serverCerts.Leaf())},
+	})
+
+	client.InsecureSkipVerify = true
+
+	_, err = handshake(server, client)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,223 @@
+//
+// Hot-reloading certificate with expiry monitoring
+//
+
+package tlsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/healthcheck"
+)
+
+type Reloader struct {
+	opts    *ReloaderOpts
+	current atomic.Pointer[tls.Certificate]
+	mu      sync.Mutex
+	certPEM []byte
+	keyPEM  []byte
+}
+
+type ReloaderOpts struct {
+	Source        Source
+	Interval      time.Duration
+	ExpiryWarning time.Duration
+	OnReload      func(leaf *x509.Certificate)
+	OnError       func(err error)
+	OnExpiring    func(leaf *x509.Certificate, remaining time.Duration)
+}
+
+var DefaultReloaderOpts = &ReloaderOpts{
+	Interval:      time.Minute,
+	ExpiryWarning: 14 * 24 * time.Hour,
+}
+
+var (
+	ErrNoCertificate      = errors.New("tlsutil: no certificate loaded")
+	ErrCertificateExpired = errors.New("tlsutil: certificate expired or expiring")
+)
+
+//
+// Initialize reloader, the initial load must succeed
+//
+
+func NewReloader(ctx context.Context, source Source) (*Reloader, error) {
+	return NewReloaderWithOpts(ctx, &ReloaderOpts{
+		Source: source,
+	})
+}
+
+func NewReloaderWithOpts(ctx context.Context, opts *ReloaderOpts) (*Reloader, error) {
+	if opts.Interval == 0 {
+		opts.Interval = DefaultReloaderOpts.Interval
+	}
+
+	if opts.ExpiryWarning == 0 {
+		opts.ExpiryWarning = DefaultReloaderOpts.ExpiryWarning
+	}
+
+	r := &Reloader{
+		opts: opts,
+	}
+
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//
+// Load from source, swapping the certificate only if the content changed
+// and parses, a broken update keeps serving the previous certificate
+//
+
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certPEM, keyPEM, err := r.opts.Source.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("tlsutil: loading certificate: %w", err)
+	}
+
+	if bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM) {
+		return nil
+	}
+
+	cert, err := ParseKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	r.certPEM = certPEM
+	r.keyPEM = keyPEM
+	r.current.Store(cert)
+
+	if r.opts.OnReload != nil {
+		r.opts.OnReload(cert.Leaf)
+	}
+
+	return nil
+}
+
+//
+// Poll source and check expiry every interval until context is done
+//
+
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.checkExpiry()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Reload(ctx); (err != nil) && (r.opts.OnError != nil) && (ctx.Err() == nil) {
+			r.opts.OnError(err)
+		}
+	}
+}
+
+func (r *Reloader) checkExpiry() {
+	leaf := r.Leaf()
+	if (leaf == nil) || (r.opts.OnExpiring == nil) {
+		return
+	}
+
+	if remaining := time.Until(leaf.NotAfter); remaining < r.opts.ExpiryWarning {
+		r.opts.OnExpiring(leaf, remaining)
+	}
+}
+
+//
+// Current certificate and its parsed leaf
+//
+
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.current.Load()
+}
+
+func (r *Reloader) Leaf() *x509.Certificate {
+	cert := r.current.Load()
+	if cert == nil {
+		return nil
+	}
+
+	return cert.Leaf
+}
+
+//
+// Callbacks for tls.Config
+//
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := r.current.Load(); cert != nil {
+		return cert, nil
+	}
+
+	return nil, ErrNoCertificate
+}
+
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := r.current.Load(); cert != nil {
+		return cert, nil
+	}
+
+	return nil, ErrNoCertificate
+}
+
+//
+// Health check failing when the certificate expires within the given window
+//
+
+func (r *Reloader) ExpiryCheck(within time.Duration) healthcheck.Check {
+	return func(ctx context.Context) error {
+		leaf := r.Leaf()
+		if leaf == nil {
+			return ErrNoCertificate
+		}
+
+		return CheckExpiry(leaf, within)
+	}
+}
+
+//
+// Parse PEM key pair, filling in the leaf
+//
+
+func ParseKeyPair(certPEM []byte, keyPEM []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("tlsutil: parsing key pair: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("tlsutil: parsing certificate: %w", err)
+	}
+
+	cert.Leaf = leaf
+	return &cert, nil
+}
+
+func CheckExpiry(cert *x509.Certificate, within time.Duration) error {
+	if remaining := time.Until(cert.NotAfter); remaining < within {
+		return fmt.Errorf("%w: %q expires %s", ErrCertificateExpired, cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+// Self-signed ECDSA certificate valid for localhost
+func newTestCert(t *testing.T, name string, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM
+}
+
+func TestReloaderFileSource(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	certPEM, keyPEM := newTestCert(t, "first", time.Now().Add(24*time.Hour))
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	var reloads atomic.Int32
+	r, err := NewReloaderWithOpts(context.Background(), &ReloaderOpts{
+		Source: FileSource(certFile, keyFile),
+		OnReload: func(leaf *x509.Certificate) {
+			reloads.Add(1)
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "first", r.Leaf().Subject.CommonName)
+	assert.Equal(t, int32(1), reloads.Load())
+
+	// Unchanged content is not reparsed
+	assert.NoError(t, r.Reload(context.Background()))
+	assert.Equal(t, int32(1), reloads.Load())
+
+	// Rotated certificate
+	certPEM, keyPEM = newTestCert(t, "second", time.Now().Add(24*time.Hour))
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	assert.NoError(t, r.Reload(context.Background()))
+	assert.Equal(t, "second", r.Leaf().Subject.CommonName)
+	assert.Equal(t, int32(2), reloads.Load())
+
+	cert, err := r.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Same(t, r.Certificate(), cert)
+
+	// Broken update keeps serving the previous certificate
+	assert.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	assert.Error(t, r.Reload(context.Background()))
+	assert.Equal(t, "second", r.Leaf().Subject.CommonName)
+}
+
+func TestReloaderInitialError(t *testing.T) {
+	_, err := NewReloader(context.Background(), FileSource("/nonexistent/tls.crt", "/nonexistent/tls.key"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReloaderSecretSource(t *testing.T) {
+	certPEM, keyPEM := newTestCert(t, "secret", time.Now().Add(24*time.Hour))
+	provider := secrets.ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		switch name {
+		case "tls#cert":
+			return string(certPEM), nil
+		case "tls#key":
+			return string(keyPEM), nil
+		}
+		return "", errors.New("not found")
+	})
+
+	r, err := NewReloader(context.Background(), SecretSource(provider, "tls#cert", "tls#key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", r.Leaf().Subject.CommonName)
+}
+
+func TestReloaderRun(t *testing.T) {
+	var calls atomic.Int32
+	var expiring atomic.Int32
+	certPEM, keyPEM := newTestCert(t, "expiring", time.Now().Add(time.Hour))
+
+	r, err := NewReloaderWithOpts(context.Background(), &ReloaderOpts{
+		Source: SourceFunc(func(ctx context.Context) ([]byte, []byte, error) {
+			if calls.Add(1) > 1 {
+				return nil, nil, errors.New("source down")
+			}
+			return certPEM, keyPEM, nil
+		}),
+		Interval: 10 * time.Millisecond,
+		OnError:  func(err error) {},
+		OnExpiring: func(leaf *x509.Certificate, remaining time.Duration) {
+			expiring.Add(1)
+		},
+	})
+
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r.Run(ctx)
+
+	assert.Greater(t, calls.Load(), int32(2))
+	assert.Greater(t, expiring.Load(), int32(0))
+	assert.Equal(t, "expiring", r.Leaf().Subject.CommonName)
+}
+
+func TestReloaderExpiryCheck(t *testing.T) {
+	certPEM, keyPEM := newTestCert(t, "short", time.Now().Add(time.Hour))
+	r, err := NewReloader(context.Background(), SourceFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		return certPEM, keyPEM, nil
+	}))
+
+	assert.NoError(t, err)
+	assert.NoError(t, r.ExpiryCheck(time.Minute)(context.Background()))
+	assert.ErrorIs(t, r.ExpiryCheck(24*time.Hour)(context.Background()), ErrCertificateExpired)
+
+	var empty Reloader
+	_, err = empty.GetCertificate(nil)
+	assert.ErrorIs(t, err, ErrNoCertificate)
+}
@@ -0,0 +1,63 @@
+//
+// Certificate sources, read on every reload
+//
+
+package tlsutil
+
+import (
+	"context"
+	"os"
+
+	"github.com/publishlab/infra-golang-toolkit/secrets"
+)
+
+// PEM encoded certificate chain and private key
+type Source interface {
+	Load(ctx context.Context) (certPEM []byte, keyPEM []byte, err error)
+}
+
+type SourceFunc func(ctx context.Context) ([]byte, []byte, error)
+
+func (f SourceFunc) Load(ctx context.Context) ([]byte, []byte, error) {
+	return f(ctx)
+}
+
+//
+// Files on disk, e.g. written by cert-manager or certbot
+//
+
+func FileSource(certFile string, keyFile string) Source {
+	return SourceFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		certPEM, err := os.ReadFile(certFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return certPEM, keyPEM, nil
+	})
+}
+
+//
+// Secret provider or store, e.g. Secrets Manager entries "tls#cert" and "tls#key"
+//
+
+func SecretSource(provider secrets.Provider, certName string, keyName string) Source {
+	return SourceFunc(func(ctx context.Context) ([]byte, []byte, error) {
+		certPEM, err := provider.Get(ctx, certName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyPEM, err := provider.Get(ctx, keyName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []byte(certPEM), []byte(keyPEM), nil
+	})
+}
@@ -0,0 +1,157 @@
+//
+// Expand RADb as-sets and fetch prefixes for all member ASNs
+//
+
+package whois
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RadbAsSetOpts struct {
+	AsSet       string
+	Hostname    string
+	Port        int
+	Timeout     time.Duration
+	Dialer      Dialer
+	Client      *Client
+	Retry       *RetryPolicy
+	Transcript  *Transcript
+	Concurrency int
+}
+
+var ErrAsSetNotFound = errors.New("whois: as-set not found")
+
+const DefaultAsSetConcurrency = 4
+
+//
+// Recursively expand as-set into member ASNs
+//
+
+func RadbAsSetMembers(ctx context.Context, opts *RadbAsSetOpts) ([]string, error) {
+	if opts.Hostname == "" {
+		opts.Hostname = RadbHostname
+	}
+
+	// IRRd expands nested sets server side with the ",1" flag
+	resp, err := QueryCtx(ctx, &QueryOpts{
+		Hostname:   opts.Hostname,
+		Port:       opts.Port,
+		Query:      fmt.Sprintf("!i%s,1", opts.AsSet),
+		Timeout:    opts.Timeout,
+		Dialer:     opts.Dialer,
+		Client:     opts.Client,
+		Retry:      opts.Retry,
+		Transcript: opts.Transcript,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := parseIrrdResponse(resp)
+	if errors.Is(err, errIrrdKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrAsSetNotFound, opts.AsSet)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var members []string
+
+	for _, member := range strings.Fields(string(data)) {
+		member = strings.ToUpper(member)
+		if !seen[member] {
+			seen[member] = true
+			members = append(members, member)
+		}
+	}
+
+	return members, nil
+}
+
+//
+// Fetch prefixes with background context
+//
+
+func RadbPrefixesByAsSet(opts *RadbAsSetOpts) (*RadbPrefixCollection, error) {
+	return RadbPrefixesByAsSetCtx(context.Background(), opts)
+}
+
+//
+// Fetch and merge prefixes for every ASN in as-set, with bounded concurrency
+//
+
+func RadbPrefixesByAsSetCtx(ctx context.Context, opts *RadbAsSetOpts) (*RadbPrefixCollection, error) {
+	result := &RadbPrefixCollection{}
+
+	members, err := RadbAsSetMembers(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+
+	prefixes, err := RadbPrefixesByAsnsCtx(ctx, &RadbPrefixesByAsnsOpts{
+		Asns:        members,
+		Hostname:    opts.Hostname,
+		Port:        opts.Port,
+		Timeout:     opts.Timeout,
+		Dialer:      opts.Dialer,
+		Client:      opts.Client,
+		Retry:       opts.Retry,
+		Transcript:  opts.Transcript,
+		Concurrency: opts.Concurrency,
+	})
+
+	for _, collection := range prefixes {
+		result.merge(collection)
+	}
+
+	return result, err
+}
+
+//
+// IRRd "!" command responses
+//
+
+var errIrrdKeyNotFound = errors.New("whois: irrd key not found")
+
+func parseIrrdResponse(resp []byte) ([]byte, error) {
+	line, rest, _ := bytes.Cut(resp, []byte("\n"))
+	line = bytes.TrimSpace(line)
+
+	switch {
+	case len(line) == 0:
+		return nil, errors.New("whois: empty irrd response")
+
+	// Success, no data
+	case line[0] == 'C':
+		return nil, nil
+
+	// Key not found
+	case line[0] == 'D':
+		return nil, errIrrdKeyNotFound
+
+	// Error message
+	case line[0] == 'F':
+		return nil, fmt.Errorf("whois: irrd error: %s", bytes.TrimSpace(line[1:]))
+
+	// Data with length prefix
+	case line[0] == 'A':
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size > len(rest) {
+			return nil, errors.New("whois: malformed irrd response")
+		}
+
+		return rest[:size], nil
+	}
+
+	return nil, fmt.Errorf("whois: unexpected irrd response %q", line)
+}
@@ -0,0 +1,118 @@
+package whois
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIrrServer(t *testing.T) (string, int) {
+	routes := map[string]string{
+		"AS64500": "route: 192.0.2.0/24\norigin: AS64500\n\nroute6: 2001:db8::/32\norigin: AS64500\n",
+		"AS64501": "route: 198.51.100.0/24\norigin: AS64501\n\nroute: 192.0.2.0/24\norigin: AS64501\n",
+		"AS64502": "route: 203.0.113.0/24\norigin: AS64502\n",
+	}
+
+	return newTestServer(t, func(query string) string {
+		switch {
+		case query == "!iAS-TEST,1":
+			members := "AS64500 AS64501 as64502 AS64500"
+			return "A" + strconv.Itoa(len(members)+1) + "\n" + members + "\nC\n"
+		case query == "!iAS-EMPTY,1":
+			return "C\n"
+		case strings.HasPrefix(query, "!i"):
+			return "D\n"
+		case strings.HasPrefix(query, "-i origin "):
+			return routes[strings.TrimPrefix(query, "-i origin ")]
+		}
+
+		return ""
+	})
+}
+
+func TestRadbAsSetMembers(t *testing.T) {
+	host, port := newTestIrrServer(t)
+
+	members, err := RadbAsSetMembers(context.Background(), &RadbAsSetOpts{
+		AsSet:    "AS-TEST",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AS64500", "AS64501", "AS64502"}, members)
+}
+
+func TestRadbAsSetMembersNotFound(t *testing.T) {
+	host, port := newTestIrrServer(t)
+
+	members, err := RadbAsSetMembers(context.Background(), &RadbAsSetOpts{
+		AsSet:    "AS-MISSING",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.ErrorIs(t, err, ErrAsSetNotFound)
+	assert.Empty(t, members)
+}
+
+func TestRadbPrefixesByAsSet(t *testing.T) {
+	host, port := newTestIrrServer(t)
+
+	result, err := RadbPrefixesByAsSet(&RadbAsSetOpts{
+		AsSet:       "AS-TEST",
+		Hostname:    host,
+		Port:        port,
+		Concurrency: 2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+	}, result.IPv4)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::/32"),
+	}, result.IPv6)
+}
+
+func TestRadbPrefixesByAsSetEmpty(t *testing.T) {
+	host, port := newTestIrrServer(t)
+
+	result, err := RadbPrefixesByAsSet(&RadbAsSetOpts{
+		AsSet:    "AS-EMPTY",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.IPv4)
+	assert.Empty(t, result.IPv6)
+}
+
+func TestParseIrrdResponse(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+		err bool
+	}{
+		{in: "A8\nAS1 AS2\nC\n", out: "AS1 AS2\n"},
+		{in: "C\n", out: ""},
+		{in: "D\n", err: true},
+		{in: "F Unrecognized command\n", err: true},
+		{in: "A100\nAS1\nC\n", err: true},
+		{in: "", err: true},
+	}
+
+	for _, test := range tests {
+		data, err := parseIrrdResponse([]byte(test.in))
+		assert.Equal(t, test.err, err != nil)
+		assert.Equal(t, test.out, string(data))
+	}
+}
@@ -0,0 +1,127 @@
+//
+// Domain availability and expiry helpers for monitoring
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDomainAvailable = errors.New("whois: domain is not registered")
+	ErrNoExpiryDate    = errors.New("whois: no expiry date in response")
+)
+
+// Phrasings registries use for unregistered domains, matched lowercase
+var domainAvailablePhrases = []string{
+	"no match for",
+	"no match!!",
+	"not found",
+	"no data found",
+	"no entries found",
+	"no object found",
+	"nothing found",
+	"object does not exist",
+	"domain not registered",
+	"is available for registration",
+	"status: free",
+	"status: available",
+	"status:\tavailable",
+}
+
+//
+// Check whether domain is unregistered with default opts
+//
+
+func IsAvailable(domain string) (bool, error) {
+	return IsAvailableCtx(context.Background(), &LookupOpts{
+		Domain: domain,
+	})
+}
+
+//
+// Check whether domain is unregistered, based on the final response in the
+// referral chain
+//
+
+func IsAvailableCtx(ctx context.Context, opts *LookupOpts) (bool, error) {
+	resp, err := lookupFinal(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return IsAvailableResponse(resp), nil
+}
+
+//
+// Expiry date of domain with default opts
+//
+
+func ExpiryDate(domain string) (time.Time, error) {
+	return ExpiryDateCtx(context.Background(), &LookupOpts{
+		Domain: domain,
+	})
+}
+
+//
+// Expiry date of domain, from the most specific response carrying one
+//
+
+func ExpiryDateCtx(ctx context.Context, opts *LookupOpts) (time.Time, error) {
+	chain, err := LookupCtx(ctx, opts)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(chain) > 0 && IsAvailableResponse(chain[len(chain)-1].Response) {
+		return time.Time{}, ErrDomainAvailable
+	}
+
+	// Registrar responses come last and are preferred over the registry
+	for i := len(chain) - 1; i >= 0; i-- {
+		record := ParseDomainRecord(chain[i].Response)
+		if !record.Expires.IsZero() {
+			return record.Expires, nil
+		}
+	}
+
+	return time.Time{}, ErrNoExpiryDate
+}
+
+//
+// Response reports an unregistered domain, registration data takes
+// precedence over phrases found in notices
+//
+
+func IsAvailableResponse(data []byte) bool {
+	record := ParseDomainRecord(data)
+	if !record.Created.IsZero() || !record.Expires.IsZero() || len(record.NameServers) > 0 || record.Registrar != "" {
+		return false
+	}
+
+	text := strings.ToLower(string(data))
+	for _, phrase := range domainAvailablePhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lookupFinal(ctx context.Context, opts *LookupOpts) ([]byte, error) {
+	chain, err := LookupCtx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("whois: empty lookup chain")
+	}
+
+	return chain[len(chain)-1].Response, nil
+}
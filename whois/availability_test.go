@@ -0,0 +1,102 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAvailableResponse(t *testing.T) {
+	available := []string{
+		"No match for \"EXAMPLE-FREE.COM\".\n>>> Last update of whois database: 2024-08-14T07:01:34Z <<<\n",
+		"% No entries found for the selected source(s).\n",
+		"Domain not found.\n",
+		"NOT FOUND\n",
+		"Domain Name: example-free.no\nStatus: free\n",
+		"%ERROR:101: no entries found\n",
+		"The queried object does not exist: DOMAIN NOT FOUND\n",
+	}
+
+	for _, resp := range available {
+		assert.True(t, IsAvailableResponse([]byte(resp)), resp)
+	}
+
+	registered := []string{
+		"Domain Name: EXAMPLE.COM\nRegistrar: Example Registrar\nRegistry Expiry Date: 2025-08-13T04:00:00Z\n" +
+			"NOTICE: If the record is not found, contact the registrar\n",
+		"Domain Name: example.com\nName Server: a.iana-servers.net\n",
+		"% IANA WHOIS server\n",
+		"",
+	}
+
+	for _, resp := range registered {
+		assert.False(t, IsAvailableResponse([]byte(resp)), resp)
+	}
+}
+
+func TestIsAvailableCtx(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		if query == "taken.example" {
+			return "Domain Name: taken.example\nCreation Date: 2020-01-01T00:00:00Z\n"
+		}
+
+		return fmt.Sprintf("No match for %q.\n", query)
+	})
+
+	available, err := IsAvailableCtx(context.Background(), &LookupOpts{
+		Domain:   "free.example",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	available, err = IsAvailableCtx(context.Background(), &LookupOpts{
+		Domain:   "taken.example",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestExpiryDateCtx(t *testing.T) {
+	regHost, regPort := newTestServer(t, func(query string) string {
+		return "Domain Name: " + query + "\nRegistrar Registration Expiration Date: 2026-03-01T00:00:00Z\n"
+	})
+
+	tldHost, tldPort := newTestServer(t, func(query string) string {
+		switch query {
+		case "free.example":
+			return "No match for \"FREE.EXAMPLE\".\n"
+		case "noexpiry.example":
+			return "Domain Name: noexpiry.example\nRegistrar: Example\n"
+		}
+
+		return fmt.Sprintf("Domain Name: %s\nRegistry Expiry Date: 2026-02-01T00:00:00Z\nRegistrar WHOIS Server: %s:%d\n",
+			query, regHost, regPort)
+	})
+
+	lookup := func(domain string) (time.Time, error) {
+		return ExpiryDateCtx(context.Background(), &LookupOpts{
+			Domain:   domain,
+			Hostname: tldHost,
+			Port:     tldPort,
+		})
+	}
+
+	expires, err := lookup("example.example")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), expires)
+
+	_, err = lookup("free.example")
+	assert.ErrorIs(t, err, ErrDomainAvailable)
+
+	_, err = lookup("noexpiry.example")
+	assert.ErrorIs(t, err, ErrNoExpiryDate)
+}
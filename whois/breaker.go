@@ -0,0 +1,194 @@
+//
+// Per-server circuit breaker, failing fast while a registry is unreachable
+// or rate limiting us
+//
+
+package whois
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/circuitbreaker"
+)
+
+type Breaker struct {
+	banners [][]byte
+	onOpen  func(hostname string, until time.Time, err error)
+	group   *circuitbreaker.Group
+}
+
+type BreakerOpts struct {
+	// Consecutive failures opening the circuit
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	// Case-insensitive response snippets opening the circuit at once
+	RateLimitBanners []string
+
+	OnOpen func(hostname string, until time.Time, err error)
+}
+
+var (
+	ErrCircuitOpen = errors.New("whois: circuit open")
+	ErrRateLimited = errors.New("whois: rate limited by server")
+)
+
+var DefaultBreakerOpts = &BreakerOpts{
+	FailureThreshold: 5,
+	Cooldown:         time.Minute,
+	RateLimitBanners: []string{
+		"limit exceeded",
+		"rate limit",
+		"too many queries",
+		"too many requests",
+		"access control limit",
+		"%error:201",
+	},
+}
+
+// Banners only ever make up short responses
+const maxBannerResponse = 2048
+
+//
+// Initialize new breaker instance
+//
+
+func NewBreaker() *Breaker {
+	return NewBreakerWithOpts(DefaultBreakerOpts)
+}
+
+func NewBreakerWithOpts(opts *BreakerOpts) *Breaker {
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = DefaultBreakerOpts.FailureThreshold
+	}
+
+	if opts.Cooldown == 0 {
+		opts.Cooldown = DefaultBreakerOpts.Cooldown
+	}
+
+	if opts.RateLimitBanners == nil {
+		opts.RateLimitBanners = DefaultBreakerOpts.RateLimitBanners
+	}
+
+	banners := make([][]byte, 0, len(opts.RateLimitBanners))
+	for _, banner := range opts.RateLimitBanners {
+		banners = append(banners, bytes.ToLower([]byte(banner)))
+	}
+
+	return &Breaker{
+		banners: banners,
+		onOpen:  opts.OnOpen,
+		group: circuitbreaker.NewGroup(&circuitbreaker.Opts{
+			ConsecutiveFailures: opts.FailureThreshold,
+			Cooldown:            opts.Cooldown,
+			HalfOpenProbes:      1,
+		}),
+	}
+}
+
+//
+// Check whether hostname may be queried, once the cooldown has passed a
+// single probe query is let through to decide whether to close the circuit
+//
+
+func (b *Breaker) Allow(hostname string) error {
+	hostname = strings.ToLower(hostname)
+	circuit := b.group.Get(hostname)
+
+	if err := circuit.Allow(); err != nil {
+		// Probe in flight
+		until := circuit.OpenUntil()
+		if until.IsZero() {
+			return fmt.Errorf("%w: %s", ErrCircuitOpen, hostname)
+		}
+
+		return fmt.Errorf("%w: %s until %s", ErrCircuitOpen, hostname, until.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+//
+// Record query outcome for hostname, caller cancellation does not count
+//
+
+func (b *Breaker) Record(hostname string, err error) {
+	if errors.Is(err, ErrCircuitOpen) {
+		return
+	}
+
+	hostname = strings.ToLower(hostname)
+	circuit := b.group.Get(hostname)
+	before := circuit.OpenUntil()
+
+	circuit.Record(err)
+
+	// Rate limit banners open the circuit at once
+	if errors.Is(err, ErrRateLimited) && (circuit.State() != circuitbreaker.StateOpen) {
+		circuit.Trip()
+	}
+
+	until := circuit.OpenUntil()
+	if !until.IsZero() && !until.Equal(before) && (b.onOpen != nil) {
+		b.onOpen(hostname, until, err)
+	}
+}
+
+//
+// Time until which the circuit for hostname is open, zero when closed
+//
+
+func (b *Breaker) OpenUntil(hostname string) time.Time {
+	return b.group.Get(strings.ToLower(hostname)).OpenUntil()
+}
+
+//
+// Close the circuit for hostname
+//
+
+func (b *Breaker) Reset(hostname string) {
+	b.group.Delete(strings.ToLower(hostname))
+}
+
+//
+// Check short responses for rate limit banners
+//
+
+func (b *Breaker) rateLimited(resp []byte) bool {
+	if len(resp) > maxBannerResponse {
+		return false
+	}
+
+	resp = bytes.ToLower(resp)
+	for _, banner := range b.banners {
+		if bytes.Contains(resp, banner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Reader keeping the start of a response for banner checks
+//
+
+type bannerReader struct {
+	reader io.Reader
+	head   []byte
+}
+
+func (r *bannerReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	if len(r.head) <= maxBannerResponse {
+		r.head = append(r.head, p[:min(n, maxBannerResponse+1-len(r.head))]...)
+	}
+
+	return n, err
+}
@@ -0,0 +1,116 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/whois/whoistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	var opened []string
+	breaker := NewBreakerWithOpts(&BreakerOpts{
+		FailureThreshold: 2,
+		Cooldown:         20 * time.Millisecond,
+		OnOpen: func(hostname string, until time.Time, err error) {
+			opened = append(opened, hostname)
+		},
+	})
+
+	failure := errors.New("connection reset")
+
+	breaker.Record("Whois.Example", failure)
+	assert.NoError(t, breaker.Allow("whois.example"))
+
+	// Cancellation is not the server's fault
+	breaker.Record("whois.example", context.Canceled)
+	breaker.Record("whois.example", failure)
+	assert.ErrorIs(t, breaker.Allow("whois.example"), ErrCircuitOpen)
+	assert.False(t, breaker.OpenUntil("whois.example").IsZero())
+	assert.Equal(t, []string{"whois.example"}, opened)
+
+	// Other servers are unaffected
+	assert.NoError(t, breaker.Allow("whois.other"))
+
+	// Single probe after cooldown, failing reopens at once
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, breaker.Allow("whois.example"))
+	assert.ErrorIs(t, breaker.Allow("whois.example"), ErrCircuitOpen)
+	breaker.Record("whois.example", failure)
+	assert.ErrorIs(t, breaker.Allow("whois.example"), ErrCircuitOpen)
+
+	// Successful probe closes
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, breaker.Allow("whois.example"))
+	breaker.Record("whois.example", nil)
+	assert.NoError(t, breaker.Allow("whois.example"))
+	assert.True(t, breaker.OpenUntil("whois.example").IsZero())
+
+	breaker.Record("whois.example", ErrRateLimited)
+	assert.ErrorIs(t, breaker.Allow("whois.example"), ErrCircuitOpen)
+	breaker.Reset("whois.example")
+	assert.NoError(t, breaker.Allow("whois.example"))
+}
+
+func TestQueryBreakerFailures(t *testing.T) {
+	srv := whoistest.NewServer(func(query string) whoistest.Response {
+		return whoistest.Response{Reset: true}
+	})
+
+	t.Cleanup(srv.Close)
+
+	client := NewClientWithOpts(&ClientOpts{
+		Breaker: NewBreakerWithOpts(&BreakerOpts{FailureThreshold: 2}),
+	})
+
+	_, err := QueryCtx(context.Background(), &QueryOpts{
+		Hostname: srv.Hostname,
+		Port:     srv.Port,
+		Query:    "example.com",
+		Client:   client,
+		Retry: &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	// Retries stop once the circuit opens
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Len(t, srv.Queries(), 2)
+}
+
+func TestQueryBreakerRateLimitBanner(t *testing.T) {
+	srv := whoistest.NewServer(whoistest.Static(map[string]string{
+		"example.com": "Domain Name: EXAMPLE.COM\n",
+	}, "Query rate limit exceeded\n"))
+
+	t.Cleanup(srv.Close)
+
+	client := NewClientWithOpts(&ClientOpts{
+		Breaker: NewBreaker(),
+	})
+
+	opts := func(query string) *QueryOpts {
+		return &QueryOpts{
+			Hostname: srv.Hostname,
+			Port:     srv.Port,
+			Query:    query,
+			Client:   client,
+			Retry:    DefaultRetryPolicy,
+		}
+	}
+
+	data, err := QueryCtx(context.Background(), opts("example.com"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "EXAMPLE.COM")
+
+	_, err = QueryCtx(context.Background(), opts("example.net"))
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = QueryCtx(context.Background(), opts("example.com"))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Len(t, srv.Queries(), 2)
+}
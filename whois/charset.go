@@ -0,0 +1,89 @@
+//
+// Response charset decoding, some registries still reply in ISO-8859-1
+//
+
+package whois
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CharsetAuto   = "auto"
+	CharsetUTF8   = "utf-8"
+	CharsetLatin1 = "iso-8859-1"
+)
+
+var (
+	ErrUnknownCharset = errors.New("whois: unknown charset")
+	ErrQueryEncoding  = errors.New("whois: query not representable in charset")
+)
+
+//
+// Normalize charset name, empty means auto detection
+//
+
+func normalizeCharset(charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", CharsetAuto:
+		return CharsetAuto, nil
+	case CharsetUTF8, "utf8":
+		return CharsetUTF8, nil
+	case CharsetLatin1, "iso8859-1", "latin1", "latin-1":
+		return CharsetLatin1, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownCharset, charset)
+}
+
+//
+// Decode response to UTF-8, auto treats invalid UTF-8 as ISO-8859-1
+//
+
+func DecodeResponse(data []byte, charset string) ([]byte, error) {
+	charset, err := normalizeCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+
+	if charset == CharsetUTF8 || (charset == CharsetAuto && utf8.Valid(data)) {
+		return data, nil
+	}
+
+	// ISO-8859-1 bytes map directly to the first 256 code points
+	result := make([]byte, 0, len(data))
+	for _, b := range data {
+		result = utf8.AppendRune(result, rune(b))
+	}
+
+	return result, nil
+}
+
+//
+// Encode query for the server, code points beyond ISO-8859-1 are rejected
+//
+
+func encodeQuery(query string, charset string) ([]byte, error) {
+	charset, err := normalizeCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+
+	if charset != CharsetLatin1 {
+		return []byte(query), nil
+	}
+
+	result := make([]byte, 0, len(query))
+	for _, r := range query {
+		if r > 0xff {
+			return nil, fmt.Errorf("%w %s: %q", ErrQueryEncoding, charset, r)
+		}
+
+		result = append(result, byte(r))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package whois
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	latin1 := []byte("Registrant: M\xfcller GmbH")
+
+	data, err := DecodeResponse(latin1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Registrant: Müller GmbH", string(data))
+
+	data, err = DecodeResponse(latin1, "latin1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Registrant: Müller GmbH", string(data))
+
+	// Valid UTF-8 is kept as is in auto mode
+	data, err = DecodeResponse([]byte("Registrant: Müller GmbH"), CharsetAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, "Registrant: Müller GmbH", string(data))
+
+	data, err = DecodeResponse(latin1, "UTF-8")
+	assert.NoError(t, err)
+	assert.Equal(t, latin1, data)
+
+	_, err = DecodeResponse(latin1, "koi8-r")
+	assert.ErrorIs(t, err, ErrUnknownCharset)
+}
+
+func TestEncodeQuery(t *testing.T) {
+	query, err := encodeQuery("müller", CharsetLatin1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("m\xfcller"), query)
+
+	query, err = encodeQuery("müller", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("müller"), query)
+
+	_, err = encodeQuery("例子", CharsetLatin1)
+	assert.ErrorIs(t, err, ErrQueryEncoding)
+}
+
+func TestQueryCharset(t *testing.T) {
+	var query string
+	host, port := newTestServer(t, func(q string) string {
+		query = q
+		return "owner: M\xfcller\n"
+	})
+
+	resp, err := QueryCtx(context.Background(), &QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "müller",
+		Charset:  CharsetLatin1,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "m\xfcller", query)
+	assert.Equal(t, "owner: Müller\n", string(resp))
+
+	_, err = QueryCtx(context.Background(), &QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example",
+		Charset:  "ebcdic",
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownCharset)
+}
@@ -0,0 +1,165 @@
+//
+// Client enforcing per-server query rate limits
+//
+
+package whois
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Client struct {
+	limit      RateLimit
+	hostLimits map[string]RateLimit
+	breaker    *Breaker
+	mu         sync.Mutex
+	hosts      map[string]*clientHost
+}
+
+type ClientOpts struct {
+	Limit      RateLimit
+	HostLimits map[string]RateLimit
+
+	// Optional, shared by every query through the client
+	Breaker *Breaker
+}
+
+type RateLimit struct {
+	MinInterval      time.Duration
+	QueriesPerMinute int
+}
+
+type clientHost struct {
+	last   time.Time
+	recent []time.Time
+}
+
+var DefaultClientOpts = &ClientOpts{
+	Limit: RateLimit{
+		MinInterval:      time.Second,
+		QueriesPerMinute: 30,
+	},
+}
+
+//
+// Initialize new client instance
+//
+
+func NewClient() *Client {
+	return NewClientWithOpts(DefaultClientOpts)
+}
+
+func NewClientWithOpts(opts *ClientOpts) *Client {
+	hostLimits := make(map[string]RateLimit)
+	for host, limit := range opts.HostLimits {
+		hostLimits[strings.ToLower(host)] = limit
+	}
+
+	return &Client{
+		limit:      opts.Limit,
+		hostLimits: hostLimits,
+		breaker:    opts.Breaker,
+		hosts:      make(map[string]*clientHost),
+	}
+}
+
+//
+// Query through client
+//
+
+func (c *Client) Query(ctx context.Context, opts *QueryOpts) ([]byte, error) {
+	opts.Client = c
+	return QueryCtx(ctx, opts)
+}
+
+//
+// Block until a query to hostname is allowed, fails at once while the
+// circuit for hostname is open
+//
+
+func (c *Client) Wait(ctx context.Context, hostname string) error {
+	if c.breaker != nil {
+		if err := c.breaker.Allow(hostname); err != nil {
+			return err
+		}
+	}
+
+	delay := c.reserve(strings.ToLower(hostname), time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//
+// Reserve the next free query slot for host, returns time to wait for it
+//
+
+func (c *Client) reserve(hostname string, now time.Time) time.Duration {
+	limit, ok := c.hostLimits[hostname]
+	if !ok {
+		limit = c.limit
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	host, exists := c.hosts[hostname]
+	if !exists {
+		host = &clientHost{}
+		c.hosts[hostname] = host
+	}
+
+	slot := now
+
+	// Minimum delay since previous query
+	if !host.last.IsZero() && host.last.Add(limit.MinInterval).After(slot) {
+		slot = host.last.Add(limit.MinInterval)
+	}
+
+	// Sliding window of queries within the last minute
+	if limit.QueriesPerMinute > 0 {
+		cutoff := 0
+		for cutoff < len(host.recent) && !host.recent[cutoff].After(now.Add(-time.Minute)) {
+			cutoff++
+		}
+
+		host.recent = host.recent[cutoff:]
+
+		if len(host.recent) >= limit.QueriesPerMinute {
+			windowSlot := host.recent[len(host.recent)-limit.QueriesPerMinute].Add(time.Minute)
+			if windowSlot.After(slot) {
+				slot = windowSlot
+			}
+		}
+
+		host.recent = append(host.recent, slot)
+	}
+
+	host.last = slot
+	return slot.Sub(now)
+}
+
+//
+// Circuit breaker of client, nil safe
+//
+
+func (c *Client) circuit() *Breaker {
+	if c == nil {
+		return nil
+	}
+
+	return c.breaker
+}
@@ -0,0 +1,87 @@
+package whois
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientReserveMinInterval(t *testing.T) {
+	client := NewClientWithOpts(&ClientOpts{
+		Limit: RateLimit{MinInterval: time.Second},
+	})
+
+	now := time.Now()
+	assert.Equal(t, time.Duration(0), client.reserve("whois.radb.net", now))
+	assert.Equal(t, time.Second, client.reserve("whois.radb.net", now))
+	assert.Equal(t, 2*time.Second, client.reserve("whois.radb.net", now))
+	assert.Equal(t, time.Duration(0), client.reserve("whois.ripe.net", now))
+}
+
+func TestClientReservePerMinute(t *testing.T) {
+	client := NewClientWithOpts(&ClientOpts{
+		Limit: RateLimit{QueriesPerMinute: 2},
+	})
+
+	now := time.Now()
+	assert.Equal(t, time.Duration(0), client.reserve("whois.radb.net", now))
+	assert.Equal(t, time.Duration(0), client.reserve("whois.radb.net", now))
+	assert.Equal(t, time.Minute, client.reserve("whois.radb.net", now))
+
+	// Window slides
+	later := now.Add(2*time.Minute + time.Second)
+	assert.Equal(t, time.Duration(0), client.reserve("whois.radb.net", later))
+}
+
+func TestClientHostLimits(t *testing.T) {
+	client := NewClientWithOpts(&ClientOpts{
+		Limit: RateLimit{MinInterval: time.Second},
+		HostLimits: map[string]RateLimit{
+			"WHOIS.RADB.NET": {MinInterval: time.Minute},
+		},
+	})
+
+	now := time.Now()
+	client.reserve("whois.radb.net", now)
+	client.reserve("whois.ripe.net", now)
+	assert.Equal(t, time.Minute, client.reserve("whois.radb.net", now))
+	assert.Equal(t, time.Second, client.reserve("whois.ripe.net", now))
+}
+
+func TestClientWaitCancel(t *testing.T) {
+	client := NewClientWithOpts(&ClientOpts{
+		Limit: RateLimit{MinInterval: time.Hour},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, client.Wait(ctx, "whois.radb.net"))
+	assert.ErrorIs(t, client.Wait(ctx, "whois.radb.net"), context.DeadlineExceeded)
+}
+
+func TestClientQuery(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "ok\n"
+	})
+
+	client := NewClientWithOpts(&ClientOpts{
+		Limit: RateLimit{MinInterval: 50 * time.Millisecond},
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		data, err := client.Query(context.Background(), &QueryOpts{
+			Hostname: host,
+			Port:     port,
+			Query:    "example.com",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ok\n", string(data))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
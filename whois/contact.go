@@ -0,0 +1,193 @@
+//
+// Domain contacts by role, telling redacted data apart from absent data
+//
+
+package whois
+
+import (
+	"strings"
+)
+
+const (
+	ContactRegistrant = "registrant"
+	ContactAdmin      = "admin"
+	ContactTech       = "tech"
+	ContactBilling    = "billing"
+)
+
+// Key prefixes naming a contact role, longest first
+var contactRolePrefixes = []struct {
+	prefix string
+	role   string
+}{
+	{"administrative contact ", ContactAdmin},
+	{"registrant contact ", ContactRegistrant},
+	{"technical contact ", ContactTech},
+	{"billing contact ", ContactBilling},
+	{"administrative ", ContactAdmin},
+	{"registrant ", ContactRegistrant},
+	{"technical ", ContactTech},
+	{"billing-c ", ContactBilling},
+	{"billing ", ContactBilling},
+	{"admin-c ", ContactAdmin},
+	{"tech-c ", ContactTech},
+	{"admin ", ContactAdmin},
+	{"tech ", ContactTech},
+}
+
+var contactAttributes = map[string]string{
+	"name":            "name",
+	"organization":    "organization",
+	"organisation":    "organization",
+	"org":             "organization",
+	"street":          "street",
+	"address":         "street",
+	"city":            "city",
+	"state/province":  "state",
+	"state":           "state",
+	"province":        "state",
+	"postal code":     "postalcode",
+	"postcode":        "postalcode",
+	"country":         "country",
+	"country code":    "country",
+	"country/economy": "country",
+	"phone":           "phone",
+	"phone number":    "phone",
+	"email":           "email",
+	"e-mail":          "email",
+	"id":              "handle",
+	"handle":          "handle",
+	"contact id":      "handle",
+}
+
+// Values registries put in place of data withheld under GDPR or similar
+var redactedMarkers = []string{
+	"redacted",
+	"not disclosed",
+	"data protected",
+	"non-public data",
+	"gdpr masked",
+	"statutory masking",
+	"hidden upon user request",
+	"please query the rdds service",
+	"please query the whois service of the registrar",
+}
+
+// Names and organizations of privacy proxy services
+var privacyProxyMarkers = []string{
+	"privacy",
+	"proxy",
+	"whoisguard",
+	"whois guard",
+	"whois agent",
+	"withheld for privacy",
+	"identity protect",
+	"private registration",
+	"anonymize",
+}
+
+//
+// Split field key into contact role and attribute, "admin-c" style keys
+// without attribute hold the contact handle
+//
+
+func contactField(key string) (string, string, bool) {
+	switch key {
+	case "registrant":
+		return ContactRegistrant, "name", true
+	case "admin-c":
+		return ContactAdmin, "handle", true
+	case "tech-c":
+		return ContactTech, "handle", true
+	case "billing-c":
+		return ContactBilling, "handle", true
+	}
+
+	for _, p := range contactRolePrefixes {
+		if rest, ok := strings.CutPrefix(key, p.prefix); ok {
+			attr, ok := contactAttributes[rest]
+			if !ok {
+				return "", "", false
+			}
+
+			return p.role, attr, true
+		}
+	}
+
+	return "", "", false
+}
+
+//
+// Set contact attribute, flagging redacted values instead of storing them
+//
+
+func (c *DomainContact) set(attr string, value string) {
+	if isRedactedValue(value) {
+		c.Redacted = true
+		return
+	}
+
+	if ((attr == "name") || (attr == "organization")) && isPrivacyProxy(value) {
+		c.PrivacyProxy = true
+	}
+
+	switch attr {
+	case "handle":
+		setDomainField(&c.Handle, value)
+	case "name":
+		setDomainField(&c.Name, value)
+	case "organization":
+		setDomainField(&c.Organization, value)
+	case "street":
+		c.Street = append(c.Street, value)
+	case "city":
+		setDomainField(&c.City, value)
+	case "state":
+		setDomainField(&c.State, value)
+	case "postalcode":
+		setDomainField(&c.PostalCode, value)
+	case "country":
+		setDomainField(&c.Country, value)
+	case "phone":
+		setDomainField(&c.Phone, value)
+	case "email":
+		setDomainField(&c.Email, value)
+	}
+}
+
+//
+// Contact data exists but is hidden, by redaction or a privacy proxy
+//
+
+func (c *DomainContact) Hidden() bool {
+	return c.Redacted || c.PrivacyProxy
+}
+
+//
+// No contact data at all, as opposed to hidden data
+//
+
+func (c *DomainContact) Absent() bool {
+	return !c.Hidden() && (c.Handle == "") && (c.Name == "") && (c.Organization == "") &&
+		(len(c.Street) == 0) && (c.City == "") && (c.State == "") && (c.PostalCode == "") &&
+		(c.Country == "") && (c.Phone == "") && (c.Email == "")
+}
+
+func isRedactedValue(value string) bool {
+	return containsMarker(value, redactedMarkers)
+}
+
+func isPrivacyProxy(value string) bool {
+	return containsMarker(value, privacyProxyMarkers)
+}
+
+func containsMarker(value string, markers []string) bool {
+	value = strings.ToLower(value)
+	for _, marker := range markers {
+		if strings.Contains(value, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package whois
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDomainContacts(t *testing.T) {
+	data := []byte(`Domain Name: example.net
+Registrant Name: Jane Doe
+Registrant Organization: Example AS
+Registrant Street: Storgata 1
+Registrant Street: 3rd floor
+Registrant City: Oslo
+Registrant Postal Code: 0155
+Registrant Country: NO
+Registrant Phone: +47.12345678
+Registrant Email: jane@example.net
+Admin Name: REDACTED FOR PRIVACY
+Admin Email: Please query the RDDS service of the Registrar of Record identified in this output for information on how to contact the Registrant, Admin, or Tech contact of the queried domain name.
+Admin Country: NO
+Tech Organization: Domains By Proxy, LLC
+Tech Email: tech@domainsbyproxy.com
+`)
+
+	record := ParseDomainRecord(data)
+
+	assert.Equal(t, DomainContact{
+		Name:         "Jane Doe",
+		Organization: "Example AS",
+		Street:       []string{"Storgata 1", "3rd floor"},
+		City:         "Oslo",
+		PostalCode:   "0155",
+		Country:      "NO",
+		Phone:        "+47.12345678",
+		Email:        "jane@example.net",
+	}, record.Registrant)
+
+	assert.True(t, record.Admin.Redacted)
+	assert.True(t, record.Admin.Hidden())
+	assert.Empty(t, record.Admin.Name)
+	assert.Empty(t, record.Admin.Email)
+	assert.Equal(t, "NO", record.Admin.Country)
+
+	assert.True(t, record.Tech.PrivacyProxy)
+	assert.False(t, record.Tech.Redacted)
+	assert.Equal(t, "Domains By Proxy, LLC", record.Tech.Organization)
+
+	// Hidden is not the same as absent
+	assert.True(t, record.Billing.Absent())
+	assert.False(t, record.Admin.Absent())
+	assert.False(t, record.Registrant.Hidden())
+}
+
+func TestParseDomainContactsHandles(t *testing.T) {
+	data := []byte(`Domain Name................: norid.no
+Registrant Handle..........: NOR1O-NORID
+Tech-c Handle..............: NH1R-NORID
+admin-c:        AA1-RIPE
+`)
+
+	record := ParseDomainRecord(data)
+	assert.Equal(t, "NOR1O-NORID", record.Registrant.Handle)
+	assert.Equal(t, "NH1R-NORID", record.Tech.Handle)
+	assert.Equal(t, "AA1-RIPE", record.Admin.Handle)
+}
+
+func TestContactField(t *testing.T) {
+	tests := []struct {
+		key  string
+		role string
+		attr string
+		ok   bool
+	}{
+		{key: "registrant", role: ContactRegistrant, attr: "name", ok: true},
+		{key: "registrant state/province", role: ContactRegistrant, attr: "state", ok: true},
+		{key: "administrative contact email", role: ContactAdmin, attr: "email", ok: true},
+		{key: "technical contact organisation", role: ContactTech, attr: "organization", ok: true},
+		{key: "billing id", role: ContactBilling, attr: "handle", ok: true},
+		{key: "registrant fax", ok: false},
+		{key: "registrar", ok: false},
+		{key: "domain name", ok: false},
+	}
+
+	for _, test := range tests {
+		role, attr, ok := contactField(test.key)
+		assert.Equal(t, test.ok, ok, test.key)
+		assert.Equal(t, test.role, role, test.key)
+		assert.Equal(t, test.attr, attr, test.key)
+	}
+}
+
+func TestContactMarkers(t *testing.T) {
+	assert.True(t, isRedactedValue("REDACTED FOR PRIVACY"))
+	assert.True(t, isRedactedValue("Data Protected"))
+	assert.False(t, isRedactedValue("Example AS"))
+
+	assert.True(t, isPrivacyProxy("Privacy service provided by Withheld for Privacy ehf"))
+	assert.True(t, isPrivacyProxy("WhoisGuard, Inc."))
+	assert.False(t, isPrivacyProxy("Example AS"))
+
+	assert.Nil(t, (&DomainRecord{}).Contact("owner"))
+}
@@ -0,0 +1,147 @@
+//
+// Differences between prefix collections, for minimal prefix-list updates
+//
+
+package whois
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+)
+
+type PrefixDiff struct {
+	AddedIPv4   []netip.Prefix
+	RemovedIPv4 []netip.Prefix
+	AddedIPv6   []netip.Prefix
+	RemovedIPv6 []netip.Prefix
+}
+
+type PrefixDiffOutput struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+//
+// Prefixes added and removed going from the old to the new collection, nil
+// collections count as empty, malformed entries are ignored
+//
+
+func DiffPrefixCollections(before *RadbPrefixCollection, after *RadbPrefixCollection) *PrefixDiff {
+	if before == nil {
+		before = &RadbPrefixCollection{}
+	}
+
+	if after == nil {
+		after = &RadbPrefixCollection{}
+	}
+
+	diff := &PrefixDiff{}
+	diff.AddedIPv4, diff.RemovedIPv4 = diffPrefixes(before.IPv4, after.IPv4)
+	diff.AddedIPv6, diff.RemovedIPv6 = diffPrefixes(before.IPv6, after.IPv6)
+
+	return diff
+}
+
+//
+// Sorted prefixes only in b and only in a
+//
+
+func diffPrefixes(a []netip.Prefix, b []netip.Prefix) ([]netip.Prefix, []netip.Prefix) {
+	a = sortPrefixes(append([]netip.Prefix(nil), a...))
+	b = sortPrefixes(append([]netip.Prefix(nil), b...))
+
+	var added, removed []netip.Prefix
+	i, j := 0, 0
+
+	for (i < len(a)) || (j < len(b)) {
+		switch {
+		case j == len(b):
+			removed = append(removed, a[i])
+			i++
+
+		case i == len(a):
+			added = append(added, b[j])
+			j++
+
+		default:
+			c := comparePrefixes(a[i], b[j])
+			switch {
+			case c < 0:
+				removed = append(removed, a[i])
+				i++
+			case c > 0:
+				added = append(added, b[j])
+				j++
+			default:
+				i++
+				j++
+			}
+		}
+	}
+
+	return added, removed
+}
+
+func (d *PrefixDiff) Empty() bool {
+	return (len(d.AddedIPv4) == 0) && (len(d.RemovedIPv4) == 0) &&
+		(len(d.AddedIPv6) == 0) && (len(d.RemovedIPv6) == 0)
+}
+
+//
+// Added prefixes of both families, IPv4 first
+//
+
+func (d *PrefixDiff) Added() []netip.Prefix {
+	return append(append([]netip.Prefix{}, d.AddedIPv4...), d.AddedIPv6...)
+}
+
+//
+// Removed prefixes of both families, IPv4 first
+//
+
+func (d *PrefixDiff) Removed() []netip.Prefix {
+	return append(append([]netip.Prefix{}, d.RemovedIPv4...), d.RemovedIPv6...)
+}
+
+//
+// Convert diff to output structure
+//
+
+func NewPrefixDiffOutput(diff *PrefixDiff) *PrefixDiffOutput {
+	// Always emit arrays, never null
+	result := &PrefixDiffOutput{
+		Added:   []string{},
+		Removed: []string{},
+	}
+
+	for _, prefix := range diff.Added() {
+		result.Added = append(result.Added, prefix.String())
+	}
+
+	for _, prefix := range diff.Removed() {
+		result.Removed = append(result.Removed, prefix.String())
+	}
+
+	return result
+}
+
+//
+// Write diff as change log lines, removals first
+//
+
+func WritePrefixDiffText(w io.Writer, diff *PrefixDiff) error {
+	for _, prefix := range diff.Removed() {
+		if _, err := fmt.Fprintf(w, "- %s\n", prefix); err != nil {
+			return err
+		}
+	}
+
+	for _, prefix := range diff.Added() {
+		if _, err := fmt.Fprintf(w, "+ %s\n", prefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package whois
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prefixes(values ...string) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		result = append(result, netip.MustParsePrefix(value))
+	}
+
+	return result
+}
+
+func TestDiffPrefixCollections(t *testing.T) {
+	old := &RadbPrefixCollection{
+		IPv4: prefixes("198.51.100.0/24", "192.0.2.0/24", "203.0.113.0/24"),
+		IPv6: prefixes("2001:db8::/32"),
+	}
+
+	current := &RadbPrefixCollection{
+		IPv4:      prefixes("192.0.2.0/24", "192.0.2.0/25", "203.0.113.0/24", "192.0.2.0/24"),
+		IPv6:      prefixes("2001:db8:1::/48", "2001:db8::/32"),
+		Malformed: []string{"bogus"},
+	}
+
+	diff := DiffPrefixCollections(old, current)
+	assert.Equal(t, prefixes("192.0.2.0/25"), diff.AddedIPv4)
+	assert.Equal(t, prefixes("198.51.100.0/24"), diff.RemovedIPv4)
+	assert.Equal(t, prefixes("2001:db8:1::/48"), diff.AddedIPv6)
+	assert.Empty(t, diff.RemovedIPv6)
+	assert.False(t, diff.Empty())
+
+	assert.Equal(t, prefixes("192.0.2.0/25", "2001:db8:1::/48"), diff.Added())
+	assert.Equal(t, prefixes("198.51.100.0/24"), diff.Removed())
+
+	// Inputs are left untouched
+	assert.Equal(t, prefixes("198.51.100.0/24", "192.0.2.0/24", "203.0.113.0/24"), old.IPv4)
+
+	assert.True(t, DiffPrefixCollections(old, old).Empty())
+}
+
+func TestDiffPrefixCollectionsNil(t *testing.T) {
+	collection := &RadbPrefixCollection{IPv4: prefixes("192.0.2.0/24")}
+
+	assert.Equal(t, prefixes("192.0.2.0/24"), DiffPrefixCollections(nil, collection).AddedIPv4)
+	assert.Equal(t, prefixes("192.0.2.0/24"), DiffPrefixCollections(collection, nil).RemovedIPv4)
+	assert.True(t, DiffPrefixCollections(nil, nil).Empty())
+}
+
+func TestWritePrefixDiff(t *testing.T) {
+	diff := DiffPrefixCollections(
+		&RadbPrefixCollection{IPv4: prefixes("198.51.100.0/24")},
+		&RadbPrefixCollection{IPv4: prefixes("192.0.2.0/24"), IPv6: prefixes("2001:db8::/32")},
+	)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixDiffText(&buf, diff))
+	assert.Equal(t, "- 198.51.100.0/24\n+ 192.0.2.0/24\n+ 2001:db8::/32\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteJSON(&buf, NewPrefixDiffOutput(&PrefixDiff{})))
+	assert.JSONEq(t, `{"added": [], "removed": []}`, buf.String())
+}
@@ -0,0 +1,177 @@
+//
+// Parse raw WHOIS domain responses into structured records
+//
+
+package whois
+
+import (
+	"strings"
+	"time"
+)
+
+type DomainRecord struct {
+	Domain      string
+	Registrar   string
+	Created     time.Time
+	Updated     time.Time
+	Expires     time.Time
+	NameServers []string
+	Statuses    []string
+	Registrant  DomainContact
+	Admin       DomainContact
+	Tech        DomainContact
+	Billing     DomainContact
+
+	// Set by LookupDomainRecord, nil when parsed from raw data
+	Source *Source
+}
+
+type DomainContact struct {
+	Handle       string
+	Name         string
+	Organization string
+	Street       []string
+	City         string
+	State        string
+	PostalCode   string
+	Country      string
+	Phone        string
+	Email        string
+
+	// Some values were withheld by the registry, e.g. under GDPR
+	Redacted bool
+
+	// Name or organization is a privacy proxy service
+	PrivacyProxy bool
+}
+
+// Date formats seen across registries
+var domainDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"02-Jan-2006",
+	"02.01.2006",
+	"January 2 2006",
+}
+
+//
+// Parse WHOIS response into domain record
+//
+
+func ParseDomainRecord(data []byte) *DomainRecord {
+	record := &DomainRecord{}
+	seenNs := make(map[string]bool)
+	seenStatus := make(map[string]bool)
+
+	for _, field := range ParseFields(data) {
+		key, value := field.Key, field.Value
+		if value == "" {
+			continue
+		}
+
+		switch key {
+		case "domain name", "domain":
+			if record.Domain == "" {
+				record.Domain = strings.ToLower(value)
+			}
+
+		case "registrar", "registrar name", "sponsoring registrar", "registrar handle":
+			if record.Registrar == "" {
+				record.Registrar = value
+			}
+
+		case "creation date", "created", "created on", "registered on", "registration time", "domain registration date":
+			setDomainDate(&record.Created, value)
+
+		case "updated date", "last updated", "last modified", "updated on", "changed":
+			setDomainDate(&record.Updated, value)
+
+		case "registry expiry date", "registrar registration expiration date", "expiry date", "expiration date", "expires", "expires on", "paid-till":
+			setDomainDate(&record.Expires, value)
+
+		case "name server", "nserver", "nameserver", "name servers":
+			ns := strings.ToLower(strings.TrimSuffix(strings.Fields(value)[0], "."))
+			if !seenNs[ns] {
+				seenNs[ns] = true
+				record.NameServers = append(record.NameServers, ns)
+			}
+
+		case "domain status", "status", "state":
+			status := strings.Fields(value)[0]
+			if !seenStatus[status] {
+				seenStatus[status] = true
+				record.Statuses = append(record.Statuses, status)
+			}
+
+		default:
+			if role, attr, ok := contactField(key); ok {
+				record.Contact(role).set(attr, value)
+			}
+		}
+	}
+
+	return record
+}
+
+//
+// Contact by role name, nil for unknown roles
+//
+
+func (r *DomainRecord) Contact(role string) *DomainContact {
+	switch role {
+	case ContactRegistrant:
+		return &r.Registrant
+	case ContactAdmin:
+		return &r.Admin
+	case ContactTech:
+		return &r.Tech
+	case ContactBilling:
+		return &r.Billing
+	}
+
+	return nil
+}
+
+//
+// Set field only if not already set
+//
+
+func setDomainField(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+//
+// Parse date value with known layouts, first match wins
+//
+
+func setDomainDate(field *time.Time, value string) {
+	if !field.IsZero() {
+		return
+	}
+
+	if t, ok := parseDomainDate(value); ok {
+		*field = t
+	}
+}
+
+func parseDomainDate(value string) (time.Time, bool) {
+	// Some registries append a timezone note in parentheses
+	if i := strings.Index(value, " ("); i > 0 {
+		value = value[:i]
+	}
+
+	for _, layout := range domainDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
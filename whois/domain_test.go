@@ -0,0 +1,106 @@
+package whois
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDomainRecordCom(t *testing.T) {
+	data := []byte(`   Domain Name: EXAMPLE.COM
+   Registry Domain ID: 2336799_DOMAIN_COM-VRSN
+   Registrar WHOIS Server: whois.iana.org
+   Updated Date: 2024-08-14T07:01:34Z
+   Creation Date: 1995-08-14T04:00:00Z
+   Registry Expiry Date: 2025-08-13T04:00:00Z
+   Registrar: RESERVED-Internet Assigned Numbers Authority
+   Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+   Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+   Name Server: A.IANA-SERVERS.NET
+   Name Server: B.IANA-SERVERS.NET
+   DNSSEC: signedDelegation
+>>> Last update of whois database: 2024-10-15T10:00:00Z <<<
+`)
+
+	record := ParseDomainRecord(data)
+	assert.Equal(t, "example.com", record.Domain)
+	assert.Equal(t, "RESERVED-Internet Assigned Numbers Authority", record.Registrar)
+	assert.Equal(t, time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC), record.Created)
+	assert.Equal(t, time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC), record.Updated)
+	assert.Equal(t, time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC), record.Expires)
+	assert.Equal(t, []string{"a.iana-servers.net", "b.iana-servers.net"}, record.NameServers)
+	assert.Equal(t, []string{"clientDeleteProhibited", "clientTransferProhibited"}, record.Statuses)
+}
+
+func TestParseDomainRecordOrg(t *testing.T) {
+	data := []byte(`Domain Name: example.org
+Registrar: Example Registrar, Inc.
+Creation Date: 1995-04-30T04:00:00Z
+Registry Expiry Date: 2030-04-29T04:00:00Z
+Registrant Organization: Example Org
+Registrant Country: NO
+Registrant Email: Please query the RDDS service of the Registrar of Record
+Name Server: ns1.example.org
+Name Server: ns2.example.org
+`)
+
+	record := ParseDomainRecord(data)
+	assert.Equal(t, "example.org", record.Domain)
+	assert.Equal(t, "Example Org", record.Registrant.Organization)
+	assert.Equal(t, "NO", record.Registrant.Country)
+	assert.Equal(t, time.Date(2030, 4, 29, 4, 0, 0, 0, time.UTC), record.Expires)
+	assert.Len(t, record.NameServers, 2)
+}
+
+func TestParseDomainRecordNorid(t *testing.T) {
+	data := []byte(`% By looking up information in the domain registration directory
+% service, you confirm that you accept the terms and conditions.
+
+Domain Information
+
+NORID Handle...............: NOR7145D-NORID
+Domain Name................: norid.no
+Registrar Handle...........: REG1-NORID
+Tech-c Handle..............: NH1R-NORID
+Name Server Handle.........: NSGO9H-NORID
+
+Additional information:
+Created:         1999-11-15
+Last updated:    2023-12-04
+`)
+
+	record := ParseDomainRecord(data)
+	assert.Equal(t, "norid.no", record.Domain)
+	assert.Equal(t, "REG1-NORID", record.Registrar)
+	assert.Equal(t, time.Date(1999, 11, 15, 0, 0, 0, 0, time.UTC), record.Created)
+	assert.Equal(t, time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), record.Updated)
+	assert.True(t, record.Expires.IsZero())
+	assert.Empty(t, record.NameServers)
+}
+
+func TestParseDomainRecordEmpty(t *testing.T) {
+	record := ParseDomainRecord([]byte("No match for \"EXAMPLE.INVALID\".\n"))
+	assert.Equal(t, &DomainRecord{}, record)
+}
+
+func TestParseDomainDate(t *testing.T) {
+	tests := []struct {
+		in  string
+		out time.Time
+		ok  bool
+	}{
+		{in: "2024-08-14T07:01:34Z", out: time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC), ok: true},
+		{in: "2024-08-14T07:01:34.5Z", out: time.Date(2024, 8, 14, 7, 1, 34, 500000000, time.UTC), ok: true},
+		{in: "2024-08-14", out: time.Date(2024, 8, 14, 0, 0, 0, 0, time.UTC), ok: true},
+		{in: "14-Aug-2024", out: time.Date(2024, 8, 14, 0, 0, 0, 0, time.UTC), ok: true},
+		{in: "2024-08-14 07:01:34 (UTC+8)", out: time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC), ok: true},
+		{in: "not a date", ok: false},
+	}
+
+	for _, test := range tests {
+		result, ok := parseDomainDate(test.in)
+		assert.Equal(t, test.ok, ok)
+		assert.Equal(t, test.out, result)
+	}
+}
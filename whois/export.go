@@ -0,0 +1,351 @@
+//
+// Versioned JSON export of query results, domain records and prefix
+// collections, for pipelines persisting lookups
+//
+
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// Bumped on incompatible changes, fields may be added within a version
+const JSONSchemaVersion = 1
+
+// Kind of exported document
+const (
+	JSONKindQuery    = "query"
+	JSONKindDomain   = "domain"
+	JSONKindPrefixes = "prefixes"
+)
+
+// Server and query a result came from
+type Source struct {
+	Hostname  string
+	Port      int
+	Query     string
+	FetchedAt time.Time
+}
+
+type QueryResult struct {
+	Source
+	Response []byte
+}
+
+var ErrUnsupportedSchema = errors.New("whois: unsupported json schema")
+
+type jsonHeader struct {
+	SchemaVersion int         `json:"schema_version"`
+	Kind          string      `json:"kind"`
+	Source        *jsonSource `json:"source"`
+}
+
+type jsonSource struct {
+	Hostname  string    `json:"hostname"`
+	Port      int       `json:"port"`
+	Query     string    `json:"query"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+type jsonQueryResult struct {
+	jsonHeader
+	Response string `json:"response"`
+}
+
+type jsonDomainRecord struct {
+	jsonHeader
+	Domain      string                  `json:"domain"`
+	Registrar   string                  `json:"registrar"`
+	Created     *time.Time              `json:"created"`
+	Updated     *time.Time              `json:"updated"`
+	Expires     *time.Time              `json:"expires"`
+	NameServers []string                `json:"name_servers"`
+	Statuses    []string                `json:"statuses"`
+	Contacts    map[string]*jsonContact `json:"contacts"`
+}
+
+type jsonContact struct {
+	Handle       string   `json:"handle"`
+	Name         string   `json:"name"`
+	Organization string   `json:"organization"`
+	Street       []string `json:"street"`
+	City         string   `json:"city"`
+	State        string   `json:"state"`
+	PostalCode   string   `json:"postal_code"`
+	Country      string   `json:"country"`
+	Phone        string   `json:"phone"`
+	Email        string   `json:"email"`
+	Redacted     bool     `json:"redacted"`
+	PrivacyProxy bool     `json:"privacy_proxy"`
+}
+
+type jsonPrefixCollection struct {
+	jsonHeader
+	IPv4      []netip.Prefix `json:"ipv4"`
+	IPv6      []netip.Prefix `json:"ipv6"`
+	Malformed []string       `json:"malformed"`
+}
+
+//
+// Query and keep the response with where and when it was fetched
+//
+
+func QueryResultCtx(ctx context.Context, opts *QueryOpts) (*QueryResult, error) {
+	fetchedAt := time.Now().UTC()
+
+	resp, err := QueryCtx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryResult{
+		Source: Source{
+			Hostname:  opts.Hostname,
+			Port:      opts.Port,
+			Query:     opts.Query,
+			FetchedAt: fetchedAt,
+		},
+		Response: resp,
+	}, nil
+}
+
+//
+// Look up domain and parse the final response in the referral chain
+//
+
+func LookupDomainRecord(ctx context.Context, opts *LookupOpts) (*DomainRecord, error) {
+	fetchedAt := time.Now().UTC()
+
+	chain, err := LookupCtx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, errors.New("whois: empty lookup chain")
+	}
+
+	final := chain[len(chain)-1]
+	record := ParseDomainRecord(final.Response)
+	record.Source = &Source{
+		Hostname:  final.Hostname,
+		Port:      final.Port,
+		Query:     final.Query,
+		FetchedAt: fetchedAt,
+	}
+
+	return record, nil
+}
+
+//
+// JSON encoding
+//
+
+func (r QueryResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonQueryResult{
+		jsonHeader: newJSONHeader(JSONKindQuery, &r.Source),
+		Response:   string(r.Response),
+	})
+}
+
+func (r *QueryResult) UnmarshalJSON(data []byte) error {
+	var doc jsonQueryResult
+	if err := unmarshalDocument(data, JSONKindQuery, &doc, &doc.jsonHeader); err != nil {
+		return err
+	}
+
+	*r = QueryResult{Response: []byte(doc.Response)}
+	if source := doc.Source.source(); source != nil {
+		r.Source = *source
+	}
+
+	return nil
+}
+
+func (r DomainRecord) MarshalJSON() ([]byte, error) {
+	doc := &jsonDomainRecord{
+		jsonHeader:  newJSONHeader(JSONKindDomain, r.Source),
+		Domain:      r.Domain,
+		Registrar:   r.Registrar,
+		Created:     optionalTime(r.Created),
+		Updated:     optionalTime(r.Updated),
+		Expires:     optionalTime(r.Expires),
+		NameServers: nonNil(r.NameServers),
+		Statuses:    nonNil(r.Statuses),
+		Contacts:    make(map[string]*jsonContact),
+	}
+
+	for _, role := range []string{ContactRegistrant, ContactAdmin, ContactTech, ContactBilling} {
+		doc.Contacts[role] = newJSONContact(r.Contact(role))
+	}
+
+	return json.Marshal(doc)
+}
+
+func (r *DomainRecord) UnmarshalJSON(data []byte) error {
+	var doc jsonDomainRecord
+	if err := unmarshalDocument(data, JSONKindDomain, &doc, &doc.jsonHeader); err != nil {
+		return err
+	}
+
+	*r = DomainRecord{
+		Domain:      doc.Domain,
+		Registrar:   doc.Registrar,
+		NameServers: doc.NameServers,
+		Statuses:    doc.Statuses,
+		Source:      doc.Source.source(),
+	}
+
+	for _, t := range []struct {
+		dst *time.Time
+		src *time.Time
+	}{{&r.Created, doc.Created}, {&r.Updated, doc.Updated}, {&r.Expires, doc.Expires}} {
+		if t.src != nil {
+			*t.dst = *t.src
+		}
+	}
+
+	for role, contact := range doc.Contacts {
+		if dst := r.Contact(role); dst != nil && contact != nil {
+			*dst = contact.contact()
+		}
+	}
+
+	return nil
+}
+
+func (c RadbPrefixCollection) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonPrefixCollection{
+		jsonHeader: newJSONHeader(JSONKindPrefixes, c.Source),
+		IPv4:       nonNil(c.IPv4),
+		IPv6:       nonNil(c.IPv6),
+		Malformed:  nonNil(c.Malformed),
+	})
+}
+
+func (c *RadbPrefixCollection) UnmarshalJSON(data []byte) error {
+	var doc jsonPrefixCollection
+	if err := unmarshalDocument(data, JSONKindPrefixes, &doc, &doc.jsonHeader); err != nil {
+		return err
+	}
+
+	*c = RadbPrefixCollection{
+		IPv4:      doc.IPv4,
+		IPv6:      doc.IPv6,
+		Malformed: doc.Malformed,
+		Source:    doc.Source.source(),
+	}
+
+	return nil
+}
+
+func newJSONHeader(kind string, source *Source) jsonHeader {
+	header := jsonHeader{
+		SchemaVersion: JSONSchemaVersion,
+		Kind:          kind,
+	}
+
+	if source != nil && *source != (Source{}) {
+		header.Source = &jsonSource{
+			Hostname:  source.Hostname,
+			Port:      source.Port,
+			Query:     source.Query,
+			FetchedAt: source.FetchedAt.UTC(),
+		}
+	}
+
+	return header
+}
+
+func (s *jsonSource) source() *Source {
+	if s == nil {
+		return nil
+	}
+
+	return &Source{
+		Hostname:  s.Hostname,
+		Port:      s.Port,
+		Query:     s.Query,
+		FetchedAt: s.FetchedAt,
+	}
+}
+
+//
+// Decode document after checking its kind and version
+//
+
+func unmarshalDocument(data []byte, kind string, doc any, header *jsonHeader) error {
+	if err := json.Unmarshal(data, doc); err != nil {
+		return err
+	}
+
+	if header.Kind != kind {
+		return fmt.Errorf("%w: kind %q, expected %q", ErrUnsupportedSchema, header.Kind, kind)
+	}
+
+	if header.SchemaVersion < 1 || header.SchemaVersion > JSONSchemaVersion {
+		return fmt.Errorf("%w: version %d", ErrUnsupportedSchema, header.SchemaVersion)
+	}
+
+	return nil
+}
+
+func newJSONContact(c *DomainContact) *jsonContact {
+	if c == nil || c.Absent() {
+		return nil
+	}
+
+	return &jsonContact{
+		Handle:       c.Handle,
+		Name:         c.Name,
+		Organization: c.Organization,
+		Street:       nonNil(c.Street),
+		City:         c.City,
+		State:        c.State,
+		PostalCode:   c.PostalCode,
+		Country:      c.Country,
+		Phone:        c.Phone,
+		Email:        c.Email,
+		Redacted:     c.Redacted,
+		PrivacyProxy: c.PrivacyProxy,
+	}
+}
+
+func (c *jsonContact) contact() DomainContact {
+	return DomainContact{
+		Handle:       c.Handle,
+		Name:         c.Name,
+		Organization: c.Organization,
+		Street:       c.Street,
+		City:         c.City,
+		State:        c.State,
+		PostalCode:   c.PostalCode,
+		Country:      c.Country,
+		Phone:        c.Phone,
+		Email:        c.Email,
+		Redacted:     c.Redacted,
+		PrivacyProxy: c.PrivacyProxy,
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// Empty arrays instead of null
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+
+	return s
+}
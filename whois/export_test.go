@@ -0,0 +1,137 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryResultJSON(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "Domain Name: EXAMPLE.COM\n"
+	})
+
+	result, err := QueryResultCtx(context.Background(), &QueryOpts{Hostname: host, Port: port, Query: "example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, host, result.Hostname)
+	assert.Equal(t, "example.com", result.Query)
+	assert.False(t, result.FetchedAt.IsZero())
+
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+
+	var decoded QueryResult
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, result.Source.Hostname, decoded.Hostname)
+	assert.True(t, result.FetchedAt.Equal(decoded.FetchedAt))
+	assert.Equal(t, "Domain Name: EXAMPLE.COM\n", string(decoded.Response))
+}
+
+func TestDomainRecordJSON(t *testing.T) {
+	record := &DomainRecord{
+		Domain:      "example.com",
+		Registrar:   "Example Registrar",
+		Created:     time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC),
+		NameServers: []string{"a.iana-servers.net"},
+		Registrant:  DomainContact{Organization: "Example Inc", Country: "US"},
+		Tech:        DomainContact{Redacted: true},
+		Source: &Source{
+			Hostname:  "whois.example.net",
+			Port:      43,
+			Query:     "example.com",
+			FetchedAt: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	data, err := json.Marshal(record)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"kind": "domain",
+		"source": {"hostname": "whois.example.net", "port": 43, "query": "example.com", "fetched_at": "2026-10-15T12:00:00Z"},
+		"domain": "example.com",
+		"registrar": "Example Registrar",
+		"created": "1995-08-14T04:00:00Z",
+		"updated": null,
+		"expires": null,
+		"name_servers": ["a.iana-servers.net"],
+		"statuses": [],
+		"contacts": {
+			"registrant": {
+				"handle": "", "name": "", "organization": "Example Inc", "street": [], "city": "", "state": "",
+				"postal_code": "", "country": "US", "phone": "", "email": "", "redacted": false, "privacy_proxy": false
+			},
+			"admin": null,
+			"tech": {
+				"handle": "", "name": "", "organization": "", "street": [], "city": "", "state": "",
+				"postal_code": "", "country": "", "phone": "", "email": "", "redacted": true, "privacy_proxy": false
+			},
+			"billing": null
+		}
+	}`, string(data))
+
+	var decoded DomainRecord
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, record.Domain, decoded.Domain)
+	assert.True(t, record.Created.Equal(decoded.Created))
+	assert.True(t, decoded.Updated.IsZero())
+	assert.Equal(t, "Example Inc", decoded.Registrant.Organization)
+	assert.True(t, decoded.Tech.Redacted)
+	assert.Equal(t, "whois.example.net", decoded.Source.Hostname)
+}
+
+func TestPrefixCollectionJSON(t *testing.T) {
+	data, err := json.Marshal(&RadbPrefixCollection{
+		IPv4: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"schema_version":1,"kind":"prefixes","source":null,"ipv4":["192.0.2.0/24"],"ipv6":[],"malformed":[]}`, string(data))
+
+	var decoded RadbPrefixCollection
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, decoded.IPv4)
+	assert.Nil(t, decoded.Source)
+}
+
+func TestUnmarshalSchemaErrors(t *testing.T) {
+	var collection RadbPrefixCollection
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"schema_version":2,"kind":"prefixes"}`), &collection), ErrUnsupportedSchema)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"schema_version":1,"kind":"domain"}`), &collection), ErrUnsupportedSchema)
+
+	var record DomainRecord
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"kind":"domain"}`), &record), ErrUnsupportedSchema)
+}
+
+func TestLookupDomainRecord(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "Domain Name: EXAMPLE.COM\nRegistrar: Example Registrar\n"
+	})
+
+	record, err := LookupDomainRecord(context.Background(), &LookupOpts{Domain: "example.com", Hostname: host, Port: port})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", record.Domain)
+	assert.Equal(t, host, record.Source.Hostname)
+	assert.Equal(t, port, record.Source.Port)
+}
+
+func TestRadbPrefixesSource(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "route: 192.0.2.0/24\norigin: AS64500\n"
+	})
+
+	result, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: "AS64500", Hostname: host, Port: port})
+	assert.NoError(t, err)
+	assert.Equal(t, &Source{
+		Hostname:  host,
+		Port:      port,
+		Query:     "-i origin AS64500",
+		FetchedAt: result.Source.FetchedAt,
+	}, result.Source)
+
+	assert.Nil(t, MergePrefixCollections(result).Source)
+}
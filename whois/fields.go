@@ -0,0 +1,144 @@
+//
+// Tolerant key: value parsing with continuation folding, for registry
+// specific extraction on top of the structured parsers
+//
+
+package whois
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields in response order, keys may repeat
+type Fields []Field
+
+const maxFieldKeyLen = 64
+
+//
+// Parse response into fields, keys are lowercased with norid style dot
+// padding removed, comments and notices are skipped, indented or "+" lines
+// without a key of their own are folded into the previous value
+//
+
+func ParseFields(data []byte) Fields {
+	var fields Fields
+	folding := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		// Blank lines end continuations, comments and notices are skipped
+		if trimmed == "" {
+			folding = false
+			continue
+		}
+
+		if trimmed[0] == '%' || trimmed[0] == '#' || strings.HasPrefix(trimmed, ">>>") {
+			continue
+		}
+
+		indented := line[0] == ' ' || line[0] == '\t' || line[0] == '+'
+
+		if key, value, ok := splitField(trimmed); ok && (line[0] != '+') {
+			fields = append(fields, Field{Key: key, Value: value})
+			folding = true
+			continue
+		}
+
+		if indented && folding && (len(fields) > 0) {
+			last := &fields[len(fields)-1]
+			last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(strings.TrimPrefix(trimmed, "+")))
+			continue
+		}
+
+		// Free text between fields
+		folding = false
+	}
+
+	return fields
+}
+
+//
+// Split "Key: value" or "Key.....: value", the colon must be followed by
+// whitespace or end the line, so URLs in values don't look like keys
+//
+
+func splitField(line string) (string, string, bool) {
+	i := strings.IndexByte(line, ':')
+	if i <= 0 {
+		return "", "", false
+	}
+
+	if (i+1 < len(line)) && (line[i+1] != ' ') && (line[i+1] != '\t') {
+		return "", "", false
+	}
+
+	key := strings.ToLower(strings.TrimSpace(strings.TrimRight(line[:i], ". ")))
+	if (key == "") || (len(key) > maxFieldKeyLen) {
+		return "", "", false
+	}
+
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+//
+// First non-empty value for key
+//
+
+func (f Fields) Get(key string) string {
+	key = strings.ToLower(key)
+
+	for _, field := range f {
+		if (field.Key == key) && (field.Value != "") {
+			return field.Value
+		}
+	}
+
+	return ""
+}
+
+//
+// All non-empty values for key, in response order
+//
+
+func (f Fields) All(key string) []string {
+	key = strings.ToLower(key)
+
+	var values []string
+	for _, field := range f {
+		if (field.Key == key) && (field.Value != "") {
+			values = append(values, field.Value)
+		}
+	}
+
+	return values
+}
+
+//
+// Distinct keys in order of first appearance
+//
+
+func (f Fields) Keys() []string {
+	seen := make(map[string]bool)
+
+	var keys []string
+	for _, field := range f {
+		if !seen[field.Key] {
+			seen[field.Key] = true
+			keys = append(keys, field.Key)
+		}
+	}
+
+	return keys
+}
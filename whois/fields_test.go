@@ -0,0 +1,76 @@
+package whois
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	data := []byte(`% Comment line
+# Another comment
+
+   Domain Name: EXAMPLE.COM
+   Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+   Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+NORID Handle...............: NOR7145D-NORID
+>>> Last update of whois database: 2024-10-15T10:00:00Z <<<
+
+    Registrant:
+        Nominet UK
+
+    Registrant's address:
+        Minerva House
+        Edmund Halley Road
+        Oxford
+
+descr:          First line
++               second line
+remarks:        see
+                https://example.com/policy
+Free text without colon
+        not a continuation
+`)
+
+	fields := ParseFields(data)
+
+	assert.Equal(t, Fields{
+		{Key: "domain name", Value: "EXAMPLE.COM"},
+		{Key: "domain status", Value: "clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited"},
+		{Key: "domain status", Value: "clientTransferProhibited https://icann.org/epp#clientTransferProhibited"},
+		{Key: "norid handle", Value: "NOR7145D-NORID"},
+		{Key: "registrant", Value: "Nominet UK"},
+		{Key: "registrant's address", Value: "Minerva House Edmund Halley Road Oxford"},
+		{Key: "descr", Value: "First line second line"},
+		{Key: "remarks", Value: "see https://example.com/policy"},
+	}, fields)
+
+	assert.Equal(t, "EXAMPLE.COM", fields.Get("Domain Name"))
+	assert.Equal(t, "", fields.Get("missing"))
+	assert.Len(t, fields.All("domain status"), 2)
+	assert.Equal(t, []string{"domain name", "domain status", "norid handle", "registrant", "registrant's address", "descr", "remarks"}, fields.Keys())
+}
+
+func TestSplitField(t *testing.T) {
+	tests := []struct {
+		in    string
+		key   string
+		value string
+		ok    bool
+	}{
+		{in: "Domain Name: example.com", key: "domain name", value: "example.com", ok: true},
+		{in: "Created:\t1999-11-15", key: "created", value: "1999-11-15", ok: true},
+		{in: "Registrant:", key: "registrant", value: "", ok: true},
+		{in: "Name Server Handle.........: NSGO9H-NORID", key: "name server handle", value: "NSGO9H-NORID", ok: true},
+		{in: "https://example.com", ok: false},
+		{in: ": no key", ok: false},
+		{in: "no colon", ok: false},
+	}
+
+	for _, test := range tests {
+		key, value, ok := splitField(test.in)
+		assert.Equal(t, test.ok, ok, test.in)
+		assert.Equal(t, test.key, key, test.in)
+		assert.Equal(t, test.value, value, test.in)
+	}
+}
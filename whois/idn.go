@@ -0,0 +1,281 @@
+//
+// Internationalized domain names, registries expect the ASCII form
+//
+
+package whois
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidDomain = errors.New("whois: invalid domain")
+
+// RFC 3492 parameters
+const (
+	punycodeBase        = 36
+	punycodeTmin        = 1
+	punycodeTmax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+	punycodeMaxValue    = 1<<31 - 1
+	acePrefix           = "xn--"
+)
+
+// Full stops treated as label separators, as in IDNA
+var labelSeparators = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+//
+// Convert domain to its ASCII form, lowercasing and punycoding non-ASCII labels
+//
+
+func ToASCII(domain string) (string, error) {
+	if !utf8.ValidString(domain) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+	}
+
+	labels := strings.Split(labelSeparators.Replace(domain), ".")
+
+	for i, label := range labels {
+		label = strings.ToLower(label)
+
+		if isASCII(label) {
+			labels[i] = label
+			continue
+		}
+
+		encoded, err := punycodeEncode(label)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+		}
+
+		labels[i] = acePrefix + encoded
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+//
+// Convert domain to its Unicode form for display, decoding "xn--" labels
+//
+
+func ToUnicode(domain string) (string, error) {
+	labels := strings.Split(domain, ".")
+
+	for i, label := range labels {
+		if len(label) < len(acePrefix) || !strings.EqualFold(label[:len(acePrefix)], acePrefix) {
+			continue
+		}
+
+		decoded, err := punycodeDecode(strings.ToLower(label[len(acePrefix):]))
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+		}
+
+		labels[i] = decoded
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// Punycode encoding and decoding of a single label
+//
+
+func punycodeEncode(input string) (string, error) {
+	runes := []rune(input)
+	var output []byte
+
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			output = append(output, byte(r))
+		}
+	}
+
+	basic := len(output)
+	handled := basic
+	if basic > 0 {
+		output = append(output, '-')
+	}
+
+	n := punycodeInitialN
+	bias := punycodeInitialBias
+	delta := 0
+
+	for handled < len(runes) {
+		// Next smallest code point not yet handled
+		m := punycodeMaxValue
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+
+		if (m - n) > (punycodeMaxValue-delta)/(handled+1) {
+			return "", errors.New("punycode overflow")
+		}
+
+		delta += (m - n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+
+			if int(r) != n {
+				continue
+			}
+
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+
+				output = append(output, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+
+			output = append(output, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+
+		delta++
+		n++
+	}
+
+	return string(output), nil
+}
+
+func punycodeDecode(input string) (string, error) {
+	var output []rune
+	pos := 0
+
+	// Basic code points precede the last delimiter
+	if b := strings.LastIndexByte(input, '-'); b >= 0 {
+		for _, r := range input[:b] {
+			if r >= utf8.RuneSelf {
+				return "", errors.New("punycode non-basic code point")
+			}
+
+			output = append(output, r)
+		}
+
+		pos = b + 1
+	}
+
+	n := punycodeInitialN
+	bias := punycodeInitialBias
+	i := 0
+
+	for pos < len(input) {
+		oldi := i
+		w := 1
+
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(input) {
+				return "", errors.New("punycode truncated input")
+			}
+
+			digit := punycodeDigitValue(input[pos])
+			pos++
+
+			if digit < 0 || digit > (punycodeMaxValue-i)/w {
+				return "", errors.New("punycode invalid digit or overflow")
+			}
+
+			i += digit * w
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+
+			w *= punycodeBase - t
+		}
+
+		length := len(output) + 1
+		bias = punycodeAdapt(i-oldi, length, oldi == 0)
+		n += i / length
+		i %= length
+
+		if n > unicode.MaxRune {
+			return "", errors.New("punycode code point out of range")
+		}
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+	}
+
+	return string(output), nil
+}
+
+func punycodeThreshold(k int, bias int) int {
+	switch {
+	case k <= bias:
+		return punycodeTmin
+	case k >= bias+punycodeTmax:
+		return punycodeTmax
+	default:
+		return k - bias
+	}
+}
+
+func punycodeAdapt(delta int, length int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+
+	delta += delta / length
+	k := 0
+
+	for delta > ((punycodeBase-punycodeTmin)*punycodeTmax)/2 {
+		delta /= punycodeBase - punycodeTmin
+		k += punycodeBase
+	}
+
+	return k + (punycodeBase-punycodeTmin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+
+	return byte('0' + d - 26)
+}
+
+func punycodeDigitValue(c byte) int {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a')
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A')
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26
+	default:
+		return -1
+	}
+}
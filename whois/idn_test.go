@@ -0,0 +1,78 @@
+package whois
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := map[string]string{
+		"bücher.example": "xn--bcher-kva.example",
+		"MÜNCHEN.de":     "xn--mnchen-3ya.de",
+		"例子.测试":          "xn--fsqu00a.xn--0zwm56d",
+		"пример.рф":      "xn--e1afmkfd.xn--p1ai",
+		"example.com":    "example.com",
+		"Example.COM":    "example.com",
+		"bücher。example": "xn--bcher-kva.example",
+	}
+
+	for input, expected := range tests {
+		result, err := ToASCII(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, result, input)
+	}
+
+	_, err := ToASCII("invalid\xff.com")
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := map[string]string{
+		"xn--bcher-kva.example":   "bücher.example",
+		"XN--MNCHEN-3YA.de":       "münchen.de",
+		"xn--fsqu00a.xn--0zwm56d": "例子.测试",
+		"example.com":             "example.com",
+	}
+
+	for input, expected := range tests {
+		result, err := ToUnicode(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, result, input)
+	}
+
+	for _, input := range []string{"xn--bcher-kv!.example", "xn--99999999999.com", "xn--ü-kva.com"} {
+		_, err := ToUnicode(input)
+		assert.ErrorIs(t, err, ErrInvalidDomain, input)
+	}
+}
+
+func TestPunycodeRoundTrip(t *testing.T) {
+	for _, label := range []string{"ü", "äöü", "ドメイン名例", "mañana", "😀smile"} {
+		encoded, err := punycodeEncode(label)
+		assert.NoError(t, err)
+
+		decoded, err := punycodeDecode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, label, decoded)
+	}
+}
+
+func TestLookupIDN(t *testing.T) {
+	var query string
+	host, port := newTestServer(t, func(q string) string {
+		query = q
+		return "Domain Name: xn--bcher-kva.example\n"
+	})
+
+	chain, err := LookupCtx(context.Background(), &LookupOpts{
+		Domain:   "bücher.example",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "xn--bcher-kva.example", query)
+	assert.Equal(t, "xn--bcher-kva.example", chain[0].Query)
+}
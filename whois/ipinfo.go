@@ -0,0 +1,297 @@
+//
+// IP address WHOIS lookups against the regional internet registries
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/netutil"
+)
+
+type IPInfoOpts struct {
+	IP           string
+	Hostname     string
+	Port         int
+	Timeout      time.Duration
+	Dialer       Dialer
+	Client       *Client
+	Retry        *RetryPolicy
+	Transcript   *Transcript
+	MaxReferrals int
+}
+
+type IPRecord struct {
+	IP         netip.Addr
+	Netblock   string
+	Prefixes   []netip.Prefix
+	NetName    string
+	OrgName    string
+	Country    string
+	AbuseEmail string
+	Server     string
+}
+
+var ErrInvalidIP = errors.New("whois: invalid ip address")
+
+const ArinHostname = "whois.arin.net"
+
+var (
+	arinReferralRe = regexp.MustCompile(`(?mi)^(?:ReferralServer|ResourceLink):\s+(\S+)\s*$`)
+	abuseCommentRe = regexp.MustCompile(`(?mi)^% Abuse contact for '[^']*' is '([^']+)'`)
+)
+
+//
+// Look up IP with default opts
+//
+
+func IPInfo(ip string) (*IPRecord, error) {
+	return IPInfoCtx(context.Background(), &IPInfoOpts{
+		IP: ip,
+	})
+}
+
+//
+// Look up IP starting at IANA, following referrals to and between RIRs
+//
+
+func IPInfoCtx(ctx context.Context, opts *IPInfoOpts) (*IPRecord, error) {
+	addr, err := netip.ParseAddr(opts.IP)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidIP, opts.IP)
+	}
+
+	addr = addr.Unmap()
+
+	if opts.Hostname == "" {
+		opts.Hostname = DefaultLookupOpts.Hostname
+	}
+
+	if opts.Port == 0 {
+		opts.Port = DefaultLookupOpts.Port
+	}
+
+	if opts.MaxReferrals == 0 {
+		opts.MaxReferrals = DefaultLookupOpts.MaxReferrals
+	}
+
+	var resp []byte
+	visited := make(map[string]bool)
+	hostname := opts.Hostname
+	port := opts.Port
+
+	for i := 0; i <= opts.MaxReferrals; i++ {
+		addrKey := net.JoinHostPort(strings.ToLower(hostname), fmt.Sprint(port))
+		visited[addrKey] = true
+
+		resp, err = QueryCtx(ctx, &QueryOpts{
+			Hostname:   hostname,
+			Port:       port,
+			Query:      ipQuery(hostname, addr),
+			Timeout:    opts.Timeout,
+			Dialer:     opts.Dialer,
+			Client:     opts.Client,
+			Retry:      opts.Retry,
+			Transcript: opts.Transcript,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		next, nextPort, ok := findIPReferral(resp)
+		if !ok || visited[net.JoinHostPort(strings.ToLower(next), fmt.Sprint(nextPort))] {
+			break
+		}
+
+		hostname = next
+		port = nextPort
+	}
+
+	record := ParseIPRecord(resp, addr)
+	record.Server = hostname
+
+	return record, nil
+}
+
+//
+// ARIN needs "n +" for the network with full details, others take the bare address
+//
+
+func ipQuery(hostname string, addr netip.Addr) string {
+	if strings.EqualFold(hostname, ArinHostname) {
+		return "n + " + addr.String()
+	}
+
+	return addr.String()
+}
+
+func findIPReferral(resp []byte) (string, int, bool) {
+	// Resource links may also point to web pages, take the first server
+	for _, match := range arinReferralRe.FindAllSubmatch(resp, -1) {
+		if hostname, port, ok := parseReferral(string(match[1])); ok {
+			return hostname, port, true
+		}
+	}
+
+	if match := ianaReferRe.FindSubmatch(resp); match != nil {
+		return parseReferral(string(match[1]))
+	}
+
+	return "", 0, false
+}
+
+//
+// Parse RIR response, using the most specific network containing addr,
+// organisation and abuse objects belong to the network preceding them
+//
+
+func ParseIPRecord(data []byte, addr netip.Addr) *IPRecord {
+	record := &IPRecord{IP: addr}
+	shared := &IPRecord{}
+	current := shared
+	var best *big.Int
+
+	for _, attrs := range parseRpslObjects(data) {
+		switch rpslClass(attrs) {
+		case "netrange", "inetnum", "inet6num", "cidr":
+			prefixes, netblock := parseNetblock(attrs)
+			current = &IPRecord{
+				Netblock: netblock,
+				Prefixes: prefixes,
+			}
+
+			// Keep the smallest network containing the address
+			if size := prefixesSize(prefixes, addr); size != nil && (best == nil || size.Cmp(best) < 0) {
+				best = size
+				record = current
+			}
+
+			for _, attr := range attrs {
+				switch attr.key {
+				case "netname":
+					setDomainField(&current.NetName, attr.value)
+				case "country":
+					setDomainField(&current.Country, strings.ToUpper(attr.value))
+				case "orgname", "custname", "org-name", "owner":
+					setDomainField(&current.OrgName, attr.value)
+				case "orgabuseemail", "abuse-mailbox":
+					setDomainField(&current.AbuseEmail, attr.value)
+				}
+			}
+
+		case "orgname", "custname", "organisation", "organization":
+			for _, attr := range attrs {
+				switch attr.key {
+				case "orgname", "custname", "org-name":
+					setDomainField(&current.OrgName, attr.value)
+				case "country":
+					setDomainField(&current.Country, strings.ToUpper(attr.value))
+				}
+			}
+
+		case "orgabusehandle":
+			for _, attr := range attrs {
+				if attr.key == "orgabuseemail" {
+					setDomainField(&current.AbuseEmail, attr.value)
+				}
+			}
+
+		case "irt", "role":
+			for _, attr := range attrs {
+				if attr.key == "abuse-mailbox" {
+					setDomainField(&current.AbuseEmail, attr.value)
+				}
+			}
+		}
+	}
+
+	// Fall back to objects not tied to a network
+	record.IP = addr
+	setDomainField(&record.OrgName, shared.OrgName)
+	setDomainField(&record.Country, shared.Country)
+	setDomainField(&record.AbuseEmail, shared.AbuseEmail)
+
+	// RIPE style abuse contact remark wins
+	if match := abuseCommentRe.FindSubmatch(data); match != nil {
+		record.AbuseEmail = string(match[1])
+	}
+
+	return record
+}
+
+//
+// Network attributes to prefixes, from CIDR lists or address ranges
+//
+
+func parseNetblock(attrs []rpslAttr) ([]netip.Prefix, string) {
+	var netblock string
+	var prefixes []netip.Prefix
+
+	for _, attr := range attrs {
+		switch attr.key {
+		case "netrange", "inetnum", "inet6num":
+			netblock = attr.value
+
+			if prefix, err := netip.ParsePrefix(attr.value); err == nil {
+				prefixes = []netip.Prefix{prefix.Masked()}
+				continue
+			}
+
+			start, end, ok := strings.Cut(attr.value, "-")
+			if !ok {
+				continue
+			}
+
+			startAddr, err1 := netip.ParseAddr(strings.TrimSpace(start))
+			endAddr, err2 := netip.ParseAddr(strings.TrimSpace(end))
+			if err1 == nil && err2 == nil && len(prefixes) == 0 {
+				prefixes = netutil.RangeToPrefixes(startAddr, endAddr)
+			}
+
+		case "cidr":
+			// Explicit CIDR list takes precedence over the range
+			var cidrs []netip.Prefix
+			for _, value := range strings.Split(attr.value, ",") {
+				if prefix, err := netip.ParsePrefix(strings.TrimSpace(value)); err == nil {
+					cidrs = append(cidrs, prefix.Masked())
+				}
+			}
+
+			if len(cidrs) > 0 {
+				prefixes = cidrs
+			}
+		}
+	}
+
+	return prefixes, netblock
+}
+
+//
+// Number of addresses in prefixes, nil when addr is not contained
+//
+
+func prefixesSize(prefixes []netip.Prefix, addr netip.Addr) *big.Int {
+	var contains bool
+	size := new(big.Int)
+
+	for _, prefix := range prefixes {
+		contains = contains || prefix.Contains(addr)
+		size.Add(size, new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits())))
+	}
+
+	if !contains {
+		return nil
+	}
+
+	return size
+}
@@ -0,0 +1,129 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testArinResponse = `
+# ARIN WHOIS data and services are subject to the Terms of Use
+
+NetRange:       8.0.0.0 - 8.255.255.255
+CIDR:           8.0.0.0/8
+NetName:        LVLT-ORG-8-8
+Country:        US
+
+OrgName:        Level 3 Parent, LLC
+Country:        US
+
+NetRange:       8.8.8.0 - 8.8.8.255
+CIDR:           8.8.8.0/24
+NetName:        GOGL
+Country:        US
+
+OrgName:        Google LLC
+OrgId:          GOGL
+Country:        US
+
+OrgAbuseHandle: ABUSE5250-ARIN
+OrgAbuseName:   Abuse
+OrgAbuseEmail:  network-abuse@google.com
+`
+
+const testRipeResponse = `
+% This is the RIPE Database query service.
+% Abuse contact for '193.0.0.0 - 193.0.7.255' is 'abuse@ripe.net'
+
+inetnum:        193.0.0.0 - 193.0.7.255
+netname:        RIPE-NCC
+country:        nl
+org:            ORG-RIEN1-RIPE
+
+organisation:   ORG-RIEN1-RIPE
+org-name:       Reseaux IP Europeens Network Coordination Centre (RIPE NCC)
+`
+
+func TestParseIPRecordArin(t *testing.T) {
+	record := ParseIPRecord([]byte(testArinResponse), netip.MustParseAddr("8.8.8.8"))
+
+	assert.Equal(t, "8.8.8.0 - 8.8.8.255", record.Netblock)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("8.8.8.0/24")}, record.Prefixes)
+	assert.Equal(t, "GOGL", record.NetName)
+	assert.Equal(t, "Google LLC", record.OrgName)
+	assert.Equal(t, "US", record.Country)
+	assert.Equal(t, "network-abuse@google.com", record.AbuseEmail)
+}
+
+func TestParseIPRecordRipe(t *testing.T) {
+	record := ParseIPRecord([]byte(testRipeResponse), netip.MustParseAddr("193.0.6.139"))
+
+	assert.Equal(t, "193.0.0.0 - 193.0.7.255", record.Netblock)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("193.0.0.0/21")}, record.Prefixes)
+	assert.Equal(t, "RIPE-NCC", record.NetName)
+	assert.Equal(t, "Reseaux IP Europeens Network Coordination Centre (RIPE NCC)", record.OrgName)
+	assert.Equal(t, "NL", record.Country)
+	assert.Equal(t, "abuse@ripe.net", record.AbuseEmail)
+}
+
+func TestIPInfoReferrals(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+
+	ripeHost, ripePort := newTestServer(t, func(query string) string {
+		mu.Lock()
+		queries = append(queries, "ripe:"+query)
+		mu.Unlock()
+
+		return testRipeResponse
+	})
+
+	arinHost, arinPort := newTestServer(t, func(query string) string {
+		mu.Lock()
+		queries = append(queries, "arin:"+query)
+		mu.Unlock()
+
+		return "NetRange: 193.0.0.0 - 193.255.255.255\n" +
+			"ResourceLink: https://apps.db.ripe.net/search/query.html\n" +
+			"ReferralServer: whois://" + net.JoinHostPort(ripeHost, strconv.Itoa(ripePort)) + "\n"
+	})
+
+	ianaHost, ianaPort := newTestServer(t, func(query string) string {
+		mu.Lock()
+		queries = append(queries, "iana:"+query)
+		mu.Unlock()
+
+		return "refer: " + net.JoinHostPort(arinHost, strconv.Itoa(arinPort)) + "\n"
+	})
+
+	record, err := IPInfoCtx(context.Background(), &IPInfoOpts{
+		IP:       "193.0.6.139",
+		Hostname: ianaHost,
+		Port:     ianaPort,
+	})
+
+	assert.Nil(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"iana:193.0.6.139", "arin:193.0.6.139", "ripe:193.0.6.139"}, queries)
+	assert.Equal(t, ripeHost, record.Server)
+	assert.Equal(t, "RIPE-NCC", record.NetName)
+	assert.Equal(t, "abuse@ripe.net", record.AbuseEmail)
+}
+
+func TestIPInfoInvalid(t *testing.T) {
+	_, err := IPInfo("not-an-ip")
+	assert.ErrorIs(t, err, ErrInvalidIP)
+}
+
+func TestIPQuery(t *testing.T) {
+	addr := netip.MustParseAddr("8.8.8.8")
+	assert.Equal(t, "n + 8.8.8.8", ipQuery("whois.arin.net", addr))
+	assert.Equal(t, "8.8.8.8", ipQuery("whois.ripe.net", addr))
+}
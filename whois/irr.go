@@ -0,0 +1,222 @@
+//
+// Query IRR databases for route objects, with source selection
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well-known IRR sources, as accepted by the -s flag
+const (
+	IrrSourceRadb   = "RADB"
+	IrrSourceRipe   = "RIPE"
+	IrrSourceArin   = "ARIN"
+	IrrSourceApnic  = "APNIC"
+	IrrSourceAltdb  = "ALTDB"
+	IrrSourceLevel3 = "LEVEL3"
+)
+
+// Authoritative servers per source, queried with Authoritative set
+var IrrServers = map[string]string{
+	IrrSourceRadb:   "whois.radb.net",
+	IrrSourceRipe:   "whois.ripe.net",
+	IrrSourceArin:   "rr.arin.net",
+	IrrSourceApnic:  "whois.apnic.net",
+	IrrSourceAltdb:  "whois.altdb.net",
+	IrrSourceLevel3: "rr.level3.net",
+}
+
+type IrrPrefixesByAsnOpts struct {
+	Asn     string
+	Sources []string
+
+	// Query each source at its own server instead of one mirror for all,
+	// servers are looked up in Servers, then IrrServers, as host or
+	// host:port
+	Authoritative bool
+	Servers       map[string]string
+
+	Hostname        string
+	Port            int
+	Timeout         time.Duration
+	MaxResponseSize int64
+	Dialer          Dialer
+	Client          *Client
+	Retry           *RetryPolicy
+	Transcript      *Transcript
+}
+
+var ErrUnknownIrrSource = errors.New("whois: no server for irr source")
+
+type IrrPrefixResult struct {
+	Merged   *RadbPrefixCollection
+	BySource map[string]*RadbPrefixCollection
+	Routes   []*RouteObject
+}
+
+//
+// Fetch route objects originated by ASN, grouped by the source they came from
+//
+
+func IrrPrefixesByAsn(ctx context.Context, opts *IrrPrefixesByAsnOpts) (*IrrPrefixResult, error) {
+	var routes []*RouteObject
+	var malformed []string
+
+	err := streamRouteObjects(ctx, opts, func(route *RouteObject, raw string) error {
+		if route == nil {
+			malformed = append(malformed, raw)
+		} else {
+			routes = append(routes, route)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return newIrrPrefixResult(nil), err
+	}
+
+	result := newIrrPrefixResult(routes)
+	result.Merged.Malformed = malformed
+
+	return result, nil
+}
+
+//
+// Yield route objects originated by ASN as they are read from the server,
+// objects with malformed prefixes are skipped
+//
+
+func StreamRouteObjects(ctx context.Context, opts *IrrPrefixesByAsnOpts, fn func(route *RouteObject) error) error {
+	return streamRouteObjects(ctx, opts, func(route *RouteObject, raw string) error {
+		if route == nil {
+			return nil
+		}
+
+		return fn(route)
+	})
+}
+
+func streamRouteObjects(ctx context.Context, opts *IrrPrefixesByAsnOpts, fn func(route *RouteObject, raw string) error) error {
+	if opts.Hostname == "" {
+		opts.Hostname = RadbHostname
+	}
+
+	if !opts.Authoritative || len(opts.Sources) == 0 {
+		return streamRouteObjectsFrom(ctx, opts, opts.Hostname, opts.Port, opts.Sources, fn)
+	}
+
+	// One query per source, in order, so the callback is never concurrent
+	for _, source := range opts.Sources {
+		source = strings.ToUpper(source)
+
+		hostname, exists := opts.Servers[source]
+		if !exists {
+			hostname, exists = IrrServers[source]
+		}
+
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrUnknownIrrSource, source)
+		}
+
+		port := opts.Port
+		if host, portStr, err := net.SplitHostPort(hostname); err == nil {
+			hostname = host
+			port, _ = strconv.Atoi(portStr)
+		}
+
+		if err := streamRouteObjectsFrom(ctx, opts, hostname, port, []string{source}, fn); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	return nil
+}
+
+func streamRouteObjectsFrom(ctx context.Context, opts *IrrPrefixesByAsnOpts, hostname string, port int, sources []string, fn func(route *RouteObject, raw string) error) error {
+	query := fmt.Sprintf("-i origin %s", opts.Asn)
+	if len(sources) > 0 {
+		query = fmt.Sprintf("-s %s %s", strings.ToUpper(strings.Join(sources, ",")), query)
+	}
+
+	return QueryStream(ctx, &QueryOpts{
+		Hostname:        hostname,
+		Port:            port,
+		Query:           query,
+		Timeout:         opts.Timeout,
+		MaxResponseSize: opts.MaxResponseSize,
+		Dialer:          opts.Dialer,
+		Client:          opts.Client,
+		Retry:           opts.Retry,
+		Transcript:      opts.Transcript,
+	}, func(r io.Reader) error {
+		return readRouteObjects(r, fn)
+	})
+}
+
+//
+// Group route objects by source
+//
+
+func newIrrPrefixResult(routes []*RouteObject) *IrrPrefixResult {
+	result := &IrrPrefixResult{
+		Merged:   &RadbPrefixCollection{},
+		BySource: make(map[string]*RadbPrefixCollection),
+		Routes:   routes,
+	}
+
+	for _, route := range routes {
+		collection, exists := result.BySource[route.Source]
+		if !exists {
+			collection = &RadbPrefixCollection{}
+			result.BySource[route.Source] = collection
+		}
+
+		collection.add(route)
+		result.Merged.add(route)
+	}
+
+	result.Merged.IPv4 = sortPrefixes(result.Merged.IPv4)
+	result.Merged.IPv6 = sortPrefixes(result.Merged.IPv6)
+
+	for _, collection := range result.BySource {
+		collection.IPv4 = sortPrefixes(collection.IPv4)
+		collection.IPv6 = sortPrefixes(collection.IPv6)
+	}
+
+	return result
+}
+
+//
+// Sources present in result, sorted
+//
+
+func (r *IrrPrefixResult) Sources() []string {
+	sources := make([]string, 0, len(r.BySource))
+	for source := range r.BySource {
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+	return sources
+}
+
+//
+// Prefixes added and removed going from one source to another, e.g. what
+// RADB has that RIPE does not, sources missing from the result count as
+// empty
+//
+
+func (r *IrrPrefixResult) DiffSources(from string, to string) *PrefixDiff {
+	return DiffPrefixCollections(r.BySource[strings.ToUpper(from)], r.BySource[strings.ToUpper(to)])
+}
@@ -0,0 +1,183 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/publishlab/infra-golang-toolkit/whois/whoistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIrrPrefixesByAsn(t *testing.T) {
+	var received string
+	host, port := newTestServer(t, func(query string) string {
+		received = query
+		return `route:          192.0.2.0/24
+origin:         AS64500
+source:         RIPE
+
+route:          198.51.100.0/24
+origin:         AS64500
+source:         RADB
+
+route:          192.0.2.0/24
+origin:         AS64500
+source:         RADB
+`
+	})
+
+	result, err := IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:      "AS64500",
+		Sources:  []string{"ripe", IrrSourceRadb},
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "-s RIPE,RADB -i origin AS64500", received)
+	assert.Equal(t, []string{"RADB", "RIPE"}, result.Sources())
+	assert.Len(t, result.Routes, 3)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+	}, result.Merged.IPv4)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+	}, result.BySource[IrrSourceRipe].IPv4)
+}
+
+func TestIrrPrefixesByAsnAuthoritative(t *testing.T) {
+	ripe := whoistest.NewServer(whoistest.Static(map[string]string{
+		"-s RIPE -i origin AS64500": "route: 192.0.2.0/24\norigin: AS64500\nsource: RIPE\n\nroute: 203.0.113.0/24\norigin: AS64500\nsource: RIPE\n",
+	}, "%ERROR:101: no entries found\n"))
+
+	radb := whoistest.NewServer(whoistest.Static(map[string]string{
+		"-s RADB -i origin AS64500": "route: 192.0.2.0/24\norigin: AS64500\nsource: RADB\n\nroute: 198.51.100.0/24\norigin: AS64500\nsource: RADB\n",
+	}, "%ERROR:101: no entries found\n"))
+
+	t.Cleanup(ripe.Close)
+	t.Cleanup(radb.Close)
+
+	// Both test servers listen on loopback, tell them apart by port
+	assert.Equal(t, ripe.Hostname, radb.Hostname)
+
+	result, err := IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:           "AS64500",
+		Sources:       []string{"ripe"},
+		Authoritative: true,
+		Servers:       map[string]string{IrrSourceRipe: ripe.Hostname},
+		Port:          ripe.Port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-s RIPE -i origin AS64500"}, ripe.Queries())
+	assert.Equal(t, []string{"RIPE"}, result.Sources())
+
+	// Each source goes to its own server
+	result, err = IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:           "AS64500",
+		Sources:       []string{IrrSourceRipe, IrrSourceRadb},
+		Authoritative: true,
+		Servers: map[string]string{
+			IrrSourceRipe: ripe.Addr(),
+			IrrSourceRadb: radb.Addr(),
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"RADB", "RIPE"}, result.Sources())
+	assert.Equal(t, []string{"-s RADB -i origin AS64500"}, radb.Queries())
+
+	diff := result.DiffSources(IrrSourceRipe, IrrSourceRadb)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}, diff.AddedIPv4)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}, diff.RemovedIPv4)
+
+	_, err = IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:           "AS64500",
+		Sources:       []string{"NOSUCH"},
+		Authoritative: true,
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownIrrSource)
+}
+
+func TestIrrPrefixesByAsnError(t *testing.T) {
+	result, err := IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:      "AS64500",
+		Hostname: "127.0.0.1",
+		Port:     1,
+	})
+
+	assert.Error(t, err)
+	assert.Empty(t, result.Merged.IPv4)
+	assert.Empty(t, result.BySource)
+}
+
+func TestStreamRouteObjects(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		var sb strings.Builder
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(&sb, "route: 10.%d.0.0/16\norigin: AS64500\nsource: RADB\n\n", i)
+		}
+
+		sb.WriteString("route: bogus\norigin: AS64500\n")
+		return sb.String()
+	})
+
+	var routes []*RouteObject
+	err := StreamRouteObjects(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:      "AS64500",
+		Hostname: host,
+		Port:     port,
+	}, func(route *RouteObject) error {
+		routes = append(routes, route)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, routes, 100)
+	assert.Equal(t, netip.MustParsePrefix("10.99.0.0/16"), routes[99].Prefix)
+
+	// Stop early
+	stop := errors.New("stop")
+	var count int
+
+	err = StreamRouteObjects(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:      "AS64500",
+		Hostname: host,
+		Port:     port,
+		Retry:    DefaultRetryPolicy,
+	}, func(route *RouteObject) error {
+		count++
+		if count == 5 {
+			return stop
+		}
+
+		return nil
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 5, count)
+}
+
+func TestStreamRouteObjectsMaxResponseSize(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return strings.Repeat("route: 10.0.0.0/8\norigin: AS64500\n\n", 1000)
+	})
+
+	result, err := IrrPrefixesByAsn(context.Background(), &IrrPrefixesByAsnOpts{
+		Asn:             "AS64500",
+		Hostname:        host,
+		Port:            port,
+		MaxResponseSize: 1024,
+	})
+
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Empty(t, result.Merged.IPv4)
+}
@@ -0,0 +1,120 @@
+//
+// Lint IRR route objects and prefix lists against ROAs
+//
+
+package whois
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Why a route did not validate
+const (
+	LintReasonNoROA          = "no covering roa"
+	LintReasonOriginMismatch = "origin not authorized by covering roas"
+	LintReasonTooSpecific    = "prefix longer than roa max length"
+)
+
+type LintFinding struct {
+	Prefix netip.Prefix
+	Origin string
+	Source string
+	State  ValidationState
+	Reason string
+
+	// Covering ROAs, empty when none was found
+	ROAs []ROA
+}
+
+//
+// Validate route objects, returns findings for every route that is not
+// valid, invalid routes first
+//
+
+func LintRouteObjects(routes []*RouteObject, roas *ROASet) []*LintFinding {
+	var findings []*LintFinding
+
+	for _, route := range routes {
+		if finding := lintRoute(route.Prefix, route.Origin, roas); finding != nil {
+			finding.Source = route.Source
+			findings = append(findings, finding)
+		}
+	}
+
+	sortFindings(findings)
+	return findings
+}
+
+//
+// Validate prefix lists keyed by origin ASN, as returned by
+// RadbPrefixesByAsnsCtx
+//
+
+func LintPrefixes(prefixes map[string]*RadbPrefixCollection, roas *ROASet) []*LintFinding {
+	var findings []*LintFinding
+
+	for asn, collection := range prefixes {
+		if collection == nil {
+			continue
+		}
+
+		for _, list := range [][]netip.Prefix{collection.IPv4, collection.IPv6} {
+			for _, prefix := range list {
+				if finding := lintRoute(prefix, asn, roas); finding != nil {
+					findings = append(findings, finding)
+				}
+			}
+		}
+	}
+
+	sortFindings(findings)
+	return findings
+}
+
+func lintRoute(prefix netip.Prefix, origin string, roas *ROASet) *LintFinding {
+	origin = strings.ToUpper(origin)
+
+	state := roas.Validate(prefix, origin)
+	if state == ValidationValid {
+		return nil
+	}
+
+	finding := &LintFinding{
+		Prefix: prefix,
+		Origin: origin,
+		State:  state,
+		Reason: LintReasonNoROA,
+		ROAs:   roas.Covering(prefix),
+	}
+
+	if state == ValidationInvalid {
+		finding.Reason = LintReasonOriginMismatch
+
+		// Authorized origin, but announced more specific than allowed
+		for _, roa := range finding.ROAs {
+			if roa.Asn == origin && roa.Asn != "AS0" {
+				finding.Reason = LintReasonTooSpecific
+				break
+			}
+		}
+	}
+
+	return finding
+}
+
+func sortFindings(findings []*LintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.State != b.State {
+			return a.State == ValidationInvalid
+		}
+
+		if c := comparePrefixes(a.Prefix, b.Prefix); c != 0 {
+			return c < 0
+		}
+
+		return a.Origin < b.Origin
+	})
+}
@@ -0,0 +1,60 @@
+package whois
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintRouteObjects(t *testing.T) {
+	roas, err := ParseROAs(strings.NewReader(testRoasJSON))
+	assert.NoError(t, err)
+
+	routes := []*RouteObject{
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), Origin: "AS64500", Source: "RADB"},
+		{Prefix: netip.MustParsePrefix("10.0.0.0/8"), Origin: "AS64500", Source: "RADB"},
+		{Prefix: netip.MustParsePrefix("192.0.2.128/25"), Origin: "AS64500", Source: "ALTDB"},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), Origin: "AS64502", Source: "RADB"},
+	}
+
+	findings := LintRouteObjects(routes, roas)
+	assert.Len(t, findings, 3)
+
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), findings[0].Prefix)
+	assert.Equal(t, "AS64502", findings[0].Origin)
+	assert.Equal(t, ValidationInvalid, findings[0].State)
+	assert.Equal(t, LintReasonOriginMismatch, findings[0].Reason)
+	assert.Len(t, findings[0].ROAs, 1)
+
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.128/25"), findings[1].Prefix)
+	assert.Equal(t, "ALTDB", findings[1].Source)
+	assert.Equal(t, LintReasonTooSpecific, findings[1].Reason)
+
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), findings[2].Prefix)
+	assert.Equal(t, ValidationNotFound, findings[2].State)
+	assert.Equal(t, LintReasonNoROA, findings[2].Reason)
+	assert.Empty(t, findings[2].ROAs)
+}
+
+func TestLintPrefixes(t *testing.T) {
+	roas, err := ParseROAs(strings.NewReader(testRoasJSON))
+	assert.NoError(t, err)
+
+	findings := LintPrefixes(map[string]*RadbPrefixCollection{
+		"AS64500": {
+			IPv4: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+			IPv6: []netip.Prefix{netip.MustParsePrefix("2001:db8::/49")},
+		},
+		"as64501": {
+			IPv4: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/23")},
+		},
+		"AS64503": nil,
+	}, roas)
+
+	assert.Len(t, findings, 1)
+	assert.Equal(t, netip.MustParsePrefix("2001:db8::/49"), findings[0].Prefix)
+	assert.Equal(t, "AS64500", findings[0].Origin)
+	assert.Equal(t, LintReasonTooSpecific, findings[0].Reason)
+}
@@ -0,0 +1,197 @@
+//
+// Domain lookup with IANA and registrar referral following
+//
+
+package whois
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ianaReferRe      = regexp.MustCompile(`(?mi)^(?:refer|whois):\s+(\S+)\s*$`)
+	registrarReferRe = regexp.MustCompile(`(?mi)^\s*Registrar WHOIS Server:\s+(\S+)\s*$`)
+)
+
+type LookupOpts struct {
+	Domain       string
+	Hostname     string
+	Port         int
+	Timeout      time.Duration
+	Dialer       Dialer
+	Client       *Client
+	Retry        *RetryPolicy
+	Transcript   *Transcript
+	MaxReferrals int
+	Charset      string
+}
+
+type LookupResponse struct {
+	Hostname string
+	Port     int
+	Query    string
+	Response []byte
+}
+
+var DefaultLookupOpts = &LookupOpts{
+	Hostname:     "whois.iana.org",
+	Port:         43,
+	MaxReferrals: 3,
+}
+
+//
+// Lookup domain with default opts
+//
+
+func Lookup(domain string) ([]*LookupResponse, error) {
+	return LookupWithOpts(&LookupOpts{
+		Domain: domain,
+	})
+}
+
+//
+// Lookup domain with opts
+//
+
+func LookupWithOpts(opts *LookupOpts) ([]*LookupResponse, error) {
+	return LookupCtx(context.Background(), opts)
+}
+
+//
+// Lookup domain with context and opts, following referrals from the TLD server
+// when known, otherwise from the root server
+//
+
+func LookupCtx(ctx context.Context, opts *LookupOpts) ([]*LookupResponse, error) {
+	if opts.MaxReferrals == 0 {
+		opts.MaxReferrals = DefaultLookupOpts.MaxReferrals
+	}
+
+	// Registries only match the ASCII form of IDN domains
+	query, err := ToASCII(opts.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname := opts.Hostname
+	port := opts.Port
+
+	// Known TLD servers are asked directly unless a starting server is given
+	if hostname == "" {
+		if server, ok := ServerForDomain(query); ok {
+			hostname = server.Hostname
+			port = server.Port
+		}
+	}
+
+	if hostname == "" {
+		hostname = DefaultLookupOpts.Hostname
+	}
+
+	if port == 0 {
+		port = DefaultLookupOpts.Port
+	}
+
+	var chain []*LookupResponse
+	visited := make(map[string]bool)
+
+	for i := 0; i <= opts.MaxReferrals; i++ {
+		addr := net.JoinHostPort(strings.ToLower(hostname), fmt.Sprint(port))
+		visited[addr] = true
+
+		// Registry quirks, like query flags or a fixed response charset
+		hopQuery := query
+		charset := opts.Charset
+
+		if server, ok := serverByAddress(hostname, port); ok {
+			hopQuery = server.query(query)
+			if charset == "" {
+				charset = server.Charset
+			}
+		}
+
+		resp, err := QueryCtx(ctx, &QueryOpts{
+			Hostname:   hostname,
+			Port:       port,
+			Query:      hopQuery,
+			Timeout:    opts.Timeout,
+			Dialer:     opts.Dialer,
+			Client:     opts.Client,
+			Retry:      opts.Retry,
+			Transcript: opts.Transcript,
+			Charset:    charset,
+		})
+
+		if err != nil {
+			return chain, err
+		}
+
+		chain = append(chain, &LookupResponse{
+			Hostname: hostname,
+			Port:     port,
+			Query:    hopQuery,
+			Response: resp,
+		})
+
+		// Find next server in chain, stop when there is none
+		next, nextPort, ok := findReferral(resp)
+		if !ok || visited[net.JoinHostPort(strings.ToLower(next), fmt.Sprint(nextPort))] {
+			break
+		}
+
+		hostname = next
+		port = nextPort
+	}
+
+	return chain, nil
+}
+
+//
+// Extract referral server from a WHOIS response
+//
+
+func findReferral(resp []byte) (string, int, bool) {
+	var match [][]byte
+
+	if m := ianaReferRe.FindSubmatch(resp); m != nil {
+		match = m
+	} else if m := registrarReferRe.FindSubmatch(resp); m != nil {
+		match = m
+	} else {
+		return "", 0, false
+	}
+
+	return parseReferral(string(match[1]))
+}
+
+//
+// Parse referral value, which may be a hostname, host:port or URL
+//
+
+func parseReferral(value string) (string, int, bool) {
+	value = strings.TrimPrefix(value, "whois://")
+	value = strings.TrimRight(value, "/")
+
+	// Registrars sometimes point to web pages instead of servers
+	if strings.Contains(value, "://") || value == "" {
+		return "", 0, false
+	}
+
+	host, portStr, err := net.SplitHostPort(value)
+	if err != nil {
+		return value, DefaultLookupOpts.Port, true
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 {
+		return "", 0, false
+	}
+
+	return host, port, true
+}
@@ -0,0 +1,84 @@
+package whois
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	regHost, regPort := newTestServer(t, func(query string) string {
+		return "Domain Name: " + query + "\nRegistrar: Example Registrar\n"
+	})
+
+	tldHost, tldPort := newTestServer(t, func(query string) string {
+		return fmt.Sprintf("Domain Name: %s\nRegistrar WHOIS Server: %s:%d\n", query, regHost, regPort)
+	})
+
+	rootHost, rootPort := newTestServer(t, func(query string) string {
+		return fmt.Sprintf("%% IANA WHOIS server\n\nrefer:        %s:%d\n\ndomain:       COM\n", tldHost, tldPort)
+	})
+
+	chain, err := LookupWithOpts(&LookupOpts{
+		Domain:   "example.com",
+		Hostname: rootHost,
+		Port:     rootPort,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, chain, 3)
+	assert.Equal(t, rootPort, chain[0].Port)
+	assert.Equal(t, tldPort, chain[1].Port)
+	assert.Equal(t, regPort, chain[2].Port)
+	assert.Contains(t, string(chain[2].Response), "Example Registrar")
+}
+
+func TestLookupLoop(t *testing.T) {
+	var port int
+	host, port := newTestServer(t, func(query string) string {
+		return fmt.Sprintf("refer: 127.0.0.1:%d\n", port)
+	})
+
+	chain, err := LookupWithOpts(&LookupOpts{
+		Domain:   "example.com",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, chain, 1)
+}
+
+func TestLookupError(t *testing.T) {
+	chain, err := LookupWithOpts(&LookupOpts{
+		Domain:   "example.com",
+		Hostname: "127.0.0.1",
+		Port:     1,
+	})
+
+	assert.Error(t, err)
+	assert.Empty(t, chain)
+}
+
+func TestParseReferral(t *testing.T) {
+	tests := []struct {
+		in   string
+		host string
+		port int
+		ok   bool
+	}{
+		{in: "whois.verisign-grs.com", host: "whois.verisign-grs.com", port: 43, ok: true},
+		{in: "whois.example.net:4343", host: "whois.example.net", port: 4343, ok: true},
+		{in: "whois://whois.example.org/", host: "whois.example.org", port: 43, ok: true},
+		{in: "https://www.example.com/whois", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, test := range tests {
+		host, port, ok := parseReferral(test.in)
+		assert.Equal(t, test.ok, ok)
+		assert.Equal(t, test.host, host)
+		assert.Equal(t, test.port, port)
+	}
+}
@@ -0,0 +1,198 @@
+//
+// Origin lookups for IP addresses and ASN details
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+type RadbOriginByPrefixOpts struct {
+	Prefix     string
+	Hostname   string
+	Port       int
+	Timeout    time.Duration
+	Dialer     Dialer
+	Client     *Client
+	Retry      *RetryPolicy
+	Transcript *Transcript
+}
+
+type AsnInfoOpts struct {
+	Asn        string
+	Hostname   string
+	Port       int
+	Timeout    time.Duration
+	Dialer     Dialer
+	Client     *Client
+	Retry      *RetryPolicy
+	Transcript *Transcript
+}
+
+type AutNum struct {
+	Asn    string
+	AsName string
+	Descr  []string
+	MntBy  []string
+	Org    string
+	Source string
+}
+
+var (
+	ErrInvalidPrefix = errors.New("whois: invalid ip address or prefix")
+	ErrAsnNotFound   = errors.New("whois: aut-num not found")
+)
+
+//
+// Find route objects covering an IP address or prefix, answering
+// "who announces this IP"
+//
+
+func RadbOriginByPrefix(ctx context.Context, opts *RadbOriginByPrefixOpts) ([]*RouteObject, error) {
+	prefix, err := parsePrefixOrAddr(opts.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Hostname == "" {
+		opts.Hostname = RadbHostname
+	}
+
+	resp, err := QueryCtx(ctx, &QueryOpts{
+		Hostname:   opts.Hostname,
+		Port:       opts.Port,
+		Query:      fmt.Sprintf("-T route,route6 %s", prefix),
+		Timeout:    opts.Timeout,
+		Dialer:     opts.Dialer,
+		Client:     opts.Client,
+		Retry:      opts.Retry,
+		Transcript: opts.Transcript,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore covering objects that do not actually contain the query
+	routes, _ := parseRouteObjects(resp)
+	result := routes[:0]
+
+	for _, route := range routes {
+		if route.Prefix.Bits() <= prefix.Bits() && route.Prefix.Contains(prefix.Addr()) {
+			result = append(result, route)
+		}
+	}
+
+	return result, nil
+}
+
+//
+// Unique origin ASNs of route objects, in order of appearance
+//
+
+func OriginAsns(routes []*RouteObject) []string {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, route := range routes {
+		if route.Origin != "" && !seen[route.Origin] {
+			seen[route.Origin] = true
+			result = append(result, route.Origin)
+		}
+	}
+
+	return result
+}
+
+//
+// Fetch aut-num objects for ASN, one per IRR source holding it
+//
+
+func AsnInfo(ctx context.Context, opts *AsnInfoOpts) ([]*AutNum, error) {
+	asn := strings.ToUpper(opts.Asn)
+	if !strings.HasPrefix(asn, "AS") {
+		asn = "AS" + asn
+	}
+
+	if opts.Hostname == "" {
+		opts.Hostname = RadbHostname
+	}
+
+	resp, err := QueryCtx(ctx, &QueryOpts{
+		Hostname:   opts.Hostname,
+		Port:       opts.Port,
+		Query:      fmt.Sprintf("-T aut-num %s", asn),
+		Timeout:    opts.Timeout,
+		Dialer:     opts.Dialer,
+		Client:     opts.Client,
+		Retry:      opts.Retry,
+		Transcript: opts.Transcript,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*AutNum
+	for _, attrs := range parseRpslObjects(resp) {
+		if rpslClass(attrs) != "aut-num" {
+			continue
+		}
+
+		result = append(result, autNumFromRpsl(attrs))
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAsnNotFound, asn)
+	}
+
+	return result, nil
+}
+
+func autNumFromRpsl(attrs []rpslAttr) *AutNum {
+	autNum := &AutNum{
+		Asn: strings.ToUpper(attrs[0].value),
+	}
+
+	for _, attr := range attrs[1:] {
+		switch attr.key {
+		case "as-name":
+			autNum.AsName = attr.value
+		case "descr":
+			autNum.Descr = append(autNum.Descr, attr.value)
+		case "mnt-by":
+			autNum.MntBy = append(autNum.MntBy, attr.value)
+		case "org":
+			autNum.Org = attr.value
+		case "source":
+			autNum.Source = strings.ToUpper(attr.value)
+		}
+	}
+
+	return autNum
+}
+
+//
+// Accept both plain addresses and prefixes
+//
+
+func parsePrefixOrAddr(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidPrefix, value)
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
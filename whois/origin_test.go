@@ -0,0 +1,95 @@
+package whois
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadbOriginByPrefix(t *testing.T) {
+	var received string
+	host, port := newTestServer(t, func(query string) string {
+		received = query
+		return `route:          192.0.2.0/24
+descr:          Example network
+origin:         AS64500
+mnt-by:         MAINT-EXAMPLE
+source:         RADB
+
+route:          192.0.2.0/24
+origin:         AS64501
+source:         ALTDB
+
+route:          198.51.100.0/24
+origin:         AS64502
+source:         RADB
+`
+	})
+
+	routes, err := RadbOriginByPrefix(context.Background(), &RadbOriginByPrefixOpts{
+		Prefix:   "192.0.2.10",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "-T route,route6 192.0.2.10/32", received)
+	assert.Len(t, routes, 2)
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), routes[0].Prefix)
+	assert.Equal(t, []string{"MAINT-EXAMPLE"}, routes[0].MntBy)
+	assert.Equal(t, []string{"AS64500", "AS64501"}, OriginAsns(routes))
+}
+
+func TestRadbOriginByPrefixInvalid(t *testing.T) {
+	routes, err := RadbOriginByPrefix(context.Background(), &RadbOriginByPrefixOpts{
+		Prefix: "not-an-ip",
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidPrefix)
+	assert.Empty(t, routes)
+}
+
+func TestAsnInfo(t *testing.T) {
+	var received string
+	host, port := newTestServer(t, func(query string) string {
+		received = query
+		if query != "-T aut-num AS64500" {
+			return "%  No entries found for the selected source(s).\n"
+		}
+
+		return `aut-num:        AS64500
+as-name:        EXAMPLE-NET
+descr:          Example Networks
+mnt-by:         MAINT-EXAMPLE
+org:            ORG-EX1-RIPE
+source:         ripe
+`
+	})
+
+	info, err := AsnInfo(context.Background(), &AsnInfoOpts{
+		Asn:      "64500",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "-T aut-num AS64500", received)
+	assert.Equal(t, []*AutNum{{
+		Asn:    "AS64500",
+		AsName: "EXAMPLE-NET",
+		Descr:  []string{"Example Networks"},
+		MntBy:  []string{"MAINT-EXAMPLE"},
+		Org:    "ORG-EX1-RIPE",
+		Source: "RIPE",
+	}}, info)
+
+	_, err = AsnInfo(context.Background(), &AsnInfoOpts{
+		Asn:      "AS64511",
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.ErrorIs(t, err, ErrAsnNotFound)
+}
@@ -0,0 +1,142 @@
+//
+// Stable JSON and text renderings of query results for command line tools
+//
+
+package whois
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type QueryOutput struct {
+	Hostname string `json:"hostname"`
+	Port     int    `json:"port"`
+	Query    string `json:"query"`
+	Response string `json:"response"`
+}
+
+type PrefixOutput struct {
+	Asn       string   `json:"asn"`
+	IPv4      []string `json:"ipv4"`
+	IPv6      []string `json:"ipv6"`
+	Malformed []string `json:"malformed"`
+}
+
+//
+// Convert results to output structures
+//
+
+func NewQueryOutput(opts *QueryOpts, resp []byte) *QueryOutput {
+	return &QueryOutput{
+		Hostname: opts.Hostname,
+		Port:     opts.Port,
+		Query:    opts.Query,
+		Response: string(resp),
+	}
+}
+
+func NewLookupOutput(chain []*LookupResponse) []*QueryOutput {
+	result := make([]*QueryOutput, 0, len(chain))
+	for _, resp := range chain {
+		result = append(result, &QueryOutput{
+			Hostname: resp.Hostname,
+			Port:     resp.Port,
+			Query:    resp.Query,
+			Response: string(resp.Response),
+		})
+	}
+
+	return result
+}
+
+func NewPrefixOutput(asn string, collection *RadbPrefixCollection) *PrefixOutput {
+	// Always emit arrays, never null
+	result := &PrefixOutput{
+		Asn:       strings.ToUpper(asn),
+		IPv4:      []string{},
+		IPv6:      []string{},
+		Malformed: []string{},
+	}
+
+	if collection == nil {
+		return result
+	}
+
+	for _, prefix := range collection.IPv4 {
+		result.IPv4 = append(result.IPv4, prefix.String())
+	}
+
+	for _, prefix := range collection.IPv6 {
+		result.IPv6 = append(result.IPv6, prefix.String())
+	}
+
+	result.Malformed = append(result.Malformed, collection.Malformed...)
+	return result
+}
+
+//
+// Write value as indented JSON with trailing newline
+//
+
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(v)
+}
+
+//
+// Write query responses as text, with a header line per server
+//
+
+func WriteQueryText(w io.Writer, outputs ...*QueryOutput) error {
+	for i, output := range outputs {
+		if i > 0 {
+			_, err := fmt.Fprintln(w)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "# %s:%d %s\n", output.Hostname, output.Port, output.Query)
+		if err != nil {
+			return err
+		}
+
+		response := strings.TrimRight(strings.ReplaceAll(output.Response, "\r\n", "\n"), "\n")
+		_, err = fmt.Fprintln(w, response)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Write prefixes as a table with one prefix per row
+//
+
+func WritePrefixText(w io.Writer, output *PrefixOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ASN\tFAMILY\tPREFIX")
+
+	for _, prefix := range output.IPv4 {
+		fmt.Fprintf(tw, "%s\tipv4\t%s\n", output.Asn, prefix)
+	}
+
+	for _, prefix := range output.IPv6 {
+		fmt.Fprintf(tw, "%s\tipv6\t%s\n", output.Asn, prefix)
+	}
+
+	for _, value := range output.Malformed {
+		fmt.Fprintf(tw, "%s\tmalformed\t%s\n", output.Asn, value)
+	}
+
+	return tw.Flush()
+}
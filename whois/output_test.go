@@ -0,0 +1,69 @@
+package whois
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPrefixOutput(t *testing.T) {
+	output := NewPrefixOutput("as64500", &RadbPrefixCollection{
+		IPv4: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		IPv6: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+	})
+
+	assert.Equal(t, &PrefixOutput{
+		Asn:       "AS64500",
+		IPv4:      []string{"192.0.2.0/24"},
+		IPv6:      []string{"2001:db8::/32"},
+		Malformed: []string{},
+	}, output)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteJSON(&buf, NewPrefixOutput("AS1", nil)))
+	assert.Equal(t, "{\n  \"asn\": \"AS1\",\n  \"ipv4\": [],\n  \"ipv6\": [],\n  \"malformed\": []\n}\n", buf.String())
+}
+
+func TestWritePrefixText(t *testing.T) {
+	var buf bytes.Buffer
+	err := WritePrefixText(&buf, &PrefixOutput{
+		Asn:       "AS64500",
+		IPv4:      []string{"192.0.2.0/24"},
+		IPv6:      []string{"2001:db8::/32"},
+		Malformed: []string{"bogus"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ""+
+		"ASN      FAMILY     PREFIX\n"+
+		"AS64500  ipv4       192.0.2.0/24\n"+
+		"AS64500  ipv6       2001:db8::/32\n"+
+		"AS64500  malformed  bogus\n", buf.String())
+}
+
+func TestQueryOutput(t *testing.T) {
+	output := NewQueryOutput(&QueryOpts{
+		Hostname: "whois.example.net",
+		Port:     43,
+		Query:    "example.com",
+	}, []byte("Domain Name: EXAMPLE.COM\r\n\r\n"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteJSON(&buf, output))
+	assert.Contains(t, buf.String(), `"hostname": "whois.example.net"`)
+
+	buf.Reset()
+	assert.NoError(t, WriteQueryText(&buf, NewLookupOutput([]*LookupResponse{
+		{Hostname: "whois.iana.org", Port: 43, Query: "example.com", Response: []byte("refer: whois.example.net\n")},
+		{Hostname: "whois.example.net", Port: 43, Query: "example.com", Response: []byte("Domain Name: EXAMPLE.COM\r\n")},
+	})...))
+
+	assert.Equal(t, ""+
+		"# whois.iana.org:43 example.com\n"+
+		"refer: whois.example.net\n"+
+		"\n"+
+		"# whois.example.net:43 example.com\n"+
+		"Domain Name: EXAMPLE.COM\n", buf.String())
+}
@@ -0,0 +1,256 @@
+//
+// Render prefix collections as router and firewall prefix lists, in the
+// style of bgpq4
+//
+
+package whois
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+type PrefixListFormat string
+
+const (
+	// JunOS prefix-list, or route-filter-list with max lengths
+	PrefixListJunos PrefixListFormat = "junos"
+
+	// IOS-XR prefix-set
+	PrefixListIosXr PrefixListFormat = "iosxr"
+
+	// nftables interval sets, one per address family
+	PrefixListNftables PrefixListFormat = "nftables"
+
+	// AWS managed prefix list, as create-managed-prefix-list input JSON
+	PrefixListAws PrefixListFormat = "aws"
+)
+
+type PrefixListOpts struct {
+	Name   string
+	Format PrefixListFormat
+
+	// Only render one address family, 4 or 6, zero renders both
+	Family int
+
+	// Also accept more specifics up to this length, zero matches prefixes
+	// exactly, ignored by nftables and AWS which match addresses
+	MaxLengthIPv4 int
+	MaxLengthIPv6 int
+
+	// AWS entry description, defaults to the name
+	Description string
+}
+
+var DefaultPrefixListOpts = &PrefixListOpts{
+	Name:   "NN",
+	Format: PrefixListJunos,
+}
+
+var (
+	ErrUnknownPrefixListFormat = errors.New("whois: unknown prefix list format")
+	ErrInvalidPrefixListName   = errors.New("whois: invalid prefix list name")
+	ErrMixedAddressFamilies    = errors.New("whois: prefix list needs a single address family")
+)
+
+var prefixListNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,62}$`)
+
+//
+// Write collection as prefix list
+//
+
+func WritePrefixList(w io.Writer, collection *RadbPrefixCollection, opts *PrefixListOpts) error {
+	if opts.Name == "" {
+		opts.Name = DefaultPrefixListOpts.Name
+	}
+
+	if opts.Format == "" {
+		opts.Format = DefaultPrefixListOpts.Format
+	}
+
+	if !prefixListNameRe.MatchString(opts.Name) {
+		return fmt.Errorf("%w: %q", ErrInvalidPrefixListName, opts.Name)
+	}
+
+	if collection == nil {
+		collection = &RadbPrefixCollection{}
+	}
+
+	var ipv4, ipv6 []netip.Prefix
+	if opts.Family != 6 {
+		ipv4 = collection.IPv4
+	}
+
+	if opts.Family != 4 {
+		ipv6 = collection.IPv6
+	}
+
+	var b strings.Builder
+
+	switch opts.Format {
+	case PrefixListJunos:
+		writeJunosPrefixList(&b, ipv4, ipv6, opts)
+	case PrefixListIosXr:
+		writeIosXrPrefixSet(&b, ipv4, ipv6, opts)
+	case PrefixListNftables:
+		writeNftablesSets(&b, ipv4, ipv6, opts)
+	case PrefixListAws:
+		if err := writeAwsPrefixList(&b, ipv4, ipv6, opts); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownPrefixListFormat, opts.Format)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeJunosPrefixList(b *strings.Builder, ipv4 []netip.Prefix, ipv6 []netip.Prefix, opts *PrefixListOpts) {
+	b.WriteString("policy-options {\nreplace:\n")
+
+	// Plain prefix lists only match exactly
+	if opts.MaxLengthIPv4 == 0 && opts.MaxLengthIPv6 == 0 {
+		fmt.Fprintf(b, "  prefix-list %s {\n", opts.Name)
+		for _, prefix := range joinPrefixes(ipv4, ipv6) {
+			fmt.Fprintf(b, "    %s;\n", prefix)
+		}
+	} else {
+		fmt.Fprintf(b, "  route-filter-list %s {\n", opts.Name)
+		for _, prefix := range joinPrefixes(ipv4, ipv6) {
+			if upto := prefixListMaxLength(prefix, opts); upto > prefix.Bits() {
+				fmt.Fprintf(b, "    %s upto /%d;\n", prefix, upto)
+			} else {
+				fmt.Fprintf(b, "    %s exact;\n", prefix)
+			}
+		}
+	}
+
+	b.WriteString("  }\n}\n")
+}
+
+func writeIosXrPrefixSet(b *strings.Builder, ipv4 []netip.Prefix, ipv6 []netip.Prefix, opts *PrefixListOpts) {
+	fmt.Fprintf(b, "no prefix-set %s\nprefix-set %s\n", opts.Name, opts.Name)
+
+	prefixes := joinPrefixes(ipv4, ipv6)
+	for i, prefix := range prefixes {
+		fmt.Fprintf(b, " %s", prefix)
+		if le := prefixListMaxLength(prefix, opts); le > prefix.Bits() {
+			fmt.Fprintf(b, " le %d", le)
+		}
+
+		// Entries are comma separated, the last one is not
+		if i < len(prefixes)-1 {
+			b.WriteString(",")
+		}
+
+		b.WriteString("\n")
+	}
+
+	b.WriteString("end-set\n")
+}
+
+func writeNftablesSets(b *strings.Builder, ipv4 []netip.Prefix, ipv6 []netip.Prefix, opts *PrefixListOpts) {
+	sets := []struct {
+		suffix   string
+		kind     string
+		prefixes []netip.Prefix
+	}{
+		{suffix: "_v4", kind: "ipv4_addr", prefixes: ipv4},
+		{suffix: "_v6", kind: "ipv6_addr", prefixes: ipv6},
+	}
+
+	first := true
+	for _, set := range sets {
+		// Empty element lists are a syntax error
+		if len(set.prefixes) == 0 {
+			continue
+		}
+
+		if !first {
+			b.WriteString("\n")
+		}
+
+		first = false
+		fmt.Fprintf(b, "set %s%s {\n\ttype %s\n\tflags interval\n\telements = {\n", opts.Name, set.suffix, set.kind)
+
+		for i, prefix := range set.prefixes {
+			fmt.Fprintf(b, "\t\t%s", prefix)
+			if i < len(set.prefixes)-1 {
+				b.WriteString(",")
+			}
+
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\t}\n}\n")
+	}
+}
+
+type awsPrefixList struct {
+	PrefixListName string           `json:"PrefixListName"`
+	AddressFamily  string           `json:"AddressFamily"`
+	MaxEntries     int              `json:"MaxEntries"`
+	Entries        []awsPrefixEntry `json:"Entries"`
+}
+
+type awsPrefixEntry struct {
+	Cidr        string `json:"Cidr"`
+	Description string `json:"Description,omitempty"`
+}
+
+func writeAwsPrefixList(b *strings.Builder, ipv4 []netip.Prefix, ipv6 []netip.Prefix, opts *PrefixListOpts) error {
+	// Managed prefix lists hold a single family
+	if len(ipv4) > 0 && len(ipv6) > 0 {
+		return ErrMixedAddressFamilies
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = opts.Name
+	}
+
+	list := &awsPrefixList{
+		PrefixListName: opts.Name,
+		AddressFamily:  "IPv4",
+		Entries:        []awsPrefixEntry{},
+	}
+
+	prefixes := ipv4
+	if len(ipv6) > 0 || opts.Family == 6 {
+		list.AddressFamily = "IPv6"
+		prefixes = ipv6
+	}
+
+	for _, prefix := range prefixes {
+		list.Entries = append(list.Entries, awsPrefixEntry{
+			Cidr:        prefix.String(),
+			Description: description,
+		})
+	}
+
+	// At least one, lists can't be created with zero capacity
+	list.MaxEntries = max(len(list.Entries), 1)
+
+	return WriteJSON(b, list)
+}
+
+func prefixListMaxLength(prefix netip.Prefix, opts *PrefixListOpts) int {
+	maxLength := opts.MaxLengthIPv4
+	if prefix.Addr().Is6() {
+		maxLength = opts.MaxLengthIPv6
+	}
+
+	return min(maxLength, prefix.Addr().BitLen())
+}
+
+// Without appending to a, which belongs to the collection
+func joinPrefixes(a []netip.Prefix, b []netip.Prefix) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
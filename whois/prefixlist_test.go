@@ -0,0 +1,148 @@
+package whois
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPrefixListCollection() *RadbPrefixCollection {
+	return &RadbPrefixCollection{
+		IPv4: []netip.Prefix{
+			netip.MustParsePrefix("192.0.2.0/24"),
+			netip.MustParsePrefix("198.51.100.0/22"),
+		},
+		IPv6: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+	}
+}
+
+func TestWritePrefixListJunos(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{Name: "AS64500"}))
+	assert.Equal(t, ""+
+		"policy-options {\n"+
+		"replace:\n"+
+		"  prefix-list AS64500 {\n"+
+		"    192.0.2.0/24;\n"+
+		"    198.51.100.0/22;\n"+
+		"    2001:db8::/32;\n"+
+		"  }\n"+
+		"}\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{
+		Name:          "AS64500",
+		MaxLengthIPv4: 24,
+		MaxLengthIPv6: 48,
+	}))
+
+	assert.Equal(t, ""+
+		"policy-options {\n"+
+		"replace:\n"+
+		"  route-filter-list AS64500 {\n"+
+		"    192.0.2.0/24 exact;\n"+
+		"    198.51.100.0/22 upto /24;\n"+
+		"    2001:db8::/32 upto /48;\n"+
+		"  }\n"+
+		"}\n", buf.String())
+}
+
+func TestWritePrefixListIosXr(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{
+		Name:          "AS64500",
+		Format:        PrefixListIosXr,
+		MaxLengthIPv4: 24,
+	}))
+
+	assert.Equal(t, ""+
+		"no prefix-set AS64500\n"+
+		"prefix-set AS64500\n"+
+		" 192.0.2.0/24,\n"+
+		" 198.51.100.0/22 le 24,\n"+
+		" 2001:db8::/32\n"+
+		"end-set\n", buf.String())
+}
+
+func TestWritePrefixListNftables(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{
+		Name:   "customers",
+		Format: PrefixListNftables,
+	}))
+
+	assert.Equal(t, ""+
+		"set customers_v4 {\n"+
+		"\ttype ipv4_addr\n"+
+		"\tflags interval\n"+
+		"\telements = {\n"+
+		"\t\t192.0.2.0/24,\n"+
+		"\t\t198.51.100.0/22\n"+
+		"\t}\n"+
+		"}\n"+
+		"\n"+
+		"set customers_v6 {\n"+
+		"\ttype ipv6_addr\n"+
+		"\tflags interval\n"+
+		"\telements = {\n"+
+		"\t\t2001:db8::/32\n"+
+		"\t}\n"+
+		"}\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{
+		Name:   "customers",
+		Format: PrefixListNftables,
+		Family: 6,
+	}))
+
+	assert.NotContains(t, buf.String(), "customers_v4")
+}
+
+func TestWritePrefixListAws(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{
+		Name:   "customers-v6",
+		Format: PrefixListAws,
+		Family: 6,
+	}))
+
+	assert.Equal(t, `{
+  "PrefixListName": "customers-v6",
+  "AddressFamily": "IPv6",
+  "MaxEntries": 1,
+  "Entries": [
+    {
+      "Cidr": "2001:db8::/32",
+      "Description": "customers-v6"
+    }
+  ]
+}
+`, buf.String())
+
+	err := WritePrefixList(&buf, testPrefixListCollection(), &PrefixListOpts{Format: PrefixListAws})
+	assert.ErrorIs(t, err, ErrMixedAddressFamilies)
+}
+
+func TestWritePrefixListErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WritePrefixList(&buf, nil, &PrefixListOpts{Format: "cisco-ios"})
+	assert.ErrorIs(t, err, ErrUnknownPrefixListFormat)
+
+	err = WritePrefixList(&buf, nil, &PrefixListOpts{Name: "bad name;"})
+	assert.ErrorIs(t, err, ErrInvalidPrefixListName)
+	assert.Empty(t, buf.String())
+}
+
+func TestWritePrefixListKeepsCollection(t *testing.T) {
+	collection := testPrefixListCollection()
+	collection.IPv4 = append(make([]netip.Prefix, 0, 8), collection.IPv4...)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrefixList(&buf, collection, &PrefixListOpts{}))
+	assert.Len(t, collection.IPv4, 2)
+	assert.Equal(t, netip.Prefix{}, collection.IPv4[:3][2])
+}
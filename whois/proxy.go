@@ -0,0 +1,305 @@
+//
+// Pluggable dialers for routing queries through proxies
+//
+
+package whois
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Compatible with net.Dialer and golang.org/x/net/proxy.ContextDialer
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+type proxyDialer struct {
+	url     *url.URL
+	forward Dialer
+}
+
+type roundRobinDialer struct {
+	dialers []Dialer
+	next    atomic.Uint64
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+var ErrProxyUnsupported = errors.New("whois: unsupported proxy scheme")
+
+//
+// Dialer tunneling connections through a socks5:// or http:// proxy,
+// socks5 resolves hostnames locally and socks5h leaves it to the proxy
+//
+
+func NewProxyDialer(proxyURL string, forward Dialer) (Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrProxyUnsupported, u.Scheme)
+	}
+
+	if u.Port() == "" {
+		return nil, fmt.Errorf("whois: proxy url %q is missing port", proxyURL)
+	}
+
+	// RFC 1929 length fields are a single byte
+	if u.User != nil && u.Scheme != "http" {
+		password, _ := u.User.Password()
+		if len(u.User.Username()) > 255 || len(password) > 255 {
+			return nil, errors.New("whois: socks5 username and password must be at most 255 bytes")
+		}
+	}
+
+	if forward == nil {
+		forward = &net.Dialer{}
+	}
+
+	return &proxyDialer{url: u, forward: forward}, nil
+}
+
+func (d *proxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	con, err := d.forward.DialContext(ctx, "tcp", d.url.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bound the handshake by the context deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		con.SetDeadline(deadline)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		con.SetDeadline(time.Now())
+	})
+
+	defer stop()
+
+	if d.url.Scheme == "http" {
+		con, err = d.connectHttp(con, address)
+	} else {
+		err = d.connectSocks5(ctx, con, address)
+	}
+
+	if err != nil {
+		con.Close()
+		return nil, contextErr(ctx, err)
+	}
+
+	con.SetDeadline(time.Time{})
+	return con, nil
+}
+
+//
+// HTTP CONNECT tunnel
+//
+
+func (d *proxyDialer) connectHttp(con net.Conn, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if d.url.User != nil {
+		password, _ := d.url.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(d.url.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+
+	err := req.Write(con)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(con)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("whois: proxy connect failed: %s", resp.Status)
+	}
+
+	// Keep anything the proxy sent past the response headers
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: con, reader: reader}, nil
+	}
+
+	return con, nil
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+//
+// SOCKS5 tunnel (RFC 1928), with optional username/password auth (RFC 1929)
+//
+
+func (d *proxyDialer) connectSocks5(ctx context.Context, con net.Conn, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if d.url.Scheme == "socks5" && net.ParseIP(host) == nil {
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return err
+		}
+
+		if len(addrs) == 0 {
+			return fmt.Errorf("whois: no addresses for %s", host)
+		}
+
+		host = addrs[0].Unmap().String()
+	}
+
+	if len(host) > 255 {
+		return fmt.Errorf("whois: socks5 hostname %q is longer than 255 bytes", host)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+
+	// Method negotiation
+	method := byte(0x00)
+	if d.url.User != nil {
+		method = 0x02
+	}
+
+	_, err = con.Write([]byte{0x05, 0x01, method})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(con, buf)
+	if err != nil {
+		return err
+	}
+
+	if buf[0] != 0x05 || buf[1] != method {
+		return errors.New("whois: socks5 proxy rejected auth method")
+	}
+
+	// Username and password auth
+	if method == 0x02 {
+		username := d.url.User.Username()
+		password, _ := d.url.User.Password()
+
+		msg := []byte{0x01, byte(len(username))}
+		msg = append(msg, username...)
+		msg = append(msg, byte(len(password)))
+		msg = append(msg, password...)
+
+		_, err = con.Write(msg)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.ReadFull(con, buf)
+		if err != nil {
+			return err
+		}
+
+		if buf[1] != 0x00 {
+			return errors.New("whois: socks5 proxy authentication failed")
+		}
+	}
+
+	// Connect request, hostnames left are resolved by the proxy
+	msg := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+		msg = append(msg, 0x01)
+		msg = append(msg, ip.To4()...)
+	} else if ip != nil {
+		msg = append(msg, 0x04)
+		msg = append(msg, ip.To16()...)
+	} else {
+		msg = append(msg, 0x03, byte(len(host)))
+		msg = append(msg, host...)
+	}
+
+	msg = binary.BigEndian.AppendUint16(msg, uint16(port))
+
+	_, err = con.Write(msg)
+	if err != nil {
+		return err
+	}
+
+	// Reply header, then skip bound address
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(con, reply)
+	if err != nil {
+		return err
+	}
+
+	if reply[1] != 0x00 {
+		return fmt.Errorf("whois: socks5 proxy connect failed with code %d", reply[1])
+	}
+
+	var skip int
+	switch reply[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		_, err = io.ReadFull(con, buf[:1])
+		if err != nil {
+			return err
+		}
+
+		skip = int(buf[0])
+	default:
+		return errors.New("whois: socks5 proxy sent invalid address type")
+	}
+
+	_, err = io.CopyN(io.Discard, con, int64(skip+2))
+	return err
+}
+
+//
+// Dialer rotating between several dialers, e.g. bound to different egress IPs
+//
+
+func NewRoundRobinDialer(dialers ...Dialer) Dialer {
+	return &roundRobinDialer{dialers: dialers}
+}
+
+func (d *roundRobinDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if len(d.dialers) == 0 {
+		return nil, errors.New("whois: round robin dialer has no dialers")
+	}
+
+	i := d.next.Add(1) - 1
+	return d.dialers[i%uint64(len(d.dialers))].DialContext(ctx, network, address)
+}
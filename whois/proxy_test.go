@@ -0,0 +1,300 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Minimal SOCKS5 proxy supporting no-auth and username/password auth,
+// address types of connect requests are sent to atyps when not nil
+func newTestSocks5Proxy(t *testing.T, username, password string, atyps chan byte) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			con, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer con.Close()
+
+				header := make([]byte, 2)
+				io.ReadFull(con, header)
+				methods := make([]byte, header[1])
+				io.ReadFull(con, methods)
+
+				if username == "" {
+					con.Write([]byte{0x05, 0x00})
+				} else {
+					con.Write([]byte{0x05, 0x02})
+
+					io.ReadFull(con, header)
+					user := make([]byte, header[1])
+					io.ReadFull(con, user)
+					io.ReadFull(con, header[:1])
+					pass := make([]byte, header[0])
+					io.ReadFull(con, pass)
+
+					if string(user) != username || string(pass) != password {
+						con.Write([]byte{0x01, 0x01})
+						return
+					}
+
+					con.Write([]byte{0x01, 0x00})
+				}
+
+				req := make([]byte, 4)
+				io.ReadFull(con, req)
+
+				if atyps != nil {
+					atyps <- req[3]
+				}
+
+				var host string
+				switch req[3] {
+				case 0x01, 0x04:
+					ip := make([]byte, net.IPv4len)
+					if req[3] == 0x04 {
+						ip = make([]byte, net.IPv6len)
+					}
+
+					io.ReadFull(con, ip)
+					host = net.IP(ip).String()
+				case 0x03:
+					io.ReadFull(con, header[:1])
+					name := make([]byte, header[0])
+					io.ReadFull(con, name)
+					host = string(name)
+				}
+
+				port := make([]byte, 2)
+				io.ReadFull(con, port)
+
+				target, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(binary.BigEndian.Uint16(port))))
+				if err != nil {
+					con.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+					return
+				}
+
+				defer target.Close()
+				con.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+
+				go io.Copy(target, con)
+				io.Copy(con, target)
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// Minimal HTTP CONNECT proxy
+func newTestHttpProxy(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			con, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer con.Close()
+
+				reader := bufio.NewReader(con)
+				req, err := http.ReadRequest(reader)
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					con.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+					return
+				}
+
+				defer target.Close()
+				con.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+				go io.Copy(target, reader)
+				io.Copy(con, target)
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestProxyDialerSocks5(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "via proxy: " + query + "\n"
+	})
+
+	proxyAddr := newTestSocks5Proxy(t, "", "", nil)
+	dialer, err := NewProxyDialer("socks5://"+proxyAddr, nil)
+	assert.NoError(t, err)
+
+	data, err := Query(&QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example.com",
+		Dialer:   dialer,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "via proxy: example.com\n", string(data))
+}
+
+func TestProxyDialerSocks5Auth(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "ok\n"
+	})
+
+	proxyAddr := newTestSocks5Proxy(t, "user", "secret", nil)
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	dialer, err := NewProxyDialer("socks5://user:secret@"+proxyAddr, nil)
+	assert.NoError(t, err)
+
+	con, err := dialer.DialContext(context.Background(), "tcp", address)
+	assert.NoError(t, err)
+	con.Close()
+
+	dialer, err = NewProxyDialer("socks5://user:wrong@"+proxyAddr, nil)
+	assert.NoError(t, err)
+
+	_, err = dialer.DialContext(context.Background(), "tcp", address)
+	assert.Error(t, err)
+}
+
+func TestProxyDialerSocks5Resolve(t *testing.T) {
+	_, port := newTestServer(t, func(query string) string {
+		return "ok\n"
+	})
+
+	atyps := make(chan byte, 1)
+	proxyAddr := newTestSocks5Proxy(t, "", "", atyps)
+	address := net.JoinHostPort("localhost", strconv.Itoa(port))
+
+	// Resolved locally, the proxy gets an address
+	dialer, err := NewProxyDialer("socks5://"+proxyAddr, nil)
+	assert.NoError(t, err)
+
+	con, err := dialer.DialContext(context.Background(), "tcp", address)
+	if err == nil {
+		con.Close()
+	}
+
+	assert.Contains(t, []byte{0x01, 0x04}, <-atyps)
+
+	// Hostname is passed on
+	dialer, err = NewProxyDialer("socks5h://"+proxyAddr, nil)
+	assert.NoError(t, err)
+
+	con, err = dialer.DialContext(context.Background(), "tcp", address)
+	if err == nil {
+		con.Close()
+	}
+
+	assert.Equal(t, byte(0x03), <-atyps)
+}
+
+func TestProxyDialerHttp(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "via connect: " + query + "\n"
+	})
+
+	dialer, err := NewProxyDialer("http://"+newTestHttpProxy(t), nil)
+	assert.NoError(t, err)
+
+	data, err := Query(&QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example.com",
+		Dialer:   dialer,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "via connect: example.com\n", string(data))
+}
+
+func TestProxyDialerInvalid(t *testing.T) {
+	_, err := NewProxyDialer("ftp://127.0.0.1:21", nil)
+	assert.ErrorIs(t, err, ErrProxyUnsupported)
+
+	_, err = NewProxyDialer("socks5://127.0.0.1", nil)
+	assert.Error(t, err)
+
+	// Longer than the one byte length field
+	_, err = NewProxyDialer("socks5://"+strings.Repeat("u", 256)+":secret@127.0.0.1:1080", nil)
+	assert.ErrorContains(t, err, "255 bytes")
+
+	_, err = NewProxyDialer("socks5h://user:"+strings.Repeat("p", 256)+"@127.0.0.1:1080", nil)
+	assert.ErrorContains(t, err, "255 bytes")
+
+	_, err = NewProxyDialer("socks5://"+strings.Repeat("u", 255)+":secret@127.0.0.1:1080", nil)
+	assert.NoError(t, err)
+}
+
+func TestRoundRobinDialer(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "ok\n"
+	})
+
+	var calls []int
+	dialers := make([]Dialer, 3)
+	for i := range dialers {
+		n := i
+		dialers[i] = dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+			calls = append(calls, n)
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		})
+	}
+
+	dialer := NewRoundRobinDialer(dialers...)
+	for i := 0; i < 4; i++ {
+		_, err := Query(&QueryOpts{
+			Hostname: host,
+			Port:     port,
+			Query:    "example.com",
+			Dialer:   dialer,
+		})
+
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 0}, calls)
+}
+
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
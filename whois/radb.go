@@ -5,49 +5,284 @@
 package whois
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
-var (
-	radbRoute4Re = regexp.MustCompile(`(?mi)^route:\s+([0-9.\/]+)$`)
-	radbRoute6Re = regexp.MustCompile(`(?mi)^route6:\s+([0-9a-fA-F:\/]+)$`)
-)
-
 type RadbPrefixCollection struct {
-	IPv4 [][]byte
-	IPv6 [][]byte
+	IPv4      []netip.Prefix
+	IPv6      []netip.Prefix
+	Malformed []string
+
+	// Set for single ASN queries, nil for merged collections
+	Source *Source
 }
 
 type RadbPrefixesByAsnOpts struct {
-	Asn     string
-	Timeout time.Duration
+	Asn string
+
+	// Drop more-specifics covered by a less-specific route of the ASN, for
+	// filters matching with max lengths, exact match lists need them all
+	CollapseCovered bool
+
+	Hostname        string
+	Port            int
+	Timeout         time.Duration
+	MaxResponseSize int64
+	Dialer          Dialer
+	Client          *Client
+	Retry           *RetryPolicy
+	Transcript      *Transcript
 }
 
+type RadbPrefixesByAsnsOpts struct {
+	Asns            []string
+	CollapseCovered bool
+	Hostname        string
+	Port            int
+	Timeout         time.Duration
+	MaxResponseSize int64
+	Dialer          Dialer
+	Client          *Client
+	Retry           *RetryPolicy
+	Transcript      *Transcript
+	Concurrency     int
+}
+
+const RadbHostname = "whois.radb.net"
+
+//
+// Fetch prefixes with background context
+//
+
 func RadbPrefixesByAsn(opts *RadbPrefixesByAsnOpts) (*RadbPrefixCollection, error) {
+	return RadbPrefixesByAsnCtx(context.Background(), opts)
+}
+
+//
+// Fetch all route and route6 prefixes originated by ASN
+//
+
+func RadbPrefixesByAsnCtx(ctx context.Context, opts *RadbPrefixesByAsnOpts) (*RadbPrefixCollection, error) {
+	fetchedAt := time.Now().UTC()
+
+	result, err := IrrPrefixesByAsn(ctx, &IrrPrefixesByAsnOpts{
+		Asn:             opts.Asn,
+		Hostname:        opts.Hostname,
+		Port:            opts.Port,
+		Timeout:         opts.Timeout,
+		MaxResponseSize: opts.MaxResponseSize,
+		Dialer:          opts.Dialer,
+		Client:          opts.Client,
+		Retry:           opts.Retry,
+		Transcript:      opts.Transcript,
+	})
+
+	if (err == nil) && opts.CollapseCovered {
+		result.Merged.IPv4 = collapseCoveredPrefixes(result.Merged.IPv4)
+		result.Merged.IPv6 = collapseCoveredPrefixes(result.Merged.IPv6)
+	}
+
+	if err == nil {
+		result.Merged.Source = &Source{
+			Hostname:  opts.Hostname,
+			Port:      opts.Port,
+			Query:     fmt.Sprintf("-i origin %s", opts.Asn),
+			FetchedAt: fetchedAt,
+		}
+
+		if result.Merged.Source.Hostname == "" {
+			result.Merged.Source.Hostname = RadbHostname
+		}
+
+		if result.Merged.Source.Port == 0 {
+			result.Merged.Source.Port = 43
+		}
+	}
+
+	return result.Merged, err
+}
+
+//
+// Fetch prefixes for many ASNs in parallel with background context
+//
+
+func RadbPrefixesByAsns(asns []string, concurrency int) (map[string]*RadbPrefixCollection, error) {
+	return RadbPrefixesByAsnsCtx(context.Background(), &RadbPrefixesByAsnsOpts{
+		Asns:        asns,
+		Concurrency: concurrency,
+	})
+}
+
+//
+// Fetch prefixes per ASN with bounded concurrency, rate limited per server
+// when a client is given, failed ASNs are left out and their errors joined
+//
+
+func RadbPrefixesByAsnsCtx(ctx context.Context, opts *RadbPrefixesByAsnsOpts) (map[string]*RadbPrefixCollection, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultAsSetConcurrency
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	result := make(map[string]*RadbPrefixCollection)
+	slots := make(chan bool, opts.Concurrency)
+
+	for _, asn := range opts.Asns {
+		asn = strings.ToUpper(strings.TrimSpace(asn))
+		if asn == "" {
+			continue
+		}
+
+		// Reserve slot in result, duplicates are fetched once
+		mu.Lock()
+		_, seen := result[asn]
+		if !seen {
+			result[asn] = nil
+		}
+
+		mu.Unlock()
+
+		if seen {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- true
+
+		go func(asn string) {
+			defer wg.Done()
+			defer func() {
+				<-slots
+			}()
+
+			prefixes, err := RadbPrefixesByAsnCtx(ctx, &RadbPrefixesByAsnOpts{
+				Asn:             asn,
+				CollapseCovered: opts.CollapseCovered,
+				Hostname:        opts.Hostname,
+				Port:            opts.Port,
+				Timeout:         opts.Timeout,
+				MaxResponseSize: opts.MaxResponseSize,
+				Dialer:          opts.Dialer,
+				Client:          opts.Client,
+				Retry:           opts.Retry,
+				Transcript:      opts.Transcript,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				delete(result, asn)
+				errs = append(errs, fmt.Errorf("%s: %w", asn, err))
+				return
+			}
+
+			result[asn] = prefixes
+		}(asn)
+	}
+
+	wg.Wait()
+	return result, errors.Join(errs...)
+}
+
+//
+// Collect validated prefixes from response
+//
+
+func parseRadbPrefixes(data []byte) *RadbPrefixCollection {
+	routes, malformed := parseRouteObjects(data)
+	result := newIrrPrefixResult(routes).Merged
+	result.Malformed = malformed
+
+	return result
+}
+
+//
+// Merge collections into a new, deduplicated collection
+//
+
+func MergePrefixCollections(collections ...*RadbPrefixCollection) *RadbPrefixCollection {
 	result := &RadbPrefixCollection{}
-	whois, err := Query(&QueryOpts{
-		Hostname: "whois.radb.net",
-		Query:    fmt.Sprintf("-i origin %s", opts.Asn),
-		Timeout:  opts.Timeout,
+	for _, collection := range collections {
+		result.merge(collection)
+	}
+
+	return result
+}
+
+//
+// Add route object prefix, caller sorts afterwards
+//
+
+func (c *RadbPrefixCollection) add(route *RouteObject) {
+	if route.Prefix.Addr().Is4() {
+		c.IPv4 = append(c.IPv4, route.Prefix)
+	} else {
+		c.IPv6 = append(c.IPv6, route.Prefix)
+	}
+}
+
+//
+// Merge other collection into this one
+//
+
+func (c *RadbPrefixCollection) merge(other *RadbPrefixCollection) {
+	c.IPv4 = sortPrefixes(append(c.IPv4, other.IPv4...))
+	c.IPv6 = sortPrefixes(append(c.IPv6, other.IPv6...))
+	c.Malformed = append(c.Malformed, other.Malformed...)
+}
+
+//
+// Sort prefixes by address and length, removing duplicates
+//
+
+func sortPrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sort.Slice(prefixes, func(i, j int) bool {
+		return comparePrefixes(prefixes[i], prefixes[j]) < 0
 	})
 
-	if err != nil {
-		return result, err
+	result := prefixes[:0]
+	for i, prefix := range prefixes {
+		if i == 0 || prefix != prefixes[i-1] {
+			result = append(result, prefix)
+		}
 	}
 
-	// Grep through response for ipv4 and ipv6 prefixes
-	matches4 := radbRoute4Re.FindAllSubmatch(whois, -1)
-	matches6 := radbRoute6Re.FindAllSubmatch(whois, -1)
+	return result
+}
+
+//
+// Drop prefixes covered by a less-specific one, input must be sorted
+//
+
+func collapseCoveredPrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	result := prefixes[:0]
+	for _, prefix := range prefixes {
+		// Sorted input puts a covering prefix first, and kept prefixes don't
+		// overlap, so only the last kept one can cover the next
+		if n := len(result); n > 0 && result[n-1].Bits() <= prefix.Bits() && result[n-1].Contains(prefix.Addr()) {
+			continue
+		}
 
-	for _, match := range matches4 {
-		result.IPv4 = append(result.IPv4, match[1])
+		result = append(result, prefix)
 	}
 
-	for _, match := range matches6 {
-		result.IPv6 = append(result.IPv6, match[1])
+	return result
+}
+
+func comparePrefixes(a, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
 	}
 
-	return result, nil
+	return a.Bits() - b.Bits()
 }
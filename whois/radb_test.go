@@ -1,29 +1,201 @@
 package whois
 
 import (
+	"context"
+	"net/netip"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRadbPrefixesByAsn(t *testing.T) {
-	tests := []string{
-		"AS8075",  // Microsoft
-		"AS15169", // Google
-		"AS32934", // Facebook
-	}
+	host, port := newTestServer(t, func(query string) string {
+		switch query {
+		case "-i origin AS64500":
+			return "route:          192.0.2.0/24\norigin:         AS64500\n\nroute6:         2001:db8::/32\norigin:         AS64500\n"
+		case "-i origin AS64501":
+			return "route:          198.51.100.0/24\norigin:         AS64501\n\nroute6:         2001:db8:1::/48\norigin:         AS64501\n"
+		}
+
+		return "%  No entries found for the selected source(s).\n"
+	})
 
-	for _, asn := range tests {
-		resp, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: asn})
+	for _, asn := range []string{"AS64500", "AS64501"} {
+		resp, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: asn, Hostname: host, Port: port})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resp.IPv4)
 		assert.NotEmpty(t, resp.IPv6)
 	}
 }
 
+func TestRadbPrefixesByAsnCollapseCovered(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return `route: 10.0.0.0/8
+origin: AS64500
+
+route: 10.1.0.0/16
+origin: AS64500
+
+route: 10.1.2.0/24
+origin: AS64500
+
+route: 11.0.0.0/16
+origin: AS64500
+
+route: 11.0.0.0/24
+origin: AS64500
+
+route: 11.1.0.0/24
+origin: AS64500
+
+route6: 2001:db8::/32
+origin: AS64500
+
+route6: 2001:db8:1::/48
+origin: AS64500
+
+route6: 2001:db9::/48
+origin: AS64500
+`
+	})
+
+	resp, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: "AS64500", Hostname: host, Port: port})
+	assert.NoError(t, err)
+	assert.Len(t, resp.IPv4, 6)
+	assert.Len(t, resp.IPv6, 3)
+
+	resp, err = RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: "AS64500", Hostname: host, Port: port, CollapseCovered: true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("11.0.0.0/16"),
+		netip.MustParsePrefix("11.1.0.0/24"),
+	}, resp.IPv4)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("2001:db9::/48"),
+	}, resp.IPv6)
+}
+
 func TestRadbPrefixesByAsnError(t *testing.T) {
-	resp, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: "AS0"})
+	host, port := newTestServer(t, func(query string) string {
+		return "%  No entries found for the selected source(s).\n"
+	})
+
+	resp, err := RadbPrefixesByAsn(&RadbPrefixesByAsnOpts{Asn: "AS0", Hostname: host, Port: port})
 	assert.NoError(t, err)
 	assert.Empty(t, resp.IPv4)
 	assert.Empty(t, resp.IPv6)
 }
+
+func TestParseRadbPrefixes(t *testing.T) {
+	data := []byte(`route:          192.0.2.0/24
+origin:         AS64500
+source:         RADB
+
+route:          198.51.100.0/24
+origin:         AS64500
+source:         RADB
+
+route:          192.0.2.1/24
+origin:         AS64500
+source:         ALTDB
+
+route:          10.0.0.0/8
+origin:         AS64500
+source:         RADB
+
+route:          300.0.0.0/8
+origin:         AS64500
+source:         RADB
+
+route6:         2001:DB8::/32
+origin:         AS64500
+source:         RADB
+
+route6:         2001:db8::/32
+origin:         AS64500
+source:         RIPE
+`)
+
+	result := parseRadbPrefixes(data)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+	}, result.IPv4)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::/32"),
+	}, result.IPv6)
+
+	assert.Equal(t, []string{"300.0.0.0/8"}, result.Malformed)
+}
+
+func TestMergePrefixCollections(t *testing.T) {
+	a := &RadbPrefixCollection{
+		IPv4: []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+	}
+
+	b := &RadbPrefixCollection{
+		IPv4:      []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("198.51.100.0/24")},
+		IPv6:      []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+		Malformed: []string{"bogus"},
+	}
+
+	result := MergePrefixCollections(a, b)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+	}, result.IPv4)
+
+	assert.Equal(t, b.IPv6, result.IPv6)
+	assert.Equal(t, []string{"bogus"}, result.Malformed)
+	assert.Len(t, a.IPv4, 1)
+}
+
+func TestRadbPrefixesByAsns(t *testing.T) {
+	host, port := newTestIrrServer(t)
+
+	result, err := RadbPrefixesByAsnsCtx(context.Background(), &RadbPrefixesByAsnsOpts{
+		Asns:        []string{"AS64500", "as64501", "AS64502", "AS64500", ""},
+		Hostname:    host,
+		Port:        port,
+		Concurrency: 2,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, result["AS64500"].IPv4)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}, result["AS64500"].IPv6)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+	}, result["AS64501"].IPv4)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}, result["AS64502"].IPv4)
+}
+
+func TestRadbPrefixesByAsnsError(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		if query == "-i origin AS64599" {
+			return strings.Repeat("route: 192.0.2.0/24\norigin: AS64599\n\n", 100)
+		}
+
+		return "route: 198.51.100.0/24\norigin: AS64500\n"
+	})
+
+	result, err := RadbPrefixesByAsnsCtx(context.Background(), &RadbPrefixesByAsnsOpts{
+		Asns:            []string{"AS64500", "AS64599"},
+		Hostname:        host,
+		Port:            port,
+		MaxResponseSize: 1024,
+	})
+
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.ErrorContains(t, err, "AS64599")
+	assert.Len(t, result, 1)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}, result["AS64500"].IPv4)
+}
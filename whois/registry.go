@@ -0,0 +1,167 @@
+//
+// Known WHOIS servers per TLD, so lookups can skip the IANA round trip
+//
+
+package whois
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type TLDServer struct {
+	Hostname string
+	Port     int
+
+	// Query template with %s for the domain, empty sends the domain as is
+	Query string
+
+	// Response charset, empty leaves it to the caller
+	Charset string
+}
+
+// Built-in registry, overridden per TLD with SetTLDServer
+var knownTLDServers = map[string]TLDServer{
+	"ai":   {Hostname: "whois.nic.ai"},
+	"app":  {Hostname: "whois.nic.google"},
+	"at":   {Hostname: "whois.nic.at"},
+	"be":   {Hostname: "whois.dns.be"},
+	"br":   {Hostname: "whois.registro.br"},
+	"ca":   {Hostname: "whois.cira.ca"},
+	"ch":   {Hostname: "whois.nic.ch"},
+	"co":   {Hostname: "whois.nic.co"},
+	"com":  {Hostname: "whois.verisign-grs.com", Query: "domain %s"},
+	"de":   {Hostname: "whois.denic.de", Query: "-T dn,ace %s"},
+	"dev":  {Hostname: "whois.nic.google"},
+	"dk":   {Hostname: "whois.punktum.dk"},
+	"edu":  {Hostname: "whois.educause.edu"},
+	"ee":   {Hostname: "whois.tld.ee"},
+	"eu":   {Hostname: "whois.eu"},
+	"fi":   {Hostname: "whois.fi"},
+	"fr":   {Hostname: "whois.nic.fr"},
+	"info": {Hostname: "whois.nic.info"},
+	"io":   {Hostname: "whois.nic.io"},
+	"is":   {Hostname: "whois.isnic.is"},
+	"it":   {Hostname: "whois.nic.it"},
+	"jp":   {Hostname: "whois.jprs.jp", Query: "%s/e"},
+	"li":   {Hostname: "whois.nic.li"},
+	"me":   {Hostname: "whois.nic.me"},
+	"net":  {Hostname: "whois.verisign-grs.com", Query: "domain %s"},
+	"nl":   {Hostname: "whois.domain-registry.nl"},
+	"no":   {Hostname: "whois.norid.no"},
+	"nu":   {Hostname: "whois.iis.nu"},
+	"org":  {Hostname: "whois.pir.org"},
+	"pl":   {Hostname: "whois.dns.pl"},
+	"se":   {Hostname: "whois.iis.se"},
+	"tv":   {Hostname: "whois.nic.tv"},
+	"uk":   {Hostname: "whois.nic.uk"},
+	"us":   {Hostname: "whois.nic.us"},
+	"xyz":  {Hostname: "whois.nic.xyz"},
+}
+
+var (
+	tldOverridesMu sync.RWMutex
+	tldOverrides   = make(map[string]TLDServer)
+)
+
+//
+// Server for TLD, overrides first, ok is false when lookups should start at IANA
+//
+
+func ServerForTLD(tld string) (TLDServer, bool) {
+	tld = strings.ToLower(strings.Trim(tld, "."))
+
+	tldOverridesMu.RLock()
+	server, ok := tldOverrides[tld]
+	tldOverridesMu.RUnlock()
+
+	if !ok {
+		server, ok = knownTLDServers[tld]
+	}
+
+	if !ok || (server.Hostname == "") {
+		return TLDServer{}, false
+	}
+
+	if server.Port == 0 {
+		server.Port = DefaultLookupOpts.Port
+	}
+
+	return server, true
+}
+
+//
+// Server for the TLD of an ASCII domain
+//
+
+func ServerForDomain(domain string) (TLDServer, bool) {
+	domain = strings.TrimRight(domain, ".")
+	return ServerForTLD(domain[strings.LastIndex(domain, ".")+1:])
+}
+
+//
+// Override server for TLD, an empty hostname sends lookups to IANA instead
+//
+
+func SetTLDServer(tld string, server TLDServer) {
+	tldOverridesMu.Lock()
+	defer tldOverridesMu.Unlock()
+
+	tldOverrides[strings.ToLower(strings.Trim(tld, "."))] = server
+}
+
+//
+// Drop override for TLD, reverting to the built-in server if any
+//
+
+func DeleteTLDServer(tld string) {
+	tldOverridesMu.Lock()
+	defer tldOverridesMu.Unlock()
+
+	delete(tldOverrides, strings.ToLower(strings.Trim(tld, ".")))
+}
+
+//
+// Registry entry for a server reached through referrals, matched by address
+//
+
+func serverByAddress(hostname string, port int) (TLDServer, bool) {
+	match := func(server TLDServer) bool {
+		serverPort := server.Port
+		if serverPort == 0 {
+			serverPort = DefaultLookupOpts.Port
+		}
+
+		return strings.EqualFold(server.Hostname, hostname) && (serverPort == port)
+	}
+
+	tldOverridesMu.RLock()
+	defer tldOverridesMu.RUnlock()
+
+	for _, server := range tldOverrides {
+		if match(server) {
+			return server, true
+		}
+	}
+
+	for tld, server := range knownTLDServers {
+		if _, overridden := tldOverrides[tld]; !overridden && match(server) {
+			return server, true
+		}
+	}
+
+	return TLDServer{}, false
+}
+
+//
+// Query string for domain
+//
+
+func (s TLDServer) query(domain string) string {
+	if s.Query == "" {
+		return domain
+	}
+
+	return fmt.Sprintf(s.Query, domain)
+}
@@ -0,0 +1,70 @@
+package whois
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerForTLD(t *testing.T) {
+	server, ok := ServerForTLD("COM")
+	assert.True(t, ok)
+	assert.Equal(t, "whois.verisign-grs.com", server.Hostname)
+	assert.Equal(t, 43, server.Port)
+	assert.Equal(t, "domain example.com", server.query("example.com"))
+
+	server, ok = ServerForDomain("example.no.")
+	assert.True(t, ok)
+	assert.Equal(t, "whois.norid.no", server.Hostname)
+	assert.Equal(t, "example.no", server.query("example.no"))
+
+	_, ok = ServerForTLD("invalid")
+	assert.False(t, ok)
+}
+
+func TestSetTLDServer(t *testing.T) {
+	t.Cleanup(func() {
+		DeleteTLDServer("no")
+	})
+
+	SetTLDServer(".NO", TLDServer{Hostname: "whois.example.net", Port: 4343})
+	server, ok := ServerForTLD("no")
+	assert.True(t, ok)
+	assert.Equal(t, TLDServer{Hostname: "whois.example.net", Port: 4343}, server)
+
+	// Built-in server is no longer matched by address
+	_, ok = serverByAddress("whois.norid.no", 43)
+	assert.False(t, ok)
+
+	DeleteTLDServer("no")
+	server, _ = ServerForTLD("no")
+	assert.Equal(t, "whois.norid.no", server.Hostname)
+
+	// Empty hostname disables the built-in server
+	SetTLDServer("no", TLDServer{})
+	_, ok = ServerForTLD("no")
+	assert.False(t, ok)
+}
+
+func TestLookupRegistry(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "query: " + query + "\n"
+	})
+
+	t.Cleanup(func() {
+		DeleteTLDServer("example")
+	})
+
+	SetTLDServer("example", TLDServer{
+		Hostname: host,
+		Port:     port,
+		Query:    "-T dn %s",
+	})
+
+	chain, err := Lookup("test.example")
+	assert.NoError(t, err)
+	assert.Len(t, chain, 1)
+	assert.Equal(t, port, chain[0].Port)
+	assert.Equal(t, "-T dn test.example", chain[0].Query)
+	assert.Equal(t, "query: -T dn test.example\n", string(chain[0].Response))
+}
@@ -0,0 +1,138 @@
+//
+// Retry policy with exponential backoff and transient error classification
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	MaxElapsed     time.Duration
+	Retryable      func(err error) bool
+}
+
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	MaxElapsed:     time.Minute,
+	Retryable:      IsRetryable,
+}
+
+//
+// Run attempt until it succeeds, fails permanently or the policy gives up,
+// a nil policy makes a single attempt
+//
+
+func (p *RetryPolicy) do(ctx context.Context, attempt func() error) error {
+	fn := func(ctx context.Context) error {
+		return attempt()
+	}
+
+	if p == nil {
+		return retry.Do(ctx, fn, retry.WithMaxAttempts(1))
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	return retry.Do(ctx, fn, retry.WithPolicy(p.policy()), retry.WithRetryIf(retryable))
+}
+
+//
+// Delay before the next attempt, after n failed attempts
+//
+
+func (p *RetryPolicy) Backoff(n int) time.Duration {
+	policy := p.policy()
+	return policy.Backoff(n)
+}
+
+//
+// Zero fields come from DefaultRetryPolicy, so partial policies still stop
+// and back off, except jitter where zero means none
+//
+
+func (p *RetryPolicy) policy() retry.Policy {
+	policy := retry.Policy{
+		MaxAttempts:    p.MaxAttempts,
+		InitialBackoff: p.InitialBackoff,
+		MaxBackoff:     p.MaxBackoff,
+		Multiplier:     p.Multiplier,
+		Jitter:         p.Jitter,
+		MaxElapsed:     p.MaxElapsed,
+	}
+
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+
+	// Never below the initial backoff the caller asked for
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = max(DefaultRetryPolicy.MaxBackoff, policy.InitialBackoff)
+	}
+
+	if policy.Multiplier == 0 {
+		policy.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+
+	if policy.MaxElapsed == 0 {
+		policy.MaxElapsed = DefaultRetryPolicy.MaxElapsed
+	}
+
+	return policy
+}
+
+//
+// Transient network failures are worth retrying, bad hostnames and refused
+// connections are not
+//
+
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound && (dnsErr.IsTimeout || dnsErr.IsTemporary)
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
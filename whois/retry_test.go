@@ -0,0 +1,163 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/whois/whoistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}
+
+	transient := &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	var calls int
+	err := policy.do(context.Background(), func() error {
+		calls++
+		return transient
+	})
+
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Equal(t, 3, calls)
+
+	// Permanent errors fail on the first attempt
+	calls = 0
+	err = policy.do(context.Background(), func() error {
+		calls++
+		return errors.New("permanent")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// Nil policy makes a single attempt
+	calls = 0
+	err = (*RetryPolicy)(nil).do(context.Background(), func() error {
+		calls++
+		return transient
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyMaxElapsed(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: time.Hour,
+		MaxElapsed:     time.Minute,
+		Retryable: func(err error) bool {
+			return true
+		},
+	}
+
+	var calls int
+	err := policy.do(context.Background(), func() error {
+		calls++
+		return errors.New("oops")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyPartial(t *testing.T) {
+	policy := &RetryPolicy{
+		Retryable: func(err error) bool {
+			return true
+		},
+	}
+
+	// Defaults fill in, instead of no delay and no limit
+	filled := policy.policy()
+	assert.Equal(t, DefaultRetryPolicy.MaxAttempts, filled.MaxAttempts)
+	assert.Equal(t, DefaultRetryPolicy.InitialBackoff, filled.InitialBackoff)
+	assert.Equal(t, DefaultRetryPolicy.MaxElapsed, filled.MaxElapsed)
+	assert.Equal(t, DefaultRetryPolicy.InitialBackoff, policy.Backoff(1))
+
+	// Gives up after the default attempts
+	policy.InitialBackoff = time.Millisecond
+
+	var calls int
+	err := policy.do(context.Background(), func() error {
+		calls++
+		return errors.New("oops")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, DefaultRetryPolicy.MaxAttempts, calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err error
+		out bool
+	}{
+		{err: nil, out: false},
+		{err: errors.New("oops"), out: false},
+		{err: context.Canceled, out: false},
+		{err: &net.DNSError{Err: "no such host", IsNotFound: true}, out: false},
+		{err: &net.DNSError{Err: "timeout", IsTimeout: true}, out: true},
+		{err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, out: false},
+		{err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, out: true},
+		{err: os.ErrDeadlineExceeded, out: true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.out, IsRetryable(test.err), "%v", test.err)
+	}
+}
+
+func TestQueryRetry(t *testing.T) {
+	// Reset the first two connections, answer the third
+	srv := whoistest.NewServer(whoistest.Sequence(
+		whoistest.Response{Reset: true},
+		whoistest.Response{Reset: true},
+		whoistest.Response{Body: "ok\n"},
+	))
+
+	t.Cleanup(srv.Close)
+
+	data, err := Query(&QueryOpts{
+		Hostname: srv.Hostname,
+		Port:     srv.Port,
+		Query:    "example.com",
+		Retry: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", string(data))
+	assert.Len(t, srv.Queries(), 3)
+}
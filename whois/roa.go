@@ -0,0 +1,332 @@
+//
+// Load validated ROA payloads from RPKI validators and validate route
+// origins against them (RFC 6811)
+//
+
+package whois
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ROA struct {
+	Prefix      netip.Prefix
+	MaxLength   int
+	Asn         string
+	TrustAnchor string
+}
+
+// ROAs indexed by prefix for covering lookups
+type ROASet struct {
+	byPrefix map[netip.Prefix][]ROA
+	size     int
+}
+
+type ValidationState int
+
+const (
+	ValidationNotFound ValidationState = iota
+	ValidationValid
+	ValidationInvalid
+)
+
+type FetchROAsOpts struct {
+	URL             string
+	Timeout         time.Duration
+	MaxResponseSize int64
+	HTTPClient      *http.Client
+}
+
+var DefaultFetchROAsOpts = &FetchROAsOpts{
+	URL:             "https://console.rpki-client.org/vrps.json",
+	Timeout:         2 * time.Minute,
+	MaxResponseSize: 512 << 20,
+}
+
+var ErrInvalidROAData = errors.New("whois: invalid roa data")
+
+func (s ValidationState) String() string {
+	switch s {
+	case ValidationValid:
+		return "valid"
+	case ValidationInvalid:
+		return "invalid"
+	}
+
+	return "not-found"
+}
+
+//
+// Initialize ROA set, entries with invalid max length are dropped
+//
+
+func NewROASet(roas []ROA) *ROASet {
+	s := &ROASet{byPrefix: make(map[netip.Prefix][]ROA)}
+
+	for _, roa := range roas {
+		roa.Prefix = roa.Prefix.Masked()
+		if roa.MaxLength == 0 {
+			roa.MaxLength = roa.Prefix.Bits()
+		}
+
+		if !roa.Prefix.IsValid() || roa.MaxLength < roa.Prefix.Bits() || roa.MaxLength > roa.Prefix.Addr().BitLen() {
+			continue
+		}
+
+		s.byPrefix[roa.Prefix] = append(s.byPrefix[roa.Prefix], roa)
+		s.size++
+	}
+
+	return s
+}
+
+func (s *ROASet) Len() int {
+	return s.size
+}
+
+//
+// ROAs whose prefix covers prefix, most specific first
+//
+
+func (s *ROASet) Covering(prefix netip.Prefix) []ROA {
+	var result []ROA
+
+	for bits := prefix.Bits(); bits >= 0; bits-- {
+		parent, err := prefix.Addr().Prefix(bits)
+		if err != nil {
+			break
+		}
+
+		result = append(result, s.byPrefix[parent]...)
+	}
+
+	return result
+}
+
+//
+// Origin validation state of a route, AS0 ROAs never make a route valid
+//
+
+func (s *ROASet) Validate(prefix netip.Prefix, origin string) ValidationState {
+	covering := s.Covering(prefix)
+	if len(covering) == 0 {
+		return ValidationNotFound
+	}
+
+	origin = strings.ToUpper(origin)
+	for _, roa := range covering {
+		if roa.Asn == origin && roa.Asn != "AS0" && prefix.Bits() <= roa.MaxLength {
+			return ValidationValid
+		}
+	}
+
+	return ValidationInvalid
+}
+
+//
+// Fetch ROAs with default opts
+//
+
+func FetchROAs(ctx context.Context) (*ROASet, error) {
+	return FetchROAsWithOpts(ctx, &FetchROAsOpts{})
+}
+
+//
+// Fetch ROAs from a validator JSON or CSV export over HTTP
+//
+
+func FetchROAsWithOpts(ctx context.Context, opts *FetchROAsOpts) (*ROASet, error) {
+	if opts.URL == "" {
+		opts.URL = DefaultFetchROAsOpts.URL
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultFetchROAsOpts.Timeout
+	}
+
+	if opts.MaxResponseSize == 0 {
+		opts.MaxResponseSize = DefaultFetchROAsOpts.MaxResponseSize
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("whois: fetch roas: %s", resp.Status)
+	}
+
+	// Read one byte more than allowed to detect oversized exports
+	data, err := io.ReadAll(io.LimitReader(resp.Body, opts.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > opts.MaxResponseSize {
+		return nil, ErrResponseTooLarge
+	}
+
+	return ParseROAs(bytes.NewReader(data))
+}
+
+//
+// Parse ROAs from a validator export, either JSON with a "roas" array as
+// written by rpki-client and Routinator, or Routinator style CSV
+//
+
+func ParseROAs(r io.Reader) (*ROASet, error) {
+	reader := bufio.NewReader(r)
+
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return NewROASet(nil), nil
+			}
+
+			return nil, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			reader.ReadByte()
+			continue
+		case '{':
+			return parseROAsJSON(reader)
+		}
+
+		return parseROAsCSV(reader)
+	}
+}
+
+func parseROAsJSON(r io.Reader) (*ROASet, error) {
+	var export struct {
+		Roas []struct {
+			Asn       json.RawMessage `json:"asn"`
+			Prefix    string          `json:"prefix"`
+			MaxLength int             `json:"maxLength"`
+			Ta        string          `json:"ta"`
+		} `json:"roas"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidROAData, err)
+	}
+
+	roas := make([]ROA, 0, len(export.Roas))
+
+	for _, entry := range export.Roas {
+		// Validators disagree on whether the ASN is a number or "AS" string
+		asn, ok := parseRoaAsn(strings.Trim(string(entry.Asn), `"`))
+		if !ok {
+			return nil, fmt.Errorf("%w: asn %s", ErrInvalidROAData, entry.Asn)
+		}
+
+		prefix, err := netip.ParsePrefix(entry.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%w: prefix %q", ErrInvalidROAData, entry.Prefix)
+		}
+
+		roas = append(roas, ROA{
+			Prefix:      prefix,
+			MaxLength:   entry.MaxLength,
+			Asn:         asn,
+			TrustAnchor: entry.Ta,
+		})
+	}
+
+	return NewROASet(roas), nil
+}
+
+func parseROAsCSV(r io.Reader) (*ROASet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var roas []ROA
+	line := 0
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidROAData, err)
+		}
+
+		line++
+
+		asn, ok := parseRoaAsn(record[0])
+		if !ok && line == 1 {
+			// Header row
+			continue
+		}
+
+		if !ok || len(record) < 3 {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidROAData, line)
+		}
+
+		prefix, err := netip.ParsePrefix(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: prefix %q", ErrInvalidROAData, line, record[1])
+		}
+
+		maxLength, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: max length %q", ErrInvalidROAData, line, record[2])
+		}
+
+		roa := ROA{Prefix: prefix, MaxLength: maxLength, Asn: asn}
+		if len(record) > 3 {
+			roa.TrustAnchor = record[3]
+		}
+
+		roas = append(roas, roa)
+	}
+
+	return NewROASet(roas), nil
+}
+
+func parseRoaAsn(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = value[2:]
+	}
+
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return "", false
+	}
+
+	return fmt.Sprintf("AS%d", n), true
+}
@@ -0,0 +1,114 @@
+package whois
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRoasJSON = `{
+  "metadata": {"buildtime": "2026-10-01T00:00:00Z"},
+  "roas": [
+    {"asn": "AS64500", "prefix": "192.0.2.0/24", "maxLength": 24, "ta": "ripe"},
+    {"asn": 64501, "prefix": "198.51.100.0/22", "maxLength": 23, "ta": "arin"},
+    {"asn": "AS0", "prefix": "203.0.113.0/24", "maxLength": 24, "ta": "apnic"},
+    {"asn": "AS64500", "prefix": "2001:db8::/32", "maxLength": 48, "ta": "ripe"}
+  ]
+}`
+
+func TestParseROAsJSON(t *testing.T) {
+	roas, err := ParseROAs(strings.NewReader(testRoasJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, roas.Len())
+
+	assert.Equal(t, []ROA{{
+		Prefix:      netip.MustParsePrefix("198.51.100.0/22"),
+		MaxLength:   23,
+		Asn:         "AS64501",
+		TrustAnchor: "arin",
+	}}, roas.Covering(netip.MustParsePrefix("198.51.101.0/24")))
+
+	_, err = ParseROAs(strings.NewReader(`{"roas": [{"asn": "bogus", "prefix": "192.0.2.0/24"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidROAData)
+}
+
+func TestParseROAsCSV(t *testing.T) {
+	roas, err := ParseROAs(strings.NewReader("ASN,IP Prefix,Max Length,Trust Anchor\nAS64500,192.0.2.0/24,24,ripe\nAS64500,2001:db8::/32,48,ripe\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, roas.Len())
+
+	_, err = ParseROAs(strings.NewReader("ASN,IP Prefix,Max Length\nAS64500,192.0.2.0/24,wide\n"))
+	assert.ErrorIs(t, err, ErrInvalidROAData)
+	assert.ErrorContains(t, err, "line 2")
+
+	roas, err = ParseROAs(strings.NewReader("  "))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, roas.Len())
+}
+
+func TestNewROASetDropsInvalid(t *testing.T) {
+	roas := NewROASet([]ROA{
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), MaxLength: 16, Asn: "AS64500"},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), MaxLength: 33, Asn: "AS64500"},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), Asn: "AS64500"},
+	})
+
+	assert.Equal(t, 1, roas.Len())
+	assert.Equal(t, 24, roas.Covering(netip.MustParsePrefix("192.0.2.0/24"))[0].MaxLength)
+}
+
+func TestROASetValidate(t *testing.T) {
+	roas, err := ParseROAs(strings.NewReader(testRoasJSON))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		prefix string
+		origin string
+		state  ValidationState
+	}{
+		{"192.0.2.0/24", "AS64500", ValidationValid},
+		{"192.0.2.0/24", "as64500", ValidationValid},
+		{"192.0.2.0/25", "AS64500", ValidationInvalid},
+		{"192.0.2.0/24", "AS64501", ValidationInvalid},
+		{"198.51.100.0/23", "AS64501", ValidationValid},
+		{"198.51.100.0/24", "AS64501", ValidationInvalid},
+		{"203.0.113.0/24", "AS0", ValidationInvalid},
+		{"2001:db8:1::/48", "AS64500", ValidationValid},
+		{"10.0.0.0/8", "AS64500", ValidationNotFound},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.state, roas.Validate(netip.MustParsePrefix(test.prefix), test.origin), test.prefix+" "+test.origin)
+	}
+
+	assert.Equal(t, "not-found", ValidationNotFound.String())
+	assert.Equal(t, "invalid", ValidationInvalid.String())
+}
+
+func TestFetchROAs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vrps.json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(testRoasJSON))
+	}))
+
+	defer server.Close()
+
+	roas, err := FetchROAsWithOpts(context.Background(), &FetchROAsOpts{URL: server.URL + "/vrps.json"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, roas.Len())
+
+	_, err = FetchROAsWithOpts(context.Background(), &FetchROAsOpts{URL: server.URL + "/missing"})
+	assert.ErrorContains(t, err, "404")
+
+	_, err = FetchROAsWithOpts(context.Background(), &FetchROAsOpts{URL: server.URL + "/vrps.json", MaxResponseSize: 64})
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+}
@@ -0,0 +1,201 @@
+//
+// Parse RPSL objects (route, route6, aut-num, ...) from IRR responses
+//
+
+package whois
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+type RouteObject struct {
+	Prefix netip.Prefix
+	Origin string
+	Descr  []string
+	MntBy  []string
+	Source string
+}
+
+type rpslAttr struct {
+	key   string
+	value string
+}
+
+//
+// Split response into objects, separated by blank lines
+//
+
+func parseRpslObjects(data []byte) [][]rpslAttr {
+	var objects [][]rpslAttr
+	var current []rpslAttr
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		var done bool
+		current, done = appendRpslLine(current, scanner.Text())
+
+		if done && len(current) > 0 {
+			objects = append(objects, current)
+			current = nil
+		}
+	}
+
+	if len(current) > 0 {
+		objects = append(objects, current)
+	}
+
+	return objects
+}
+
+//
+// Add line to object, returns true when the line ends the object
+//
+
+func appendRpslLine(attrs []rpslAttr, line string) ([]rpslAttr, bool) {
+	if strings.TrimSpace(line) == "" {
+		return attrs, true
+	}
+
+	// Comments and server remarks
+	if line[0] == '%' || line[0] == '#' {
+		return attrs, false
+	}
+
+	// Continuation of previous attribute
+	if line[0] == ' ' || line[0] == '\t' || line[0] == '+' {
+		if len(attrs) > 0 {
+			value := strings.TrimSpace(line[1:])
+			last := &attrs[len(attrs)-1]
+			last.value = strings.TrimSpace(last.value + " " + value)
+		}
+
+		return attrs, false
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return attrs, false
+	}
+
+	return append(attrs, rpslAttr{
+		key:   strings.ToLower(strings.TrimSpace(key)),
+		value: strings.TrimSpace(value),
+	}), false
+}
+
+//
+// Object class is the key of its first attribute
+//
+
+func rpslClass(attrs []rpslAttr) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	return attrs[0].key
+}
+
+//
+// Convert route or route6 object, returns raw prefix if it is malformed
+//
+
+func routeObjectFromRpsl(attrs []rpslAttr) (*RouteObject, string, bool) {
+	class := rpslClass(attrs)
+	if class != "route" && class != "route6" {
+		return nil, "", false
+	}
+
+	route := &RouteObject{}
+	raw := attrs[0].value
+
+	for _, attr := range attrs[1:] {
+		switch attr.key {
+		case "origin":
+			route.Origin = strings.ToUpper(attr.value)
+		case "descr":
+			route.Descr = append(route.Descr, attr.value)
+		case "mnt-by":
+			route.MntBy = append(route.MntBy, attr.value)
+		case "source":
+			route.Source = strings.ToUpper(attr.value)
+		}
+	}
+
+	prefix, err := netip.ParsePrefix(raw)
+	if err != nil || (class == "route") != prefix.Addr().Is4() {
+		return nil, raw, true
+	}
+
+	route.Prefix = prefix.Masked()
+	return route, "", true
+}
+
+//
+// Read route objects from stream as they arrive, fn receives either a route
+// or the raw value of a malformed prefix
+//
+
+func readRouteObjects(r io.Reader, fn func(route *RouteObject, malformed string) error) error {
+	reader := bufio.NewReader(r)
+	var attrs []rpslAttr
+
+	flush := func() error {
+		route, raw, ok := routeObjectFromRpsl(attrs)
+		attrs = nil
+
+		if !ok {
+			return nil
+		}
+
+		return fn(route, raw)
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			var done bool
+			attrs, done = appendRpslLine(attrs, strings.TrimRight(line, "\r\n"))
+
+			if done {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return flush()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//
+// Extract all route objects from response
+//
+
+func parseRouteObjects(data []byte) ([]*RouteObject, []string) {
+	var routes []*RouteObject
+	var malformed []string
+
+	readRouteObjects(bytes.NewReader(data), func(route *RouteObject, raw string) error {
+		if route == nil {
+			malformed = append(malformed, raw)
+		} else {
+			routes = append(routes, route)
+		}
+
+		return nil
+	})
+
+	return routes, malformed
+}
@@ -0,0 +1,68 @@
+package whois
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRpslObjects(t *testing.T) {
+	data := []byte(`% This is the RIPE Database query service.
+
+route:          192.0.2.0/24
+descr:          Example
+                network
+origin:         AS64500
++               
+mnt-by:         MAINT-EXAMPLE
+source:         RIPE
+
+aut-num:        AS64500
+as-name:        EXAMPLE
+`)
+
+	objects := parseRpslObjects(data)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "route", rpslClass(objects[0]))
+	assert.Equal(t, rpslAttr{key: "descr", value: "Example network"}, objects[0][1])
+	assert.Equal(t, "aut-num", rpslClass(objects[1]))
+	assert.Equal(t, "", rpslClass(nil))
+}
+
+func TestParseRouteObjects(t *testing.T) {
+	data := []byte(`route:          192.0.2.1/24
+descr:          Example
+origin:         as64500
+mnt-by:         MAINT-A
+mnt-by:         MAINT-B
+source:         radb
+
+route6:         2001:db8::/32
+origin:         AS64500
+source:         RIPE
+
+route:          2001:db8::/32
+origin:         AS64500
+
+aut-num:        AS64500
+`)
+
+	routes, malformed := parseRouteObjects(data)
+	assert.Equal(t, []*RouteObject{
+		{
+			Prefix: netip.MustParsePrefix("192.0.2.0/24"),
+			Origin: "AS64500",
+			Descr:  []string{"Example"},
+			MntBy:  []string{"MAINT-A", "MAINT-B"},
+			Source: "RADB",
+		},
+		{
+			Prefix: netip.MustParsePrefix("2001:db8::/32"),
+			Origin: "AS64500",
+			Source: "RIPE",
+		},
+	}, routes)
+
+	assert.Equal(t, []string{"2001:db8::/32"}, malformed)
+}
@@ -0,0 +1,279 @@
+//
+// Mirror sets with health tracking and failover between servers
+//
+
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
+)
+
+type Server struct {
+	Hostname string
+	Port     int
+}
+
+type Servers struct {
+	cooldown     time.Duration
+	probeTimeout time.Duration
+	dialer       Dialer
+	mu           sync.Mutex
+	servers      []*serverState
+}
+
+type ServersOpts struct {
+	Servers      []Server
+	Cooldown     time.Duration
+	ProbeTimeout time.Duration
+	Dialer       Dialer
+}
+
+type serverState struct {
+	server    Server
+	downUntil time.Time
+}
+
+var DefaultServersOpts = &ServersOpts{
+	Cooldown:     time.Minute,
+	ProbeTimeout: 5 * time.Second,
+}
+
+var ErrNoServers = errors.New("whois: no servers configured")
+
+//
+// Initialize new mirror set
+//
+
+func NewServers(servers ...Server) *Servers {
+	return NewServersWithOpts(&ServersOpts{
+		Servers: servers,
+	})
+}
+
+func NewServersWithOpts(opts *ServersOpts) *Servers {
+	if opts.Cooldown == 0 {
+		opts.Cooldown = DefaultServersOpts.Cooldown
+	}
+
+	if opts.ProbeTimeout == 0 {
+		opts.ProbeTimeout = DefaultServersOpts.ProbeTimeout
+	}
+
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	s := &Servers{
+		cooldown:     opts.Cooldown,
+		probeTimeout: opts.ProbeTimeout,
+		dialer:       dialer,
+	}
+
+	for _, server := range opts.Servers {
+		if server.Port == 0 {
+			server.Port = 43
+		}
+
+		s.servers = append(s.servers, &serverState{server: server})
+	}
+
+	return s
+}
+
+//
+// Servers in the order they should be tried, healthy ones first in configured
+// order, then unhealthy ones by how soon their cooldown ends
+//
+
+func (s *Servers) order(now time.Time) []Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make([]*serverState, len(s.servers))
+	copy(states, s.servers)
+
+	sort.SliceStable(states, func(i, j int) bool {
+		iUp := !now.Before(states[i].downUntil)
+		jUp := !now.Before(states[j].downUntil)
+
+		if iUp || jUp {
+			return iUp && !jUp
+		}
+
+		return states[i].downUntil.Before(states[j].downUntil)
+	})
+
+	result := make([]Server, 0, len(states))
+	for _, state := range states {
+		result = append(result, state.server)
+	}
+
+	return result
+}
+
+//
+// Currently healthy servers
+//
+
+func (s *Servers) Healthy() []Server {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Server
+	for _, state := range s.servers {
+		if !now.Before(state.downUntil) {
+			result = append(result, state.server)
+		}
+	}
+
+	return result
+}
+
+//
+// Health state updates
+//
+
+func (s *Servers) markDown(server Server) {
+	s.setDownUntil(server, time.Now().Add(s.cooldown))
+}
+
+func (s *Servers) markUp(server Server) {
+	s.setDownUntil(server, time.Time{})
+}
+
+func (s *Servers) setDownUntil(server Server, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, state := range s.servers {
+		if state.server == server {
+			state.downUntil = t
+		}
+	}
+}
+
+//
+// Probe all servers concurrently by opening a connection
+//
+
+func (s *Servers) Probe(ctx context.Context) {
+	s.mu.Lock()
+	servers := make([]Server, 0, len(s.servers))
+	for _, state := range s.servers {
+		servers = append(servers, state.server)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+
+		go func(server Server) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, s.probeTimeout)
+			defer cancel()
+
+			con, err := s.dialer.DialContext(probeCtx, "tcp", net.JoinHostPort(server.Hostname, fmt.Sprint(server.Port)))
+			if err != nil {
+				// Don't blame the server for our own cancellation
+				if ctx.Err() == nil {
+					s.markDown(server)
+				}
+
+				return
+			}
+
+			con.Close()
+			s.markUp(server)
+		}(server)
+	}
+
+	wg.Wait()
+}
+
+//
+// Probe servers periodically until context is done
+//
+
+func (s *Servers) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Probe(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//
+// Query attempt failing over between servers on connection level errors
+//
+
+func (s *Servers) query(ctx context.Context, opts *QueryOpts, fn func(r io.Reader) error) error {
+	servers := s.order(time.Now())
+	if len(servers) == 0 {
+		return retry.Permanent(ErrNoServers)
+	}
+
+	var err error
+	for _, server := range servers {
+		serverOpts := *opts
+		serverOpts.Hostname = server.Hostname
+		serverOpts.Port = server.Port
+		serverOpts.Servers = nil
+
+		err = queryOnce(ctx, &serverOpts, fn)
+		if err == nil {
+			s.markUp(server)
+			return nil
+		}
+
+		if !isFailoverError(err) {
+			return err
+		}
+
+		s.markDown(server)
+	}
+
+	return err
+}
+
+//
+// Dead or unreachable servers are worth failing over from, errors in the
+// response or our own cancellation are not
+//
+
+func isFailoverError(err error) bool {
+	// Mirrors have their own circuits
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+
+	if retry.IsPermanent(err) || errors.Is(err, ErrResponseTooLarge) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &opErr) || errors.As(err, &dnsErr) || IsRetryable(err)
+}
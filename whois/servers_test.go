@@ -0,0 +1,82 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deadTestServer(t *testing.T) Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+
+	return Server{Hostname: "127.0.0.1", Port: addr.Port}
+}
+
+func TestServersFailover(t *testing.T) {
+	dead := deadTestServer(t)
+	host, port := newTestServer(t, func(query string) string {
+		return "ok " + query
+	})
+
+	alive := Server{Hostname: host, Port: port}
+	servers := NewServers(dead, alive)
+
+	resp, err := QueryCtx(context.Background(), &QueryOpts{
+		Query:   "test",
+		Timeout: time.Second,
+		Servers: servers,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok test", string(resp))
+	assert.Equal(t, []Server{alive}, servers.Healthy())
+	assert.Equal(t, []Server{alive, dead}, servers.order(time.Now()))
+
+	// Dead server is tried again after cooldown
+	assert.Equal(t, []Server{dead, alive}, servers.order(time.Now().Add(2*time.Minute)))
+}
+
+func TestServersAllDown(t *testing.T) {
+	servers := NewServers(deadTestServer(t), deadTestServer(t))
+
+	_, err := QueryCtx(context.Background(), &QueryOpts{
+		Query:   "test",
+		Timeout: time.Second,
+		Servers: servers,
+	})
+
+	assert.Error(t, err)
+	assert.Empty(t, servers.Healthy())
+
+	_, err = QueryCtx(context.Background(), &QueryOpts{
+		Query:   "test",
+		Servers: NewServers(),
+	})
+
+	assert.ErrorIs(t, err, ErrNoServers)
+}
+
+func TestServersProbe(t *testing.T) {
+	dead := deadTestServer(t)
+	host, port := newTestServer(t, func(query string) string {
+		return ""
+	})
+
+	alive := Server{Hostname: host, Port: port}
+	servers := NewServersWithOpts(&ServersOpts{
+		Servers:      []Server{dead, alive},
+		ProbeTimeout: time.Second,
+	})
+
+	servers.Probe(context.Background())
+	assert.Equal(t, []Server{alive}, servers.Healthy())
+}
@@ -0,0 +1,219 @@
+//
+// Persistent connections, RIPE style "-k" mode answering many queries
+// over one TCP connection, each response ends with two empty lines
+//
+
+package whois
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SessionOpts struct {
+	Hostname        string
+	Port            int
+	Timeout         time.Duration
+	MaxResponseSize int64
+	Dialer          Dialer
+	Client          *Client
+	Transcript      *Transcript
+	Charset         string
+}
+
+type Session struct {
+	opts      *SessionOpts
+	mu        sync.Mutex
+	con       net.Conn
+	reader    *bufio.Reader
+	persisted bool
+	closed    bool
+}
+
+var ErrSessionClosed = errors.New("whois: session closed")
+
+//
+// Connect to server, persistent mode starts with the first query
+//
+
+func OpenSession(ctx context.Context, opts *SessionOpts) (*Session, error) {
+	if opts.Port == 0 {
+		opts.Port = 43
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Second * 10
+	}
+
+	if _, err := normalizeCharset(opts.Charset); err != nil {
+		return nil, err
+	}
+
+	var dialer Dialer = &net.Dialer{}
+	if opts.Dialer != nil {
+		dialer = opts.Dialer
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	con, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(opts.Hostname, fmt.Sprint(opts.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		opts:   opts,
+		con:    con,
+		reader: bufio.NewReader(con),
+	}, nil
+}
+
+//
+// Send query and read its response, the session is unusable after errors
+//
+
+func (s *Session) Query(ctx context.Context, query string) (resp []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+
+	// Respect server rate limits
+	if s.opts.Client != nil {
+		err := s.opts.Client.Wait(ctx, s.opts.Hostname)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// First query switches the server to persistent mode
+	line := query
+	if !s.persisted {
+		line = "-k " + query
+	}
+
+	attempt := newTranscriptAttempt(&QueryOpts{
+		Hostname:   s.opts.Hostname,
+		Port:       s.opts.Port,
+		Query:      line,
+		Transcript: s.opts.Transcript,
+	})
+
+	if attempt != nil {
+		attempt.connected(s.con)
+		defer func() {
+			attempt.BytesReceived = int64(len(resp))
+			s.opts.Transcript.record(attempt, err)
+		}()
+	}
+
+	payload, err := encodeQuery(line, s.opts.Charset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err = s.roundTrip(ctx, payload)
+	if breaker := s.opts.Client.circuit(); breaker != nil {
+		breaker.Record(s.opts.Hostname, err)
+	}
+
+	if err != nil {
+		s.shutdown()
+		return nil, contextErr(ctx, err)
+	}
+
+	s.persisted = true
+	return DecodeResponse(resp, s.opts.Charset)
+}
+
+func (s *Session) roundTrip(ctx context.Context, payload []byte) ([]byte, error) {
+	deadline := time.Now().Add(s.opts.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	err := s.con.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unblock pending reads and writes on cancellation
+	stop := context.AfterFunc(ctx, func() {
+		s.con.SetDeadline(time.Now())
+	})
+
+	defer stop()
+
+	_, err = s.con.Write(append(payload, "\r\n"...))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []byte
+	var empty int
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		// Two empty lines close the response, single ones separate objects
+		if line == "" {
+			empty++
+			if empty == 2 {
+				return resp, nil
+			}
+
+			continue
+		}
+
+		for ; empty > 0; empty-- {
+			resp = append(resp, '\n')
+		}
+
+		resp = append(resp, line...)
+		resp = append(resp, '\n')
+
+		if (s.opts.MaxResponseSize > 0) && (int64(len(resp)) > s.opts.MaxResponseSize) {
+			return nil, ErrResponseTooLarge
+		}
+	}
+}
+
+//
+// Leave persistent mode and close connection
+//
+
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	// Repeating "-k" ends persistent mode, the server hangs up after
+	if s.persisted {
+		s.con.SetDeadline(time.Now().Add(time.Second))
+		s.con.Write([]byte("-k\r\n"))
+	}
+
+	return s.shutdown()
+}
+
+func (s *Session) shutdown() error {
+	s.closed = true
+	return s.con.Close()
+}
@@ -0,0 +1,178 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Persistent mode server, counts connections and records queries
+func newTestSessionServer(t *testing.T, handler func(query string) string) (string, int, func() (int, []string)) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	var mu sync.Mutex
+	var connections int
+	var queries []string
+
+	go func() {
+		for {
+			con, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			connections++
+			mu.Unlock()
+
+			go func() {
+				defer con.Close()
+				reader := bufio.NewReader(con)
+				persistent := false
+
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					query := strings.TrimSpace(line)
+					mu.Lock()
+					queries = append(queries, query)
+					mu.Unlock()
+
+					if query == "-k" {
+						return
+					}
+
+					if strings.HasPrefix(query, "-k ") {
+						persistent = true
+						query = strings.TrimPrefix(query, "-k ")
+					}
+
+					con.Write([]byte(handler(query) + "\n\n"))
+
+					if !persistent {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, func() (int, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return connections, append([]string(nil), queries...)
+	}
+}
+
+func TestSession(t *testing.T) {
+	host, port, stats := newTestSessionServer(t, func(query string) string {
+		return "% RIPE\r\n\r\ninetnum: " + query + "\r\n"
+	})
+
+	transcript := NewTranscript()
+	session, err := OpenSession(context.Background(), &SessionOpts{
+		Hostname:   host,
+		Port:       port,
+		Transcript: transcript,
+	})
+
+	assert.NoError(t, err)
+
+	for _, query := range []string{"193.0.0.1", "193.0.0.2", "193.0.0.3"} {
+		resp, err := session.Query(context.Background(), query)
+		assert.NoError(t, err)
+		assert.Equal(t, "% RIPE\n\ninetnum: "+query+"\n", string(resp))
+	}
+
+	assert.NoError(t, session.Close())
+	assert.NoError(t, session.Close())
+
+	_, err = session.Query(context.Background(), "193.0.0.4")
+	assert.ErrorIs(t, err, ErrSessionClosed)
+
+	assert.Eventually(t, func() bool {
+		_, queries := stats()
+		return len(queries) == 4
+	}, time.Second, time.Millisecond)
+
+	connections, queries := stats()
+	assert.Equal(t, 1, connections)
+	assert.Equal(t, []string{"-k 193.0.0.1", "193.0.0.2", "193.0.0.3", "-k"}, queries)
+
+	attempts := transcript.Attempts()
+	assert.Len(t, attempts, 3)
+	assert.Equal(t, "-k 193.0.0.1", attempts[0].Query)
+	assert.Equal(t, int64(len("% RIPE\n\ninetnum: 193.0.0.1\n")), attempts[0].BytesReceived)
+}
+
+func TestSessionEmptyResponse(t *testing.T) {
+	host, port, _ := newTestSessionServer(t, func(query string) string {
+		return ""
+	})
+
+	session, err := OpenSession(context.Background(), &SessionOpts{
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+	defer session.Close()
+
+	resp, err := session.Query(context.Background(), "nothing")
+	assert.NoError(t, err)
+	assert.Empty(t, resp)
+}
+
+func TestSessionServerHangup(t *testing.T) {
+	// Plain server answers once and closes, without the terminator
+	host, port := newTestServer(t, func(query string) string {
+		return "inetnum: 193.0.0.1\n"
+	})
+
+	session, err := OpenSession(context.Background(), &SessionOpts{
+		Hostname: host,
+		Port:     port,
+	})
+
+	assert.NoError(t, err)
+
+	_, err = session.Query(context.Background(), "193.0.0.1")
+	assert.Error(t, err)
+
+	_, err = session.Query(context.Background(), "193.0.0.1")
+	assert.ErrorIs(t, err, ErrSessionClosed)
+}
+
+func TestSessionTooLarge(t *testing.T) {
+	host, port, _ := newTestSessionServer(t, func(query string) string {
+		return strings.Repeat("remarks: padding\n", 100)
+	})
+
+	session, err := OpenSession(context.Background(), &SessionOpts{
+		Hostname:        host,
+		Port:            port,
+		MaxResponseSize: 256,
+	})
+
+	assert.NoError(t, err)
+
+	_, err = session.Query(context.Background(), "193.0.0.1")
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+}
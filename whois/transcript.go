@@ -0,0 +1,121 @@
+//
+// Per-attempt query transcripts for auditing
+//
+
+package whois
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+type Transcript struct {
+	mu       sync.Mutex
+	attempts []*TranscriptAttempt
+}
+
+type TranscriptAttempt struct {
+	Hostname      string    `json:"hostname"`
+	Port          int       `json:"port"`
+	RemoteAddr    string    `json:"remote_addr,omitempty"`
+	Query         string    `json:"query"`
+	Started       time.Time `json:"started"`
+	Connected     time.Time `json:"connected,omitempty"`
+	Finished      time.Time `json:"finished"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	Error         string    `json:"error,omitempty"`
+}
+
+//
+// Initialize empty transcript, safe for concurrent queries
+//
+
+func NewTranscript() *Transcript {
+	return &Transcript{}
+}
+
+//
+// Copy of recorded attempts in the order they finished
+//
+
+func (t *Transcript) Attempts() []*TranscriptAttempt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]*TranscriptAttempt, 0, len(t.attempts))
+	for _, attempt := range t.attempts {
+		copied := *attempt
+		result = append(result, &copied)
+	}
+
+	return result
+}
+
+func (t *Transcript) record(attempt *TranscriptAttempt, err error) {
+	attempt.Finished = time.Now()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts = append(t.attempts, attempt)
+}
+
+//
+// Attempt bookkeeping, all methods are no-ops on a nil attempt
+//
+
+func newTranscriptAttempt(opts *QueryOpts) *TranscriptAttempt {
+	if opts.Transcript == nil {
+		return nil
+	}
+
+	return &TranscriptAttempt{
+		Hostname: opts.Hostname,
+		Port:     opts.Port,
+		Query:    opts.Query,
+		Started:  time.Now(),
+	}
+}
+
+func (a *TranscriptAttempt) connected(con net.Conn) {
+	if a == nil {
+		return
+	}
+
+	a.Connected = time.Now()
+	if addr := con.RemoteAddr(); addr != nil {
+		a.RemoteAddr = addr.String()
+	}
+}
+
+func (a *TranscriptAttempt) sent(n int) {
+	if a != nil {
+		a.BytesSent += int64(n)
+	}
+}
+
+func (a *TranscriptAttempt) reader(r io.Reader) io.Reader {
+	if a == nil {
+		return r
+	}
+
+	return &countingReader{reader: r, count: &a.BytesReceived}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	*r.count += int64(n)
+
+	return n, err
+}
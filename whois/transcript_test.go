@@ -0,0 +1,93 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTranscript(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "response"
+	})
+
+	transcript := NewTranscript()
+	_, err := QueryCtx(context.Background(), &QueryOpts{
+		Hostname:   host,
+		Port:       port,
+		Query:      "example.com",
+		Transcript: transcript,
+	})
+
+	assert.NoError(t, err)
+
+	attempts := transcript.Attempts()
+	assert.Len(t, attempts, 1)
+	assert.Equal(t, host, attempts[0].Hostname)
+	assert.Equal(t, port, attempts[0].Port)
+	assert.Equal(t, fmt.Sprintf("%s:%d", host, port), attempts[0].RemoteAddr)
+	assert.Equal(t, "example.com", attempts[0].Query)
+	assert.Equal(t, int64(len("example.com\r\n")), attempts[0].BytesSent)
+	assert.Equal(t, int64(len("response")), attempts[0].BytesReceived)
+	assert.Empty(t, attempts[0].Error)
+	assert.False(t, attempts[0].Connected.Before(attempts[0].Started))
+	assert.False(t, attempts[0].Finished.Before(attempts[0].Connected))
+
+	data, err := json.Marshal(attempts[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"bytes_received":8`)
+}
+
+func TestQueryTranscriptFailover(t *testing.T) {
+	dead := deadTestServer(t)
+	host, port := newTestServer(t, func(query string) string {
+		return "ok"
+	})
+
+	transcript := NewTranscript()
+	_, err := QueryCtx(context.Background(), &QueryOpts{
+		Query:      "test",
+		Timeout:    time.Second,
+		Servers:    NewServers(dead, Server{Hostname: host, Port: port}),
+		Transcript: transcript,
+	})
+
+	assert.NoError(t, err)
+
+	attempts := transcript.Attempts()
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, dead.Port, attempts[0].Port)
+	assert.NotEmpty(t, attempts[0].Error)
+	assert.True(t, attempts[0].Connected.IsZero())
+	assert.Equal(t, port, attempts[1].Port)
+	assert.Empty(t, attempts[1].Error)
+}
+
+func TestLookupTranscript(t *testing.T) {
+	regHost, regPort := newTestServer(t, func(query string) string {
+		return "Domain Name: " + query + "\n"
+	})
+
+	rootHost, rootPort := newTestServer(t, func(query string) string {
+		return fmt.Sprintf("refer: %s:%d\n", regHost, regPort)
+	})
+
+	transcript := NewTranscript()
+	_, err := LookupCtx(context.Background(), &LookupOpts{
+		Domain:     "example.com",
+		Hostname:   rootHost,
+		Port:       rootPort,
+		Transcript: transcript,
+	})
+
+	assert.NoError(t, err)
+
+	attempts := transcript.Attempts()
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, rootPort, attempts[0].Port)
+	assert.Equal(t, regPort, attempts[1].Port)
+}
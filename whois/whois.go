@@ -5,53 +5,271 @@
 package whois
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"time"
+
+	"github.com/publishlab/infra-golang-toolkit/retry"
 )
 
 type QueryOpts struct {
-	Hostname string
-	Port     int
-	Query    string
-	Timeout  time.Duration
+	Hostname        string
+	Port            int
+	Query           string
+	Timeout         time.Duration
+	AttemptTimeout  time.Duration
+	TotalTimeout    time.Duration
+	MaxResponseSize int64
+	Dialer          Dialer
+	Client          *Client
+	Retry           *RetryPolicy
+	Servers         *Servers
+	Transcript      *Transcript
+	Charset         string
 }
 
+var ErrResponseTooLarge = errors.New("whois: response exceeds max size")
+
+//
+// Query with background context
+//
+
 func Query(opts *QueryOpts) ([]byte, error) {
+	return QueryCtx(context.Background(), opts)
+}
+
+//
+// Query with context, each attempt ends at the earliest of context deadline,
+// total timeout and attempt timeout, the retry loop at either of the former
+//
+
+func QueryCtx(ctx context.Context, opts *QueryOpts) ([]byte, error) {
+	setQueryDefaults(opts)
+
+	ctx, cancel := withTotalTimeout(ctx, opts)
+	defer cancel()
+
+	if _, err := normalizeCharset(opts.Charset); err != nil {
+		return nil, err
+	}
+
+	var resp []byte
+	err := opts.Retry.do(ctx, func() error {
+		return queryOnce(ctx, opts, func(r io.Reader) error {
+			var err error
+			resp, err = io.ReadAll(r)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeResponse(resp, opts.Charset)
+}
+
+//
+// Query and hand the response stream to fn, failures are only retried
+// before fn has been called, the stream is passed on undecoded
+//
+
+func QueryStream(ctx context.Context, opts *QueryOpts, fn func(r io.Reader) error) error {
+	setQueryDefaults(opts)
+
+	ctx, cancel := withTotalTimeout(ctx, opts)
+	defer cancel()
+
+	return opts.Retry.do(ctx, func() error {
+		return queryOnce(ctx, opts, func(r io.Reader) error {
+			return retry.Permanent(fn(r))
+		})
+	})
+}
+
+// Timeout is the per attempt timeout, AttemptTimeout takes precedence
+func setQueryDefaults(opts *QueryOpts) {
 	if opts.Port == 0 {
 		opts.Port = 43
 	}
 
-	if opts.Timeout == 0 {
-		opts.Timeout = time.Second * 10
+	if opts.AttemptTimeout == 0 {
+		opts.AttemptTimeout = opts.Timeout
+	}
+
+	if opts.AttemptTimeout == 0 {
+		opts.AttemptTimeout = time.Second * 10
+	}
+}
+
+func withTotalTimeout(ctx context.Context, opts *QueryOpts) (context.Context, context.CancelFunc) {
+	if opts.TotalTimeout > 0 {
+		return context.WithTimeout(ctx, opts.TotalTimeout)
+	}
+
+	return ctx, func() {}
+}
+
+//
+// Single query attempt
+//
+
+func queryOnce(ctx context.Context, opts *QueryOpts, fn func(r io.Reader) error) (err error) {
+	// Mirror set overrides hostname and port
+	if opts.Servers != nil {
+		return opts.Servers.query(ctx, opts, fn)
+	}
+
+	query, err := encodeQuery(opts.Query, opts.Charset)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	// Respect server rate limits and open circuits
+	if opts.Client != nil {
+		err := opts.Client.Wait(ctx, opts.Hostname)
+		if errors.Is(err, ErrCircuitOpen) {
+			return retry.Permanent(err)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	breaker := opts.Client.circuit()
+	if breaker != nil {
+		defer func() {
+			// Server did answer, whatever the caller made of it
+			if (retry.IsPermanent(err) && !errors.Is(err, ErrRateLimited)) || errors.Is(err, ErrResponseTooLarge) {
+				breaker.Record(opts.Hostname, nil)
+				return
+			}
+
+			breaker.Record(opts.Hostname, err)
+		}()
+	}
+
+	// Record attempt once it is on its way to the network
+	attempt := newTranscriptAttempt(opts)
+	if attempt != nil {
+		defer func() {
+			opts.Transcript.record(attempt, err)
+		}()
+	}
+
+	deadline := time.Now().Add(opts.AttemptTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	var dialer Dialer = &net.Dialer{}
+	if opts.Dialer != nil {
+		dialer = opts.Dialer
 	}
 
 	// Open connection
-	con, err := net.Dial("tcp", net.JoinHostPort(opts.Hostname, fmt.Sprint(opts.Port)))
+	dialCtx, cancel := context.WithDeadline(ctx, deadline)
+	con, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(opts.Hostname, fmt.Sprint(opts.Port)))
+	cancel()
+
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer con.Close()
+	attempt.connected(con)
 
 	// Timeout
-	err = con.SetDeadline(time.Now().Add(opts.Timeout))
+	err = con.SetDeadline(deadline)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	// Unblock pending reads and writes on cancellation
+	stop := context.AfterFunc(ctx, func() {
+		con.SetDeadline(time.Now())
+	})
+
+	defer stop()
+
 	// Write query
-	_, err = con.Write([]byte(opts.Query + "\r\n"))
+	n, err := con.Write(append(query, "\r\n"...))
+	attempt.sent(n)
+
 	if err != nil {
-		return nil, err
+		return contextErr(ctx, err)
+	}
+
+	// Read response, guarding against endless streams
+	reader := attempt.reader(con)
+	if opts.MaxResponseSize > 0 {
+		reader = &limitedReader{reader: reader, remaining: opts.MaxResponseSize}
 	}
 
-	// Read response
-	resp, err := io.ReadAll(con)
+	var banner *bannerReader
+	if breaker != nil {
+		banner = &bannerReader{reader: reader}
+		reader = banner
+	}
+
+	err = fn(reader)
 	if err != nil {
-		return nil, err
+		return contextErr(ctx, err)
+	}
+
+	if (banner != nil) && breaker.rateLimited(banner.head) {
+		return retry.Permanent(fmt.Errorf("%w: %s", ErrRateLimited, opts.Hostname))
+	}
+
+	return nil
+}
+
+//
+// Prefer context error over the deadline error it caused
+//
+
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	// Connection deadline may fire just before the context notices
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+//
+// Reader failing once more than the allowed bytes have been read
+//
+
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	// Read one byte past the limit to detect overflow
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+
+	if r.remaining < 0 {
+		return n + int(r.remaining), ErrResponseTooLarge
 	}
 
-	return resp, nil
+	return n, err
 }
@@ -1,38 +1,215 @@
 package whois
 
 import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/publishlab/infra-golang-toolkit/whois/whoistest"
 	"github.com/stretchr/testify/assert"
 )
 
+// Start local WHOIS server answering queries with handler
+func newTestServer(t *testing.T, handler func(query string) string) (string, int) {
+	srv := whoistest.NewServer(func(query string) whoistest.Response {
+		return whoistest.Response{Body: handler(query)}
+	})
+
+	t.Cleanup(srv.Close)
+	return srv.Hostname, srv.Port
+}
+
 func TestQuery(t *testing.T) {
+	srv := whoistest.NewServer(whoistest.Static(map[string]string{
+		"norid.no":          "Domain Name................: norid.no\nRegistrar Handle...........: REG1-NORID\n",
+		"-i origin AS64500": "route:          192.0.2.0/24\norigin:         AS64500\nsource:         RADB\n",
+	}, "% No match\n"))
+
+	t.Cleanup(srv.Close)
+
 	data, err := Query(&QueryOpts{
-		Hostname: "whois.norid.no",
-		Port:     43,
+		Hostname: srv.Hostname,
+		Port:     srv.Port,
 		Query:    "norid.no",
 		Timeout:  10 * time.Second,
 	})
 
-	assert.NotEmpty(t, data)
 	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Domain Name")
 
 	data, err = Query(&QueryOpts{
-		Hostname: "whois.radb.net",
-		Query:    "-i origin AS32934",
+		Hostname: srv.Hostname,
+		Port:     srv.Port,
+		Query:    "-i origin AS64500",
 	})
 
-	assert.NotEmpty(t, data)
 	assert.NoError(t, err)
+	assert.Contains(t, string(data), "192.0.2.0/24")
+	assert.Equal(t, []string{"norid.no", "-i origin AS64500"}, srv.Queries())
 }
 
 func TestQueryError(t *testing.T) {
+	srv := whoistest.NewServer(func(query string) whoistest.Response {
+		return whoistest.Response{Reset: true}
+	})
+
+	t.Cleanup(srv.Close)
+
 	data, err := Query(&QueryOpts{
-		Hostname: "whois.example.org",
+		Hostname: srv.Hostname,
+		Port:     srv.Port,
 		Query:    "example.org",
 	})
 
 	assert.Empty(t, data)
 	assert.Error(t, err)
+
+	data, err = Query(&QueryOpts{
+		Hostname: "whois.invalid",
+		Query:    "example.org",
+	})
+
+	assert.Empty(t, data)
+	assert.Error(t, err)
+}
+
+func TestQueryLocal(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "query: " + query + "\n"
+	})
+
+	data, err := Query(&QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "query: example.com\n", string(data))
+}
+
+func TestQueryCtxCancel(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		time.Sleep(time.Second)
+		return "late\n"
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	data, err := QueryCtx(ctx, &QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example.com",
+	})
+
+	assert.Empty(t, data)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestQueryMaxResponseSize(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return strings.Repeat("x", 100)
+	})
+
+	data, err := Query(&QueryOpts{
+		Hostname:        host,
+		Port:            port,
+		Query:           "example.com",
+		MaxResponseSize: 10,
+	})
+
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Empty(t, data)
+
+	data, err = Query(&QueryOpts{
+		Hostname:        host,
+		Port:            port,
+		Query:           "example.com",
+		MaxResponseSize: 100,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, data, 100)
+}
+
+func TestQueryStream(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		return "line 1\nline 2\n"
+	})
+
+	var lines []string
+	err := QueryStream(context.Background(), &QueryOpts{
+		Hostname: host,
+		Port:     port,
+		Query:    "example.com",
+		Retry:    DefaultRetryPolicy,
+	}, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		return scanner.Err()
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 1", "line 2"}, lines)
+}
+
+func TestQueryAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	host, port := newTestServer(t, func(query string) string {
+		// First attempt hangs past its timeout, the retry is answered
+		if calls.Add(1) == 1 {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		return "ok\n"
+	})
+
+	data, err := Query(&QueryOpts{
+		Hostname:       host,
+		Port:           port,
+		Query:          "example.com",
+		AttemptTimeout: 50 * time.Millisecond,
+		TotalTimeout:   5 * time.Second,
+		Retry: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", string(data))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestQueryTotalTimeout(t *testing.T) {
+	host, port := newTestServer(t, func(query string) string {
+		time.Sleep(time.Second)
+		return "late\n"
+	})
+
+	start := time.Now()
+	_, err := Query(&QueryOpts{
+		Hostname:       host,
+		Port:           port,
+		Query:          "example.com",
+		AttemptTimeout: 80 * time.Millisecond,
+		TotalTimeout:   200 * time.Millisecond,
+		Retry: &RetryPolicy{
+			MaxAttempts:    10,
+			InitialBackoff: time.Millisecond,
+		},
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
 }
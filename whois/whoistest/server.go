@@ -0,0 +1,221 @@
+//
+// In-process WHOIS server with canned responses and scripted failures, for
+// testing code that depends on WHOIS without reaching real registries
+//
+
+package whoistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Server struct {
+	Hostname string
+	Port     int
+
+	handler Handler
+	ln      net.Listener
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	conns   map[net.Conn]bool
+	queries []string
+}
+
+// Response to a single query, failure fields are applied in field order
+type Response struct {
+	Body string
+
+	// Wait before answering
+	Delay time.Duration
+
+	// Never answer, the connection stays open until the client gives up
+	Hang bool
+
+	// Write only the first bytes of body before resetting the connection
+	Partial int
+
+	// Reset the connection without answering
+	Reset bool
+}
+
+type Handler func(query string) Response
+
+//
+// Start server on a random loopback port, panics if no port can be bound
+//
+
+func NewServer(handler Handler) *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("whoistest: failed to listen: %v", err))
+	}
+
+	addr := ln.Addr().(*net.TCPAddr)
+	s := &Server{
+		Hostname: addr.IP.String(),
+		Port:     addr.Port,
+		handler:  handler,
+		ln:       ln,
+		conns:    make(map[net.Conn]bool),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+//
+// Address as host:port
+//
+
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+//
+// Queries received so far, in arrival order
+//
+
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.queries...)
+}
+
+//
+// Stop listening and drop open connections, including hanging ones
+//
+
+func (s *Server) Close() {
+	s.ln.Close()
+
+	s.mu.Lock()
+	for con := range s.conns {
+		con.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		con, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[con] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(con)
+
+			s.mu.Lock()
+			delete(s.conns, con)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+func (s *Server) handle(con net.Conn) {
+	defer con.Close()
+
+	line, err := bufio.NewReader(con).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	query := strings.TrimSpace(line)
+
+	s.mu.Lock()
+	s.queries = append(s.queries, query)
+	s.mu.Unlock()
+
+	resp := s.handler(query)
+
+	if resp.Delay > 0 {
+		time.Sleep(resp.Delay)
+	}
+
+	// Blocks until the client or Close drops the connection
+	if resp.Hang {
+		io.Copy(io.Discard, con)
+		return
+	}
+
+	if resp.Partial > 0 {
+		con.Write([]byte(resp.Body[:min(resp.Partial, len(resp.Body))]))
+		reset(con)
+		return
+	}
+
+	if resp.Reset {
+		reset(con)
+		return
+	}
+
+	con.Write([]byte(resp.Body))
+}
+
+// Close with RST instead of FIN, so the client sees a connection reset
+func reset(con net.Conn) {
+	if tcp, ok := con.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+}
+
+//
+// Handlers
+//
+
+// Same body for every query
+func Text(body string) Handler {
+	return func(query string) Response {
+		return Response{Body: body}
+	}
+}
+
+// Body per exact query, unknown queries get fallback
+func Static(bodies map[string]string, fallback string) Handler {
+	return func(query string) Response {
+		if body, ok := bodies[query]; ok {
+			return Response{Body: body}
+		}
+
+		return Response{Body: fallback}
+	}
+}
+
+// Responses in order across all queries, the last one repeats
+func Sequence(responses ...Response) Handler {
+	var mu sync.Mutex
+	next := 0
+
+	return func(query string) Response {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(responses) == 0 {
+			return Response{}
+		}
+
+		resp := responses[min(next, len(responses)-1)]
+		next++
+
+		return resp
+	}
+}
@@ -0,0 +1,104 @@
+package whoistest
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func query(t *testing.T, s *Server, q string) (string, error) {
+	con, err := net.DialTimeout("tcp", s.Addr(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer con.Close()
+	con.SetDeadline(time.Now().Add(200 * time.Millisecond))
+
+	if _, err := con.Write([]byte(q + "\r\n")); err != nil {
+		return "", err
+	}
+
+	data, err := io.ReadAll(con)
+	return string(data), err
+}
+
+func TestServerStatic(t *testing.T) {
+	s := NewServer(Static(map[string]string{
+		"example.com": "Domain Name: EXAMPLE.COM\n",
+	}, "No match\n"))
+
+	defer s.Close()
+
+	data, err := query(t, s, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Domain Name: EXAMPLE.COM\n", data)
+
+	data, err = query(t, s, "unknown.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "No match\n", data)
+
+	assert.Equal(t, []string{"example.com", "unknown.com"}, s.Queries())
+	assert.Equal(t, "127.0.0.1", s.Hostname)
+	assert.NotZero(t, s.Port)
+}
+
+func TestServerFailures(t *testing.T) {
+	s := NewServer(Sequence(
+		Response{Reset: true},
+		Response{Body: "truncated response", Partial: 9},
+		Response{Hang: true},
+		Response{Body: "ok\n", Delay: 10 * time.Millisecond},
+	))
+
+	defer s.Close()
+
+	_, err := query(t, s, "first")
+	assert.Error(t, err)
+
+	data, err := query(t, s, "second")
+	assert.Equal(t, "truncated", data)
+	assert.Error(t, err)
+
+	_, err = query(t, s, "third")
+	var netErr net.Error
+	assert.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+
+	// Last response repeats
+	for i := 0; i < 2; i++ {
+		data, err = query(t, s, "fourth")
+		assert.NoError(t, err)
+		assert.Equal(t, "ok\n", data)
+	}
+}
+
+func TestServerCloseHanging(t *testing.T) {
+	s := NewServer(func(query string) Response {
+		return Response{Hang: true}
+	})
+
+	con, err := net.Dial("tcp", s.Addr())
+	assert.NoError(t, err)
+	defer con.Close()
+
+	con.Write([]byte("hang\r\n"))
+	assert.Eventually(t, func() bool {
+		return len(s.Queries()) == 1
+	}, time.Second, time.Millisecond)
+
+	done := make(chan bool)
+	go func() {
+		s.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("close blocked on hanging connection")
+	}
+}